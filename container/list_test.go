@@ -0,0 +1,103 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package container
+
+/* container/list_test.sgo:3 */ import (
+/* container/list_test.sgo:4 */ 	"reflect"
+/* container/list_test.sgo:5 */ 	"testing"
+/* container/list_test.sgo:6 */ )
+
+/* container/list_test.sgo:8 */ func checkList(t *testing.T, l *List[int], expected []int) {
+/* container/list_test.sgo:9 */ 	if got := l.Values(); !reflect.DeepEqual(got, expected) && (len(got) > 0 || len(expected) > 0) {
+/* container/list_test.sgo:10 */ 		t.Errorf("values: expected %v, got %v", expected, got)
+/* container/list_test.sgo:11 */ 	}
+/* container/list_test.sgo:12 */ 	if l.Len() != len(expected) {
+/* container/list_test.sgo:13 */ 		t.Errorf("len: expected %d, got %d", len(expected), l.Len())
+/* container/list_test.sgo:14 */ 	}
+
+	// Walk backwards too, to check the prev links.
+/* container/list_test.sgo:17 */ 	var back []int
+/* container/list_test.sgo:18 */ 	prev := l.Back()
+/* container/list_test.sgo:19 */ 	for {
+/* container/list_test.sgo:20 */ 		e := prev
+/* container/list_test.sgo:21 */ 		if e != nil {
+/* container/list_test.sgo:22 */ 			back = append([]int{e.Value}, back...)
+/* container/list_test.sgo:23 */ 			prev = e.Prev()
+/* container/list_test.sgo:24 */ 			continue
+/* container/list_test.sgo:25 */ 		}
+/* container/list_test.sgo:26 */ 		break
+/* container/list_test.sgo:27 */ 	}
+/* container/list_test.sgo:28 */ 	if !reflect.DeepEqual(back, expected) && (len(back) > 0 || len(expected) > 0) {
+/* container/list_test.sgo:29 */ 		t.Errorf("backwards: expected %v, got %v", expected, back)
+/* container/list_test.sgo:30 */ 	}
+/* container/list_test.sgo:31 */ }
+
+// For SGo: func(t *testing.T)
+func TestList(t *testing.T) {
+/* container/list_test.sgo:34 */ 	var l List[int]
+/* container/list_test.sgo:35 */ 	checkList(t, &l, nil)
+
+/* container/list_test.sgo:37 */ 	e2 := l.PushBack(2)
+/* container/list_test.sgo:38 */ 	e1 := l.PushFront(1)
+/* container/list_test.sgo:39 */ 	checkList(t, &l, []int{1, 2})
+
+/* container/list_test.sgo:41 */ 	e3, ok := l.InsertAfter(3, e2)
+/* container/list_test.sgo:42 */ 	if !ok {
+/* container/list_test.sgo:43 */ 		t.Fatal("InsertAfter: mark not found")
+/* container/list_test.sgo:44 */ 		return
+/* container/list_test.sgo:45 */ 	}
+/* container/list_test.sgo:46 */ 	_, ok = l.InsertBefore(0, e1)
+/* container/list_test.sgo:47 */ 	if !ok {
+/* container/list_test.sgo:48 */ 		t.Fatal("InsertBefore: mark not found")
+/* container/list_test.sgo:49 */ 	}
+/* container/list_test.sgo:50 */ 	checkList(t, &l, []int{0, 1, 2, 3})
+
+/* container/list_test.sgo:52 */ 	l.MoveToFront(e3)
+/* container/list_test.sgo:53 */ 	checkList(t, &l, []int{3, 0, 1, 2})
+/* container/list_test.sgo:54 */ 	l.MoveToBack(e3)
+/* container/list_test.sgo:55 */ 	checkList(t, &l, []int{0, 1, 2, 3})
+/* container/list_test.sgo:56 */ 	l.MoveBefore(e2, e1)
+/* container/list_test.sgo:57 */ 	checkList(t, &l, []int{0, 2, 1, 3})
+/* container/list_test.sgo:58 */ 	l.MoveAfter(e2, e3)
+/* container/list_test.sgo:59 */ 	checkList(t, &l, []int{0, 1, 3, 2})
+
+/* container/list_test.sgo:61 */ 	if !l.Remove(e1) {
+/* container/list_test.sgo:62 */ 		t.Error("Remove: element not found")
+/* container/list_test.sgo:63 */ 	}
+/* container/list_test.sgo:64 */ 	checkList(t, &l, []int{0, 3, 2})
+/* container/list_test.sgo:65 */ 	if e1.Next() != nil || e1.Prev() != nil {
+/* container/list_test.sgo:66 */ 		t.Error("removed element still linked")
+/* container/list_test.sgo:67 */ 	}
+
+	// Operations with elements from other lists don't modify l.
+/* container/list_test.sgo:70 */ 	other := NewList[int]()
+/* container/list_test.sgo:71 */ 	if l.Remove(e1) {
+/* container/list_test.sgo:72 */ 		t.Error("Remove: removed element found")
+/* container/list_test.sgo:73 */ 	}
+/* container/list_test.sgo:74 */ 	mark := other.PushBack(5)
+/* container/list_test.sgo:75 */ 	_, ok = l.InsertAfter(4, mark)
+/* container/list_test.sgo:76 */ 	if ok {
+/* container/list_test.sgo:77 */ 		t.Error("InsertAfter: mark from another list found")
+/* container/list_test.sgo:78 */ 	}
+/* container/list_test.sgo:79 */ 	l.MoveToFront(mark)
+/* container/list_test.sgo:80 */ 	checkList(t, &l, []int{0, 3, 2})
+/* container/list_test.sgo:81 */ }
+
+// For SGo: func(t *testing.T)
+func TestListAll(t *testing.T) {
+/* container/list_test.sgo:84 */ 	l := NewList[string]()
+/* container/list_test.sgo:85 */ 	l.PushBack("a")
+/* container/list_test.sgo:86 */ 	l.PushBack("b")
+/* container/list_test.sgo:87 */ 	l.PushBack("c")
+
+/* container/list_test.sgo:89 */ 	var got []string
+/* container/list_test.sgo:90 */ 	for v := range l.All() {
+/* container/list_test.sgo:91 */ 		got = append(got, v)
+/* container/list_test.sgo:92 */ 		if v == "b" {
+/* container/list_test.sgo:93 */ 			break
+/* container/list_test.sgo:94 */ 		}
+/* container/list_test.sgo:95 */ 	}
+/* container/list_test.sgo:96 */ 	if expected := []string{"a", "b"}; !reflect.DeepEqual(got, expected) {
+/* container/list_test.sgo:97 */ 		t.Errorf("expected %v, got %v", expected, got)
+/* container/list_test.sgo:98 */ 	}
+/* container/list_test.sgo:99 */ }
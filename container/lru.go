@@ -0,0 +1,85 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package container
+
+// An LRU is a cache that holds up to a fixed number of entries. When adding
+// an entry to a full cache, the least recently used one is evicted.
+//
+// The zero value isn't usable; use NewLRU.
+/* container/lru.sgo:7 */ type LRU[K comparable, V any] struct {
+	// Entries from the least to the most recently used.
+/* container/lru.sgo:9 */ 	entries  *OrderedMap[K, V]
+/* container/lru.sgo:10 */ 	capacity int
+/* container/lru.sgo:11 */ }
+
+// NewLRU returns an empty LRU cache that holds up to capacity entries. It
+// panics if capacity isn't positive.
+// For SGo: func(capacity int) *LRU[K, V]
+func NewLRU[K comparable, V any](capacity int) *LRU[K, V] {
+/* container/lru.sgo:16 */ 	if capacity <= 0 {
+/* container/lru.sgo:17 */ 		panic("container: non-positive LRU capacity")
+/* container/lru.sgo:18 */ 	}
+/* container/lru.sgo:19 */ 	return &LRU[K, V]{entries: NewOrderedMap[K, V](), capacity: capacity}
+/* container/lru.sgo:20 */ }
+
+// Len returns the number of entries in c.
+// For SGo: (*LRU[K, V]) func() int
+func (c *LRU[K, V]) Len() int {
+/* container/lru.sgo:24 */ 	return c.entries.Len()
+/* container/lru.sgo:25 */ }
+
+// Cap returns the maximum number of entries in c.
+// For SGo: (*LRU[K, V]) func() int
+func (c *LRU[K, V]) Cap() int {
+/* container/lru.sgo:29 */ 	return c.capacity
+/* container/lru.sgo:30 */ }
+
+// Get returns the value cached for k, and marks it as the most recently used.
+// If k isn't cached, ok is false.
+// For SGo: (*LRU[K, V]) func(k K) (v V \ ok bool)
+func (c *LRU[K, V]) Get(k K) (v V, ok bool) {
+/* container/lru.sgo:35 */ 	v, ok = c.entries.Get(k)
+/* container/lru.sgo:36 */ 	if !ok {
+/* container/lru.sgo:37 */ 		return *new(V), false
+/* container/lru.sgo:38 */ 	}
+/* container/lru.sgo:39 */ 	c.entries.MoveToBack(k)
+/* container/lru.sgo:40 */ 	return v, true
+/* container/lru.sgo:41 */ }
+
+// Peek is like Get, but doesn't mark k as used.
+// For SGo: (*LRU[K, V]) func(k K) (v V \ ok bool)
+func (c *LRU[K, V]) Peek(k K) (v V, ok bool) {
+/* container/lru.sgo:45 */ 	return c.entries.Get(k)
+/* container/lru.sgo:46 */ }
+
+// Add caches v for k, and marks it as the most recently used. If that makes
+// c hold more than Cap entries, the least recently used one is evicted and
+// returned; otherwise, evicted is false.
+// For SGo: (*LRU[K, V]) func(k K, v V) (evictedKey K, evictedValue V \ evicted bool)
+func (c *LRU[K, V]) Add(k K, v V) (evictedKey K, evictedValue V, evicted bool) {
+/* container/lru.sgo:52 */ 	c.entries.Set(k, v)
+/* container/lru.sgo:53 */ 	c.entries.MoveToBack(k)
+/* container/lru.sgo:54 */ 	if c.entries.Len() <= c.capacity {
+/* container/lru.sgo:55 */ 		return *new(K), *new(V), false
+/* container/lru.sgo:56 */ 	}
+/* container/lru.sgo:57 */ 	evictedKey, evictedValue, evicted = c.entries.Front()
+/* container/lru.sgo:58 */ 	if !evicted {
+/* container/lru.sgo:59 */ 		return *new(K), *new(V), false
+/* container/lru.sgo:60 */ 	}
+/* container/lru.sgo:61 */ 	c.entries.Delete(evictedKey)
+/* container/lru.sgo:62 */ 	return evictedKey, evictedValue, true
+/* container/lru.sgo:63 */ }
+
+// Remove removes k from c, and returns the value that was cached for it. If k
+// wasn't cached, ok is false.
+// For SGo: (*LRU[K, V]) func(k K) (v V \ ok bool)
+func (c *LRU[K, V]) Remove(k K) (v V, ok bool) {
+/* container/lru.sgo:68 */ 	return c.entries.Delete(k)
+/* container/lru.sgo:69 */ }
+
+// All returns an iterator over the entries of c, from the least to the most
+// recently used. It doesn't mark them as used.
+// For SGo: (*LRU[K, V]) func() func(yield func(K, V) bool)
+func (c *LRU[K, V]) All() func(yield func(K, V) bool) {
+/* container/lru.sgo:74 */ 	return c.entries.All()
+/* container/lru.sgo:75 */ }
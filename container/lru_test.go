@@ -0,0 +1,79 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package container
+
+/* container/lru_test.sgo:3 */ import (
+/* container/lru_test.sgo:4 */ 	"reflect"
+/* container/lru_test.sgo:5 */ 	"testing"
+/* container/lru_test.sgo:6 */ )
+
+// For SGo: func(t *testing.T)
+func TestLRU(t *testing.T) {
+/* container/lru_test.sgo:9 */ 	c := NewLRU[string, int](2)
+/* container/lru_test.sgo:10 */ 	c.Add("a", 1)
+/* container/lru_test.sgo:11 */ 	c.Add("b", 2)
+
+	// Using a makes b the least recently used.
+/* container/lru_test.sgo:14 */ 	v, ok := c.Get("a")
+/* container/lru_test.sgo:15 */ 	if !ok {
+/* container/lru_test.sgo:16 */ 		t.Errorf("Get(a): not found")
+/* container/lru_test.sgo:17 */ 	} else if v != 1 {
+/* container/lru_test.sgo:18 */ 		t.Errorf("Get(a): expected 1, got %d", v)
+/* container/lru_test.sgo:19 */ 	}
+/* container/lru_test.sgo:20 */ 	k, v, evicted := c.Add("c", 3)
+/* container/lru_test.sgo:21 */ 	if !evicted {
+/* container/lru_test.sgo:22 */ 		t.Errorf("Add(c): nothing evicted")
+/* container/lru_test.sgo:23 */ 	} else if k != "b" || v != 2 {
+/* container/lru_test.sgo:24 */ 		t.Errorf("Add(c): expected to evict b, 2, got %s, %d", k, v)
+/* container/lru_test.sgo:25 */ 	}
+/* container/lru_test.sgo:26 */ 	_, ok = c.Peek("b")
+/* container/lru_test.sgo:27 */ 	if ok {
+/* container/lru_test.sgo:28 */ 		t.Errorf("Peek(b): found evicted entry")
+/* container/lru_test.sgo:29 */ 	}
+
+	// Peeking doesn't use a, so it's evicted next.
+/* container/lru_test.sgo:32 */ 	_, ok = c.Peek("a")
+/* container/lru_test.sgo:33 */ 	if !ok {
+/* container/lru_test.sgo:34 */ 		t.Errorf("Peek(a): not found")
+/* container/lru_test.sgo:35 */ 	}
+/* container/lru_test.sgo:36 */ 	k, _, evicted = c.Add("d", 4)
+/* container/lru_test.sgo:37 */ 	if !evicted {
+/* container/lru_test.sgo:38 */ 		t.Errorf("Add(d): nothing evicted")
+/* container/lru_test.sgo:39 */ 	} else if k != "a" {
+/* container/lru_test.sgo:40 */ 		t.Errorf("Add(d): expected to evict a, got %s", k)
+/* container/lru_test.sgo:41 */ 	}
+
+	// Updating an entry doesn't evict anything.
+/* container/lru_test.sgo:44 */ 	_, _, evicted = c.Add("c", 30)
+/* container/lru_test.sgo:45 */ 	if evicted {
+/* container/lru_test.sgo:46 */ 		t.Errorf("Add(c) again: evicted")
+/* container/lru_test.sgo:47 */ 	}
+
+/* container/lru_test.sgo:49 */ 	var got []string
+/* container/lru_test.sgo:50 */ 	for k := range c.All() {
+/* container/lru_test.sgo:51 */ 		got = append(got, k)
+/* container/lru_test.sgo:52 */ 	}
+/* container/lru_test.sgo:53 */ 	if expected := []string{"d", "c"}; !reflect.DeepEqual(got, expected) {
+/* container/lru_test.sgo:54 */ 		t.Errorf("All: expected %v, got %v", expected, got)
+/* container/lru_test.sgo:55 */ 	}
+
+/* container/lru_test.sgo:57 */ 	v, ok = c.Remove("d")
+/* container/lru_test.sgo:58 */ 	if !ok {
+/* container/lru_test.sgo:59 */ 		t.Errorf("Remove(d): not found")
+/* container/lru_test.sgo:60 */ 	} else if v != 4 {
+/* container/lru_test.sgo:61 */ 		t.Errorf("Remove(d): expected 4, got %d", v)
+/* container/lru_test.sgo:62 */ 	}
+/* container/lru_test.sgo:63 */ 	if c.Len() != 1 || c.Cap() != 2 {
+/* container/lru_test.sgo:64 */ 		t.Errorf("Len, Cap: expected 1, 2, got %d, %d", c.Len(), c.Cap())
+/* container/lru_test.sgo:65 */ 	}
+/* container/lru_test.sgo:66 */ }
+
+// For SGo: func(t *testing.T)
+func TestLRUCapacity(t *testing.T) {
+/* container/lru_test.sgo:69 */ 	defer func() {
+/* container/lru_test.sgo:70 */ 		if recover() == nil {
+/* container/lru_test.sgo:71 */ 			t.Error("expected panic")
+/* container/lru_test.sgo:72 */ 		}
+/* container/lru_test.sgo:73 */ 	}()
+/* container/lru_test.sgo:74 */ 	NewLRU[int, int](0)
+/* container/lru_test.sgo:75 */ }
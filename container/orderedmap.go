@@ -0,0 +1,137 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package container
+
+// entry is a key-value pair kept in an OrderedMap's list.
+/* container/orderedmap.sgo:4 */ type entry[K comparable, V any] struct {
+/* container/orderedmap.sgo:5 */ 	key   K
+/* container/orderedmap.sgo:6 */ 	value V
+/* container/orderedmap.sgo:7 */ }
+
+// An OrderedMap is a map that remembers the order in which its keys were
+// first set. Iterating over it yields its entries in that order, oldest
+// first, unless they are moved with MoveToFront or MoveToBack.
+//
+// The zero value isn't usable; use NewOrderedMap.
+/* container/orderedmap.sgo:14 */ type OrderedMap[K comparable, V any] struct {
+/* container/orderedmap.sgo:15 */ 	elems map[K]*Element[entry[K, V]]
+/* container/orderedmap.sgo:16 */ 	order List[entry[K, V]]
+/* container/orderedmap.sgo:17 */ }
+
+// NewOrderedMap returns an empty OrderedMap.
+// For SGo: func() *OrderedMap[K, V]
+func NewOrderedMap[K comparable, V any]() *OrderedMap[K, V] {
+/* container/orderedmap.sgo:21 */ 	return &OrderedMap[K, V]{elems: map[K]*Element[entry[K, V]]{}}
+/* container/orderedmap.sgo:22 */ }
+
+// Len returns the number of entries in m.
+// For SGo: (*OrderedMap[K, V]) func() int
+func (m *OrderedMap[K, V]) Len() int {
+/* container/orderedmap.sgo:26 */ 	return m.order.Len()
+/* container/orderedmap.sgo:27 */ }
+
+// Has reports whether k is set in m.
+// For SGo: (*OrderedMap[K, V]) func(k K) bool
+func (m *OrderedMap[K, V]) Has(k K) bool {
+/* container/orderedmap.sgo:31 */ 	_, ok := m.elems[k]
+/* container/orderedmap.sgo:32 */ 	return ok
+/* container/orderedmap.sgo:33 */ }
+
+// Get returns the value set for k. If k isn't set, ok is false.
+// For SGo: (*OrderedMap[K, V]) func(k K) (v V \ ok bool)
+func (m *OrderedMap[K, V]) Get(k K) (v V, ok bool) {
+/* container/orderedmap.sgo:37 */ 	e, ok := m.elems[k]
+/* container/orderedmap.sgo:38 */ 	if !ok {
+/* container/orderedmap.sgo:39 */ 		return *new(V), false
+/* container/orderedmap.sgo:40 */ 	}
+/* container/orderedmap.sgo:41 */ 	return e.Value.value, true
+/* container/orderedmap.sgo:42 */ }
+
+// Set sets the value for k to v. If k wasn't set, it's placed at the back of
+// m; otherwise, its position is kept.
+// For SGo: (*OrderedMap[K, V]) func(k K, v V)
+func (m *OrderedMap[K, V]) Set(k K, v V) {
+/* container/orderedmap.sgo:47 */ 	e, ok := m.elems[k]
+/* container/orderedmap.sgo:48 */ 	if ok {
+/* container/orderedmap.sgo:49 */ 		e.Value.value = v
+/* container/orderedmap.sgo:50 */ 		return
+/* container/orderedmap.sgo:51 */ 	}
+/* container/orderedmap.sgo:52 */ 	m.elems[k] = m.order.PushBack(entry[K, V]{key: k, value: v})
+/* container/orderedmap.sgo:53 */ }
+
+// Delete removes k from m, and returns the value that was set for it. If k
+// wasn't set, ok is false.
+// For SGo: (*OrderedMap[K, V]) func(k K) (v V \ ok bool)
+func (m *OrderedMap[K, V]) Delete(k K) (v V, ok bool) {
+/* container/orderedmap.sgo:58 */ 	e, ok := m.elems[k]
+/* container/orderedmap.sgo:59 */ 	if !ok {
+/* container/orderedmap.sgo:60 */ 		return *new(V), false
+/* container/orderedmap.sgo:61 */ 	}
+/* container/orderedmap.sgo:62 */ 	delete(m.elems, k)
+/* container/orderedmap.sgo:63 */ 	m.order.remove(e)
+/* container/orderedmap.sgo:64 */ 	return e.Value.value, true
+/* container/orderedmap.sgo:65 */ }
+
+// Front returns the first entry of m. If m is empty, ok is false.
+// For SGo: (*OrderedMap[K, V]) func() (k K, v V \ ok bool)
+func (m *OrderedMap[K, V]) Front() (k K, v V, ok bool) {
+/* container/orderedmap.sgo:69 */ 	e := m.order.Front()
+/* container/orderedmap.sgo:70 */ 	if e == nil {
+/* container/orderedmap.sgo:71 */ 		return *new(K), *new(V), false
+/* container/orderedmap.sgo:72 */ 	}
+/* container/orderedmap.sgo:73 */ 	return e.Value.key, e.Value.value, true
+/* container/orderedmap.sgo:74 */ }
+
+// Back returns the last entry of m. If m is empty, ok is false.
+// For SGo: (*OrderedMap[K, V]) func() (k K, v V \ ok bool)
+func (m *OrderedMap[K, V]) Back() (k K, v V, ok bool) {
+/* container/orderedmap.sgo:78 */ 	e := m.order.Back()
+/* container/orderedmap.sgo:79 */ 	if e == nil {
+/* container/orderedmap.sgo:80 */ 		return *new(K), *new(V), false
+/* container/orderedmap.sgo:81 */ 	}
+/* container/orderedmap.sgo:82 */ 	return e.Value.key, e.Value.value, true
+/* container/orderedmap.sgo:83 */ }
+
+// MoveToFront moves k to the front of m, and reports whether k is set.
+// For SGo: (*OrderedMap[K, V]) func(k K) bool
+func (m *OrderedMap[K, V]) MoveToFront(k K) bool {
+/* container/orderedmap.sgo:87 */ 	e, ok := m.elems[k]
+/* container/orderedmap.sgo:88 */ 	if !ok {
+/* container/orderedmap.sgo:89 */ 		return false
+/* container/orderedmap.sgo:90 */ 	}
+/* container/orderedmap.sgo:91 */ 	m.order.MoveToFront(e)
+/* container/orderedmap.sgo:92 */ 	return true
+/* container/orderedmap.sgo:93 */ }
+
+// MoveToBack moves k to the back of m, and reports whether k is set.
+// For SGo: (*OrderedMap[K, V]) func(k K) bool
+func (m *OrderedMap[K, V]) MoveToBack(k K) bool {
+/* container/orderedmap.sgo:97 */ 	e, ok := m.elems[k]
+/* container/orderedmap.sgo:98 */ 	if !ok {
+/* container/orderedmap.sgo:99 */ 		return false
+/* container/orderedmap.sgo:100 */ 	}
+/* container/orderedmap.sgo:101 */ 	m.order.MoveToBack(e)
+/* container/orderedmap.sgo:102 */ 	return true
+/* container/orderedmap.sgo:103 */ }
+
+// All returns an iterator over the entries of m, from front to back.
+// For SGo: (*OrderedMap[K, V]) func() func(yield func(K, V) bool)
+func (m *OrderedMap[K, V]) All() func(yield func(K, V) bool) {
+/* container/orderedmap.sgo:107 */ 	return func(yield func(K, V) bool) {
+/* container/orderedmap.sgo:108 */ 		for e := range m.order.All() {
+/* container/orderedmap.sgo:109 */ 			if !yield(e.key, e.value) {
+/* container/orderedmap.sgo:110 */ 				return
+/* container/orderedmap.sgo:111 */ 			}
+/* container/orderedmap.sgo:112 */ 		}
+/* container/orderedmap.sgo:113 */ 	}
+/* container/orderedmap.sgo:114 */ }
+
+// Keys returns the keys of m, from front to back.
+// For SGo: (*OrderedMap[K, V]) func() []K
+func (m *OrderedMap[K, V]) Keys() []K {
+/* container/orderedmap.sgo:118 */ 	ks := make([]K, 0, m.Len())
+/* container/orderedmap.sgo:119 */ 	for k := range m.All() {
+/* container/orderedmap.sgo:120 */ 		ks = append(ks, k)
+/* container/orderedmap.sgo:121 */ 	}
+/* container/orderedmap.sgo:122 */ 	return ks
+/* container/orderedmap.sgo:123 */ }
@@ -0,0 +1,88 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package container
+
+/* container/orderedmap_test.sgo:3 */ import (
+/* container/orderedmap_test.sgo:4 */ 	"reflect"
+/* container/orderedmap_test.sgo:5 */ 	"testing"
+/* container/orderedmap_test.sgo:6 */ )
+
+// For SGo: func(t *testing.T)
+func TestOrderedMap(t *testing.T) {
+/* container/orderedmap_test.sgo:9 */ 	m := NewOrderedMap[string, int]()
+/* container/orderedmap_test.sgo:10 */ 	m.Set("b", 2)
+/* container/orderedmap_test.sgo:11 */ 	m.Set("a", 1)
+/* container/orderedmap_test.sgo:12 */ 	m.Set("c", 3)
+/* container/orderedmap_test.sgo:13 */ 	m.Set("b", 20)
+
+/* container/orderedmap_test.sgo:15 */ 	if expected := []string{"b", "a", "c"}; !reflect.DeepEqual(m.Keys(), expected) {
+/* container/orderedmap_test.sgo:16 */ 		t.Errorf("keys: expected %v, got %v", expected, m.Keys())
+/* container/orderedmap_test.sgo:17 */ 	}
+/* container/orderedmap_test.sgo:18 */ 	v, ok := m.Get("b")
+/* container/orderedmap_test.sgo:19 */ 	if !ok {
+/* container/orderedmap_test.sgo:20 */ 		t.Errorf("Get(b): not found")
+/* container/orderedmap_test.sgo:21 */ 	} else if v != 20 {
+/* container/orderedmap_test.sgo:22 */ 		t.Errorf("Get(b): expected 20, got %d", v)
+/* container/orderedmap_test.sgo:23 */ 	}
+/* container/orderedmap_test.sgo:24 */ 	_, ok = m.Get("z")
+/* container/orderedmap_test.sgo:25 */ 	if ok {
+/* container/orderedmap_test.sgo:26 */ 		t.Errorf("Get(z): found")
+/* container/orderedmap_test.sgo:27 */ 	}
+
+/* container/orderedmap_test.sgo:29 */ 	k, v, ok := m.Front()
+/* container/orderedmap_test.sgo:30 */ 	if !ok {
+/* container/orderedmap_test.sgo:31 */ 		t.Errorf("Front: not found")
+/* container/orderedmap_test.sgo:32 */ 	} else if k != "b" || v != 20 {
+/* container/orderedmap_test.sgo:33 */ 		t.Errorf("Front: expected b, 20, got %s, %d", k, v)
+/* container/orderedmap_test.sgo:34 */ 	}
+/* container/orderedmap_test.sgo:35 */ 	if !m.MoveToBack("b") || m.MoveToBack("z") {
+/* container/orderedmap_test.sgo:36 */ 		t.Errorf("MoveToBack: wrong result")
+/* container/orderedmap_test.sgo:37 */ 	}
+/* container/orderedmap_test.sgo:38 */ 	if !m.MoveToFront("c") {
+/* container/orderedmap_test.sgo:39 */ 		t.Errorf("MoveToFront: wrong result")
+/* container/orderedmap_test.sgo:40 */ 	}
+/* container/orderedmap_test.sgo:41 */ 	k, v, ok = m.Back()
+/* container/orderedmap_test.sgo:42 */ 	if !ok {
+/* container/orderedmap_test.sgo:43 */ 		t.Errorf("Back: not found")
+/* container/orderedmap_test.sgo:44 */ 	} else if k != "b" || v != 20 {
+/* container/orderedmap_test.sgo:45 */ 		t.Errorf("Back: expected b, 20, got %s, %d", k, v)
+/* container/orderedmap_test.sgo:46 */ 	}
+
+/* container/orderedmap_test.sgo:48 */ 	v, ok = m.Delete("a")
+/* container/orderedmap_test.sgo:49 */ 	if !ok {
+/* container/orderedmap_test.sgo:50 */ 		t.Errorf("Delete(a): not found")
+/* container/orderedmap_test.sgo:51 */ 	} else if v != 1 {
+/* container/orderedmap_test.sgo:52 */ 		t.Errorf("Delete(a): expected 1, got %d", v)
+/* container/orderedmap_test.sgo:53 */ 	}
+/* container/orderedmap_test.sgo:54 */ 	if m.Has("a") {
+/* container/orderedmap_test.sgo:55 */ 		t.Errorf("Has(a) after Delete")
+/* container/orderedmap_test.sgo:56 */ 	}
+/* container/orderedmap_test.sgo:57 */ 	_, ok = m.Delete("a")
+/* container/orderedmap_test.sgo:58 */ 	if ok {
+/* container/orderedmap_test.sgo:59 */ 		t.Errorf("Delete(a) twice: found")
+/* container/orderedmap_test.sgo:60 */ 	}
+
+/* container/orderedmap_test.sgo:62 */ 	var got []string
+/* container/orderedmap_test.sgo:63 */ 	for k := range m.All() {
+/* container/orderedmap_test.sgo:64 */ 		got = append(got, k)
+/* container/orderedmap_test.sgo:65 */ 	}
+/* container/orderedmap_test.sgo:66 */ 	if expected := []string{"c", "b"}; !reflect.DeepEqual(got, expected) {
+/* container/orderedmap_test.sgo:67 */ 		t.Errorf("All: expected %v, got %v", expected, got)
+/* container/orderedmap_test.sgo:68 */ 	}
+/* container/orderedmap_test.sgo:69 */ 	if m.Len() != 2 {
+/* container/orderedmap_test.sgo:70 */ 		t.Errorf("Len: expected 2, got %d", m.Len())
+/* container/orderedmap_test.sgo:71 */ 	}
+/* container/orderedmap_test.sgo:72 */ }
+
+// For SGo: func(t *testing.T)
+func TestOrderedMapEmpty(t *testing.T) {
+/* container/orderedmap_test.sgo:75 */ 	m := NewOrderedMap[int, *int]()
+/* container/orderedmap_test.sgo:76 */ 	_, _, ok := m.Front()
+/* container/orderedmap_test.sgo:77 */ 	if ok {
+/* container/orderedmap_test.sgo:78 */ 		t.Errorf("Front: found")
+/* container/orderedmap_test.sgo:79 */ 	}
+/* container/orderedmap_test.sgo:80 */ 	_, _, ok = m.Back()
+/* container/orderedmap_test.sgo:81 */ 	if ok {
+/* container/orderedmap_test.sgo:82 */ 		t.Errorf("Back: found")
+/* container/orderedmap_test.sgo:83 */ 	}
+/* container/orderedmap_test.sgo:84 */ }
@@ -0,0 +1,105 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package container
+
+// A Set is an unordered collection of distinct values.
+// For SGo: map[T]struct{}
+type Set[T comparable] map[T]struct{}
+
+// NewSet returns a set with the given values.
+// For SGo: func(vs ...T) Set[T]
+func NewSet[T comparable](vs ...T) Set[T] {
+/* container/set.sgo:8 */ 	s := make(Set[T], len(vs))
+/* container/set.sgo:9 */ 	s.Add(vs...)
+/* container/set.sgo:10 */ 	return s
+/* container/set.sgo:11 */ }
+
+// Len returns the number of values in s.
+// For SGo: (Set[T]) func() int
+func (s Set[T]) Len() int {
+/* container/set.sgo:15 */ 	return len(s)
+/* container/set.sgo:16 */ }
+
+// Has reports whether v is in s.
+// For SGo: (Set[T]) func(v T) bool
+func (s Set[T]) Has(v T) bool {
+/* container/set.sgo:20 */ 	_, ok := s[v]
+/* container/set.sgo:21 */ 	return ok
+/* container/set.sgo:22 */ }
+
+// Add adds the given values to s.
+// For SGo: (Set[T]) func(vs ...T)
+func (s Set[T]) Add(vs ...T) {
+/* container/set.sgo:26 */ 	for _, v := range vs {
+/* container/set.sgo:27 */ 		s[v] = struct{}{}
+/* container/set.sgo:28 */ 	}
+/* container/set.sgo:29 */ }
+
+// Remove removes v from s, and reports whether it was in s.
+// For SGo: (Set[T]) func(v T) bool
+func (s Set[T]) Remove(v T) bool {
+/* container/set.sgo:33 */ 	if !s.Has(v) {
+/* container/set.sgo:34 */ 		return false
+/* container/set.sgo:35 */ 	}
+/* container/set.sgo:36 */ 	delete(s, v)
+/* container/set.sgo:37 */ 	return true
+/* container/set.sgo:38 */ }
+
+// All returns an iterator over the values of s, in no particular order.
+// For SGo: (Set[T]) func() func(yield func(T) bool)
+func (s Set[T]) All() func(yield func(T) bool) {
+/* container/set.sgo:42 */ 	return func(yield func(T) bool) {
+/* container/set.sgo:43 */ 		for v := range s {
+/* container/set.sgo:44 */ 			if !yield(v) {
+/* container/set.sgo:45 */ 				return
+/* container/set.sgo:46 */ 			}
+/* container/set.sgo:47 */ 		}
+/* container/set.sgo:48 */ 	}
+/* container/set.sgo:49 */ }
+
+// Values returns the values of s, in no particular order.
+// For SGo: (Set[T]) func() []T
+func (s Set[T]) Values() []T {
+/* container/set.sgo:53 */ 	vs := make([]T, 0, len(s))
+/* container/set.sgo:54 */ 	for v := range s {
+/* container/set.sgo:55 */ 		vs = append(vs, v)
+/* container/set.sgo:56 */ 	}
+/* container/set.sgo:57 */ 	return vs
+/* container/set.sgo:58 */ }
+
+// Union returns a new set with the values that are in s, t, or both.
+// For SGo: (Set[T]) func(t Set[T]) Set[T]
+func (s Set[T]) Union(t Set[T]) Set[T] {
+/* container/set.sgo:62 */ 	u := make(Set[T], len(s)+len(t))
+/* container/set.sgo:63 */ 	for v := range s {
+/* container/set.sgo:64 */ 		u[v] = struct{}{}
+/* container/set.sgo:65 */ 	}
+/* container/set.sgo:66 */ 	for v := range t {
+/* container/set.sgo:67 */ 		u[v] = struct{}{}
+/* container/set.sgo:68 */ 	}
+/* container/set.sgo:69 */ 	return u
+/* container/set.sgo:70 */ }
+
+// Intersect returns a new set with the values that are in both s and t.
+// For SGo: (Set[T]) func(t Set[T]) Set[T]
+func (s Set[T]) Intersect(t Set[T]) Set[T] {
+/* container/set.sgo:74 */ 	u := Set[T]{}
+/* container/set.sgo:75 */ 	for v := range s {
+/* container/set.sgo:76 */ 		if t.Has(v) {
+/* container/set.sgo:77 */ 			u[v] = struct{}{}
+/* container/set.sgo:78 */ 		}
+/* container/set.sgo:79 */ 	}
+/* container/set.sgo:80 */ 	return u
+/* container/set.sgo:81 */ }
+
+// Difference returns a new set with the values that are in s but not in t.
+// For SGo: (Set[T]) func(t Set[T]) Set[T]
+func (s Set[T]) Difference(t Set[T]) Set[T] {
+/* container/set.sgo:85 */ 	u := Set[T]{}
+/* container/set.sgo:86 */ 	for v := range s {
+/* container/set.sgo:87 */ 		if !t.Has(v) {
+/* container/set.sgo:88 */ 			u[v] = struct{}{}
+/* container/set.sgo:89 */ 		}
+/* container/set.sgo:90 */ 	}
+/* container/set.sgo:91 */ 	return u
+/* container/set.sgo:92 */ }
@@ -0,0 +1,54 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package container
+
+/* container/set_test.sgo:3 */ import (
+/* container/set_test.sgo:4 */ 	"reflect"
+/* container/set_test.sgo:5 */ 	"sort"
+/* container/set_test.sgo:6 */ 	"testing"
+/* container/set_test.sgo:7 */ )
+
+/* container/set_test.sgo:9 */ func sorted(s Set[int]) []int {
+/* container/set_test.sgo:10 */ 	vs := s.Values()
+/* container/set_test.sgo:11 */ 	sort.Ints(vs)
+/* container/set_test.sgo:12 */ 	return vs
+/* container/set_test.sgo:13 */ }
+
+// For SGo: func(t *testing.T)
+func TestSet(t *testing.T) {
+/* container/set_test.sgo:16 */ 	s := NewSet(1, 2, 3, 2)
+/* container/set_test.sgo:17 */ 	if s.Len() != 3 {
+/* container/set_test.sgo:18 */ 		t.Errorf("Len: expected 3, got %d", s.Len())
+/* container/set_test.sgo:19 */ 	}
+/* container/set_test.sgo:20 */ 	if !s.Has(2) || s.Has(4) {
+/* container/set_test.sgo:21 */ 		t.Errorf("Has: wrong result")
+/* container/set_test.sgo:22 */ 	}
+/* container/set_test.sgo:23 */ 	if !s.Remove(2) || s.Remove(2) {
+/* container/set_test.sgo:24 */ 		t.Errorf("Remove: wrong result")
+/* container/set_test.sgo:25 */ 	}
+/* container/set_test.sgo:26 */ 	s.Add(4, 5)
+
+/* container/set_test.sgo:28 */ 	o := NewSet(3, 4, 6)
+/* container/set_test.sgo:29 */ 	for _, c := range []struct {
+/* container/set_test.sgo:30 */ 		name     string
+/* container/set_test.sgo:31 */ 		got      Set[int]
+/* container/set_test.sgo:32 */ 		expected []int
+/* container/set_test.sgo:33 */ 	}{
+/* container/set_test.sgo:34 */ 		{"Union", s.Union(o), []int{1, 3, 4, 5, 6}},
+/* container/set_test.sgo:35 */ 		{"Intersect", s.Intersect(o), []int{3, 4}},
+/* container/set_test.sgo:36 */ 		{"Difference", s.Difference(o), []int{1, 5}},
+/* container/set_test.sgo:37 */ 	} {
+/* container/set_test.sgo:38 */ 		if got := sorted(c.got); !reflect.DeepEqual(got, c.expected) {
+/* container/set_test.sgo:39 */ 			t.Errorf("%s: expected %v, got %v", c.name, c.expected, got)
+/* container/set_test.sgo:40 */ 		}
+/* container/set_test.sgo:41 */ 	}
+
+/* container/set_test.sgo:43 */ 	n := 0
+/* container/set_test.sgo:44 */ 	for range s.All() {
+/* container/set_test.sgo:45 */ 		n++
+/* container/set_test.sgo:46 */ 		break
+/* container/set_test.sgo:47 */ 	}
+/* container/set_test.sgo:48 */ 	if n != 1 {
+/* container/set_test.sgo:49 */ 		t.Errorf("All: didn't stop")
+/* container/set_test.sgo:50 */ 	}
+/* container/set_test.sgo:51 */ }
@@ -5,104 +5,212 @@ package main
 /* main.sgo:3 */ import (
 /* main.sgo:4 */ 	"fmt"
 /* main.sgo:5 */ 	"io"
-/* main.sgo:6 */ 	"os"
-/* main.sgo:7 */ 	"os/exec"
-
-/* main.sgo:9 */ 	"github.com/tcard/sgo/sgo"
-/* main.sgo:10 */ 	"github.com/tcard/sgo/sgo/scanner"
-/* main.sgo:11 */ )
-
-/* main.sgo:13 */ func main() {
-/* main.sgo:14 */ 	if len(os.Args) == 1 {
-/* main.sgo:15 */ 		fmt.Print(helpMsg)
-/* main.sgo:16 */ 		return
-/* main.sgo:17 */ 	}
-
-/* main.sgo:19 */ 	var buildFlags []string
-/* main.sgo:20 */ 	var extraArgs []string
-/* main.sgo:21 */ 	for i, arg := range os.Args[2:] {
-/* main.sgo:22 */ 		if arg[0] == '-' {
-/* main.sgo:23 */ 			buildFlags = append(buildFlags, arg)
-/* main.sgo:24 */ 		} else {
-/* main.sgo:25 */ 			extraArgs = os.Args[i+2:]
-/* main.sgo:26 */ 			break
-/* main.sgo:27 */ 		}
-/* main.sgo:28 */ 	}
-
-/* main.sgo:30 */ 	switch os.Args[1] {
-/* main.sgo:31 */ 	case "version":
-/* main.sgo:32 */ 		fmt.Println("sgo version 0.7 (compatible with go1.7)")
-/* main.sgo:33 */ 		return
-/* main.sgo:34 */ 	case "run":
-/* main.sgo:35 */ 		if len(extraArgs) == 0 {
-/* main.sgo:36 */ 			fmt.Fprintln(os.Stderr, "sgo run: no files listed")
-/* main.sgo:37 */ 			os.Exit(1)
-/* main.sgo:38 */ 		}
-/* main.sgo:39 */ 		created, errs := sgo.TranslateFilePaths(extraArgs...)
-/* main.sgo:40 */ 		reportErrs(errs...)
-/* main.sgo:41 */ 		if len(errs) > 0 {
+/* main.sgo:6 */ 	"net/http"
+/* main.sgo:7 */ 	"os"
+/* main.sgo:8 */ 	"os/exec"
+/* main.sgo:9 */ 	"strings"
+
+/* main.sgo:11 */ 	"github.com/tcard/sgo/sgo"
+/* main.sgo:12 */ 	"github.com/tcard/sgo/sgo/importer"
+/* main.sgo:13 */ 	"github.com/tcard/sgo/sgo/scanner"
+/* main.sgo:14 */ )
+
+/* main.sgo:16 */ func main() {
+/* main.sgo:17 */ 	if len(os.Args) == 1 {
+/* main.sgo:18 */ 		fmt.Print(helpMsg)
+/* main.sgo:19 */ 		return
+/* main.sgo:20 */ 	}
+
+/* main.sgo:22 */ 	var buildFlags []string
+/* main.sgo:23 */ 	var extraArgs []string
+/* main.sgo:24 */ 	for i, arg := range os.Args[2:] {
+/* main.sgo:25 */ 		if arg == "-sgo.boundarychecks" {
+/* main.sgo:26 */ 			os.Setenv(sgo.BoundaryChecksEnv, "1")
+/* main.sgo:27 */ 		} else if arg[0] == '-' {
+/* main.sgo:28 */ 			buildFlags = append(buildFlags, arg)
+/* main.sgo:29 */ 		} else {
+/* main.sgo:30 */ 			extraArgs = os.Args[i+2:]
+/* main.sgo:31 */ 			break
+/* main.sgo:32 */ 		}
+/* main.sgo:33 */ 	}
+
+/* main.sgo:35 */ 	switch os.Args[1] {
+/* main.sgo:36 */ 	case "version":
+/* main.sgo:37 */ 		fmt.Println("sgo version 0.7 (compatible with go1.23)")
+/* main.sgo:38 */ 		return
+/* main.sgo:39 */ 	case "run":
+/* main.sgo:40 */ 		if len(extraArgs) == 0 {
+/* main.sgo:41 */ 			fmt.Fprintln(os.Stderr, "sgo run: no files listed")
 /* main.sgo:42 */ 			os.Exit(1)
 /* main.sgo:43 */ 		}
-/* main.sgo:44 */ 		runGoCommand("run", buildFlags, created...)
-/* main.sgo:45 */ 		return
-/* main.sgo:46 */ 	case "help":
-/* main.sgo:47 */ 		if len(extraArgs) == 0 {
-/* main.sgo:48 */ 			fmt.Print(helpMsg)
-/* main.sgo:49 */ 		} else {
-/* main.sgo:50 */ 			switch extraArgs[0] {
-/* main.sgo:51 */ 			case "translate":
-/* main.sgo:52 */ 				fmt.Print(translateHelpMsg)
-/* main.sgo:53 */ 				return
-/* main.sgo:54 */ 			case "version":
-/* main.sgo:55 */ 				fmt.Print(versionHelpMsg)
-/* main.sgo:56 */ 				return
-/* main.sgo:57 */ 			}
-/* main.sgo:58 */ 			runGoCommand("help", buildFlags, extraArgs...)
-/* main.sgo:59 */ 		}
-/* main.sgo:60 */ 		return
-/* main.sgo:61 */ 	case "translate":
-/* main.sgo:62 */ 		errs := sgo.TranslateFile(func() (io.Writer, error) { return os.Stdout, nil }, os.Stdin, "stdin.sgo")
-/* main.sgo:63 */ 		if len(errs) > 0 {
-/* main.sgo:64 */ 			reportErrs(errs...)
-/* main.sgo:65 */ 			os.Exit(1)
-/* main.sgo:66 */ 		}
-/* main.sgo:67 */ 		return
-/* main.sgo:68 */ 	}
-
-/* main.sgo:70 */ 	if len(extraArgs) == 0 {
-/* main.sgo:71 */ 		extraArgs = append(extraArgs, ".")
-/* main.sgo:72 */ 	}
-/* main.sgo:73 */ 	_, warnings, errs := sgo.TranslatePaths(extraArgs)
-/* main.sgo:74 */ 	reportErrs(warnings...)
-/* main.sgo:75 */ 	reportErrs(errs...)
-/* main.sgo:76 */ 	if len(errs) > 0 {
-/* main.sgo:77 */ 		os.Exit(1)
-/* main.sgo:78 */ 	}
-
-/* main.sgo:80 */ 	runGoCommand(os.Args[1], buildFlags, extraArgs...)
-/* main.sgo:81 */ }
-
-/* main.sgo:83 */ func reportErrs(errs ...error) {
-/* main.sgo:84 */ 	for _, err := range errs {
-/* main.sgo:85 */ 		if errs, ok := err.(scanner.ErrorList); ok {
-/* main.sgo:86 */ 			for _, err := range errs {
-/* main.sgo:87 */ 				fmt.Fprintln(os.Stderr, err)
-/* main.sgo:88 */ 			}
-/* main.sgo:89 */ 		} else {
-/* main.sgo:90 */ 			fmt.Fprintln(os.Stderr, err)
-/* main.sgo:91 */ 		}
-/* main.sgo:92 */ 	}
-/* main.sgo:93 */ }
-
-/* main.sgo:95 */ func runGoCommand(cmd string, buildFlags []string, extraArgs ...string) {
-/* main.sgo:96 */ 	c := exec.Command("go", append(append([]string{cmd}, buildFlags...), extraArgs...)...)
-/* main.sgo:97 */ 	c.Stdin = os.Stdin
-/* main.sgo:98 */ 	c.Stdout = os.Stdout
-/* main.sgo:99 */ 	c.Stderr = os.Stderr
-/* main.sgo:100 */ 	c.Run()
-/* main.sgo:101 */ }
-
-/* main.sgo:103 */ const helpMsg = `sgo is a tool for managing SGo source code.
+/* main.sgo:44 */ 		created, warnings, errs := sgo.TranslateFilePathsWithWarnings(extraArgs...)
+/* main.sgo:45 */ 		reportErrs(warnings...)
+/* main.sgo:46 */ 		reportErrs(errs...)
+/* main.sgo:47 */ 		if len(errs) > 0 {
+/* main.sgo:48 */ 			os.Exit(1)
+/* main.sgo:49 */ 		}
+/* main.sgo:50 */ 		runGoCommand("run", buildFlags, created...)
+/* main.sgo:51 */ 		return
+/* main.sgo:52 */ 	case "help":
+/* main.sgo:53 */ 		if len(extraArgs) == 0 {
+/* main.sgo:54 */ 			fmt.Print(helpMsg)
+/* main.sgo:55 */ 		} else {
+/* main.sgo:56 */ 			switch extraArgs[0] {
+/* main.sgo:57 */ 			case "translate":
+/* main.sgo:58 */ 				fmt.Print(translateHelpMsg)
+/* main.sgo:59 */ 				return
+/* main.sgo:60 */ 			case "version":
+/* main.sgo:61 */ 				fmt.Print(versionHelpMsg)
+/* main.sgo:62 */ 				return
+/* main.sgo:63 */ 			case "annotations":
+/* main.sgo:64 */ 				fmt.Print(annotationsHelpMsg)
+/* main.sgo:65 */ 				return
+/* main.sgo:66 */ 			case "migrate":
+/* main.sgo:67 */ 				fmt.Print(migrateHelpMsg)
+/* main.sgo:68 */ 				return
+/* main.sgo:69 */ 			case "fix":
+/* main.sgo:70 */ 				fmt.Print(fixHelpMsg)
+/* main.sgo:71 */ 				return
+/* main.sgo:72 */ 			case "doc":
+/* main.sgo:73 */ 				fmt.Print(docHelpMsg)
+/* main.sgo:74 */ 				return
+/* main.sgo:75 */ 			case "types":
+/* main.sgo:76 */ 				fmt.Print(typesHelpMsg)
+/* main.sgo:77 */ 				return
+/* main.sgo:78 */ 			}
+/* main.sgo:79 */ 			runGoCommand("help", buildFlags, extraArgs...)
+/* main.sgo:80 */ 		}
+/* main.sgo:81 */ 		return
+/* main.sgo:82 */ 	case "translate":
+/* main.sgo:83 */ 		errs := sgo.TranslateFile(func() (io.Writer, error) { return os.Stdout, nil }, os.Stdin, "stdin.sgo")
+/* main.sgo:84 */ 		if len(errs) > 0 {
+/* main.sgo:85 */ 			reportErrs(errs...)
+/* main.sgo:86 */ 			os.Exit(1)
+/* main.sgo:87 */ 		}
+/* main.sgo:88 */ 		return
+/* main.sgo:89 */ 	case "annotations":
+/* main.sgo:90 */ 		whence := "."
+/* main.sgo:91 */ 		if len(extraArgs) > 0 {
+/* main.sgo:92 */ 			whence = extraArgs[0]
+/* main.sgo:93 */ 		}
+/* main.sgo:94 */ 		srcs, err := importer.AnnotationSources(whence)
+/* main.sgo:95 */ 		if err != nil {
+/* main.sgo:96 */ 			reportErrs(err)
+/* main.sgo:97 */ 			os.Exit(1)
+/* main.sgo:98 */ 		}
+/* main.sgo:99 */ 		for _, src := range srcs {
+/* main.sgo:100 */ 			from := src.Dir
+/* main.sgo:101 */ 			if src.Builtin() {
+/* main.sgo:102 */ 				from = "(built-in)"
+/* main.sgo:103 */ 			}
+/* main.sgo:104 */ 			fmt.Printf("%s\t%s\n", src.Path, from)
+/* main.sgo:105 */ 			for _, shadowed := range src.Shadowed {
+/* main.sgo:106 */ 				fmt.Printf("\t(shadowed) %s\n", shadowed)
+/* main.sgo:107 */ 			}
+/* main.sgo:108 */ 		}
+/* main.sgo:109 */ 		return
+/* main.sgo:110 */ 	case "migrate":
+/* main.sgo:111 */ 		if len(extraArgs) == 0 {
+/* main.sgo:112 */ 			extraArgs = append(extraArgs, ".")
+/* main.sgo:113 */ 		}
+/* main.sgo:114 */ 		created, warnings, errs := sgo.MigratePaths(extraArgs)
+/* main.sgo:115 */ 		for _, path := range created {
+/* main.sgo:116 */ 			fmt.Println(path)
+/* main.sgo:117 */ 		}
+/* main.sgo:118 */ 		reportErrs(warnings...)
+/* main.sgo:119 */ 		reportErrs(errs...)
+/* main.sgo:120 */ 		if len(errs) > 0 {
+/* main.sgo:121 */ 			os.Exit(1)
+/* main.sgo:122 */ 		}
+/* main.sgo:123 */ 		return
+/* main.sgo:124 */ 	case "fix":
+/* main.sgo:125 */ 		if len(extraArgs) == 0 {
+/* main.sgo:126 */ 			extraArgs = append(extraArgs, ".")
+/* main.sgo:127 */ 		}
+/* main.sgo:128 */ 		fixed, warnings, errs := sgo.FixPaths(extraArgs)
+/* main.sgo:129 */ 		for _, fix := range fixed {
+/* main.sgo:130 */ 			fmt.Println(fix)
+/* main.sgo:131 */ 		}
+/* main.sgo:132 */ 		reportErrs(warnings...)
+/* main.sgo:133 */ 		reportErrs(errs...)
+/* main.sgo:134 */ 		if len(errs) > 0 {
+/* main.sgo:135 */ 			os.Exit(1)
+/* main.sgo:136 */ 		}
+/* main.sgo:137 */ 		return
+/* main.sgo:138 */ 	case "doc":
+/* main.sgo:139 */ 		httpAddr := ""
+/* main.sgo:140 */ 		for _, flag := range buildFlags {
+/* main.sgo:141 */ 			if !strings.HasPrefix(flag, "-http=") {
+/* main.sgo:142 */ 				fmt.Fprintf(os.Stderr, "sgo doc: unknown flag %s\n", flag)
+/* main.sgo:143 */ 				os.Exit(2)
+/* main.sgo:144 */ 			}
+/* main.sgo:145 */ 			httpAddr = flag[len("-http="):]
+/* main.sgo:146 */ 		}
+/* main.sgo:147 */ 		if httpAddr != "" {
+/* main.sgo:148 */ 			fmt.Fprintf(os.Stderr, "serving documentation at http://%s/pkg/\n", httpAddr)
+/* main.sgo:149 */ 			err := http.ListenAndServe(httpAddr, sgo.DocHandler("."))
+/* main.sgo:150 */ 			if err != nil {
+/* main.sgo:151 */ 				reportErrs(err)
+/* main.sgo:152 */ 			}
+/* main.sgo:153 */ 			os.Exit(1)
+/* main.sgo:154 */ 		}
+/* main.sgo:155 */ 		arg := "."
+/* main.sgo:156 */ 		if len(extraArgs) > 0 {
+/* main.sgo:157 */ 			arg = extraArgs[0]
+/* main.sgo:158 */ 		}
+/* main.sgo:159 */ 		err := sgo.WriteDoc(os.Stdout, arg, ".")
+/* main.sgo:160 */ 		if err != nil {
+/* main.sgo:161 */ 			reportErrs(err)
+/* main.sgo:162 */ 			os.Exit(1)
+/* main.sgo:163 */ 		}
+/* main.sgo:164 */ 		return
+/* main.sgo:165 */ 	case "types":
+/* main.sgo:166 */ 		path := "."
+/* main.sgo:167 */ 		if len(extraArgs) > 0 {
+/* main.sgo:168 */ 			path = extraArgs[0]
+/* main.sgo:169 */ 		}
+/* main.sgo:170 */ 		err := sgo.WriteTypes(os.Stdout, path, ".")
+/* main.sgo:171 */ 		if err != nil {
+/* main.sgo:172 */ 			reportErrs(err)
+/* main.sgo:173 */ 			os.Exit(1)
+/* main.sgo:174 */ 		}
+/* main.sgo:175 */ 		return
+/* main.sgo:176 */ 	}
+
+/* main.sgo:178 */ 	if len(extraArgs) == 0 {
+/* main.sgo:179 */ 		extraArgs = append(extraArgs, ".")
+/* main.sgo:180 */ 	}
+/* main.sgo:181 */ 	_, warnings, errs := sgo.TranslatePaths(extraArgs)
+/* main.sgo:182 */ 	reportErrs(warnings...)
+/* main.sgo:183 */ 	reportErrs(errs...)
+/* main.sgo:184 */ 	if len(errs) > 0 {
+/* main.sgo:185 */ 		os.Exit(1)
+/* main.sgo:186 */ 	}
+
+/* main.sgo:188 */ 	runGoCommand(os.Args[1], buildFlags, extraArgs...)
+/* main.sgo:189 */ }
+
+/* main.sgo:191 */ func reportErrs(errs ...error) {
+/* main.sgo:192 */ 	for _, err := range errs {
+/* main.sgo:193 */ 		if errs, ok := err.(scanner.ErrorList); ok {
+/* main.sgo:194 */ 			for _, err := range errs {
+/* main.sgo:195 */ 				fmt.Fprintln(os.Stderr, err)
+/* main.sgo:196 */ 			}
+/* main.sgo:197 */ 		} else {
+/* main.sgo:198 */ 			fmt.Fprintln(os.Stderr, err)
+/* main.sgo:199 */ 		}
+/* main.sgo:200 */ 	}
+/* main.sgo:201 */ }
+
+/* main.sgo:203 */ func runGoCommand(cmd string, buildFlags []string, extraArgs ...string) {
+/* main.sgo:204 */ 	c := exec.Command("go", append(append([]string{cmd}, buildFlags...), extraArgs...)...)
+/* main.sgo:205 */ 	c.Stdin = os.Stdin
+/* main.sgo:206 */ 	c.Stdout = os.Stdout
+/* main.sgo:207 */ 	c.Stderr = os.Stderr
+/* main.sgo:208 */ 	c.Run()
+/* main.sgo:209 */ }
+
+/* main.sgo:211 */ const helpMsg = `sgo is a tool for managing SGo source code.
 
 Usage:
 
@@ -117,15 +225,24 @@ To see a list of those commands:
 
 Additionally, SGo supports or overrides the following commands:
 	
+	annotations list where the annotations for each Go package come from
+	doc         show documentation for a package or symbol, with SGo types
+	fix         apply the fixes suggested for SGo type errors (not go fix)
+	migrate     convert Go packages to SGo
 	translate   read SGo code, print the resulting Go code
+	types       print the SGo types of a package's objects, and their source
 	version     print SGo version, and the Go version it works with
 
+Translations of SGo code also take the -sgo.boundarychecks flag, which makes
+the resulting code check calls to Go packages, and panic when they return nil
+where their SGo annotations say they can't.
+
 Use "sgo help [command]" for more information about a command.
 
 Use "go help" to see a complete list of help topics.
 `
 
-/* main.sgo:126 */ const translateHelpMsg = `usage: sgo translate
+/* main.sgo:243 */ const translateHelpMsg = `usage: sgo translate
 
 Translate reads SGo code from the standard input, and prints the resulting Go
 code to the standard output.
@@ -134,9 +251,110 @@ If there are errors in the provided SGo code, they will be reported to the
 standard error and the command will exit with a non-zero exit code.
 `
 
-/* main.sgo:135 */ const versionHelpMsg = `usage: sgo version
+/* main.sgo:252 */ const versionHelpMsg = `usage: sgo version
 
 Version prints the SGo version. It also reports the Go version it is compatible
 with. "Compatible" means that SGo compiles to this Go version, and is able to
 import all the packages that this Go version is able to.
 `
+
+/* main.sgo:259 */ const annotationsHelpMsg = `usage: sgo annotations [dir]
+
+Annotations lists, for each Go package that has SGo annotations, where those
+annotations are read from when importing from dir, which defaults to the
+current directory.
+
+Annotations are looked up, in order of precedence:
+
+	- In the annotations built into SGo.
+	- In sgovendor folders, starting from dir and then going up to its
+	  parents.
+	- In the directories listed in the SGOANNPATH environment variable,
+	  separated by the OS path list separator. Those directories must be laid
+	  out like sgovendor folders.
+
+For each package, the directory actually used is printed, and then other
+directories with annotations for the package that are ignored because of the
+former, marked as shadowed.
+`
+
+/* main.sgo:279 */ const migrateHelpMsg = `usage: sgo migrate [packages]
+
+Migrate converts the Go packages named by the import paths, which default to
+the current directory, to SGo. For each Go file, it writes an SGo file with the
+same name and the .sgo extension next to it, and prints its path. As the next
+translation of the package overwrites the Go files, each of them is first
+copied to a backup file with the .go.orig extension. If a backup file exists
+already, the package is not migrated.
+
+The migration is done in three steps:
+
+	- Functions that return (T, error) are made to return (T \ error) if they
+	  return either a nil error or zero values and an error, and all their
+	  callers in the package check the error right away.
+	- All declared types that can be nil are made optional, like when
+	  importing a Go package without annotations.
+	- Each of those types is made non-optional again if that doesn't cause
+	  type errors in new places, until there are no more of them to change.
+
+The type errors left in the resulting SGo code are reported to the standard
+error, so that they can be fixed by hand, usually by adding nil checks. Files
+that are already translated from SGo are not migrated.
+`
+
+/* main.sgo:303 */ const docHelpMsg = `usage: sgo doc [package|[package.]symbol[.method]]
+   or: sgo doc -http=addr
+
+Doc prints the documentation for a package, which defaults to the one in the
+current directory, or for one of its exported constants, variables, functions,
+types or methods. It replaces go doc.
+
+Declarations are shown with their SGo types. For SGo packages, those are as
+written. For Go packages, they are the types that SGo converts them to when
+importing them, after applying their annotations; if those come from a
+sgovendor folder or SGOANNPATH, the documentation says so.
+
+With -http, doc instead serves the documentation of all packages as HTML at the
+given address, such as localhost:6060, under /pkg/. Annotations used for Go
+packages are linked from their documentation.
+`
+
+/* main.sgo:320 */ const fixHelpMsg = `usage: sgo fix [packages]
+
+Fix applies the fixes that SGo suggests for the type errors in the packages
+named by the import paths, which default to the current directory, and prints
+each of them.
+
+Fix is a separate command from go fix, which updates Go code to newer APIs:
+the sgo tool doesn't pass fix on to the go tool, so run go fix itself on the
+Go files translated from SGo for that.
+
+The fixes are for using an optional where a non-optional is needed, and for
+using a value entangled with an error or a bool before checking them:
+
+	- Return early if an optional variable is nil, or if an error entangled
+	  with a variable isn't nil.
+	- Make optional the declared type of the variable, field, parameter or
+	  result being assigned to.
+
+For each error, the first fix suggested is applied. The errors left unfixed
+are reported to the standard error. Fixes can uncover or cause other errors,
+so running sgo fix again may fix more.
+`
+
+/* main.sgo:343 */ const typesHelpMsg = `usage: sgo types [package]
+
+Types prints the exported objects of a package, which defaults to the one in
+the current directory, with the SGo types that importing the package gives
+them: its constants, variables, functions and types, and the exported fields
+and methods of those types.
+
+Each object is followed by where its type comes from:
+
+	- The package's annotations: built-in, or in a sgovendor folder or
+	  SGOANNPATH, in which case their directory is shown.
+	- A "For SGo:" doc comment.
+	- Automatic ? wrapping, the default conversion of Go types.
+	- The Go type, unchanged.
+	- SGo, for packages written in SGo.
+`
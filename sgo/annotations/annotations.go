@@ -5,6 +5,31 @@ import "strings"
 
 // TODO: Translate this file to SGo when we have optional method receivers.
 
+// Policies that can be set for a whole package with a "@policy" directive.
+// They change how the package's functions and methods are converted by
+// default, while explicit annotations for them still take precedence.
+const (
+	// ErrorsEntangled makes functions and methods whose last result is an
+	// error return their other results entangled with it, and not
+	// optional, as in (T \ error).
+	ErrorsEntangled = "errors-entangled"
+	// ReceiversNonNil makes method receivers not optional.
+	ReceiversNonNil = "receivers-nonnil"
+	// ParamsNonNil makes the parameters of functions and methods not
+	// optional.
+	ParamsNonNil = "params-nonnil"
+)
+
+var validPolicies = map[string]bool{
+	ErrorsEntangled: true,
+	ReceiversNonNil: true,
+	ParamsNonNil:    true,
+}
+
+func policyKey(policy string) string {
+	return "@policy " + policy
+}
+
 // An Annotation holds SGo type annotations for a Go package or identifier, and
 // its children. If its Cursor is empty, it refers to a Go package. From there,
 // use Lookup to get annotations to its declared identifiers, and from those to
@@ -38,6 +63,34 @@ func (a *Annotation) Type() (string, bool) {
 	return a.typ, true
 }
 
+// HasChildren reports whether there are annotations for any subidentifier of
+// the package or identifier referred to by Cursor.
+func (a *Annotation) HasChildren() bool {
+	if a == nil {
+		return false
+	}
+	prefix := ""
+	if a.cursor != "" {
+		prefix = a.cursor + "."
+	}
+	for k := range a.anns {
+		if strings.HasPrefix(k, prefix) && !strings.HasPrefix(k, "@") {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy reports whether the given policy, set with a "@policy" directive,
+// applies to the package the Annotation is for.
+func (a *Annotation) Policy(policy string) bool {
+	if a == nil {
+		return false
+	}
+	_, ok := a.anns[policyKey(policy)]
+	return ok
+}
+
 // String implements fmt.Stringer for Annotation.
 func (a *Annotation) String() string {
 	if typ, ok := a.Type(); ok {
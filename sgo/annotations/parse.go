@@ -15,19 +15,32 @@ import (
 //
 // The source must conform to this grammar:
 //
+// 	File -> (Directive | Item)*
+// 	Directive -> "@" Ident Type /[\n;]*/
 // 	List -> Item*
 // 	Item -> Name Def /[\n;]*/
 // 	Name -> Ident | Receiver
-// 	Receiver -> "(" "*" Ident ")"
+// 	Receiver -> "(" ["*"] Ident ")"
 // 	Ident -> (Go identifier)
 // 	Def -> Type | "{" List "}"
 // 	Type -> /[^{][^\n;]*/
+//
+// The only directive is "policy", followed by the name of one of the policies
+// defined in this package, which then apply to the whole package.
 func Parse(src string) (*Annotation, error) {
-	anns, err := parseList(NewTokenizer(src))
+	anns, err := parseFile(NewTokenizer(src))
 	return NewAnnotation(anns), err
 }
 
+func parseFile(src *Tokenizer) (map[string]string, error) {
+	return parseItems(src, true)
+}
+
 func parseList(src *Tokenizer) (map[string]string, error) {
+	return parseItems(src, false)
+}
+
+func parseItems(src *Tokenizer, directives bool) (map[string]string, error) {
 	anns := map[string]string{}
 	for {
 		src.SkipWhite()
@@ -36,6 +49,18 @@ func parseList(src *Tokenizer) (map[string]string, error) {
 			return nil, err
 		}
 
+		if err == nil && directives && tk.Lexeme == '@' {
+			k, err := parseDirective(src)
+			if err != nil {
+				if err == io.EOF {
+					return nil, EOF
+				}
+				return nil, err
+			}
+			anns[k] = ""
+			continue
+		}
+
 		if err == io.EOF || tk.Lexeme != '(' && tk.Lexeme != '_' && !unicode.IsLetter(tk.Lexeme) {
 			return anns, nil
 		}
@@ -85,6 +110,40 @@ func parseItem(src *Tokenizer) (map[string]string, error) {
 	return ret, nil
 }
 
+// parseDirective parses a directive, and returns the key under which it's
+// kept in the annotations map.
+func parseDirective(src *Tokenizer) (string, error) {
+	at, _ := src.Next() // We know it's '@'
+
+	name, err := parseIdent(src)
+	if err != nil {
+		return "", err
+	}
+	if name != "policy" {
+		return "", NewInvalidDirectiveError(at, "@"+name)
+	}
+
+	src.SkipWhiteUntilLine()
+	policy, err := parseType(src)
+	if err != nil {
+		return "", err
+	}
+	if !validPolicies[policy] {
+		return "", NewInvalidDirectiveError(at, "@"+name+" "+policy)
+	}
+
+	src.SkipWhiteUntilLine()
+	tk, err := src.Next()
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if err != io.EOF && tk.Lexeme != ';' && tk.Lexeme != '\n' {
+		return "", NewUnexpectedTokenError(tk)
+	}
+
+	return policyKey(policy), nil
+}
+
 func parseName(src *Tokenizer) (string, error) {
 	tk, err := src.Peek()
 	if err != nil {
@@ -103,12 +162,17 @@ func parseReceiver(src *Tokenizer) (string, error) {
 	src.Next() // We know it's '('
 
 	src.SkipWhite()
-	err := expect('*', src)
+	tk, err := src.Peek()
 	if err != nil {
 		return "", err
 	}
+	star := ""
+	if tk.Lexeme == '*' {
+		src.Next()
+		star = "*"
+		src.SkipWhite()
+	}
 
-	src.SkipWhite()
 	id, err := parseIdent(src)
 	if err != nil {
 		return "", err
@@ -120,7 +184,7 @@ func parseReceiver(src *Tokenizer) (string, error) {
 		return "", err
 	}
 
-	return "(*" + id + ")", nil
+	return "(" + star + id + ")", nil
 }
 
 func parseIdent(src *Tokenizer) (string, error) {
@@ -343,5 +407,22 @@ func (err UnexpectedTokenError) Error() string {
 	return fmt.Sprintf("unexpected token at %d:%d: '%v'", err.Token.Line, err.Token.Col, string(err.Token.Lexeme))
 }
 
+// InvalidDirectiveError reports an unknown directive, or a directive with
+// invalid arguments, while parsing a .sgoann source.
+type InvalidDirectiveError struct {
+	Token     Token
+	Directive string
+}
+
+// NewInvalidDirectiveError returns an InvalidDirectiveError.
+func NewInvalidDirectiveError(tk Token, directive string) InvalidDirectiveError {
+	return InvalidDirectiveError{tk, directive}
+}
+
+// Error implements the error interface.
+func (err InvalidDirectiveError) Error() string {
+	return fmt.Sprintf("invalid directive at %d:%d: '%v'", err.Token.Line, err.Token.Col, err.Directive)
+}
+
 // EOF represents an unexpected end of file while parsing a .sgoann source.
 var EOF error = errors.New("unexpected end of file")
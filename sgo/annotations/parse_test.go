@@ -16,6 +16,15 @@ func TestParse(t *testing.T) {
 				"(*bar).qux.ñandú": "poqe{ñ..asd(oan)",
 			},
 		},
+		{
+			input: "(Value) { String func() string\n}\n( * Ptr ) {\n\tLen func() int\n}\nS {\n\tEmbedded *Embedded\n\tanon { x *int; }\n}\n",
+			output: map[string]string{
+				"(Value).String": "func() string",
+				"(*Ptr).Len":     "func() int",
+				"S.Embedded":     "*Embedded",
+				"S.anon.x":       "*int",
+			},
+		},
 	}
 	for i, c := range cases {
 		anns, err := parseList(NewTokenizer(c.input))
@@ -27,6 +36,33 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestHasChildren(t *testing.T) {
+	ann, err := Parse("S { a *int; b { c *int; }; }\nT *int")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		path     []string
+		expected bool
+	}{
+		{nil, true},
+		{[]string{"S"}, true},
+		{[]string{"S", "a"}, false},
+		{[]string{"S", "b"}, true},
+		{[]string{"T"}, false},
+		{[]string{"U"}, false},
+	}
+	for i, c := range cases {
+		a := ann
+		for _, name := range c.path {
+			a = a.Lookup(name)
+		}
+		if got := a.HasChildren(); got != c.expected {
+			t.Errorf("case %d: %v: expected %v, got %v", i, c.path, c.expected, got)
+		}
+	}
+}
+
 func mapEqual(a, b map[string]string) bool {
 	if (a == nil && b != nil) || (b == nil && a != nil) {
 		return false
@@ -41,3 +77,34 @@ func mapEqual(a, b map[string]string) bool {
 	}
 	return true
 }
+
+func TestParsePolicies(t *testing.T) {
+	ann, err := Parse("@policy errors-entangled\n@policy  params-nonnil ;\nF func(p ?*int)\nS { a *int; }\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []*Annotation{ann, ann.Lookup("S"), ann.Lookup("G")} {
+		if !a.Policy(ErrorsEntangled) || !a.Policy(ParamsNonNil) {
+			t.Errorf("%s: expected errors-entangled and params-nonnil policies", a)
+		}
+		if a.Policy(ReceiversNonNil) {
+			t.Errorf("%s: unexpected receivers-nonnil policy", a)
+		}
+	}
+	if typ, ok := ann.Lookup("F").Type(); !ok || typ != "func(p ?*int)" {
+		t.Errorf("expected type for F, got %q", typ)
+	}
+	if ann.Lookup("F").HasChildren() {
+		t.Errorf("expected F to have no children")
+	}
+
+	for _, src := range []string{
+		"@policy whatever\n",
+		"@strict\n",
+		"S {\n@policy params-nonnil\n}\n",
+	} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("%q: expected error", src)
+		}
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/tcard/sgo/sgo/ast"
 	"github.com/tcard/sgo/sgo/importer"
@@ -39,8 +41,9 @@ func TranslatePaths(paths []string) (created []string, warnings []error, errs []
 			errs = append(errs, err)
 			continue
 		}
-		transCreated, transErrs := TranslateDir(pkg.Dir)
+		transCreated, transWarnings, transErrs := TranslateDirWithWarnings(pkg.Dir)
 		created = append(created, transCreated...)
+		warnings = append(warnings, transWarnings...)
 		errs = append(errs, transErrs...)
 	}
 	return created, warnings, errs
@@ -51,17 +54,25 @@ func TranslatePaths(paths []string) (created []string, warnings []error, errs []
 //
 // For SGo: func(dirName string) ([]string, []error)
 func TranslateDir(dirName string) ([]string, []error) {
-	var errs []error
+	created, _, errs := TranslateDirWithWarnings(dirName)
+	return created, errs
+}
+
+// TranslateDirWithWarnings is like TranslateDir, but it also returns the
+// warnings found while translating.
+//
+// For SGo: func(dirName string) (created []string, warnings []error, errs []error)
+func TranslateDirWithWarnings(dirName string) (created []string, warnings []error, errs []error) {
 	var paths []string
 
 	dir, err := os.Open(dirName)
 	if err != nil {
-		return nil, []error{err}
+		return nil, nil, []error{err}
 	}
 	fileNames, err := dir.Readdirnames(-1)
 	dir.Close()
 	if err != nil {
-		return nil, []error{err}
+		return nil, nil, []error{err}
 	}
 	for _, fileName := range fileNames {
 		ext := filepath.Ext(fileName)
@@ -72,9 +83,40 @@ func TranslateDir(dirName string) ([]string, []error) {
 	}
 	if err != nil {
 		errs = append(errs, err)
-		return nil, errs
+		return nil, nil, errs
+	}
+	created, pkg, fset, warnings, errs := translateFilePathsFrom(dirName, paths...)
+	if len(errs) > 0 {
+		return created, warnings, errs
+	}
+	err = writeExportData(dirName, fset, pkg)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return created, warnings, errs
+}
+
+// writeExportData writes the export data for the package in dirName, checked
+// as pkg while translating it, next to its generated Go files, so that it can
+// be imported by other SGo packages without translating it again. If the
+// package has Go files not generated from SGo, no export data is written, as
+// it would be incomplete.
+func writeExportData(dirName string, fset *token.FileSet, pkg *types.Package) error {
+	buildPkg, err := build.ImportDir(dirName, 0)
+	if err != nil {
+		return err
+	}
+	if build.IsLocalImport(buildPkg.ImportPath) {
+		// Can't be imported by other packages.
+		return nil
+	}
+	for _, name := range buildPkg.GoFiles {
+		sgoName := name[:len(name)-len(".go")] + ".sgo"
+		if _, err := os.Stat(filepath.Join(dirName, sgoName)); err != nil {
+			return nil
+		}
 	}
-	return TranslateFilePathsFrom(dirName, paths...)
+	return importer.WriteDirExportData(buildPkg, fset, pkg)
 }
 
 // TranslateFilePaths translates SGo code from the given files. It returns
@@ -85,29 +127,53 @@ func TranslateFilePaths(paths ...string) ([]string, []error) {
 	return TranslateFilePathsFrom("", paths...)
 }
 
+// TranslateFilePathsWithWarnings is like TranslateFilePaths, but it also
+// returns the warnings found while translating.
+//
+// For SGo: func(paths ...string) (created []string, warnings []error, errs []error)
+func TranslateFilePathsWithWarnings(paths ...string) (created []string, warnings []error, errs []error) {
+	return TranslateFilePathsFromWithWarnings("", paths...)
+}
+
 // TranslateFilePaths translates SGo code from the given files. The optional
 // argument whence is the path to the directory the files are on. It returns
 // the paths to the created Go files.
 //
 // For SGo: func(whence string, paths ...string) ([]string, []error)
 func TranslateFilePathsFrom(whence string, paths ...string) ([]string, []error) {
+	created, _, errs := TranslateFilePathsFromWithWarnings(whence, paths...)
+	return created, errs
+}
+
+// TranslateFilePathsFromWithWarnings is like TranslateFilePathsFrom, but it
+// also returns the warnings found while translating.
+//
+// For SGo: func(whence string, paths ...string) (created []string, warnings []error, errs []error)
+func TranslateFilePathsFromWithWarnings(whence string, paths ...string) (created []string, warnings []error, errs []error) {
+	created, _, _, warnings, errs = translateFilePathsFrom(whence, paths...)
+	return created, warnings, errs
+}
+
+// translateFilePathsFrom is like TranslateFilePathsFromWithWarnings, but it
+// also returns the package checked while translating, with the file set its
+// positions are from.
+func translateFilePathsFrom(whence string, paths ...string) (created []string, pkg *types.Package, fset *token.FileSet, warnings []error, errs []error) {
 	var named []NamedFile
 
 	for _, path := range paths {
 		f, err := os.Open(path)
 		if err != nil {
-			return nil, []error{err}
+			return nil, nil, nil, nil, []error{err}
 		}
 		defer f.Close()
 		named = append(named, NamedFile{path, f})
 	}
 
-	translated, errs := TranslateFilesFrom(whence, named...)
+	translated, pkg, fset, warnings, errs := translateFilesFrom(whence, named...)
 	if len(errs) > 0 {
-		return nil, errs
+		return nil, nil, nil, warnings, errs
 	}
 
-	var created []string
 	for i, t := range translated {
 		path := named[i].Path
 		ext := filepath.Ext(path)
@@ -125,7 +191,7 @@ func TranslateFilePathsFrom(whence string, paths ...string) ([]string, []error)
 		}
 	}
 
-	return created, errs
+	return created, pkg, fset, warnings, errs
 }
 
 // A NamedFile is a io.Reader for a file with its path.
@@ -149,12 +215,19 @@ func TranslateFiles(files ...NamedFile) ([][]byte, []error) {
 //
 // For SGo: func(whence string, files ...NamedFile) ([][]byte, []error)
 func TranslateFilesFrom(whence string, files ...NamedFile) ([][]byte, []error) {
-	var errs []error
-	fset := token.NewFileSet()
+	translated, _, _, _, errs := translateFilesFrom(whence, files...)
+	return translated, errs
+}
+
+// translateFilesFrom is like TranslateFilesFrom, but it also returns the
+// package checked while translating, with the file set its positions are from,
+// and the warnings found while checking it.
+func translateFilesFrom(whence string, files ...NamedFile) (translated [][]byte, pkg *types.Package, fset *token.FileSet, warnings []error, errs []error) {
+	fset = token.NewFileSet()
 
 	cwd, err := os.Getwd()
 	if err != nil {
-		return nil, []error{err}
+		return nil, nil, nil, nil, []error{err}
 	}
 
 	var parsed []*ast.File
@@ -179,16 +252,19 @@ func TranslateFilesFrom(whence string, files ...NamedFile) ([][]byte, []error) {
 	}
 
 	if len(errs) > 0 {
-		return nil, errs
+		return nil, nil, nil, nil, errs
 	}
 
-	info, typeErrs := typecheck("translate", fset, whence, parsed...)
+	pkg, info, typeWarnings, typeErrs := typecheck("translate", fset, whence, parsed...)
+	if len(typeWarnings) > 0 {
+		warnings = append(warnings, makeErrList(fset, typeWarnings))
+	}
 	if len(typeErrs) > 0 {
 		errs = append(errs, makeErrList(fset, typeErrs))
-		return nil, errs
+		return nil, nil, nil, warnings, errs
 	}
 
-	return translate(info, srcs, parsed, fset), errs
+	return translate(info, srcs, parsed, fset, os.Getenv(BoundaryChecksEnv) != ""), pkg, fset, warnings, errs
 }
 
 // TranslateFile translates SGo code from the given io.Reader to the io.Writer
@@ -217,7 +293,7 @@ func TranslateFile(w func() (io.Writer, error), r io.Reader, filename string) []
 func makeErrList(fset *token.FileSet, errs []error) scanner.ErrorList {
 	var errList scanner.ErrorList
 	for _, err := range errs {
-		if v, ok := err.(*types.Error); ok {
+		if v, ok := err.(types.Error); ok {
 			errList = append(errList, &scanner.Error{
 				Pos: fset.Position(v.Pos),
 				Msg: v.Msg,
@@ -232,19 +308,42 @@ func makeErrList(fset *token.FileSet, errs []error) scanner.ErrorList {
 	return errList
 }
 
-func typecheck(path string, fset *token.FileSet, whence string, sgoFiles ...*ast.File) (*types.Info, []error) {
-	var errors []error
-	imp, err := importer.DefaultFrom(sgoFiles, whence)
+// UncheckedNilEnv is the name of the environment variable that sets
+// types.Config.UncheckedNil for translations: "error" for
+// types.UncheckedNilError, "assert" for types.UncheckedNilAssert.
+const UncheckedNilEnv = "SGOUNCHECKEDNIL"
+
+// BoundaryChecksEnv is the name of the environment variable that, when set to
+// a non-empty value, makes translations check at runtime that calls to
+// packages written in Go don't return nil where their SGo types say they
+// can't. The sgo tool sets it for the -sgo.boundarychecks flag.
+const BoundaryChecksEnv = "SGOBOUNDARYCHECKS"
+
+func typecheck(path string, fset *token.FileSet, whence string, sgoFiles ...*ast.File) (pkg *types.Package, info *types.Info, warnings []error, errors []error) {
+	newImporter := importer.DefaultFrom
+	if os.Getenv(importer.ImportModeEnv) == "compiled" {
+		newImporter = importer.CompiledFrom
+	}
+	imp, err := newImporter(sgoFiles, whence)
 	if err != nil {
-		return nil, []error{err}
+		return nil, nil, nil, []error{err}
 	}
 	cfg := &types.Config{
 		Error: func(err error) {
 			errors = append(errors, err)
 		},
+		Warn: func(err error) {
+			warnings = append(warnings, err)
+		},
 		Importer: imp,
 	}
-	info := &types.Info{
+	switch os.Getenv(UncheckedNilEnv) {
+	case "error":
+		cfg.UncheckedNil = types.UncheckedNilError
+	case "assert":
+		cfg.UncheckedNil = types.UncheckedNilAssert
+	}
+	info = &types.Info{
 		Types:      map[ast.Expr]types.TypeAndValue{},
 		Defs:       map[*ast.Ident]types.Object{},
 		Uses:       map[*ast.Ident]types.Object{},
@@ -252,18 +351,19 @@ func typecheck(path string, fset *token.FileSet, whence string, sgoFiles ...*ast
 		Selections: map[*ast.SelectorExpr]*types.Selection{},
 		Scopes:     map[ast.Node]*types.Scope{},
 		InitOrder:  []*types.Initializer{},
+		NilChecks:  map[ast.Expr]types.Object{},
 	}
-	_, err = cfg.Check(path, fset, sgoFiles, info)
+	pkg, err = cfg.Check(path, fset, sgoFiles, info)
 	if err != nil {
-		return nil, errors
+		return nil, nil, warnings, errors
 	}
-	return info, nil
+	return pkg, info, warnings, nil
 }
 
-func translate(info *types.Info, srcs [][]byte, sgoFiles []*ast.File, fset *token.FileSet) [][]byte {
+func translate(info *types.Info, srcs [][]byte, sgoFiles []*ast.File, fset *token.FileSet, boundaryChecks bool) [][]byte {
 	dsts := make([][]byte, 0, len(sgoFiles))
 	for i, sgoFile := range sgoFiles {
-		dsts = append(dsts, convertAST(info, srcs[i], sgoFile, fset))
+		dsts = append(dsts, convertAST(info, srcs[i], sgoFile, fset, boundaryChecks))
 	}
 	return dsts
 }
@@ -388,20 +488,83 @@ func (v visitorFunc) Visit(node ast.Node) (w ast.Visitor) {
 	return v(node)
 }
 
-func convertAST(info *types.Info, src []byte, sgoAST *ast.File, fset *token.FileSet) []byte {
+func convertAST(info *types.Info, src []byte, sgoAST *ast.File, fset *token.FileSet, boundaryChecks bool) []byte {
 	c := converter{
-		Info:          info,
-		src:           src,
-		base:          fset.File(sgoAST.Pos()).Base() - 1,
-		fset:          fset,
-		file:          sgoAST,
-		nextIsNewLine: true,
+		Info:           info,
+		src:            src,
+		base:           fset.File(sgoAST.Pos()).Base() - 1,
+		fset:           fset,
+		file:           sgoAST,
+		nextIsNewLine:  true,
+		boundaryChecks: boundaryChecks,
 	}
 	c.docAnns = c.annotationsFromDocs()
 	autogenComment := []byte("// Autogenerated by SGo. DO NOT EDIT!\n\n")
 	c.putChunks(c.base, nil, autogenComment)
 	c.convertFile(sgoAST)
 	c.putChunks(c.base, src[c.lastChunkEnd:], nil)
+	// Same line as the package clause, so that line numbers are kept.
+	var imps []byte
+	if c.usesReflect {
+		imps = append(imps, `; import __sgo_reflect "reflect"`...)
+	}
+	for i, pkg := range c.typeImports {
+		imps = append(imps, fmt.Sprintf("; import __sgo_import%d %s", i, strconv.Quote(pkg.Path()))...)
+	}
+	if len(imps) > 0 {
+		c.dstChunks = append(c.dstChunks[:c.importsChunk], append([][]byte{imps}, c.dstChunks[c.importsChunk:]...)...)
+	}
+	if c.usesIsNil {
+		c.dstChunks = append(c.dstChunks, []byte(fmt.Sprintf(`
+func %s[T any](x T) bool {
+	return __sgo_reflect.ValueOf(&x).Elem().IsNil()
+}
+`, c.helperFunc("isnil"))))
+	}
+	if c.usesNonNil {
+		c.dstChunks = append(c.dstChunks, []byte(fmt.Sprintf(`
+func %s[T any](p *T) *T {
+	if p != nil {
+		return p
+	}
+	return new(T)
+}
+`, c.helperFunc("nonnil"))))
+	}
+	if c.usesCoalesce {
+		c.dstChunks = append(c.dstChunks, []byte(fmt.Sprintf(`
+func %s[T any](x T, y func() interface{}) T {
+	if !__sgo_reflect.ValueOf(&x).Elem().IsNil() {
+		return x
+	}
+	var z T
+	if v := __sgo_reflect.ValueOf(y()); v.IsValid() {
+		__sgo_reflect.ValueOf(&z).Elem().Set(v)
+	}
+	return z
+}
+`, c.helperFunc("coalesce"))))
+	}
+	if c.usesCheckNil {
+		c.dstChunks = append(c.dstChunks, []byte(fmt.Sprintf(`
+func %s[P interface{ ~*E }, E any](p P, msg string) P {
+	if p == nil {
+		panic(msg)
+	}
+	return p
+}
+`, c.helperFunc("checknil"))))
+	}
+	if len(c.boundaryFuncs) > 0 {
+		c.dstChunks = append(c.dstChunks, c.boundaryFuncs...)
+		c.dstChunks = append(c.dstChunks, []byte(fmt.Sprintf(`
+func %s(p interface{}, msg string) {
+	if __sgo_reflect.ValueOf(p).Elem().IsNil() {
+		panic(msg)
+	}
+}
+`, c.helperFunc("boundary"))))
+	}
 	return bytes.Join(c.dstChunks, nil)
 }
 
@@ -426,6 +589,38 @@ type converter struct {
 	// for putSourceMap
 	nextIsNewLine bool
 
+	// for optional type parameters, which need reflect to be compared
+	// to nil
+	importsChunk int
+	usesReflect  bool
+
+	// for optional type parameters compared to nil, which need a function
+	// that checks the value itself, as it may be an interface holding a
+	// value that can't be nil
+	usesIsNil bool
+
+	// for types written by goTypes from packages that the file doesn't
+	// import, or whose names are shadowed where they're written
+	typeImports []*types.Package
+
+	// for safe navigation selectors whose types can't be written, which
+	// need a function that replaces nil pointers
+	usesNonNil bool
+
+	// for ?? expressions whose types can't be written, which need a
+	// function that checks for nil and evaluates the right operand lazily
+	usesCoalesce bool
+
+	// for pointers from unchecked packages converted to interfaces, which
+	// are checked for nil at runtime; nilChecking is the one being wrapped
+	usesCheckNil bool
+	nilChecking  ast.Expr
+
+	// for calls to Go packages, whose results are checked at runtime if
+	// boundaryChecks is set, with a function for each call
+	boundaryChecks bool
+	boundaryFuncs  [][]byte
+
 	fset *token.FileSet
 }
 
@@ -435,6 +630,8 @@ func (c *converter) convertFile(v *ast.File) {
 	}
 	c.annotationFromDocs(v)
 	c.convertIdent(v.Name)
+	c.putChunks(int(v.Name.End())-1, c.src[c.lastChunkEnd:int(v.Name.End())-c.base-1], nil)
+	c.importsChunk = len(c.dstChunks)
 	for _, v := range v.Decls {
 		c.convertDecl(v)
 	}
@@ -504,6 +701,7 @@ func (c *converter) convertTypeSpec(v *ast.TypeSpec) {
 	c.annotationFromDocs(v)
 
 	c.convertIdent(v.Name)
+	c.convertFieldList(v.TypeParams)
 	c.convertExpr(v.Type)
 }
 
@@ -578,6 +776,7 @@ func (c *converter) convertFuncType(v *ast.FuncType) {
 	}
 	c.annotationFromDocs(v)
 
+	c.convertFieldList(v.TypeParams)
 	c.convertFieldList(v.Params)
 	c.convertFieldList(v.Results)
 }
@@ -784,7 +983,12 @@ func (c *converter) convertReturnStmt(v *ast.ReturnStmt) {
 		results := make([][]byte, 0, resultsLen)
 		for i := 0; i < resultsLen; i++ {
 			typ := c.lastFunc.Results().At(i).Type()
+			if tpar := optionalTypeParam(typ); tpar != nil {
+				typ = tpar
+			}
 			switch underlying := typ.Underlying().(type) {
+			case *types.TypeParam:
+				results = append(results, []byte("*new("+underlying.Obj().Name()+")"))
 			case *types.Pointer, *types.Map, *types.Slice, *types.Signature, *types.Interface, *types.Optional:
 				results = append(results, []byte("nil"))
 			case *types.Struct:
@@ -1038,6 +1242,10 @@ func (c *converter) convertExpr(v ast.Expr) {
 	if v == nil {
 		return
 	}
+	if obj, ok := c.NilChecks[v]; ok && c.nilChecking != v {
+		c.convertNilCheck(v, obj)
+		return
+	}
 	switch v := v.(type) {
 	case *ast.StructType:
 		c.convertStructType(v)
@@ -1046,7 +1254,11 @@ func (c *converter) convertExpr(v ast.Expr) {
 		c.convertIdent(v)
 		return
 	case *ast.CallExpr:
-		c.convertCallExpr(v)
+		if c.boundaryChecks {
+			c.convertBoundaryCheck(v)
+		} else {
+			c.convertCallExpr(v)
+		}
 		return
 	case *ast.StarExpr:
 		c.convertStarExpr(v)
@@ -1093,6 +1305,9 @@ func (c *converter) convertExpr(v ast.Expr) {
 	case *ast.IndexExpr:
 		c.convertIndexExpr(v)
 		return
+	case *ast.IndexListExpr:
+		c.convertIndexListExpr(v)
+		return
 	case *ast.KeyValueExpr:
 		c.convertKeyValueExpr(v)
 		return
@@ -1126,6 +1341,18 @@ func (c *converter) convertIndexExpr(v *ast.IndexExpr) {
 	c.convertExpr(v.Index)
 }
 
+func (c *converter) convertIndexListExpr(v *ast.IndexListExpr) {
+	if v == nil {
+		return
+	}
+	c.annotationFromDocs(v)
+
+	c.convertExpr(v.X)
+	for _, v := range v.Indices {
+		c.convertExpr(v)
+	}
+}
+
 func (c *converter) convertEllipsis(v *ast.Ellipsis) {
 	if v == nil {
 		return
@@ -1173,6 +1400,120 @@ func (c *converter) convertCallExpr(v *ast.CallExpr) {
 	}
 }
 
+// convertBoundaryCheck translates v, if it calls a function or method from a
+// package written in Go, to a call to a function that takes its results and
+// returns them after checking that those that aren't optional in SGo aren't
+// nil. If some are entangled, they're only checked if the entangled value says
+// they're valid. Go statements and defer statements don't get here, so their
+// calls aren't evaluated early.
+func (c *converter) convertBoundaryCheck(v *ast.CallExpr) {
+	var obj types.Object
+	switch fun := unparen(v.Fun).(type) {
+	case *ast.Ident:
+		obj = c.Uses[fun]
+	case *ast.SelectorExpr:
+		obj = c.Uses[fun.Sel]
+	case *ast.IndexExpr:
+		obj = c.ObjectOf(calleeIdent(fun.X))
+	case *ast.IndexListExpr:
+		obj = c.ObjectOf(calleeIdent(fun.X))
+	}
+	fn, ok := obj.(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		c.convertCallExpr(v)
+		return
+	}
+	from, ok := fn.Pkg().FromGo()
+	sig, _ := c.TypeOf(v.Fun).Underlying().(*types.Signature)
+	if !ok || sig == nil {
+		c.convertCallExpr(v)
+		return
+	}
+
+	results := sig.Results()
+	n := results.Len()
+	var checks []string
+	for i := 0; i < n; i++ {
+		typ := results.At(i).Type()
+		if _, ok := typ.(*types.Optional); ok || !types.IsOptionable(typ) {
+			continue
+		}
+		if _, ok := typ.(*types.TypeParam); ok {
+			continue
+		}
+		what := fmt.Sprintf("result %d", i)
+		if name := results.At(i).Name(); name != "" && name != "_" {
+			what = "result " + name
+		}
+		msg := fmt.Sprintf("%s: %s returned a nil %s as %s, which its SGo type, from %s, says can't be nil",
+			c.fset.Position(v.Pos()), funcSymbol(fn), types.TypeString(typ, (*types.Package).Name), what, from)
+		checks = append(checks, fmt.Sprintf("\t%s(&r%d, %s)\n", c.helperFunc("boundary"), i, strconv.Quote(msg)))
+	}
+	if len(checks) == 0 {
+		c.convertCallExpr(v)
+		return
+	}
+
+	var tparams, params, rets []string
+	for i := 0; i < n; i++ {
+		tparams = append(tparams, fmt.Sprintf("R%d", i))
+		params = append(params, fmt.Sprintf("r%d R%d", i, i))
+		rets = append(rets, fmt.Sprintf("r%d", i))
+	}
+	body := strings.Join(checks, "")
+	if e := results.Entangled(); e != nil {
+		tparams = append(tparams, fmt.Sprintf("R%d", n))
+		params = append(params, fmt.Sprintf("r%d R%d", n, n))
+		rets = append(rets, fmt.Sprintf("r%d", n))
+		valid := fmt.Sprintf("__sgo_reflect.ValueOf(&r%d).Elem().IsNil()", n)
+		if b, ok := e.Type().Underlying().(*types.Basic); ok && b.Info()&types.IsBoolean != 0 {
+			valid = fmt.Sprintf("__sgo_reflect.ValueOf(&r%d).Elem().Bool()", n)
+		}
+		body = "\tif " + valid + " {\n" + strings.Replace(body, "\t", "\t\t", -1) + "\t}\n"
+	}
+	resultList := strings.Join(tparams, ", ")
+	if len(tparams) > 1 {
+		resultList = "(" + resultList + ")"
+	}
+	name := fmt.Sprintf("%s_%d", c.helperFunc("boundary"), len(c.boundaryFuncs))
+	c.boundaryFuncs = append(c.boundaryFuncs, []byte(fmt.Sprintf(`
+func %s[%s any](%s) %s {
+%s	return %s
+}
+`, name, strings.Join(tparams, ", "), strings.Join(params, ", "), resultList, body, strings.Join(rets, ", "))))
+	c.usesReflect = true
+
+	c.putChunks(int(v.Pos())-1, c.src[c.lastChunkEnd:int(v.Pos())-c.base-1], []byte(name+"("))
+	c.convertCallExpr(v)
+	c.putChunks(int(v.End())-1, c.src[c.lastChunkEnd:int(v.End())-c.base-1], []byte(")"))
+}
+
+// calleeIdent returns the identifier that names the function in e, which is
+// either an identifier or a qualified one.
+func calleeIdent(e ast.Expr) *ast.Ident {
+	switch e := unparen(e).(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
+
+// funcSymbol returns the name of fn qualified by its package or, for
+// methods, by its receiver type.
+func funcSymbol(fn *types.Func) string {
+	sig := fn.Type().(*types.Signature)
+	if recv := sig.Recv(); recv != nil {
+		recvType := types.TypeString(recv.Type(), (*types.Package).Name)
+		if strings.HasPrefix(recvType, "*") {
+			recvType = "(" + recvType + ")"
+		}
+		return recvType + "." + fn.Name()
+	}
+	return fn.Pkg().Name() + "." + fn.Name()
+}
+
 func (c *converter) convertStarExpr(v *ast.StarExpr) {
 	if v == nil {
 		return
@@ -1188,10 +1529,124 @@ func (c *converter) convertSelectorExpr(v *ast.SelectorExpr) {
 	}
 	c.annotationFromDocs(v)
 
+	if v.Quest.IsValid() {
+		c.convertOptionalSelector(v)
+		return
+	}
+
+	c.convertExpr(v.X)
+	c.convertIdent(v.Sel)
+}
+
+// convertOptionalSelector translates v, x?.f, to a call with x to a function
+// literal that returns the zero value if its argument is nil or else its field
+// f, so that x is evaluated only once. Each embedded pointer the field is
+// promoted through is checked for nil likewise.
+//
+// If the types can't be written where v is, x and each embedded pointer are
+// instead replaced with a new zero value if they're nil, which gives the same
+// result.
+func (c *converter) convertOptionalSelector(v *ast.SelectorExpr) {
+	// goTypes records the imports that the types need, so it's only called
+	// once nothing else can make this fall back.
+	sel := c.Selections[v]
+	if sel == nil {
+		c.convertOptionalSelectorNonNil(v)
+		return
+	}
+	typs, ok := c.goTypes(v.Pos(), c.TypeOf(v.X), c.TypeOf(v))
+	if !ok {
+		c.convertOptionalSelectorNonNil(v)
+		return
+	}
+
+	var steps, path string
+	ptr := "__sgo_p"
+	typ := sel.Recv()
+	for _, i := range sel.Index()[:len(sel.Index())-1] {
+		if p, ok := typ.Underlying().(*types.Pointer); ok {
+			typ = p.Elem()
+		}
+		f := typ.Underlying().(*types.Struct).Field(i)
+		path += "." + f.Name()
+		if _, ok := f.Type().Underlying().(*types.Pointer); ok {
+			next := fmt.Sprintf("__sgo_p%d", strings.Count(steps, ":=")+1)
+			steps += fmt.Sprintf("%s := %s%s; if %s == nil { return }; ", next, ptr, path, next)
+			ptr, path = next, ""
+		}
+		typ = f.Type()
+	}
+
+	c.putChunks(int(v.X.Pos())-1, c.src[c.lastChunkEnd:int(v.X.Pos())-c.base-1], []byte(fmt.Sprintf(
+		"func(__sgo_p %s) (__sgo_f %s) { if __sgo_p == nil { return }; %sreturn %s%s.%s }(",
+		typs[0], typs[1], steps, ptr, path, v.Sel.Name)))
 	c.convertExpr(v.X)
+	c.putChunks(int(v.X.End())-1, c.src[c.lastChunkEnd:int(v.X.End())-c.base-1], nil)
+	// Only the lines between x and f are kept, before the closing
+	// parenthesis so that no semicolon is inserted after x.
+	lines := bytes.Repeat([]byte("\n"), bytes.Count(c.src[c.lastChunkEnd:int(v.End())-c.base-1], []byte("\n")))
+	if len(lines) > 0 {
+		c.putChunks(int(v.X.End())-1, nil, []byte(","))
+	}
+	c.putChunks(int(v.End())-1, lines, []byte(")"))
+}
+
+// convertOptionalSelectorNonNil translates v, x?.f, to a selection of f from x
+// or, if x is nil, from a new zero value, so that x is evaluated only once.
+// Each embedded pointer the field is promoted through is replaced likewise.
+func (c *converter) convertOptionalSelectorNonNil(v *ast.SelectorExpr) {
+	c.usesNonNil = true
+	nonNil := c.helperFunc("nonnil")
+
+	wraps := 1
+	var path, embedded string
+	if sel, ok := c.Selections[v]; ok {
+		typ := sel.Recv()
+		for _, i := range sel.Index()[:len(sel.Index())-1] {
+			if p, ok := typ.Underlying().(*types.Pointer); ok {
+				typ = p.Elem()
+			}
+			f := typ.Underlying().(*types.Struct).Field(i)
+			embedded += "." + f.Name()
+			if _, ok := f.Type().Underlying().(*types.Pointer); ok {
+				path += embedded + ")"
+				embedded = ""
+				wraps++
+			}
+			typ = f.Type()
+		}
+	}
+
+	c.putChunks(int(v.X.Pos())-1, c.src[c.lastChunkEnd:int(v.X.Pos())-c.base-1], []byte(strings.Repeat(nonNil+"(", wraps)))
+	c.convertExpr(v.X)
+	c.putChunks(int(v.Quest)+1, c.src[c.lastChunkEnd:int(v.Quest)-c.base-1], []byte(")"+path+"."))
 	c.convertIdent(v.Sel)
 }
 
+// helperFunc returns the name of a function that the translation uses, which
+// is declared at the end of each file that uses it. It's named after the file
+// so that it doesn't clash with other files' in the package: underscores are
+// doubled and other characters that can't be in identifiers are written as
+// their code point between underscores, so that different names give
+// different functions.
+func (c *converter) helperFunc(kind string) string {
+	name := filepath.Base(c.fset.File(c.file.Pos()).Name())
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	var b bytes.Buffer
+	b.WriteString("__sgo_" + kind + "_")
+	for _, r := range name {
+		switch {
+		case r == '_':
+			b.WriteString("__")
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "_%x_", r)
+		}
+	}
+	return b.String()
+}
+
 func (c *converter) convertParenExpr(v *ast.ParenExpr) {
 	if v == nil {
 		return
@@ -1372,8 +1827,123 @@ func (c *converter) convertBinaryExpr(v *ast.BinaryExpr) {
 	}
 	c.annotationFromDocs(v)
 
+	if v.Op == token.EQL || v.Op == token.NEQ {
+		// Optionals of type parameter type are erased to the type
+		// parameter, which can't be compared to nil in Go.
+		x, y := v.X, v.Y
+		if c.isNil(x) {
+			x, y = y, x
+		}
+		if c.isNil(y) && optionalTypeParam(c.TypeOf(x)) != nil {
+			c.convertNilComparison(v, x)
+			return
+		}
+	}
+	if v.Op == token.QUEST_QUEST {
+		c.convertCoalesce(v)
+		return
+	}
+
+	c.convertExpr(v.X)
+	c.convertExpr(v.Y)
+}
+
+// convertNilComparison translates v, which compares x to nil, to a call to a
+// function that checks x with reflect. It looks at x itself rather than at the
+// value it holds, which for interface type arguments may not be nilable.
+func (c *converter) convertNilComparison(v *ast.BinaryExpr, x ast.Expr) {
+	c.usesReflect = true
+	c.usesIsNil = true
+	not := ""
+	if v.Op == token.NEQ {
+		not = "!"
+	}
+	c.putChunks(int(v.Pos())-1, c.src[c.lastChunkEnd:int(v.Pos())-c.base-1], []byte(not+c.helperFunc("isnil")+"("))
+	c.putChunks(int(x.Pos())-1, nil, nil)
+	c.convertExpr(x)
+	c.putChunks(int(v.End())-1, c.src[c.lastChunkEnd:int(x.End())-c.base-1], []byte(")"))
+}
+
+// convertCoalesce translates v, x ?? y, to a call with x and a function
+// literal that returns y to another function literal that returns x if it isn't
+// nil or else calls the first one, so that x is evaluated only once and y only
+// if needed. Optional type parameters are compared to nil with reflection, as
+// in convertNilComparison.
+//
+// If v's type can't be written where v is, the function literal returns y as
+// an interface instead, and a function that sets it with reflection is called.
+func (c *converter) convertCoalesce(v *ast.BinaryExpr) {
+	var call, thunk string
+	if typs, ok := c.goTypes(v.Pos(), c.TypeOf(v)); ok {
+		notNil := "__sgo_x != nil"
+		if optionalTypeParam(c.TypeOf(v.X)) != nil {
+			c.usesReflect = true
+			notNil = "!__sgo_reflect.ValueOf(&__sgo_x).Elem().IsNil()"
+		}
+		call = fmt.Sprintf("func(__sgo_x %[1]s, __sgo_y func() %[1]s) %[1]s { if %[2]s { return __sgo_x }; return __sgo_y() }(", typs[0], notNil)
+		thunk = "func() " + typs[0]
+	} else {
+		c.usesReflect = true
+		c.usesCoalesce = true
+		call = c.helperFunc("coalesce") + "("
+		thunk = "func() interface{}"
+	}
+
+	// The comma and the parenthesis go right after x and return so that no
+	// semicolons are inserted if ?? isn't on the same line.
+	c.putChunks(int(v.X.Pos())-1, c.src[c.lastChunkEnd:int(v.X.Pos())-c.base-1], []byte(call))
 	c.convertExpr(v.X)
+	c.putChunks(int(v.X.End())-1, c.src[c.lastChunkEnd:int(v.X.End())-c.base-1], []byte(","))
+	c.putChunks(int(v.OpPos)+1, c.src[c.lastChunkEnd:int(v.OpPos)-c.base-1], []byte(thunk+" { return ("))
 	c.convertExpr(v.Y)
+	c.putChunks(int(v.Y.End())-1, c.src[c.lastChunkEnd:int(v.Y.End())-c.base-1], []byte(") })"))
+}
+
+// convertNilCheck wraps v, a pointer from obj's unchecked package that is
+// converted to a non-optional interface, with a call to a function that
+// panics if it's nil, before it becomes an interface holding a nil pointer.
+func (c *converter) convertNilCheck(v ast.Expr, obj types.Object) {
+	c.usesCheckNil = true
+	from := obj.Pkg().Name() + "." + obj.Name()
+	if x, ok := unparen(v).(*ast.SelectorExpr); ok && c.Selections[x] != nil && c.Selections[x].Kind() == types.FieldVal {
+		from = "field " + obj.Name()
+	} else if _, ok := obj.(*types.Func); ok {
+		from += "()"
+	}
+	msg := fmt.Sprintf("%s: nil %s from %s in unchecked package %s converted to a non-optional interface",
+		c.fset.Position(v.Pos()), types.TypeString(c.TypeOf(v), (*types.Package).Name), from, obj.Pkg().Path())
+	c.putChunks(int(v.Pos())-1, c.src[c.lastChunkEnd:int(v.Pos())-c.base-1], []byte(c.helperFunc("checknil")+"("))
+	outer := c.nilChecking
+	c.nilChecking = v
+	c.convertExpr(v)
+	c.nilChecking = outer
+	c.putChunks(int(v.End())-1, c.src[c.lastChunkEnd:int(v.End())-c.base-1], []byte(", "+strconv.Quote(msg)+")"))
+}
+
+func (c *converter) isNil(e ast.Expr) bool {
+	id, ok := unparen(e).(*ast.Ident)
+	return ok && c.Uses[id] == types.Universe.Lookup("nil")
+}
+
+// optionalTypeParam returns T if typ is ?T for a type parameter T, or
+// nil otherwise.
+func optionalTypeParam(typ types.Type) *types.TypeParam {
+	opt, ok := typ.(*types.Optional)
+	if !ok {
+		return nil
+	}
+	tpar, _ := opt.Elem().(*types.TypeParam)
+	return tpar
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
 }
 
 func (c *converter) convertCompositeLit(v *ast.CompositeLit) {
@@ -1436,6 +2006,25 @@ func (c *converter) convertIdent(v *ast.Ident) {
 		return
 	}
 	c.annotationFromDocs(v)
+
+	var repl string
+	switch obj := c.Uses[v]; {
+	case obj == nil:
+		return
+	case obj == types.Universe.Lookup("optionable"), obj == types.Universe.Lookup("nonoptionable"):
+		// Go has no such constraints; type arguments have already
+		// been checked.
+		repl = "any"
+	case obj == types.Universe.Lookup("nil"):
+		tpar := optionalTypeParam(c.TypeOf(v))
+		if tpar == nil {
+			return
+		}
+		repl = "*new(" + tpar.Obj().Name() + ")"
+	default:
+		return
+	}
+	c.putChunks(int(v.End())-1, c.src[c.lastChunkEnd:int(v.Pos())-c.base-1], []byte(repl))
 }
 
 func (c *converter) convertFuncLit(v *ast.FuncLit) {
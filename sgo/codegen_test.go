@@ -0,0 +1,231 @@
+package sgo
+
+import (
+	"bytes"
+	gotoken "go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/parser"
+	"github.com/tcard/sgo/sgo/scanner"
+	"github.com/tcard/sgo/sgo/token"
+)
+
+func TestTranslateFileErrorPositions(t *testing.T) {
+	src := `package p
+
+func f(p ?*int) int {
+	return *p
+}
+`
+	errs := TranslateFile(func() (io.Writer, error) { return ioutil.Discard, nil }, strings.NewReader(src), "p.sgo")
+	if len(errs) != 1 {
+		t.Fatalf("got errors %v, want one", errs)
+	}
+	list, ok := errs[0].(scanner.ErrorList)
+	if !ok || len(list) != 1 {
+		t.Fatalf("got %#v, want an ErrorList with one error", errs[0])
+	}
+	pos := list[0].Pos
+	if pos.Filename != "p.sgo" || pos.Line != 4 || pos.Column != 10 {
+		t.Errorf("got position %v, want p.sgo:4:10", pos)
+	}
+	if strings.HasPrefix(list[0].Msg, "p.sgo") {
+		t.Errorf("message %q has the position in it", list[0].Msg)
+	}
+}
+
+const optionalsSrc = `package main
+
+import "fmt"
+
+type T struct {
+	*E
+	Next ?*T
+	N    ?*int
+}
+
+type E struct{ M map[string]int }
+
+var calls []string
+
+func get(name string, t ?*T) ?*T {
+	calls = append(calls, name)
+	return t
+}
+
+func def(name string, n int) *int {
+	calls = append(calls, name)
+	return &n
+}
+
+func or[P optionable](x ?P, y P) P {
+	return x ?? y
+}
+
+func main() {
+	one := 1
+	t := &T{E: &E{M: map[string]int{"a": 1}}, N: &one}
+	var none ?*T
+
+	fmt.Println(get("t", t)?.N == &one, get("none", none)?.N == nil)
+	fmt.Println(get("t", t)?.M, get("none", none)?.M == nil)
+	fmt.Println(get("t", t)?.Next?.Next?.N == nil)
+	fmt.Println(*(get("t", t)?.N ?? def("def1", 2)), *(get("none", none)?.N ?? def("def2", 3)))
+	fmt.Println(*or[*int](none?.N, &one), *or[*int](&one, def("def3", 3)))
+	fmt.Println(calls)
+}
+`
+
+const optionalsOutput = `true true
+map[a:1] true
+true
+1 3
+1 1
+[t none t none t t none def2 def3]
+`
+
+func TestTranslateOptionals(t *testing.T) {
+	gen, errs := TranslateFiles(NamedFile{"main.sgo", strings.NewReader(optionalsSrc)})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if bytes.Contains(gen[0], []byte("func __sgo_")) {
+		t.Errorf("got helper functions for types that can be written:\n%s", gen[0])
+	}
+
+	if out := runTranslation(t, gen[0]); out != optionalsOutput {
+		t.Errorf("got output:\n%s\nwant:\n%s", out, optionalsOutput)
+	}
+}
+
+const nilComparisonsSrc = `package main
+
+import "fmt"
+
+type E struct{}
+
+func (E) Error() string { return "E" }
+
+func isNil[T optionable](x ?T) bool {
+	return x == nil
+}
+
+func notNil[T optionable](x ?T) bool {
+	return nil != x
+}
+
+func main() {
+	var err ?error
+	var p ?*int
+	var m ?map[string]int
+	fmt.Println(isNil[error](E{}), isNil[error](&E{}), isNil[error](err), notNil[error](E{}))
+	fmt.Println(isNil[*int](p), isNil[map[string]int](m), notNil[map[string]int](map[string]int{}))
+}
+`
+
+const nilComparisonsOutput = `false false true true
+true true true
+`
+
+func TestTranslateNilComparisons(t *testing.T) {
+	gen, errs := TranslateFiles(NamedFile{"main.sgo", strings.NewReader(nilComparisonsSrc)})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if out := runTranslation(t, gen[0]); out != nilComparisonsOutput {
+		t.Errorf("got output:\n%s\nwant:\n%s", out, nilComparisonsOutput)
+	}
+}
+
+// runTranslation runs the translated main package gen, and returns its
+// output. It skips the test if it's short or there's no go command.
+func runTranslation(t *testing.T, gen []byte) string {
+	if testing.Short() {
+		t.Skip("runs the translation")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip(err)
+	}
+	dir, err := ioutil.TempDir("", "sgo-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, src := range map[string][]byte{
+		"go.mod":  []byte("module p\n\ngo 1.18\n"),
+		"main.go": gen,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), src, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s\n%s", err, out, gen)
+	}
+	return string(out)
+}
+
+const foreignSelectorSrc = `package p
+
+import "os/exec"
+
+func Process(c ?*exec.Cmd) {
+	_ = c?.Process
+}
+`
+
+func TestOptionalSelectorImports(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.sgo", foreignSelectorSrc, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, info, _, errs := typecheck("p", fset, ".", f)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	// *os.Process is written with os imported again.
+	gen := convertAST(info, []byte(foreignSelectorSrc), f, fset, false)
+	if !bytes.Contains(gen, []byte(`"os"`)) {
+		t.Errorf("got no import of os:\n%s", gen)
+	}
+
+	// The fallback for selectors without a selection doesn't write types,
+	// so it must not import anything for them.
+	for sel := range info.Selections {
+		delete(info.Selections, sel)
+	}
+	gen = convertAST(info, []byte(foreignSelectorSrc), f, fset, false)
+	if bytes.Contains(gen, []byte(`"os"`)) {
+		t.Errorf("got an unused import of os:\n%s", gen)
+	}
+}
+
+func TestHelperFuncNames(t *testing.T) {
+	seen := map[string]string{}
+	for _, name := range []string{"a-b.sgo", "a_b.sgo", "a__b.sgo", "a_2d_b.sgo", "ab.sgo", "a.b.sgo"} {
+		fset := token.NewFileSet()
+		f := fset.AddFile(name, -1, 1)
+		c := converter{fset: fset, file: &ast.File{Package: token.Pos(f.Base())}}
+		helper := c.helperFunc("nonnil")
+		if other, ok := seen[helper]; ok {
+			t.Errorf("%s and %s both have helper %s", other, name, helper)
+		}
+		seen[helper] = name
+		if !gotoken.IsIdentifier(helper) {
+			t.Errorf("%s has helper %q, which isn't an identifier", name, helper)
+		}
+	}
+}
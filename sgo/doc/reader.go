@@ -24,7 +24,7 @@ import (
 type methodSet map[string]*Func
 
 // recvString returns a string representation of recv of the
-// form "T", "*T", or "BADRECV" (if not a proper receiver type).
+// form "T", "*T", "?*T", or "BADRECV" (if not a proper receiver type).
 //
 func recvString(recv ast.Expr) string {
 	switch t := recv.(type) {
@@ -32,6 +32,8 @@ func recvString(recv ast.Expr) string {
 		return t.Name
 	case *ast.StarExpr:
 		return "*" + recvString(t.X)
+	case *ast.OptionalType:
+		return "?" + recvString(t.Elt)
 	}
 	return "BADRECV"
 }
@@ -107,6 +109,8 @@ func baseTypeName(x ast.Expr) (name string, imported bool) {
 		}
 	case *ast.StarExpr:
 		return baseTypeName(t.X)
+	case *ast.OptionalType:
+		return baseTypeName(t.Elt)
 	}
 	return
 }
@@ -203,6 +207,9 @@ func (r *reader) recordAnonymousField(parent *namedType, fieldType ast.Expr) (fn
 	}
 	if ftype := r.lookupType(fname); ftype != nil {
 		ftype.isEmbedded = true
+		if opt, ok := fieldType.(*ast.OptionalType); ok {
+			fieldType = opt.Elt
+		}
 		_, ptr := fieldType.(*ast.StarExpr)
 		parent.embedded[ftype] = ptr
 	}
@@ -580,13 +587,21 @@ func customizeRecv(f *Func, recvTypeName string, embeddedIsPtr bool, level int)
 
 	// copy existing receiver field and set new type
 	newField := *f.Decl.Recv.List[0]
-	origPos := newField.Type.Pos()
-	_, origRecvIsPtr := newField.Type.(*ast.StarExpr)
+	origType := newField.Type
+	opt, origRecvIsOpt := origType.(*ast.OptionalType)
+	if origRecvIsOpt {
+		origType = opt.Elt
+	}
+	origPos := origType.Pos()
+	_, origRecvIsPtr := origType.(*ast.StarExpr)
 	newIdent := &ast.Ident{NamePos: origPos, Name: recvTypeName}
 	var typ ast.Expr = newIdent
 	if !embeddedIsPtr && origRecvIsPtr {
 		newIdent.NamePos++ // '*' is one character
 		typ = &ast.StarExpr{Star: origPos, X: newIdent}
+		if origRecvIsOpt {
+			typ = &ast.OptionalType{Mark: opt.Mark, Elt: typ}
+		}
 	}
 	newField.Type = typ
 
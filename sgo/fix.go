@@ -0,0 +1,227 @@
+package sgo
+
+import (
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/importpaths"
+	"github.com/tcard/sgo/sgo/parser"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+// FixPaths applies the fixes suggested for the type errors in the SGo
+// packages from the given import paths. It returns the applied fixes, as
+// their error positions followed by what they do.
+//
+// For SGo: func(paths []string) (fixed []string, warnings []error, errs []error)
+func FixPaths(paths []string) (fixed []string, warnings []error, errs []error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		errs = append(errs, err)
+		return
+	}
+
+	paths, warnings = importpaths.ImportPaths(paths)
+	for _, path := range paths {
+		pkg, err := build.Default.Import(path, cwd, build.FindOnly|build.IgnoreVendor)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		dirFixed, dirWarnings, dirErrs := FixDir(pkg.Dir)
+		fixed = append(fixed, dirFixed...)
+		warnings = append(warnings, dirWarnings...)
+		errs = append(errs, dirErrs...)
+	}
+	return fixed, warnings, errs
+}
+
+// FixDir applies the fixes suggested for the type errors in the SGo package
+// in the given directory, and rewrites its files. It returns the applied
+// fixes, as their error positions followed by what they do.
+//
+// For each error, the first of its suggested fixes is applied. As a fix can
+// make others unnecessary or wrong, like a nil check does with later ones for
+// the same variable, only a fix per top-level declaration is applied at a
+// time, and the package is checked again until there are no more fixes to
+// apply. The errors left unfixed are returned as warnings.
+//
+// For SGo: func(dirName string) (fixed []string, warnings []error, errs []error)
+func FixDir(dirName string) (fixed []string, warnings []error, errs []error) {
+	buildPkg, err := build.ImportDir(dirName, build.FindOnly)
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+	paths, err := filepath.Glob(filepath.Join(dirName, "*.sgo"))
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+
+	var names []string
+	srcs := map[string][]byte{}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.sgo") {
+			continue
+		}
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, []error{err}
+		}
+		names = append(names, path)
+		srcs[path] = src
+	}
+	if len(names) == 0 {
+		return nil, nil, nil
+	}
+
+	changed := map[string]bool{}
+	for pass := 0; pass < maxFixPasses; pass++ {
+		fset := token.NewFileSet()
+		var files []*ast.File
+		for _, name := range names {
+			file, err := parser.ParseFile(fset, name, srcs[name], parser.ParseComments)
+			if err != nil {
+				return fixed, nil, []error{err}
+			}
+			files = append(files, file)
+		}
+
+		_, _, _, typeErrs := typecheck(buildPkg.ImportPath, fset, dirName, files...)
+
+		edits := map[string][]fileEdit{}
+		fixedDecls := map[ast.Decl]bool{}
+		var unfixed []error
+		for _, err := range typeErrs {
+			typeErr, ok := err.(types.Error)
+			if !ok || len(typeErr.Fixes) == 0 || len(typeErr.Fixes[0].Edits) == 0 {
+				unfixed = append(unfixed, err)
+				continue
+			}
+			fix := typeErr.Fixes[0]
+			// The last edit, as the first ones may add imports.
+			decl := enclosingDecl(files, fix.Edits[len(fix.Edits)-1].Pos)
+			if fixedDecls[decl] || !addFixEdits(fset, edits, fix) {
+				unfixed = append(unfixed, err)
+				continue
+			}
+			fixedDecls[decl] = true
+			fixed = append(fixed, fmt.Sprintf("%s: %s", fset.Position(typeErr.Pos), fix.Message))
+		}
+		if len(edits) == 0 {
+			if len(unfixed) > 0 {
+				warnings = append(warnings, makeErrList(fset, unfixed))
+			}
+			break
+		}
+		for name, fileEdits := range edits {
+			srcs[name] = applyEdits(srcs[name], fileEdits)
+			changed[name] = true
+		}
+	}
+
+	for _, name := range names {
+		if !changed[name] {
+			continue
+		}
+		if err := ioutil.WriteFile(name, srcs[name], 0666); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return fixed, warnings, errs
+}
+
+// maxFixPasses bounds the times FixDir checks a package again after applying
+// fixes.
+const maxFixPasses = 100
+
+// enclosingDecl returns the top-level declaration in files that contains pos,
+// or nil.
+func enclosingDecl(files []*ast.File, pos token.Pos) ast.Decl {
+	for _, file := range files {
+		if pos < file.Pos() || pos > file.End() {
+			continue
+		}
+		for _, decl := range file.Decls {
+			if decl.Pos() <= pos && pos <= decl.End() {
+				return decl
+			}
+		}
+	}
+	return nil
+}
+
+// A fileEdit is a types.TextEdit resolved to offsets in a file.
+type fileEdit struct {
+	start, end int
+	text       string
+	seq        int // order in which the edit was added
+}
+
+// addFixEdits adds the edits of fix to edits, by file name, and reports
+// whether it did. It doesn't if any of them overlaps a different edit already
+// there. Edits already there are not added again.
+func addFixEdits(fset *token.FileSet, edits map[string][]fileEdit, fix types.SuggestedFix) bool {
+	var add []fileEdit
+	var names []string
+	for _, e := range fix.Edits {
+		start, end := fset.Position(e.Pos), fset.Position(e.End)
+		fe := fileEdit{start.Offset, end.Offset, e.NewText, 0}
+		dup := false
+		for _, other := range edits[start.Filename] {
+			if other.start == fe.start && other.end == fe.end && other.text == fe.text {
+				dup = true
+				break
+			}
+			if fe.overlaps(other) {
+				return false
+			}
+		}
+		if !dup {
+			add = append(add, fe)
+			names = append(names, start.Filename)
+		}
+	}
+	for i, fe := range add {
+		fe.seq = len(edits[names[i]])
+		edits[names[i]] = append(edits[names[i]], fe)
+	}
+	return true
+}
+
+// overlaps reports whether e and other change the same code, or one is
+// inserted into code that the other changes.
+func (e fileEdit) overlaps(other fileEdit) bool {
+	if e.start == e.end {
+		return other.start < e.start && e.start < other.end
+	}
+	if other.start == other.end {
+		return e.start < other.start && other.start < e.end
+	}
+	return e.start < other.end && other.start < e.end
+}
+
+// applyEdits returns src with edits applied. Insertions at the same offset
+// are kept in the order they were added.
+func applyEdits(src []byte, edits []fileEdit) []byte {
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		return edits[i].seq < edits[j].seq
+	})
+	var out []byte
+	last := 0
+	for _, e := range edits {
+		out = append(out, src[last:e.start]...)
+		out = append(out, e.text...)
+		last = e.end
+	}
+	return append(out, src[last:]...)
+}
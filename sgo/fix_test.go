@@ -0,0 +1,98 @@
+package sgo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const fixSrc = `package p
+
+type T struct{ N int }
+
+func Get() (*T \ error) {
+	return &T{} \
+}
+
+func Sum(p ?*T) int {
+	var q *T = nil
+	_ = q
+	t \ err := Get()
+	_ = err
+	return p.N + t.N + p.N
+}
+
+func Find(p ?*T) (*T \ error) {
+	return &T{N: p.N} \
+}
+`
+
+const fixWant = `package p
+
+import "errors"
+
+type T struct{ N int }
+
+func Get() (*T \ error) {
+	return &T{} \
+}
+
+func Sum(p ?*T) int {
+	var q ?*T = nil
+	_ = q
+	t \ err := Get()
+	_ = err
+	if p == nil {
+		return 0
+	}
+	if err != nil {
+		return 0
+	}
+	return p.N + t.N + p.N
+}
+
+func Find(p ?*T) (*T \ error) {
+	if p == nil {
+		return \ errors.New("p is nil")
+	}
+	return &T{N: p.N} \
+}
+`
+
+func TestFixDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sgo-fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "p.sgo")
+	if err := ioutil.WriteFile(path, []byte(fixSrc), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	fixed, warnings, errs := FixDir(dir)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("warnings: %v", warnings)
+	}
+	want := []string{
+		path + ":10:13: change the type of q to ?*T",
+		path + ":18:15: check p for nil",
+		path + ":16:9: check p for nil",
+		path + ":19:15: check err before using t",
+	}
+	if !reflect.DeepEqual(fixed, want) {
+		t.Errorf("fixed: got %q, want %q", fixed, want)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != fixWant {
+		t.Errorf("got:\n%s\nwant:\n%s", got, fixWant)
+	}
+}
@@ -0,0 +1,542 @@
+package sgo
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/doc"
+	"github.com/tcard/sgo/sgo/format"
+	"github.com/tcard/sgo/sgo/importer"
+	"github.com/tcard/sgo/sgo/importpaths"
+	"github.com/tcard/sgo/sgo/token"
+)
+
+// WriteDoc writes to w the documentation for what arg names, found from the
+// directory srcDir: a package, by import path or directory, or one of its
+// exported declarations, as in "net/http.Get", "net/http.Client" or
+// "net/http.Client.Do". Declarations of the package in srcDir can be named
+// alone, as in "Get".
+//
+// Declarations are shown with their SGo types. For Go packages, those are the
+// ones that importing them from SGo gives them.
+//
+// For SGo: func(w io.Writer, arg, srcDir string) ?error
+func WriteDoc(w io.Writer, arg, srcDir string) error {
+	pkg, sym, err := findDoc(arg, srcDir)
+	if err != nil {
+		return err
+	}
+	if sym == "" {
+		pkg.writeText(w)
+		return nil
+	}
+	return pkg.writeSymbolText(w, sym)
+}
+
+// DocHandler returns an HTTP handler that serves, as HTML, the documentation
+// that WriteDoc writes for packages, at /pkg/<import path>, and the list of
+// all packages at /pkg/. The annotations from sgovendor folders or
+// SGOANNPATH that are used for Go packages are served at
+// /annotations/<import path>. Import paths are resolved from srcDir.
+func DocHandler(srcDir string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, "/pkg/", http.StatusFound)
+	})
+	mux.HandleFunc("/pkg/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/pkg/"), "/")
+		if path == "" {
+			serveHTML(w, docListTemplate, sourcePackages())
+			return
+		}
+		pkg, err := loadDoc(path, srcDir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		serveHTML(w, docPackageTemplate, pkg.page())
+	})
+	mux.HandleFunc("/annotations/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/annotations/"), "/")
+		files, err := annotationFiles(path, srcDir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		serveHTML(w, docAnnotationsTemplate, struct {
+			Path  string
+			Files []annotationFile
+		}{path, files})
+	})
+	return mux
+}
+
+// sourcePackages returns the import paths of the packages in GOROOT and
+// GOPATH that have Go or SGo files.
+func sourcePackages() []string {
+	paths, _ := importpaths.AllPackages("...")
+	var pkgs []string
+	for _, path := range paths {
+		buildPkg, err := build.Import(path, "", build.FindOnly)
+		if err != nil {
+			continue
+		}
+		for _, pattern := range []string{"*.go", "*.sgo"} {
+			if names, _ := filepath.Glob(filepath.Join(buildPkg.Dir, pattern)); len(names) > 0 {
+				pkgs = append(pkgs, path)
+				break
+			}
+		}
+	}
+	return pkgs
+}
+
+// A docPackage is the documentation for a package, with its declarations in
+// SGo.
+type docPackage struct {
+	*doc.Package
+	fset *token.FileSet
+	ann  *importer.AnnotationSource // nil if there are no annotations
+}
+
+// findDoc loads the documentation for the package that arg names, and
+// returns the name of the declaration in it that arg names after it, if any.
+func findDoc(arg, srcDir string) (*docPackage, string, error) {
+	pkg, err := loadDoc(arg, srcDir)
+	if err == nil {
+		return pkg, "", nil
+	}
+	slash := strings.LastIndex(arg, "/")
+	if dot := strings.Index(arg[slash+1:], "."); dot >= 0 {
+		dot += slash + 1
+		if pkg, err := loadDoc(arg[:dot], srcDir); err == nil {
+			return pkg, arg[dot+1:], nil
+		}
+	}
+	if slash < 0 && ast.IsExported(strings.SplitN(arg, ".", 2)[0]) {
+		if pkg, err := loadDoc(".", srcDir); err == nil {
+			return pkg, arg, nil
+		}
+	}
+	return nil, "", err
+}
+
+// loadDoc loads the documentation for the package with the given import path,
+// found from srcDir.
+func loadDoc(path, srcDir string) (*docPackage, error) {
+	fset := token.NewFileSet()
+	files, buildPkg, ann, err := importer.SourceFiles(fset, path, srcDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no SGo or Go files in %s", buildPkg.Dir)
+	}
+	astPkg := &ast.Package{
+		Name:  files[0].Name.Name,
+		Files: map[string]*ast.File{},
+	}
+	for _, f := range files {
+		astPkg.Files[fset.Position(f.Package).Filename] = f
+	}
+	return &docPackage{
+		Package: doc.New(astPkg, buildPkg.ImportPath, 0),
+		fset:    fset,
+		ann:     ann,
+	}, nil
+}
+
+const (
+	docIndent = "    "
+	docWidth  = 80
+)
+
+// writeText writes the package's documentation and a summary of its
+// declarations.
+func (p *docPackage) writeText(w io.Writer) {
+	fmt.Fprintf(w, "package %s // import %q\n\n", p.Name, p.ImportPath)
+	if p.Doc != "" {
+		doc.ToText(w, p.Doc, "", docIndent, docWidth)
+		fmt.Fprintln(w)
+	}
+	if note := p.annotationsNote(); note != "" {
+		fmt.Fprintf(w, "%s\n\n", note)
+	}
+
+	for _, v := range p.Consts {
+		fmt.Fprintln(w, p.summary(v.Decl))
+	}
+	for _, v := range p.Vars {
+		fmt.Fprintln(w, p.summary(v.Decl))
+	}
+	for _, f := range p.Funcs {
+		fmt.Fprintln(w, p.summary(f.Decl))
+	}
+	for _, t := range p.Types {
+		fmt.Fprintln(w, p.summary(t.Decl))
+		p.writeTypeSummary(w, t, docIndent, false)
+	}
+}
+
+// writeTypeSummary writes the summaries of the declarations associated with
+// t, prefixed by indent, including its methods if methods is set.
+func (p *docPackage) writeTypeSummary(w io.Writer, t *doc.Type, indent string, methods bool) {
+	for _, v := range t.Consts {
+		fmt.Fprintln(w, indentLines(p.summary(v.Decl), indent))
+	}
+	for _, v := range t.Vars {
+		fmt.Fprintln(w, indentLines(p.summary(v.Decl), indent))
+	}
+	for _, f := range t.Funcs {
+		fmt.Fprintln(w, indent+p.summary(f.Decl))
+	}
+	if methods {
+		for _, f := range t.Methods {
+			fmt.Fprintln(w, indent+p.summary(f.Decl))
+		}
+	}
+}
+
+// writeSymbolText writes the documentation for the package's declaration
+// named sym, which can be a method, as in "T.M".
+func (p *docPackage) writeSymbolText(w io.Writer, sym string) error {
+	name, method := sym, ""
+	if i := strings.Index(sym, "."); i >= 0 {
+		name, method = sym[:i], sym[i+1:]
+	}
+
+	for _, t := range p.Types {
+		if t.Name != name {
+			continue
+		}
+		if method == "" {
+			p.writeDeclText(w, t.Decl, t.Doc)
+			var buf bytes.Buffer
+			p.writeTypeSummary(&buf, t, "", true)
+			if buf.Len() > 0 {
+				fmt.Fprintf(w, "\n%s", buf.Bytes())
+			}
+			return nil
+		}
+		for _, f := range t.Methods {
+			if f.Name == method {
+				p.writeDeclText(w, f.Decl, f.Doc)
+				return nil
+			}
+		}
+	}
+
+	if method == "" {
+		for _, f := range p.allFuncs() {
+			if f.Name == name {
+				p.writeDeclText(w, f.Decl, f.Doc)
+				return nil
+			}
+		}
+		for _, v := range p.allValues() {
+			for _, n := range v.Names {
+				if n == name {
+					p.writeDeclText(w, v.Decl, v.Doc)
+					return nil
+				}
+			}
+		}
+	}
+
+	return fmt.Errorf("no symbol %s in package %s", sym, p.ImportPath)
+}
+
+// writeDeclText writes decl followed by its documentation, indented.
+func (p *docPackage) writeDeclText(w io.Writer, decl ast.Decl, text string) {
+	fmt.Fprintln(w, p.code(decl))
+	if text != "" {
+		doc.ToText(w, text, docIndent, docIndent+"\t", docWidth)
+	}
+	if note := p.annotationsNote(); note != "" {
+		fmt.Fprintf(w, "\n%s%s\n", docIndent, note)
+	}
+}
+
+// allFuncs returns the package's functions, including those associated with
+// its types.
+func (p *docPackage) allFuncs() []*doc.Func {
+	funcs := append([]*doc.Func{}, p.Funcs...)
+	for _, t := range p.Types {
+		funcs = append(funcs, t.Funcs...)
+	}
+	return funcs
+}
+
+// allValues returns the package's constants and variables, including those
+// associated with its types.
+func (p *docPackage) allValues() []*doc.Value {
+	values := append(append([]*doc.Value{}, p.Consts...), p.Vars...)
+	for _, t := range p.Types {
+		values = append(append(values, t.Consts...), t.Vars...)
+	}
+	return values
+}
+
+// annotationsNote tells where the annotations applied to the package's
+// declarations come from, if there are any.
+func (p *docPackage) annotationsNote() string {
+	switch {
+	case p.ann == nil:
+		return ""
+	case p.ann.Builtin():
+		return "SGo types from built-in annotations."
+	default:
+		return "SGo types from annotations in " + p.ann.Dir + "."
+	}
+}
+
+// code returns decl in SGo source form, without its doc comment or, for
+// functions, body.
+func (p *docPackage) code(decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		c := *d
+		c.Doc = nil
+		c.Body = nil
+		decl = &c
+	case *ast.GenDecl:
+		c := *d
+		c.Doc = nil
+		decl = &c
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, p.fset, decl); err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return buf.String()
+}
+
+// summary returns the first line of decl in SGo source form, with the rest
+// of a type's body elided. Declarations of several constants or variables
+// are kept whole.
+func (p *docPackage) summary(decl ast.Decl) string {
+	code := p.code(decl)
+	if d, ok := decl.(*ast.GenDecl); ok && d.Lparen.IsValid() {
+		return code
+	}
+	if i := strings.Index(code, "\n"); i >= 0 {
+		code = code[:i]
+		if strings.HasSuffix(code, "{") {
+			code += " ... }"
+		}
+	}
+	return code
+}
+
+// indentLines prefixes each line of s with indent.
+func indentLines(s, indent string) string {
+	return indent + strings.Replace(s, "\n", "\n"+indent, -1)
+}
+
+// A docPage holds the documentation of a package as HTML.
+type docPage struct {
+	Name, ImportPath string
+	Doc              template.HTML
+	AnnBuiltin       bool
+	AnnDir           string
+	Consts, Vars     []docDecl
+	Funcs            []docDecl
+	Types            []docType
+}
+
+// A docDecl holds the documentation of a declaration as HTML.
+type docDecl struct {
+	ID, Name string
+	Code     string
+	Doc      template.HTML
+}
+
+// A docType holds the documentation of a type declaration, and those
+// associated with it, as HTML.
+type docType struct {
+	docDecl
+	Consts, Vars   []docDecl
+	Funcs, Methods []docDecl
+}
+
+// page returns the package's documentation as HTML.
+func (p *docPackage) page() docPage {
+	page := docPage{
+		Name:       p.Name,
+		ImportPath: p.ImportPath,
+		Doc:        docHTML(p.Doc),
+		Consts:     p.valueDecls(p.Consts),
+		Vars:       p.valueDecls(p.Vars),
+		Funcs:      p.funcDecls(p.Funcs, ""),
+	}
+	if p.ann != nil {
+		page.AnnBuiltin = p.ann.Builtin()
+		page.AnnDir = p.ann.Dir
+	}
+	for _, t := range p.Types {
+		page.Types = append(page.Types, docType{
+			docDecl: docDecl{ID: t.Name, Name: t.Name, Code: p.code(t.Decl), Doc: docHTML(t.Doc)},
+			Consts:  p.valueDecls(t.Consts),
+			Vars:    p.valueDecls(t.Vars),
+			Funcs:   p.funcDecls(t.Funcs, ""),
+			Methods: p.funcDecls(t.Methods, t.Name),
+		})
+	}
+	return page
+}
+
+func (p *docPackage) valueDecls(values []*doc.Value) []docDecl {
+	var decls []docDecl
+	for _, v := range values {
+		decls = append(decls, docDecl{ID: v.Names[0], Name: strings.Join(v.Names, ", "), Code: p.code(v.Decl), Doc: docHTML(v.Doc)})
+	}
+	return decls
+}
+
+// funcDecls returns the documentation for funcs, which are methods of the
+// type recv if it isn't empty.
+func (p *docPackage) funcDecls(funcs []*doc.Func, recv string) []docDecl {
+	var decls []docDecl
+	for _, f := range funcs {
+		id := f.Name
+		if recv != "" {
+			id = recv + "." + f.Name
+		}
+		decls = append(decls, docDecl{ID: id, Name: id, Code: p.code(f.Decl), Doc: docHTML(f.Doc)})
+	}
+	return decls
+}
+
+// docHTML returns the doc comment text as HTML.
+func docHTML(text string) template.HTML {
+	var buf bytes.Buffer
+	doc.ToHTML(&buf, text, nil)
+	return template.HTML(buf.String())
+}
+
+// An annotationFile is a .sgoann file.
+type annotationFile struct {
+	Name, Content string
+}
+
+// annotationFiles returns the .sgoann files used for the package with the
+// given import path, when importing it from srcDir.
+func annotationFiles(path, srcDir string) ([]annotationFile, error) {
+	srcs, err := importer.AnnotationSources(srcDir)
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(srcs), func(i int) bool { return srcs[i].Path >= path })
+	if i == len(srcs) || srcs[i].Path != path {
+		return nil, fmt.Errorf("no annotations for %s", path)
+	}
+	if srcs[i].Builtin() {
+		return nil, fmt.Errorf("%s has built-in annotations", path)
+	}
+	names, err := filepath.Glob(filepath.Join(srcs[i].Dir, "*.sgoann"))
+	if err != nil {
+		return nil, err
+	}
+	var files []annotationFile
+	for _, name := range names {
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, annotationFile{name, string(content)})
+	}
+	return files, nil
+}
+
+func serveHTML(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+const docStyle = `<style>
+body { font-family: sans-serif; max-width: 60em; margin: 1em auto; }
+pre { background: #f4f4f4; padding: 0.5em; overflow-x: auto; }
+h3 a, h4 a { color: inherit; text-decoration: none; }
+.note { color: #555; font-style: italic; }
+</style>`
+
+var docListTemplate = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<title>Packages</title>
+` + docStyle + `
+<h1>Packages</h1>
+<ul>
+{{range .}}<li><a href="/pkg/{{.}}">{{.}}</a></li>
+{{end}}</ul>
+`))
+
+var docPackageTemplate = template.Must(template.New("package").Parse(`<!DOCTYPE html>
+<title>{{.Name}} - {{.ImportPath}}</title>
+` + docStyle + `
+<h1>package {{.Name}}</h1>
+<pre>import "{{.ImportPath}}"</pre>
+{{if .AnnBuiltin}}<p class="note">SGo types from built-in annotations.</p>
+{{else if .AnnDir}}<p class="note">SGo types from <a href="/annotations/{{.ImportPath}}">annotations in {{.AnnDir}}</a>.</p>
+{{end}}{{.Doc}}
+<h2>Index</h2>
+<ul>
+{{range .Consts}}<li><a href="#{{.ID}}">const {{.Name}}</a></li>
+{{end}}{{range .Vars}}<li><a href="#{{.ID}}">var {{.Name}}</a></li>
+{{end}}{{range .Funcs}}<li><a href="#{{.ID}}">func {{.Name}}</a></li>
+{{end}}{{range .Types}}<li><a href="#{{.ID}}">type {{.Name}}</a>
+{{if or .Funcs .Methods}}<ul>
+{{range .Funcs}}<li><a href="#{{.ID}}">func {{.Name}}</a></li>
+{{end}}{{range .Methods}}<li><a href="#{{.ID}}">func {{.Name}}</a></li>
+{{end}}</ul>
+{{end}}</li>
+{{end}}</ul>
+{{with .Consts}}<h2>Constants</h2>
+{{range .}}<pre id="{{.ID}}">{{.Code}}</pre>
+{{.Doc}}
+{{end}}{{end}}{{with .Vars}}<h2>Variables</h2>
+{{range .}}<pre id="{{.ID}}">{{.Code}}</pre>
+{{.Doc}}
+{{end}}{{end}}{{with .Funcs}}<h2>Functions</h2>
+{{range .}}<h3 id="{{.ID}}"><a href="#{{.ID}}">func {{.Name}}</a></h3>
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{end}}{{end}}{{with .Types}}<h2>Types</h2>
+{{range .}}<h3 id="{{.ID}}"><a href="#{{.ID}}">type {{.Name}}</a></h3>
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{range .Consts}}<pre id="{{.ID}}">{{.Code}}</pre>
+{{.Doc}}
+{{end}}{{range .Vars}}<pre id="{{.ID}}">{{.Code}}</pre>
+{{.Doc}}
+{{end}}{{range .Funcs}}<h4 id="{{.ID}}"><a href="#{{.ID}}">func {{.Name}}</a></h4>
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{end}}{{range .Methods}}<h4 id="{{.ID}}"><a href="#{{.ID}}">func {{.Name}}</a></h4>
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{end}}{{end}}{{end}}`))
+
+var docAnnotationsTemplate = template.Must(template.New("annotations").Parse(`<!DOCTYPE html>
+<title>Annotations for {{.Path}}</title>
+` + docStyle + `
+<h1>Annotations for <a href="/pkg/{{.Path}}">{{.Path}}</a></h1>
+{{range .Files}}<h2>{{.Name}}</h2>
+<pre>{{.Content}}</pre>
+{{end}}`))
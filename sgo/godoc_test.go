@@ -0,0 +1,137 @@
+package sgo
+
+import (
+	"bytes"
+	"go/build"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteDoc(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "sgo-doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	oldGOPATH := build.Default.GOPATH
+	build.Default.GOPATH = gopath
+	defer func() {
+		build.Default.GOPATH = oldGOPATH
+	}()
+
+	files := map[string]string{
+		"src/example.com/s/s.sgo": `// Package s is written in SGo.
+package s
+
+// A T is a T.
+type T struct{ N int }
+
+// Get gets a T.
+func Get() (*T \ error) {
+	return &T{} \
+}
+
+// Next returns the next T, if any.
+func (t ?*T) Next() ?*T {
+	return nil
+}
+`,
+		"src/example.com/g/g.go": `package g
+
+type Node struct{}
+
+// Find finds a node.
+func Find(n *Node) *Node { return n }
+
+func Last(n *Node) *Node { return n }
+`,
+		"src/example.com/app/sgovendor/example.com/g/g.sgoann": "Find func(n *Node) *Node\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(gopath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := filepath.Join(gopath, "src", "example.com", "app")
+	annDir := filepath.Join(app, "sgovendor", "example.com", "g")
+
+	for _, tt := range []struct {
+		arg, srcDir, want string
+	}{
+		{"example.com/s", app, `package s // import "example.com/s"
+
+Package s is written in SGo.
+
+type T struct{ N int }
+    func Get() (*T \ error)
+`},
+		{"Get", filepath.Join(gopath, "src", "example.com", "s"), `func Get() (*T \ error)
+    Get gets a T.
+`},
+		{"example.com/s.T.Next", app, `func (t ?*T) Next() ?*T
+    Next returns the next T, if any.
+`},
+		{"example.com/g.Find", app, `func Find(n *Node) *Node
+    Find finds a node.
+
+    SGo types from annotations in ` + annDir + `.
+`},
+		{"example.com/g.Node", gopath, `type Node struct{}
+
+func Find(n ?*Node) ?*Node
+func Last(n ?*Node) ?*Node
+`},
+	} {
+		var buf bytes.Buffer
+		if err := WriteDoc(&buf, tt.arg, tt.srcDir); err != nil {
+			t.Errorf("%s: %v", tt.arg, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.arg, got, tt.want)
+		}
+	}
+
+	if err := WriteDoc(ioutil.Discard, "example.com/s.Missing", app); err == nil {
+		t.Errorf("example.com/s.Missing: expected error")
+	}
+
+	srv := httptest.NewServer(DocHandler(app))
+	defer srv.Close()
+	for path, wants := range map[string][]string{
+		"/pkg/example.com/s": {
+			`<pre>func Get() (*T \ error)</pre>`,
+			`<h4 id="T.Next">`,
+		},
+		"/pkg/example.com/g": {
+			`<a href="/annotations/example.com/g">annotations in ` + annDir + `</a>`,
+			`<pre>func Find(n *Node) *Node</pre>`,
+		},
+		"/annotations/example.com/g": {
+			"<pre>Find func(n *Node) *Node\n</pre>",
+		},
+	} {
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range wants {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("%s: %q not found in:\n%s", path, want, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package sgo
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+// goTypes returns how typs are written in Go at pos in the file being
+// translated, with optionals erased and entangled results written as the last
+// result, so that a translation can declare values of them. Packages not
+// imported by the file, or whose names are shadowed at pos, are imported
+// again with a new name. It returns false if some type can't be written at
+// pos: if it has a type or field that isn't exported from another package, or
+// a name that is shadowed at pos.
+func (c *converter) goTypes(pos token.Pos, typs ...types.Type) ([]string, bool) {
+	p := goTypePrinter{c: c, pos: pos, scope: c.Scopes[c.file]}
+	if p.scope == nil {
+		return nil, false
+	}
+	if inner := p.scope.Innermost(pos); inner != nil {
+		p.scope = inner
+	}
+	var written []string
+	for _, typ := range typs {
+		p.buf.Reset()
+		p.writeType(typ)
+		if p.failed {
+			return nil, false
+		}
+		written = append(written, p.buf.String())
+	}
+	c.typeImports = append(c.typeImports, p.imports...)
+	return written, true
+}
+
+type goTypePrinter struct {
+	c     *converter
+	pos   token.Pos
+	scope *types.Scope
+	buf   bytes.Buffer
+
+	// packages that need to be imported again for the type to be
+	// written, after those in c.typeImports
+	imports []*types.Package
+	failed  bool
+}
+
+// visible reports whether name is obj at pos.
+func (p *goTypePrinter) visible(name string, obj types.Object) bool {
+	_, found := p.scope.LookupParent(name, p.pos)
+	return found == obj
+}
+
+// universal reports whether name is the predeclared one at pos.
+func (p *goTypePrinter) universal(name string) bool {
+	return p.visible(name, types.Universe.Lookup(name))
+}
+
+// local reports whether pkg is the package being translated.
+func (p *goTypePrinter) local(pkg *types.Package) bool {
+	return pkg.Scope() == p.c.Scopes[p.c.file].Parent()
+}
+
+// qualifier writes the name with which pkg is referred to at pos.
+func (p *goTypePrinter) qualifier(pkg *types.Package) {
+	for _, spec := range p.c.file.Imports {
+		var obj types.Object
+		if spec.Name != nil {
+			obj = p.c.Defs[spec.Name]
+		} else {
+			obj = p.c.Implicits[spec]
+		}
+		pkgName, ok := obj.(*types.PkgName)
+		if !ok || pkgName.Imported() != pkg || pkgName.Name() == "_" || pkgName.Name() == "." {
+			continue
+		}
+		if p.visible(pkgName.Name(), pkgName) {
+			p.buf.WriteString(pkgName.Name() + ".")
+			return
+		}
+	}
+
+	for _, elem := range strings.Split(pkg.Path(), "/") {
+		if elem == "internal" || elem == "vendor" {
+			// Might not be importable from here.
+			p.failed = true
+			return
+		}
+	}
+	imported := append(p.c.typeImports[:len(p.c.typeImports):len(p.c.typeImports)], p.imports...)
+	i := 0
+	for i < len(imported) && imported[i] != pkg {
+		i++
+	}
+	if i == len(imported) {
+		p.imports = append(p.imports, pkg)
+	}
+	fmt.Fprintf(&p.buf, "__sgo_import%d.", i)
+}
+
+func (p *goTypePrinter) writeType(typ types.Type) {
+	if p.failed {
+		return
+	}
+
+	switch t := typ.(type) {
+	case *types.Optional:
+		p.writeType(t.Elem())
+
+	case *types.Basic:
+		switch {
+		case t.Kind() == types.UnsafePointer:
+			p.qualifier(types.Unsafe)
+			p.buf.WriteString("Pointer")
+		case t.Info()&types.IsUntyped != 0 || !p.universal(t.Name()):
+			p.failed = true
+		default:
+			p.buf.WriteString(t.Name())
+		}
+
+	case *types.Named:
+		obj := t.Obj()
+		switch {
+		case obj.Pkg() == nil:
+			if !p.universal(obj.Name()) {
+				p.failed = true
+				return
+			}
+		case p.local(obj.Pkg()):
+			if !p.visible(obj.Name(), obj) {
+				p.failed = true
+				return
+			}
+		case !obj.Exported():
+			p.failed = true
+			return
+		default:
+			p.qualifier(obj.Pkg())
+		}
+		p.buf.WriteString(obj.Name())
+		if args := t.TypeArgs(); args != nil && args.Len() > 0 {
+			p.buf.WriteByte('[')
+			for i := 0; i < args.Len(); i++ {
+				if i > 0 {
+					p.buf.WriteString(", ")
+				}
+				p.writeType(args.At(i))
+			}
+			p.buf.WriteByte(']')
+		}
+
+	case *types.TypeParam:
+		if !p.visible(t.Obj().Name(), t.Obj()) {
+			p.failed = true
+			return
+		}
+		p.buf.WriteString(t.Obj().Name())
+
+	case *types.Pointer:
+		p.buf.WriteByte('*')
+		p.writeType(t.Elem())
+
+	case *types.Slice:
+		p.buf.WriteString("[]")
+		p.writeType(t.Elem())
+
+	case *types.Array:
+		fmt.Fprintf(&p.buf, "[%d]", t.Len())
+		p.writeType(t.Elem())
+
+	case *types.Map:
+		p.buf.WriteString("map[")
+		p.writeType(t.Key())
+		p.buf.WriteByte(']')
+		p.writeType(t.Elem())
+
+	case *types.Chan:
+		parens := false
+		switch t.Dir() {
+		case types.SendRecv:
+			p.buf.WriteString("chan ")
+			// chan (<-chan T) requires parentheses
+			elem, _ := t.Elem().(*types.Chan)
+			parens = elem != nil && elem.Dir() == types.RecvOnly
+		case types.SendOnly:
+			p.buf.WriteString("chan<- ")
+		case types.RecvOnly:
+			p.buf.WriteString("<-chan ")
+		}
+		if parens {
+			p.buf.WriteByte('(')
+		}
+		p.writeType(t.Elem())
+		if parens {
+			p.buf.WriteByte(')')
+		}
+
+	case *types.Signature:
+		p.buf.WriteString("func")
+		p.writeSignature(t)
+
+	case *types.Struct:
+		p.buf.WriteString("struct{")
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			if !f.Exported() && !p.local(f.Pkg()) {
+				p.failed = true
+				return
+			}
+			if i > 0 {
+				p.buf.WriteString("; ")
+			}
+			if !f.Anonymous() {
+				p.buf.WriteString(f.Name() + " ")
+			}
+			p.writeType(f.Type())
+			if tag := t.Tag(i); tag != "" {
+				p.buf.WriteString(" " + strconv.Quote(tag))
+			}
+		}
+		p.buf.WriteByte('}')
+
+	case *types.Interface:
+		if t.NumEmbeddedUnions() > 0 {
+			// Only constraints have them.
+			p.failed = true
+			return
+		}
+		p.buf.WriteString("interface{")
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			m := t.ExplicitMethod(i)
+			if !m.Exported() && !p.local(m.Pkg()) {
+				p.failed = true
+				return
+			}
+			if i > 0 {
+				p.buf.WriteString("; ")
+			}
+			p.buf.WriteString(m.Name())
+			p.writeSignature(m.Type().(*types.Signature))
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			if i > 0 || t.NumExplicitMethods() > 0 {
+				p.buf.WriteString("; ")
+			}
+			p.writeType(t.Embedded(i))
+		}
+		p.buf.WriteByte('}')
+
+	default:
+		p.failed = true
+	}
+}
+
+func (p *goTypePrinter) writeSignature(sig *types.Signature) {
+	p.buf.WriteByte('(')
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		if i > 0 {
+			p.buf.WriteString(", ")
+		}
+		typ := params.At(i).Type()
+		if sig.Variadic() && i == params.Len()-1 {
+			p.buf.WriteString("...")
+			if s, ok := typ.(*types.Slice); ok {
+				typ = s.Elem()
+			}
+		}
+		p.writeType(typ)
+	}
+	p.buf.WriteByte(')')
+
+	results := sig.Results()
+	var list []types.Type
+	for i := 0; i < results.Len(); i++ {
+		list = append(list, results.At(i).Type())
+	}
+	if entangled := results.Entangled(); entangled != nil {
+		list = append(list, entangled.Type())
+	}
+	if len(list) == 0 {
+		return
+	}
+	p.buf.WriteByte(' ')
+	if len(list) == 1 {
+		p.writeType(list[0])
+		return
+	}
+	p.buf.WriteByte('(')
+	for i, typ := range list {
+		if i > 0 {
+			p.buf.WriteString(", ")
+		}
+		p.writeType(typ)
+	}
+	p.buf.WriteByte(')')
+}
@@ -0,0 +1,90 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnnotationSources(t *testing.T) {
+	root, err := ioutil.TempDir("", "sgoannpath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := []string{
+		"project/sgovendor/github.com/foo/bar/bar.sgoann",
+		"project/pkg/sgovendor/github.com/foo/bar/bar.sgoann",
+		"project/pkg/sgovendor/github.com/foo/baz/baz.sgoann",
+		"shared1/github.com/foo/baz/baz.sgoann",
+		"shared1/github.com/foo/qux/qux.sgoann",
+		"shared2/github.com/foo/qux/a.sgoann",
+		"shared2/github.com/foo/qux/b.sgoann",
+		"shared2/os/os.sgoann",
+	}
+	for _, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := func(rel string) string {
+		return filepath.Join(root, filepath.FromSlash(rel))
+	}
+
+	oldAnnPath := os.Getenv(AnnPathEnv)
+	defer os.Setenv(AnnPathEnv, oldAnnPath)
+	os.Setenv(AnnPathEnv, strings.Join([]string{
+		dir("shared1"),
+		dir("missing"),
+		dir("shared2"),
+	}, string(os.PathListSeparator)))
+
+	srcs, err := AnnotationSources(dir("project/pkg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]AnnotationSource{}
+	for _, src := range srcs {
+		got[src.Path] = src
+	}
+
+	expected := []AnnotationSource{{
+		Path:     "github.com/foo/bar",
+		Dir:      dir("project/pkg/sgovendor/github.com/foo/bar"),
+		Shadowed: []string{dir("project/sgovendor/github.com/foo/bar")},
+	}, {
+		Path:     "github.com/foo/baz",
+		Dir:      dir("project/pkg/sgovendor/github.com/foo/baz"),
+		Shadowed: []string{dir("shared1/github.com/foo/baz")},
+	}, {
+		Path:     "github.com/foo/qux",
+		Dir:      dir("shared1/github.com/foo/qux"),
+		Shadowed: []string{dir("shared2/github.com/foo/qux")},
+	}, {
+		Path:     "os",
+		Shadowed: []string{dir("shared2/os")},
+	}}
+	for _, e := range expected {
+		if g := got[e.Path]; !reflect.DeepEqual(e, g) {
+			t.Errorf("%s: expected %+v, got %+v", e.Path, e, g)
+		}
+	}
+	if !got["os"].Builtin() {
+		t.Errorf("expected os annotations to be built-in")
+	}
+
+	for i := 1; i < len(srcs); i++ {
+		if srcs[i-1].Path >= srcs[i].Path {
+			t.Errorf("sources not sorted by path: %q before %q", srcs[i-1].Path, srcs[i].Path)
+		}
+	}
+}
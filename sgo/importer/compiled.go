@@ -0,0 +1,660 @@
+package importer
+
+import (
+	"fmt"
+	goimporter "go/importer"
+	gotypes "go/types"
+	"strings"
+
+	"github.com/tcard/sgo/sgo/annotations"
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/parser"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+// ImportModeEnv is the name of the environment variable that, when set to
+// "compiled", makes the sgo tool import packages with CompiledFrom instead of
+// DefaultFrom.
+const ImportModeEnv = "SGOIMPORT"
+
+// CompiledFrom is like DefaultFrom, but the packages imported from files are
+// imported from compiled Go export data instead of from source.
+//
+// Those packages are converted to SGo like ConvertAST does by default, and
+// then the annotations for them, either built-in or from sgovendor folders, are
+// applied by object path, so their source isn't needed. "For SGo:" doc
+// comments are lost in export data, though, so packages written originally in
+// SGo should be imported from source.
+//
+// DefaultFrom also falls back to this for packages that it can't find the
+// source of.
+func CompiledFrom(files []*ast.File, whence string) (types.Importer, error) {
+	imp, err := newImporter(importedPaths(files), whence)
+	if err != nil {
+		return nil, err
+	}
+	imp.compiled = goimporter.Default()
+	return imp, nil
+}
+
+func (imp *importer) importCompiled(path, srcDir string) (*types.Package, error) {
+	gimp := imp.compiled
+	if gimp == nil {
+		gimp = goimporter.Default()
+	}
+	var gopkg *gotypes.Package
+	var err error
+	if from, ok := gimp.(gotypes.ImporterFrom); ok {
+		gopkg, err = from.ImportFrom(path, srcDir, 0)
+	} else {
+		gopkg, err = gimp.Import(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ann, err := imp.annotations(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &compiledConverter{
+		converter: &converter{gopkg: gopkg, converted: map[interface{}]interface{}{}},
+		imp:       imp.fromPkg(),
+		ann:       ann,
+	}
+	pkg, err := c.convert()
+	if err != nil {
+		return nil, fmt.Errorf("converting %s: %v", path, err)
+	}
+	from := imp.annotationSource(path)
+	if from == "" {
+		from = "no annotations"
+	}
+	pkg.MarkFromGo(from)
+
+	imp.imported[path] = pkg
+	return pkg, nil
+}
+
+// A compiledConverter converts a go/types package to SGo as importing it from
+// source would do: types are wrapped in optionals where ConvertAST would do it
+// by default, unless annotated otherwise.
+//
+// Types from other packages are taken from the packages imported with imp, so
+// that they are the same as when importing those packages directly.
+type compiledConverter struct {
+	*converter
+	imp types.Importer
+	ann *annotations.Annotation
+
+	evalPkg *types.Package
+	err     error
+}
+
+// A typePos tells how a type is converted by default, depending on where it
+// appears.
+type typePos int
+
+const (
+	// plainPos types aren't converted at all, like variadic parameters.
+	plainPos typePos = iota
+	// innerPos types aren't wrapped, but the types they are made of are,
+	// like the underlying types of type declarations.
+	innerPos
+	// optionalPos types are wrapped, like parameters, results, fields and
+	// element types.
+	optionalPos
+)
+
+func (c *compiledConverter) convert() (*types.Package, error) {
+	v := c.gopkg
+	c.ret = types.NewPackage(v.Path(), v.Name())
+	c.converted[v] = c.ret
+
+	var imports []*types.Package
+	for _, imported := range v.Imports() {
+		pkg, err := c.importPkg(imported.Path())
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, pkg)
+	}
+	c.ret.SetImports(imports)
+
+	scope := v.Scope()
+	names := scope.Names()
+
+	// 1. Declare the package's types and constants, with the types
+	//    converted by default, so that annotations can refer to them.
+
+	var typeNames, aliases []*gotypes.TypeName
+	for _, name := range names {
+		switch obj := scope.Lookup(name).(type) {
+		case *gotypes.TypeName:
+			if obj.IsAlias() {
+				aliases = append(aliases, obj)
+				continue
+			}
+			c.ret.Scope().Insert(c.typeName(obj))
+			typeNames = append(typeNames, obj)
+		case *gotypes.Const:
+			c.ret.Scope().Insert(types.NewConst(
+				token.Pos(obj.Pos()),
+				c.ret,
+				obj.Name(),
+				c.typ(obj.Type(), plainPos, nil),
+				c.convertConstantValue(obj.Val()),
+			))
+		}
+	}
+	for _, tn := range typeNames {
+		named := c.converted[tn.Type()].(*types.Named)
+		named.SetUnderlying(c.typ(tn.Type().Underlying(), innerPos, nil))
+	}
+	for _, obj := range aliases {
+		c.ret.Scope().Insert(c.alias(obj))
+	}
+
+	// 2. Redo the underlying types with annotations, and add the methods.
+
+	for _, tn := range typeNames {
+		named := c.converted[tn.Type()].(*types.Named)
+		ann := c.ann.Lookup(tn.Name())
+		if typ, ok := ann.Type(); ok {
+			t := c.eval(typ)
+			if t != nil {
+				named.SetUnderlying(t.Underlying())
+			}
+		} else if ann.HasChildren() {
+			named.SetUnderlying(c.typ(tn.Type().Underlying(), innerPos, ann))
+		}
+
+		if gonamed, ok := tn.Type().(*gotypes.Named); ok {
+			for i := 0; i < gonamed.NumMethods(); i++ {
+				named.AddMethod(c.method(tn.Name(), gonamed.Method(i)))
+			}
+		}
+	}
+
+	// 3. Declare the rest of objects.
+
+	for _, name := range names {
+		switch obj := scope.Lookup(name).(type) {
+		case *gotypes.Func:
+			var sig *types.Signature
+			if typ, ok := c.ann.Lookup(name).Type(); ok {
+				sig, _ = c.eval(typ).(*types.Signature)
+			}
+			if sig == nil {
+				sig = c.funcSignature(nil, obj.Type().(*gotypes.Signature))
+			}
+			c.ret.Scope().Insert(types.NewFunc(token.Pos(obj.Pos()), c.ret, name, sig))
+		case *gotypes.Var:
+			c.ret.Scope().Insert(types.NewVar(token.Pos(obj.Pos()), c.ret, name, c.annotated(obj.Type(), optionalPos, c.ann.Lookup(name))))
+		}
+	}
+
+	for _, iface := range c.ifaces {
+		iface.Complete()
+	}
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.ret.MarkComplete()
+	return c.ret, nil
+}
+
+// typeName declares a type name from the package, with its named type still
+// without underlying type and methods.
+func (c *compiledConverter) typeName(v *gotypes.TypeName) *types.TypeName {
+	if v, ok := c.converted[v]; ok {
+		return v.(*types.TypeName)
+	}
+	ret := types.NewTypeName(token.Pos(v.Pos()), c.ret, v.Name(), nil)
+	c.converted[v] = ret
+	named := types.NewNamed(ret, nil, nil)
+	c.converted[v.Type()] = named
+	if gonamed, ok := v.Type().(*gotypes.Named); ok {
+		named.SetTypeParams(c.tparams(gonamed.TypeParams()))
+	}
+	return ret
+}
+
+// alias declares the type alias v from the package scope, with the aliased
+// type converted like the underlying types of type declarations, unless
+// annotated.
+func (c *compiledConverter) alias(v *gotypes.TypeName) *types.TypeName {
+	if v, ok := c.converted[v]; ok {
+		return v.(*types.TypeName)
+	}
+	ret := types.NewTypeName(token.Pos(v.Pos()), c.ret, v.Name(), c.annotated(gotypes.Unalias(v.Type()), innerPos, c.ann.Lookup(v.Name())))
+	c.converted[v] = ret
+	return ret
+}
+
+// tparams converts the type parameters in v.
+func (c *compiledConverter) tparams(v *gotypes.TypeParamList) []*types.TypeParam {
+	var ret []*types.TypeParam
+	for i := 0; i < v.Len(); i++ {
+		ret = append(ret, c.tparam(v.At(i)))
+	}
+	return ret
+}
+
+// tparam converts the type parameter v, with its constraint as is.
+func (c *compiledConverter) tparam(v *gotypes.TypeParam) *types.TypeParam {
+	if v, ok := c.converted[v]; ok {
+		return v.(*types.TypeParam)
+	}
+	obj := v.Obj()
+	ret := types.NewTypeParam(types.NewTypeName(token.Pos(obj.Pos()), c.ret, obj.Name(), nil), nil)
+	c.converted[v] = ret
+	ret.SetConstraint(c.typ(v.Constraint(), plainPos, nil))
+	return ret
+}
+
+// generic returns sig with the type parameters of v, if any.
+func (c *compiledConverter) generic(sig *types.Signature, v *gotypes.Signature) *types.Signature {
+	if v.RecvTypeParams().Len() == 0 && v.TypeParams().Len() == 0 {
+		return sig
+	}
+	return types.NewSignatureType(sig.Recv(), c.tparams(v.RecvTypeParams()), c.tparams(v.TypeParams()), sig.Params(), sig.Results(), sig.Variadic())
+}
+
+// annotated converts v, at position pos, unless ann has a type for it, which
+// is then used instead.
+func (c *compiledConverter) annotated(v gotypes.Type, pos typePos, ann *annotations.Annotation) types.Type {
+	if typ, ok := ann.Type(); ok {
+		if t := c.eval(typ); t != nil {
+			return t
+		}
+	}
+	return c.typ(v, pos, ann)
+}
+
+// typ converts v like ConvertAST converts a type expression by default, at the
+// given position. ann holds the annotations for nested fields and methods.
+func (c *compiledConverter) typ(v gotypes.Type, pos typePos, ann *annotations.Annotation) types.Type {
+	inner := pos
+	if inner != plainPos {
+		inner = optionalPos
+	}
+
+	var ret types.Type
+	switch v := v.(type) {
+	case *gotypes.Alias:
+		if v.Obj() == gotypes.Universe.Lookup("any") {
+			return types.Universe.Lookup("any").Type()
+		}
+		if ret, ok := c.aliased(v, pos); ok {
+			return ret
+		}
+		return c.typ(gotypes.Unalias(v), pos, ann)
+	case *gotypes.TypeParam:
+		return c.tparam(v)
+	case *gotypes.Union:
+		terms := make([]*types.Term, v.Len())
+		for i := range terms {
+			t := v.Term(i)
+			terms[i] = types.NewTerm(t.Tilde(), c.typ(t.Type(), plainPos, nil))
+		}
+		return types.NewUnion(terms)
+	case *gotypes.Basic:
+		return c.convertBasic(v)
+	case *gotypes.Named:
+		ret = c.named(v, inner)
+		if v.Obj().Pkg() != nil && v.Obj().Pkg() != c.gopkg {
+			// ConvertAST only wraps the identifiers of the package's own
+			// types, not the qualified ones.
+			return ret
+		}
+	case *gotypes.Pointer:
+		ret = types.NewPointer(c.typ(v.Elem(), inner, ann))
+	case *gotypes.Slice:
+		return types.NewSlice(c.typ(v.Elem(), inner, ann))
+	case *gotypes.Array:
+		return types.NewArray(c.typ(v.Elem(), inner, ann), v.Len())
+	case *gotypes.Map:
+		ret = types.NewMap(c.typ(v.Key(), inner, ann), c.typ(v.Elem(), inner, ann))
+	case *gotypes.Chan:
+		ret = types.NewChan(types.ChanDir(v.Dir()), c.typ(v.Elem(), inner, ann))
+	case *gotypes.Signature:
+		ret = c.signature(nil, v, pos)
+	case *gotypes.Struct:
+		return c.strct(v, pos, ann)
+	case *gotypes.Interface:
+		ret = c.iface(v, pos, ann)
+	default:
+		c.fail(fmt.Errorf("unhandled Type %T", v))
+		return types.Typ[types.Invalid]
+	}
+
+	if pos == optionalPos && isOptionable(v) {
+		return types.NewOptional(ret)
+	}
+	return ret
+}
+
+// aliased returns the type that a package-level alias v was converted to, if
+// any. Like named types, the package's own aliases are wrapped in optionals
+// at pos if optionable.
+func (c *compiledConverter) aliased(v *gotypes.Alias, pos typePos) (types.Type, bool) {
+	obj := v.Obj()
+	if obj.Pkg() == nil || v.TypeArgs().Len() > 0 || obj.Pkg().Scope().Lookup(obj.Name()) != obj {
+		return nil, false
+	}
+	if obj.Pkg() != c.gopkg {
+		pkg, err := c.importPkg(obj.Pkg().Path())
+		if err != nil {
+			c.fail(err)
+			return types.Typ[types.Invalid], true
+		}
+		tn, ok := pkg.Scope().Lookup(obj.Name()).(*types.TypeName)
+		if !ok {
+			return nil, false
+		}
+		return tn.Type(), true
+	}
+	ret := c.alias(obj).Type()
+	if pos == optionalPos && types.IsOptionable(ret) {
+		return types.NewOptional(ret), true
+	}
+	return ret, true
+}
+
+// named returns the converted named type for v, looking it up in its package
+// if it's from another one. The type arguments of instantiated types are
+// converted at the position targPos, like element types.
+func (c *compiledConverter) named(v *gotypes.Named, targPos typePos) types.Type {
+	if v, ok := c.converted[v]; ok {
+		return v.(types.Type)
+	}
+	if orig := v.Origin(); orig != v {
+		targs := make([]types.Type, v.TypeArgs().Len())
+		for i := range targs {
+			targs[i] = c.typ(v.TypeArgs().At(i), targPos, nil)
+		}
+		ret, err := types.Instantiate(c.named(orig, targPos), targs, false)
+		if err != nil {
+			c.fail(err)
+			return types.Typ[types.Invalid]
+		}
+		return ret
+	}
+	obj := v.Obj()
+	if obj.Pkg() == nil {
+		return types.Universe.Lookup(obj.Name()).Type()
+	}
+	if obj.Pkg() == c.gopkg {
+		// A type not in the package scope, so it hasn't been declared yet.
+		named := c.typeName(obj).Type().(*types.Named)
+		named.SetUnderlying(c.typ(v.Underlying(), innerPos, nil))
+		for i := 0; i < v.NumMethods(); i++ {
+			named.AddMethod(c.method(obj.Name(), v.Method(i)))
+		}
+		return named
+	}
+	pkg, err := c.importPkg(obj.Pkg().Path())
+	if err != nil {
+		c.fail(err)
+		return types.Typ[types.Invalid]
+	}
+	if tn, ok := pkg.Scope().Lookup(obj.Name()).(*types.TypeName); ok {
+		c.converted[v] = tn.Type()
+		return tn.Type()
+	}
+	ret := (&converter{gopkg: obj.Pkg(), converted: map[interface{}]interface{}{}}).convertType(v)
+	c.converted[v] = ret
+	return ret
+}
+
+func (c *compiledConverter) importPkg(path string) (*types.Package, error) {
+	return c.imp.Import(path)
+}
+
+// method converts the method m of the named type typeName, as declared in its
+// package.
+func (c *compiledConverter) method(typeName string, m *gotypes.Func) *types.Func {
+	sig := m.Type().(*gotypes.Signature)
+	recv := sig.Recv()
+
+	var path, legacyPath string
+	ptr := false
+	if _, ok := recv.Type().(*gotypes.Pointer); ok {
+		ptr = true
+		path = "(*" + typeName + ")." + m.Name()
+	} else {
+		path = "(" + typeName + ")." + m.Name()
+		// Value receivers used to be annotated like fields, as T.Method.
+		legacyPath = typeName + "." + m.Name()
+	}
+	typ, ok := c.ann.Lookup(path).Type()
+	if !ok && legacyPath != "" {
+		path = legacyPath
+		typ, ok = c.ann.Lookup(path).Type()
+	}
+
+	var recvType types.Type
+	if c.ann.Policy(annotations.ReceiversNonNil) {
+		recvType = c.typ(recv.Type(), plainPos, nil)
+	} else if ptr {
+		// ConvertAST wraps *T, leaving T as is.
+		recvType = types.NewOptional(types.NewPointer(c.typ(recv.Type().(*gotypes.Pointer).Elem(), plainPos, nil)))
+	} else {
+		recvType = c.typ(recv.Type(), optionalPos, nil)
+	}
+	recvVar := types.NewParam(token.Pos(recv.Pos()), c.ret, recv.Name(), recvType)
+
+	if ok {
+		if ret, ok := c.annotatedMethod(m, typ, recvVar); ok {
+			return ret
+		}
+	}
+	return types.NewFunc(token.Pos(m.Pos()), c.ret, m.Name(), c.funcSignature(recvVar, sig))
+}
+
+// annotatedMethod converts the method m with the type from its annotation,
+// which is a function type, optionally preceded by a receiver type in
+// parentheses. Without it, recv is kept as the receiver.
+func (c *compiledConverter) annotatedMethod(m *gotypes.Func, typ string, recv *types.Var) (*types.Func, bool) {
+	funTyp := typ
+	if strings.HasPrefix(strings.TrimSpace(typ), "(") {
+		fset := token.NewFileSet()
+		fun, recvExpr, err := parser.ParseMethodExprsFrom(fset, "", []byte(typ), 0)
+		if err != nil {
+			return nil, false
+		}
+		file := fset.File(fun.Pos())
+		exprString := func(e ast.Node) string {
+			return typ[file.Offset(e.Pos()):file.Offset(e.End())]
+		}
+
+		recvType := c.eval(exprString(recvExpr))
+		if recvType == nil {
+			return nil, false
+		}
+		recv = types.NewParam(recv.Pos(), c.ret, recv.Name(), recvType)
+		funTyp = exprString(fun)
+	}
+
+	sig, _ := c.eval(funTyp).(*types.Signature)
+	if sig == nil {
+		return nil, false
+	}
+	sig = types.NewSignature(recv, sig.Params(), sig.Results(), sig.Variadic())
+	return types.NewFunc(token.Pos(m.Pos()), c.ret, m.Name(), sig), true
+}
+
+// signature converts v like ConvertAST converts a function type at the given
+// position, without wrapping it.
+func (c *compiledConverter) signature(recv *types.Var, v *gotypes.Signature, pos typePos) *types.Signature {
+	if pos != plainPos {
+		pos = optionalPos
+	}
+	params := c.tuple(v.Params(), v.Variadic(), pos)
+	results := c.tuple(v.Results(), false, pos)
+	return c.generic(types.NewSignature(recv, params, results, v.Variadic()), v)
+}
+
+// funcSignature converts the type of a declared function or method like
+// signature, following the package's policies.
+func (c *compiledConverter) funcSignature(recv *types.Var, v *gotypes.Signature) *types.Signature {
+	paramsPos := optionalPos
+	if c.ann.Policy(annotations.ParamsNonNil) {
+		paramsPos = innerPos
+	}
+	params := c.tuple(v.Params(), v.Variadic(), paramsPos)
+
+	results := v.Results()
+	n := results.Len()
+	if !c.ann.Policy(annotations.ErrorsEntangled) || n < 2 || results.At(n-1).Type() != gotypes.Universe.Lookup("error").Type() {
+		return c.generic(types.NewSignature(recv, params, c.tuple(results, false, optionalPos), v.Variadic()), v)
+	}
+	vars := make([]*types.Var, 0, n)
+	for i := 0; i < n; i++ {
+		r := results.At(i)
+		pos := innerPos
+		if i == n-1 {
+			pos = plainPos
+		}
+		vars = append(vars, types.NewParam(token.Pos(r.Pos()), c.ret, r.Name(), c.typ(r.Type(), pos, nil)))
+	}
+	return c.generic(types.NewSignature(recv, params, types.NewTupleEntangled(vars...), v.Variadic()), v)
+}
+
+func (c *compiledConverter) tuple(v *gotypes.Tuple, variadic bool, pos typePos) *types.Tuple {
+	if v == nil {
+		return nil
+	}
+	vars := make([]*types.Var, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		pPos := pos
+		if variadic && i == v.Len()-1 {
+			// ConvertAST leaves ...T as is.
+			pPos = plainPos
+		}
+		p := v.At(i)
+		vars = append(vars, types.NewParam(token.Pos(p.Pos()), c.ret, p.Name(), c.typ(p.Type(), pPos, nil)))
+	}
+	return types.NewTuple(vars...)
+}
+
+func (c *compiledConverter) strct(v *gotypes.Struct, pos typePos, ann *annotations.Annotation) *types.Struct {
+	fields := make([]*types.Var, 0, v.NumFields())
+	tags := make([]string, 0, v.NumFields())
+	for i := 0; i < v.NumFields(); i++ {
+		f := v.Field(i)
+		var typ types.Type
+		if pos == plainPos {
+			typ = c.typ(f.Type(), plainPos, nil)
+		} else {
+			// Embedded fields are annotated by their type's name, which is
+			// also their field name.
+			typ = c.annotated(f.Type(), optionalPos, ann.Lookup(f.Name()))
+		}
+		fields = append(fields, types.NewField(token.Pos(f.Pos()), c.ret, f.Name(), typ, f.Anonymous()))
+		tags = append(tags, v.Tag(i))
+	}
+	return types.NewStruct(fields, tags)
+}
+
+func (c *compiledConverter) iface(v *gotypes.Interface, pos typePos, ann *annotations.Annotation) *types.Interface {
+	ret := types.NewInterface(nil, nil)
+	for i := 0; i < v.NumExplicitMethods(); i++ {
+		ret.AddMethod(c.ifaceMethod(v.ExplicitMethod(i), pos, ann.Lookup(v.ExplicitMethod(i).Name())))
+	}
+	for i := 0; i < v.NumEmbeddeds(); i++ {
+		embedded := v.Embedded(i)
+		if embedded == nil {
+			continue
+		}
+		eAnn := ann.Lookup(embedded.Obj().Name())
+		if pos != plainPos && eAnn.HasChildren() {
+			// Annotations for the methods of an embedded interface. We
+			// replace the embedding with those methods, so that we can
+			// change their types.
+			if embeddedIface, ok := embedded.Underlying().(*gotypes.Interface); ok {
+				for j := 0; j < embeddedIface.NumMethods(); j++ {
+					m := embeddedIface.Method(j)
+					ret.AddMethod(c.ifaceMethod(m, pos, eAnn.Lookup(m.Name())))
+				}
+				continue
+			}
+		}
+		if named, ok := c.named(embedded, plainPos).(*types.Named); ok {
+			ret.AddEmbedded(named)
+		}
+	}
+	for i := 0; i < v.NumEmbeddeds(); i++ {
+		// Unions and other non-interface types of constraints.
+		if e := v.EmbeddedType(i); v.Embedded(i) == nil {
+			ret.AddEmbeddedType(c.typ(e, plainPos, nil))
+		}
+	}
+	c.ifaces = append(c.ifaces, ret)
+	return ret
+}
+
+func (c *compiledConverter) ifaceMethod(m *gotypes.Func, pos typePos, ann *annotations.Annotation) *types.Func {
+	var sig *types.Signature
+	if typ, ok := ann.Type(); ok && pos != plainPos {
+		sig, _ = c.eval(typ).(*types.Signature)
+	}
+	if sig == nil {
+		if pos == plainPos {
+			sig = c.signature(nil, m.Type().(*gotypes.Signature), pos)
+		} else {
+			sig = c.funcSignature(nil, m.Type().(*gotypes.Signature))
+		}
+	}
+	return types.NewFunc(token.Pos(m.Pos()), c.ret, m.Name(), sig)
+}
+
+// eval evaluates the annotated type expression typ in the scope of the
+// converted package, with its imports visible by name.
+func (c *compiledConverter) eval(typ string) types.Type {
+	if c.evalPkg == nil {
+		// A throwaway package with the converted package's declarations so
+		// far and the imports, to evaluate in instead of the package itself,
+		// whose scope shouldn't have the imports.
+		c.evalPkg = types.NewPackage(c.ret.Path(), c.ret.Name())
+		scope := c.ret.Scope()
+		for _, name := range scope.Names() {
+			c.evalPkg.Scope().Insert(scope.Lookup(name))
+		}
+		for _, imported := range c.ret.Imports() {
+			if c.evalPkg.Scope().Lookup(imported.Name()) == nil {
+				c.evalPkg.Scope().Insert(types.NewPkgName(token.NoPos, c.evalPkg, imported.Name(), imported))
+			}
+		}
+	}
+
+	tv, err := types.Eval(token.NewFileSet(), c.evalPkg, token.NoPos, typ)
+	if err != nil {
+		c.fail(fmt.Errorf("annotation %q: %v", typ, err))
+		return nil
+	}
+	if !tv.IsType() {
+		c.fail(fmt.Errorf("annotation %q: not a type", typ))
+		return nil
+	}
+	return tv.Type
+}
+
+func (c *compiledConverter) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// isOptionable is like types.IsOptionable for go/types types.
+func isOptionable(typ gotypes.Type) bool {
+	switch typ.Underlying().(type) {
+	case *gotypes.Interface, *gotypes.Map, *gotypes.Pointer, *gotypes.Signature, *gotypes.Chan:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+package importer
+
+import (
+	"fmt"
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	gotypes "go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tcard/sgo/sgo/types"
+)
+
+const compiledTestQSrc = `package q
+
+type Reader interface {
+	Read(p []byte) (int, error)
+}
+
+type Node struct {
+	Next *Node
+}
+`
+
+const compiledTestPSrc = `package p
+
+import "example.com/q"
+
+type T struct {
+	A *int
+	B []*int
+	C map[string]*T
+	*q.Node
+}
+
+func (t *T) M(f func(*int) error) (*int, error) { return nil, nil }
+func (t T) N() *T                                 { return nil }
+func (t *T) O(p *int)                             {}
+
+type I interface {
+	M() *int
+	J
+}
+
+type J interface {
+	L(*int) *int
+	K(*int) *int
+}
+
+type F func(*int) *int
+
+func NewT(x *int, xs ...*int) (*T, error) { return nil, nil }
+
+func Read(r q.Reader) q.Reader { return r }
+
+var V *T
+
+const C = 1
+
+type List[E any] struct {
+	Head *E
+	Next *List[E]
+}
+
+func (l *List[E]) Push(e *E) {}
+
+func Map[T, U any](xs []*T, f func(T) U) []U { return nil }
+
+type Number interface {
+	~int | ~float64
+}
+
+var Ints List[int]
+
+type (
+	PT     = *T
+	Ts     = []*T
+	Strict = []*T
+	Node   = q.Node
+)
+
+func Get(ts Ts) PT { return nil }
+
+func All() Strict { return nil }
+
+var Nodes []*Node
+`
+
+const compiledTestAnn = `
+NewT func(x *int, xs ...*int) (*T \ error)
+Read func(r ?q.Reader) q.Reader
+T {
+	A *int
+	Node *q.Node
+}
+(*T) {
+	M (*T) func(f func(*int) error) (*int \ error)
+}
+(T) {
+	N func() *T
+}
+I {
+	J {
+		L func(*int) *int
+	}
+}
+Strict []*T
+`
+
+func TestImportCompiled(t *testing.T) {
+	pkg, got := importCompiledTest(t, compiledTestAnn)
+
+	from, ok := pkg.FromGo()
+	if annDir := filepath.Join("sgovendor", "example.com", "p"); !ok || !strings.HasPrefix(from, "annotations in ") || !strings.HasSuffix(from, annDir) {
+		t.Errorf("expected package from Go with annotations in %s, got %q, %v", annDir, from, ok)
+	}
+
+	// Types from other packages are the ones from the imported packages.
+	q := pkg.Imports()[0]
+	field := pkg.Scope().Lookup("T").Type().Underlying().(*types.Struct).Field(3)
+	if field.Type().(*types.Pointer).Elem() != q.Scope().Lookup("Node").Type() {
+		t.Errorf("expected embedded *q.Node to refer to q's Node")
+	}
+
+	expected := map[string]string{
+		"C":         `const C untyped int`,
+		"F":         `type F func(?*int) ?*int`,
+		"I":         `type I interface{K(?*int) ?*int; L(*int) *int; M() ?*int}`,
+		"J":         `type J interface{K(?*int) ?*int; L(?*int) ?*int}`,
+		"NewT":      `func NewT(x *int, xs ...*int) (*T \ ?error)`,
+		"Read":      `func Read(r ?example.com/q.Reader) example.com/q.Reader`,
+		"T":         `type T struct{A *int; B []?*int; C ?map[string]?*T; *example.com/q.Node}`,
+		"T.M":       `func (*T).M(f func(*int) error) (*int \ ?error)`,
+		"T.N":       `func (T).N() *T`,
+		"T.O":       `func (?*T).O(p ?*int)`,
+		"V":         `var V ?*T`,
+		"List":      `type List[E any] struct{Head ?*E; Next ?*List[E]}`,
+		"List.Push": `func (?*List[E]).Push(e ?*E)`,
+		"Map":       `func Map[T, U any](xs []?*T, f ?func(T) U) []U`,
+		"Number":    `type Number interface{~int | ~float64}`,
+		"Ints":      `var Ints List[int]`,
+		"PT":        `type PT = *T`,
+		"Ts":        `type Ts = []?*T`,
+		"Strict":    `type Strict = []*T`,
+		"Node":      `type Node = example.com/q.Node`,
+		"Get":       `func Get(ts []?*T) ?*T`,
+		"All":       `func All() []*T`,
+		"Nodes":     `var Nodes []?*example.com/q.Node`,
+	}
+	for k, v := range expected {
+		if got[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got[k])
+		}
+	}
+}
+
+func TestImportCompiledPolicies(t *testing.T) {
+	_, got := importCompiledTest(t, `
+		@policy errors-entangled
+		@policy receivers-nonnil
+		@policy params-nonnil
+		Read func(r ?q.Reader) q.Reader
+	`)
+
+	expected := map[string]string{
+		"J":    `type J interface{K(*int) ?*int; L(*int) ?*int}`,
+		"NewT": `func NewT(x *int, xs ...*int) (*T \ error)`,
+		"Read": `func Read(r ?example.com/q.Reader) example.com/q.Reader`,
+		"T.M":  `func (*T).M(f func(?*int) ?error) (*int \ error)`,
+		"T.N":  `func (T).N() ?*T`,
+		"T.O":  `func (*T).O(p *int)`,
+	}
+	for k, v := range expected {
+		if got[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got[k])
+		}
+	}
+}
+
+// importCompiledTest imports the test package p, compiled, with the given
+// annotations. It returns it, and the strings for its objects and methods.
+func importCompiledTest(t *testing.T, ann string) (*types.Package, map[string]string) {
+	whence, err := ioutil.TempDir("", "sgocompiled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(whence)
+
+	annDir := filepath.Join(whence, "sgovendor", "example.com", "p")
+	if err := os.MkdirAll(annDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(annDir, "p.sgoann"), []byte(ann), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	imp, err := newImporter(map[string]struct{}{"example.com/p": {}}, whence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp.compiled = &srcGoImporter{
+		fset: gotoken.NewFileSet(),
+		srcs: map[string]string{
+			"example.com/p": compiledTestPSrc,
+			"example.com/q": compiledTestQSrc,
+		},
+		pkgs: map[string]*gotypes.Package{},
+	}
+
+	pkg, err := imp.Import("example.com/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	qual := types.RelativeTo(pkg)
+	for _, name := range pkg.Scope().Names() {
+		obj := pkg.Scope().Lookup(name)
+		got[name] = types.ObjectString(obj, qual)
+		if tn, ok := obj.(*types.TypeName); ok && !tn.IsAlias() {
+			named := tn.Type().(*types.Named)
+			for i := 0; i < named.NumMethods(); i++ {
+				m := named.Method(i)
+				got[name+"."+m.Name()] = types.ObjectString(m, qual)
+			}
+		}
+	}
+	return pkg, got
+}
+
+// srcGoImporter is a go/types importer for packages from source, so that tests
+// don't depend on compiled packages being available.
+type srcGoImporter struct {
+	fset *gotoken.FileSet
+	srcs map[string]string
+	pkgs map[string]*gotypes.Package
+}
+
+func (imp *srcGoImporter) Import(path string) (*gotypes.Package, error) {
+	if pkg, ok := imp.pkgs[path]; ok {
+		return pkg, nil
+	}
+	src, ok := imp.srcs[path]
+	if !ok {
+		return nil, fmt.Errorf("can't find package %s", path)
+	}
+	f, err := goparser.ParseFile(imp.fset, path+".go", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &gotypes.Config{Importer: imp}
+	pkg, err := cfg.Check(path, imp.fset, []*goast.File{f}, nil)
+	if err != nil {
+		return nil, err
+	}
+	imp.pkgs[path] = pkg
+	return pkg, nil
+}
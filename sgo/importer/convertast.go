@@ -2,11 +2,13 @@ package importer
 
 import (
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/tcard/sgo/sgo/annotations"
 	"github.com/tcard/sgo/sgo/ast"
 	"github.com/tcard/sgo/sgo/parser"
+	"github.com/tcard/sgo/sgo/token"
 	"github.com/tcard/sgo/sgo/types"
 )
 
@@ -17,13 +19,28 @@ import (
 // where <annotation> is a type expression. If found, the annotation replaces
 // the type of the documented declaration or field.
 func ConvertAST(a *ast.File, info *types.Info, ann *annotations.Annotation) {
-	c := astConverter{info: info}
+	convertAST(a, info, ann, nil)
+}
+
+// convertAST is like ConvertAST, and also records in sources, if not nil, the
+// type expressions that annotations or doc comments replaced.
+func convertAST(a *ast.File, info *types.Info, ann *annotations.Annotation, sources map[ast.Expr]TypeSource) {
+	c := astConverter{info: info, file: a, ann: ann, aliases: map[types.Object]ast.Expr{}, sources: sources}
 	c.convertAST(a, ann, nil)
 }
 
 type astConverter struct {
 	info      *types.Info
+	file      *ast.File
+	ann       *annotations.Annotation
 	converted map[interface{}]struct{}
+
+	// aliases holds the converted types of the file's type aliases.
+	aliases map[types.Object]ast.Expr
+
+	// sources, if not nil, records the type expressions that replaced the
+	// converted ones, by where they come from.
+	sources map[ast.Expr]TypeSource
 }
 
 func (c *astConverter) convertAST(node ast.Node, ann *annotations.Annotation, replace func(e ast.Expr)) {
@@ -63,7 +80,7 @@ func (c *astConverter) convertAST(node ast.Node, ann *annotations.Annotation, re
 		if !ok {
 			break
 		}
-		if replace != nil && types.IsOptionable(tn.Type()) {
+		if replace != nil && types.IsOptionable(tn.Type()) && !c.optionalAlias(tn) {
 			replace(&ast.OptionalType{Elt: n})
 		}
 
@@ -72,7 +89,19 @@ func (c *astConverter) convertAST(node ast.Node, ann *annotations.Annotation, re
 		c.convertAST(n.Elt, ann, func(e ast.Expr) { n.Elt = e })
 
 	case *ast.StructType:
-		c.convertAST(n.Fields, ann, nil)
+		for _, f := range n.Fields.List {
+			if len(f.Names) > 0 {
+				c.convertAST(f, ann, nil)
+				continue
+			}
+			// Embedded fields are annotated by their type's name.
+			f := f
+			fAnn := ann.Lookup(embeddedName(f.Type))
+			if replaced := c.maybeReplace(f, fAnn, func(e ast.Expr) { f.Type = e }); replaced {
+				continue
+			}
+			c.convertAST(f.Type, fAnn, func(e ast.Expr) { f.Type = e })
+		}
 
 	case *ast.FuncType:
 		if replace != nil {
@@ -89,34 +118,40 @@ func (c *astConverter) convertAST(node ast.Node, ann *annotations.Annotation, re
 		if replace != nil {
 			replace(&ast.OptionalType{Elt: n})
 		}
+		var methods []*ast.Field
 		for _, f := range n.Methods.List {
-			name := ""
+			f := f
+			var name string
 			if len(f.Names) == 0 {
-				var id *ast.Ident
-
-				switch t := f.Type.(type) {
-				case *ast.Ident:
-					id = t
-				case *ast.StarExpr:
-					if t, ok := t.X.(*ast.Ident); ok {
-						id = t
-					}
-				}
-
-				if id != nil {
-					name = id.Name
-				}
+				name = embeddedName(f.Type)
 			} else {
 				name = f.Names[0].Name
 			}
+			fAnn := ann.Lookup(name)
 			// Call maybeReplace here because, if it won't replace anything, we don't
 			// want to pass a replace function to convertAST as it would think that
 			// we're converting a function literal and make it optional by default.
-			if replaced := c.maybeReplace(f.Type, ann.Lookup(name), func(e ast.Expr) { f.Type = e }); replaced {
-				return
+			if replaced := c.maybeReplace(f.Type, fAnn, func(e ast.Expr) { f.Type = e }); replaced {
+				methods = append(methods, f)
+				continue
+			}
+			if len(f.Names) == 0 && fAnn.HasChildren() {
+				// Annotations for the methods of an embedded interface. We
+				// replace the embedding with those methods, so that we can
+				// change their types.
+				if embedded, ok := c.embeddedMethods(f.Type, fAnn); ok {
+					methods = append(methods, embedded...)
+					continue
+				}
+			}
+			if fun, ok := f.Type.(*ast.FuncType); ok {
+				c.convertFuncType(fun, fAnn)
+			} else {
+				c.convertAST(f.Type, fAnn, nil)
 			}
-			c.convertAST(f.Type, ann.Lookup(name), nil)
+			methods = append(methods, f)
 		}
+		n.Methods.List = methods
 
 	case *ast.MapType:
 		if replace != nil {
@@ -131,10 +166,22 @@ func (c *astConverter) convertAST(node ast.Node, ann *annotations.Annotation, re
 		}
 		c.convertAST(n.Value, ann, func(e ast.Expr) { n.Value = e })
 
+	case *ast.IndexExpr:
+		// Type arguments are converted like element types.
+		c.convertAST(n.Index, ann, func(e ast.Expr) { n.Index = e })
+		c.convertInstance(n, n.X, replace)
+
+	case *ast.IndexListExpr:
+		for i := range n.Indices {
+			i := i
+			c.convertAST(n.Indices[i], ann, func(e ast.Expr) { n.Indices[i] = e })
+		}
+		c.convertInstance(n, n.X, replace)
+
 	// Declarations
 	case *ast.ValueSpec:
 		if n.Type != nil {
-			c.convertAST(n.Type, nil, func(e ast.Expr) { n.Type = e })
+			c.convertAST(n.Type, ann, func(e ast.Expr) { n.Type = e })
 		}
 
 	case *ast.TypeSpec:
@@ -172,9 +219,13 @@ func (c *astConverter) convertAST(node ast.Node, ann *annotations.Annotation, re
 		}
 
 	case *ast.FuncDecl:
+		// Annotations don't declare type parameters; keep the declared
+		// ones, which the annotated type may refer to.
+		tparams := n.Type.TypeParams
 		if n.Recv != nil {
 			if replaced := c.maybeReplaceFuncDecl(n, ann, func(fun *ast.FuncType, recv ast.Expr) {
 				n.Type = fun
+				n.Type.TypeParams = tparams
 				n.Recv.List[0].Type = recv
 			}); replaced {
 				return
@@ -183,39 +234,68 @@ func (c *astConverter) convertAST(node ast.Node, ann *annotations.Annotation, re
 			recv := n.Recv.List[0]
 			switch typ := recv.Type.(type) {
 			case *ast.StarExpr:
-				recv.Type = &ast.OptionalType{Elt: typ}
-			case *ast.Ident:
-				c.convertAST(recv.Type, nil, func(e ast.Expr) { recv.Type = e })
+				if !ann.Policy(annotations.ReceiversNonNil) {
+					recv.Type = &ast.OptionalType{Elt: typ}
+				}
+			case *ast.Ident, *ast.IndexExpr, *ast.IndexListExpr:
+				if !ann.Policy(annotations.ReceiversNonNil) {
+					c.convertAST(recv.Type, nil, func(e ast.Expr) { recv.Type = e })
+				}
 			}
 		}
 
 		// Call maybeReplace here because, if it won't replace anything, we don't
 		// want to pass a replace function to convertAST as it would think that
 		// we're converting a function literal and make it optional by default.
-		if replaced := c.maybeReplace(n.Type, ann, func(e ast.Expr) { n.Type = e.(*ast.FuncType) }); replaced {
+		if replaced := c.maybeReplace(n.Type, ann, func(e ast.Expr) {
+			n.Type = e.(*ast.FuncType)
+			n.Type.TypeParams = tparams
+		}); replaced {
 			return
 		}
-		c.convertAST(n.Type, nil, nil)
+		c.convertFuncType(n.Type, ann)
 
 	case *ast.File:
+		// Type declarations go first, so that the converted types of
+		// aliases are known where they are used.
+		for _, d := range n.Decls {
+			if d, ok := d.(*ast.GenDecl); ok && d.Tok == token.TYPE {
+				c.convertAST(d, ann, nil)
+				for _, s := range d.Specs {
+					if s := s.(*ast.TypeSpec); s.Assign.IsValid() {
+						c.aliases[c.info.Defs[s.Name]] = s.Type
+					}
+				}
+			}
+		}
 		for _, d := range n.Decls {
 			switch d := d.(type) {
 			case *ast.GenDecl:
-				c.convertAST(d, ann, nil)
+				if d.Tok != token.TYPE {
+					c.convertAST(d, ann, nil)
+				}
 			case *ast.FuncDecl:
-				name := d.Name.Name
+				dAnn := ann.Lookup(d.Name.Name)
 				if d.Recv != nil && len(d.Recv.List) > 0 {
 					switch t := d.Recv.List[0].Type.(type) {
 					case *ast.StarExpr:
-						if id, ok := t.X.(*ast.Ident); ok {
-							name = "(*" + id.Name + ")." + name
+						if id := recvBaseName(t.X); id != nil {
+							dAnn = ann.Lookup("(*" + id.Name + ")." + d.Name.Name)
+						}
+					default:
+						if id := recvBaseName(t); id != nil {
+							dAnn = ann.Lookup("(" + id.Name + ")." + d.Name.Name)
+							if _, ok := dAnn.Type(); !ok {
+								// Value receivers used to be annotated like
+								// fields, as T.Method.
+								dAnn = ann.Lookup(id.Name + "." + d.Name.Name)
+							}
 						}
-					case *ast.Ident:
-						name = t.Name + "." + name
 					}
 				}
-				c.convertAST(d, ann.Lookup(name), func(e ast.Expr) {
+				c.convertAST(d, dAnn, func(e ast.Expr) {
 					if e, ok := e.(*ast.FuncType); ok {
+						e.TypeParams = d.Type.TypeParams
 						d.Type = e
 					}
 				})
@@ -224,6 +304,351 @@ func (c *astConverter) convertAST(node ast.Node, ann *annotations.Annotation, re
 	}
 }
 
+// convertInstance wraps the instantiated generic type n, of the generic
+// type x, in an optional if the generic type is optionable.
+func (c *astConverter) convertInstance(n ast.Expr, x ast.Expr, replace func(e ast.Expr)) {
+	var id *ast.Ident
+	switch x := x.(type) {
+	case *ast.Ident:
+		id = x
+	case *ast.SelectorExpr:
+		id = x.Sel
+	}
+	if id == nil || replace == nil {
+		return
+	}
+	if tn, ok := c.info.Uses[id].(*types.TypeName); ok && types.IsOptionable(tn.Type()) {
+		replace(&ast.OptionalType{Elt: n})
+	}
+}
+
+// recvBaseName returns the identifier of the receiver base type in the
+// receiver type expression typ, without pointer indirection, or nil.
+func recvBaseName(typ ast.Expr) *ast.Ident {
+	switch t := typ.(type) {
+	case *ast.Ident:
+		return t
+	case *ast.IndexExpr:
+		return recvBaseName(t.X)
+	case *ast.IndexListExpr:
+		return recvBaseName(t.X)
+	}
+	return nil
+}
+
+// convertFuncType converts the type of a declared function or method by
+// default, following the package's policies.
+func (c *astConverter) convertFuncType(n *ast.FuncType, ann *annotations.Annotation) {
+	if n.Params != nil {
+		if ann.Policy(annotations.ParamsNonNil) {
+			for _, f := range n.Params.List {
+				c.convertAST(f.Type, nil, nil)
+			}
+		} else {
+			c.convertAST(n.Params, nil, nil)
+		}
+	}
+	if n.Results != nil {
+		if ann.Policy(annotations.ErrorsEntangled) && c.entangleError(n.Results) {
+			for _, f := range n.Results.List {
+				c.convertAST(f.Type, nil, nil)
+			}
+		} else {
+			c.convertAST(n.Results, nil, nil)
+		}
+	}
+}
+
+// entangleError makes the last result in results, if it's an error and there
+// are other results, entangled with them. It reports whether it did.
+func (c *astConverter) entangleError(results *ast.FieldList) bool {
+	if results.Entangled != nil || results.NumFields() < 2 {
+		return false
+	}
+	last := results.List[len(results.List)-1]
+	id, ok := last.Type.(*ast.Ident)
+	if !ok || c.info.Uses[id] != types.Universe.Lookup("error") {
+		return false
+	}
+
+	results.List = results.List[:len(results.List)-1]
+	if len(last.Names) > 1 {
+		// (a, err error) becomes (a error \ err error).
+		results.List = append(results.List, &ast.Field{
+			Names: last.Names[:len(last.Names)-1],
+			Type:  last.Type,
+		})
+		last = &ast.Field{
+			Names: last.Names[len(last.Names)-1:],
+			Type:  last.Type,
+		}
+	}
+	results.Entangled = last
+	return true
+}
+
+// embeddedMethods returns the methods of the interface embedded by typ, with
+// their types converted with the given annotations.
+func (c *astConverter) embeddedMethods(typ ast.Expr, ann *annotations.Annotation) ([]*ast.Field, bool) {
+	var id *ast.Ident
+	switch t := typ.(type) {
+	case *ast.Ident:
+		id = t
+	case *ast.SelectorExpr:
+		id = t.Sel
+	}
+	if id == nil {
+		return nil, false
+	}
+	tn, ok := c.info.Uses[id].(*types.TypeName)
+	if !ok {
+		return nil, false
+	}
+	iface, ok := tn.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, false
+	}
+
+	var methods []*ast.Field
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		mAnn := ann.Lookup(m.Name())
+		fun, ok := c.typeExpr(m.Type())
+		if !ok {
+			return nil, false
+		}
+		f := &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(m.Name())},
+			Type:  fun,
+		}
+		if replaced := c.maybeReplace(f.Type, mAnn, func(e ast.Expr) { f.Type = e }); !replaced {
+			c.convertFuncType(fun.(*ast.FuncType), mAnn)
+		}
+		methods = append(methods, f)
+	}
+	return methods, true
+}
+
+// typeExpr returns a type expression for typ, as it would be written in the
+// converted file. Identifiers referring to named types are recorded as uses in
+// the converter's Info, so that they can be converted like the ones from
+// source.
+func (c *astConverter) typeExpr(typ types.Type) (ast.Expr, bool) {
+	switch t := typ.(type) {
+	case *types.Basic:
+		return ast.NewIdent(t.Name()), true
+
+	case *types.Named:
+		obj := t.Obj()
+		id := ast.NewIdent(obj.Name())
+		c.info.Uses[id] = obj
+		var x ast.Expr = id
+		if obj.Pkg() != nil && obj.Pkg() != c.pkg() {
+			pkgName, ok := c.importedName(obj.Pkg())
+			if !ok {
+				return nil, false
+			}
+			x = &ast.SelectorExpr{X: ast.NewIdent(pkgName), Sel: id}
+		}
+		targs := t.TypeArgs()
+		if targs.Len() == 0 {
+			return x, true
+		}
+		indices := make([]ast.Expr, targs.Len())
+		for i := range indices {
+			var ok bool
+			if indices[i], ok = c.typeExpr(targs.At(i)); !ok {
+				return nil, false
+			}
+		}
+		if len(indices) == 1 {
+			return &ast.IndexExpr{X: x, Index: indices[0]}, true
+		}
+		return &ast.IndexListExpr{X: x, Indices: indices}, true
+
+	case *types.TypeParam:
+		id := ast.NewIdent(t.Obj().Name())
+		c.info.Uses[id] = t.Obj()
+		return id, true
+
+	case *types.Pointer:
+		elem, ok := c.typeExpr(t.Elem())
+		return &ast.StarExpr{X: elem}, ok
+
+	case *types.Slice:
+		elem, ok := c.typeExpr(t.Elem())
+		return &ast.ArrayType{Elt: elem}, ok
+
+	case *types.Array:
+		elem, ok := c.typeExpr(t.Elem())
+		return &ast.ArrayType{
+			Len: &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(t.Len(), 10)},
+			Elt: elem,
+		}, ok
+
+	case *types.Map:
+		key, ok := c.typeExpr(t.Key())
+		if !ok {
+			return nil, false
+		}
+		value, ok := c.typeExpr(t.Elem())
+		return &ast.MapType{Key: key, Value: value}, ok
+
+	case *types.Chan:
+		value, ok := c.typeExpr(t.Elem())
+		var dir ast.ChanDir
+		switch t.Dir() {
+		case types.SendRecv:
+			dir = ast.SEND | ast.RECV
+		case types.SendOnly:
+			dir = ast.SEND
+		case types.RecvOnly:
+			dir = ast.RECV
+		}
+		return &ast.ChanType{Dir: dir, Value: value}, ok
+
+	case *types.Optional:
+		elem, ok := c.typeExpr(t.Elem())
+		return &ast.OptionalType{Elt: elem}, ok
+
+	case *types.Signature:
+		params, ok := c.tupleFields(t.Params(), t.Variadic())
+		if !ok {
+			return nil, false
+		}
+		results, ok := c.tupleFields(t.Results(), false)
+		return &ast.FuncType{Params: params, Results: results}, ok
+
+	case *types.Struct:
+		fields := &ast.FieldList{}
+		for i := 0; i < t.NumFields(); i++ {
+			v := t.Field(i)
+			ftyp, ok := c.typeExpr(v.Type())
+			if !ok {
+				return nil, false
+			}
+			f := &ast.Field{Type: ftyp}
+			if !v.Anonymous() {
+				f.Names = []*ast.Ident{ast.NewIdent(v.Name())}
+			}
+			if tag := t.Tag(i); tag != "" {
+				f.Tag = &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(tag)}
+			}
+			fields.List = append(fields.List, f)
+		}
+		return &ast.StructType{Fields: fields}, true
+
+	case *types.Interface:
+		methods := &ast.FieldList{}
+		for i := 0; i < t.NumMethods(); i++ {
+			m := t.Method(i)
+			fun, ok := c.typeExpr(m.Type())
+			if !ok {
+				return nil, false
+			}
+			methods.List = append(methods.List, &ast.Field{
+				Names: []*ast.Ident{ast.NewIdent(m.Name())},
+				Type:  fun,
+			})
+		}
+		return &ast.InterfaceType{Methods: methods}, true
+	}
+	return nil, false
+}
+
+func (c *astConverter) tupleFields(t *types.Tuple, variadic bool) (*ast.FieldList, bool) {
+	fields := &ast.FieldList{}
+	for i := 0; i < t.Len(); i++ {
+		v := t.At(i)
+		var typ ast.Expr
+		if variadic && i == t.Len()-1 {
+			elem, ok := c.typeExpr(v.Type().(*types.Slice).Elem())
+			if !ok {
+				return nil, false
+			}
+			typ = &ast.Ellipsis{Elt: elem}
+		} else {
+			var ok bool
+			typ, ok = c.typeExpr(v.Type())
+			if !ok {
+				return nil, false
+			}
+		}
+		f := &ast.Field{Type: typ}
+		if v.Name() != "" {
+			f.Names = []*ast.Ident{ast.NewIdent(v.Name())}
+		}
+		fields.List = append(fields.List, f)
+	}
+	return fields, true
+}
+
+// optionalAlias reports whether tn is a type alias from the converted package
+// for an optional type, which then can't be wrapped again.
+func (c *astConverter) optionalAlias(tn *types.TypeName) bool {
+	if !tn.IsAlias() || tn.Pkg() != c.pkg() {
+		return false
+	}
+	typ, ok := c.aliases[tn]
+	if !ok {
+		// Declared in another file of the package; only its annotation, if
+		// any, is known.
+		s, ok := c.ann.Lookup(tn.Name()).Type()
+		if !ok {
+			return false
+		}
+		var err error
+		typ, err = parser.ParseExpr(s)
+		if err != nil {
+			return false
+		}
+	}
+	_, ok = typ.(*ast.OptionalType)
+	return ok
+}
+
+// pkg returns the package the converted file belongs to.
+func (c *astConverter) pkg() *types.Package {
+	for _, obj := range c.info.Defs {
+		if obj != nil && obj.Pkg() != nil {
+			return obj.Pkg()
+		}
+	}
+	return nil
+}
+
+// importedName returns the name by which the converted file refers to pkg.
+func (c *astConverter) importedName(pkg *types.Package) (string, bool) {
+	for _, spec := range c.file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || path != pkg.Path() {
+			continue
+		}
+		if spec.Name == nil {
+			return pkg.Name(), true
+		}
+		if spec.Name.Name == "_" || spec.Name.Name == "." {
+			return "", false
+		}
+		return spec.Name.Name, true
+	}
+	return "", false
+}
+
+// embeddedName returns the name of the field or method set embedded by the
+// type expression typ.
+func embeddedName(typ ast.Expr) string {
+	switch t := typ.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
 func (c *astConverter) maybeReplace(node ast.Node, ann *annotations.Annotation, replace func(e ast.Expr)) bool {
 	if replace == nil {
 		return false
@@ -232,6 +657,7 @@ func (c *astConverter) maybeReplace(node ast.Node, ann *annotations.Annotation,
 	if typ, ok := ann.Type(); ok {
 		e, err := parser.ParseExpr(typ)
 		if err == nil {
+			c.record(e, FromAnnotations)
 			replace(e)
 			return true
 		}
@@ -247,6 +673,7 @@ func (c *astConverter) maybeReplace(node ast.Node, ann *annotations.Annotation,
 		return false
 	}
 
+	c.record(e, FromDocComment)
 	replace(e)
 	return true
 }
@@ -259,6 +686,7 @@ func (c *astConverter) maybeReplaceFuncDecl(node *ast.FuncDecl, ann *annotations
 	if typ, ok := ann.Type(); ok {
 		fun, recv, err := parser.ParseMethodExprs(typ)
 		if err == nil {
+			c.record(fun, FromAnnotations)
 			replace(fun, recv)
 			return true
 		}
@@ -274,10 +702,19 @@ func (c *astConverter) maybeReplaceFuncDecl(node *ast.FuncDecl, ann *annotations
 		return false
 	}
 
+	c.record(fun, FromDocComment)
 	replace(fun, recv)
 	return true
 }
 
+// record records, if the converter records sources, that the type expression
+// e comes from src.
+func (c *astConverter) record(e ast.Expr, src TypeSource) {
+	if c.sources != nil {
+		c.sources[e] = src
+	}
+}
+
 func annFromDoc(node ast.Node) (string, bool) {
 	n := reflect.ValueOf(node)
 
@@ -0,0 +1,308 @@
+package importer_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tcard/sgo/sgo/annotations"
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/importer"
+	"github.com/tcard/sgo/sgo/parser"
+	"github.com/tcard/sgo/sgo/printer"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+func TestConvertASTAnnotations(t *testing.T) {
+	cases := []struct {
+		name     string
+		src      string
+		ann      string
+		expected string
+	}{{
+		name: "pointer receiver",
+		src: `
+			type T struct{}
+			func (t *T) M(p *int) *int { return p }
+		`,
+		ann: `(*T) {
+			M (*T) func(p ?*int) *int
+		}`,
+		expected: `
+			type T struct{}
+			func (t *T) M(p ?*int) *int { return p }
+		`,
+	}, {
+		name: "value receiver",
+		src: `
+			type T struct{}
+			func (t T) M(p *int) *int { return p }
+			func (t T) N(p *int) *int { return p }
+		`,
+		ann: `(T) {
+			M (T) func(p ?*int) *int
+		}`,
+		expected: `
+			type T struct{}
+			func (t T) M(p ?*int) *int { return p }
+			func (t T) N(p ?*int) ?*int { return p }
+		`,
+	}, {
+		name: "legacy value receiver",
+		src: `
+			type T struct{}
+			func (t T) M(p *int) *int { return p }
+		`,
+		ann: `T {
+			M (T) func(p ?*int) *int
+		}`,
+		expected: `
+			type T struct{}
+			func (t T) M(p ?*int) *int { return p }
+		`,
+	}, {
+		name: "embedded fields",
+		src: `
+			type T struct{}
+			type U struct{}
+			type S struct {
+				*T
+				*U
+				A *int
+			}
+		`,
+		ann: `S {
+			T *T
+		}`,
+		expected: `
+			type T struct{}
+			type U struct{}
+			type S struct {
+				*T
+				?*U
+				A ?*int
+			}
+		`,
+	}, {
+		name: "nested anonymous structs",
+		src: `
+			type S struct {
+				A struct {
+					B *int
+					C struct {
+						D *int
+						E *int
+					}
+				}
+			}
+			var V struct {
+				P *int
+				Q *int
+			}
+		`,
+		ann: `S {
+			A {
+				C {
+					D *int
+				}
+			}
+		}
+		V {
+			P *int
+		}`,
+		expected: `
+			type S struct {
+				A struct {
+					B ?*int
+					C struct {
+						D *int
+						E ?*int
+					}
+				}
+			}
+			var V struct {
+				P *int
+				Q ?*int
+			}
+		`,
+	}, {
+		name: "interface methods",
+		src: `
+			type I interface {
+				M() *int
+				N() *int
+			}
+		`,
+		ann: `I {
+			M func() *int
+		}`,
+		expected: `
+			type I interface {
+				M() *int
+				N() ?*int
+			}
+		`,
+	}, {
+		name: "interface embeddings",
+		src: `
+			type J interface {
+				M(p *int) *int
+			}
+			type K interface {
+				L() *int
+			}
+			type I interface {
+				J
+				K
+			}
+		`,
+		ann: `I {
+			J {
+				M func(p *int) *int
+			}
+		}`,
+		expected: `
+			type J interface {
+				M(p ?*int) ?*int
+			}
+			type K interface {
+				L() ?*int
+			}
+			type I interface {
+				M(p *int) *int
+				K
+			}
+		`,
+	}, {
+		name: "aliases",
+		src: `
+			type T struct{}
+			type PT = *T
+			type Ts = []*T
+			type Strict = []*T
+			type Maybe = *T
+			func F(p PT, ts Ts) (Strict, Maybe)
+		`,
+		ann: `
+			Strict []*T
+			Maybe ?*T
+		`,
+		expected: `
+			type T struct{}
+			type PT = *T
+			type Ts = []?*T
+			type Strict = []*T
+			type Maybe = ?*T
+			func F(p ?PT, ts Ts) (Strict, Maybe)
+		`,
+	}, {
+		name: "generics",
+		src: `
+			type L[T any] struct{}
+			func New[T any]() *L[T]
+			func (l *L[T]) Get(i int) (T, bool)
+		`,
+		ann: `
+			New func() *L[T]
+			(*L) {
+				Get (*L[T]) func(i int) (T \ bool)
+			}
+		`,
+		expected: `
+			type L[T any] struct{}
+			func New[T any]() *L[T]
+			func (l *L[T]) Get(i int) (T \ bool)
+		`,
+	}, {
+		name: "policies",
+		src: `
+			type T struct{}
+			func (t *T) M(p *int) (*T, error)
+			func F(p *int, f func(*int)) (a, err error)
+			func G(p *int) (*int, error)
+			func H() error
+			type I interface {
+				N(p *int) (*int, error)
+			}
+		`,
+		ann: `
+			@policy errors-entangled
+			@policy receivers-nonnil
+			@policy params-nonnil
+			G func(p ?*int) (*int, error)
+		`,
+		expected: `
+			type T struct{}
+			func (t *T) M(p *int) (*T \ error)
+			func F(p *int, f func(?*int)) (a error \ err error)
+			func G(p ?*int) (*int, error)
+			func H() ?error
+			type I interface {
+				N(p *int) (*int \ error)
+			}
+		`,
+	}}
+
+	for _, c := range cases {
+		ann, err := annotations.Parse(c.ann)
+		if err != nil {
+			t.Errorf("%s: parsing annotations: %v", c.name, err)
+			continue
+		}
+		got, err := convertSrc(c.src, ann)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		expected, err := convertSrc(c.expected, nil)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if normalizeSpace(got) != normalizeSpace(expected) {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s", c.name, expected, got)
+		}
+	}
+}
+
+// normalizeSpace removes the formatting differences caused by replaced
+// expressions having positions from annotations instead of the file.
+func normalizeSpace(s string) string {
+	s = strings.Join(strings.Fields(s), "")
+	return strings.Replace(s, ",)", ")", -1)
+}
+
+// convertSrc converts the declarations in src with the given annotations, and
+// returns them formatted. With nil annotations, it returns them just
+// formatted.
+func convertSrc(src string, ann *annotations.Annotation) (string, error) {
+	fset := token.NewFileSet()
+	a, err := parser.ParseFile(fset, "example.go", "package example\n"+src, 0)
+	if err != nil {
+		return "", err
+	}
+
+	if ann != nil {
+		info := &types.Info{
+			Defs: map[*ast.Ident]types.Object{},
+			Uses: map[*ast.Ident]types.Object{},
+		}
+		cfg := &types.Config{}
+		_, err = cfg.Check("example", fset, []*ast.File{a}, info)
+		if err != nil {
+			return "", err
+		}
+		importer.ConvertAST(a, info, ann)
+	}
+
+	var buf bytes.Buffer
+	for _, decl := range a.Decls {
+		err := printer.Fprint(&buf, fset, decl)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString("\n")
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
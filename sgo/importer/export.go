@@ -0,0 +1,970 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tcard/sgo/sgo/constant"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+// ExportDataFile is the name of the file with the export data of a package
+// translated from SGo, next to the generated Go files.
+const ExportDataFile = "sgo.export"
+
+const exportDataVersion = 3
+
+// WriteExportData writes export data for pkg to w. Export data holds the SGo
+// types of all objects declared at package level, exported or not, so that the
+// package can be imported without parsing and type-checking its sources.
+//
+// Types from other packages are only referred to by package path and name.
+func WriteExportData(w io.Writer, pkg *types.Package) error {
+	return newExporter(pkg).write(w)
+}
+
+func newExporter(pkg *types.Package) *exporter {
+	return &exporter{
+		pkg:   pkg,
+		types: map[types.Type]int{},
+		data: exportData{
+			Version: exportDataVersion,
+			Path:    pkg.Path(),
+			Name:    pkg.Name(),
+		},
+	}
+}
+
+func (e *exporter) write(w io.Writer) error {
+	for _, imported := range e.pkg.Imports() {
+		if e.fset != nil && !e.goImports[imported.Path()] {
+			continue
+		}
+		e.data.Imports = append(e.data.Imports, imported.Path())
+	}
+	scope := e.pkg.Scope()
+	for _, name := range scope.Names() {
+		if e.skip(scope.Lookup(name)) {
+			continue
+		}
+		obj, err := e.object(scope.Lookup(name))
+		if err != nil {
+			return err
+		}
+		e.data.Objects = append(e.data.Objects, obj)
+	}
+	return json.NewEncoder(w).Encode(e.data)
+}
+
+// ReadExportData reads a package from export data written by
+// WriteExportData. Packages referred to by the export data are imported with
+// imp.
+func ReadExportData(r io.Reader, imp types.Importer) (*types.Package, error) {
+	var data exportData
+	err := json.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	if data.Version != exportDataVersion {
+		return nil, fmt.Errorf("unsupported export data version %d", data.Version)
+	}
+	return readExportData(&data, imp)
+}
+
+func readExportData(data *exportData, imp types.Importer) (*types.Package, error) {
+	d := &exportReader{
+		imp:     imp,
+		data:    data,
+		pkg:     types.NewPackage(data.Path, data.Name),
+		types:   make([]types.Type, len(data.Types)),
+		pkgs:    map[string]*types.Package{},
+		pending: map[int]bool{},
+	}
+	return d.read()
+}
+
+type exportData struct {
+	Version int
+	Path    string
+	Name    string
+	Sources string         `json:",omitempty"`
+	Imports []string       `json:",omitempty"`
+	Objects []exportObject `json:",omitempty"`
+	Types   []exportType   `json:",omitempty"`
+}
+
+type exportObject struct {
+	Kind  string
+	Name  string
+	Type  int
+	Value *exportConst `json:",omitempty"`
+}
+
+type exportConst struct {
+	Kind       constant.Kind
+	Val        string       `json:",omitempty"`
+	Num, Denom string       `json:",omitempty"`
+	Imag       *exportConst `json:",omitempty"`
+}
+
+type exportType struct {
+	Kind string
+
+	// Basic types, type parameters, and named types from other packages
+	// or from the universe.
+	Name string `json:",omitempty"`
+	Pkg  string `json:",omitempty"`
+
+	Basic          types.BasicKind `json:",omitempty"`
+	Elem           int             `json:",omitempty"`
+	Key            int             `json:",omitempty"`
+	Len            int64           `json:",omitempty"`
+	Dir            types.ChanDir   `json:",omitempty"`
+	Fields         []exportVar     `json:",omitempty"`
+	Tags           []string        `json:",omitempty"`
+	Methods        []exportMethod  `json:",omitempty"`
+	Embeddeds      []int           `json:",omitempty"`
+	Unions         [][]exportTerm  `json:",omitempty"`
+	Recv           *exportVar      `json:",omitempty"`
+	RecvTypeParams []int           `json:",omitempty"`
+	TypeParams     []int           `json:",omitempty"`
+	TypeArgs       []int           `json:",omitempty"`
+	Params         *exportTuple    `json:",omitempty"`
+	Results        *exportTuple    `json:",omitempty"`
+	Variadic       bool            `json:",omitempty"`
+}
+
+type exportVar struct {
+	Name      string `json:",omitempty"`
+	Type      int
+	Anonymous bool `json:",omitempty"`
+}
+
+type exportTuple struct {
+	Vars      []exportVar `json:",omitempty"`
+	Entangled *exportVar  `json:",omitempty"`
+}
+
+type exportMethod struct {
+	Name string
+	Type int
+}
+
+type exportTerm struct {
+	Tilde bool `json:",omitempty"`
+	Type  int
+}
+
+type exporter struct {
+	pkg   *types.Package
+	types map[types.Type]int
+	data  exportData
+
+	// If fset is not nil, objects declared in test files, as positioned in
+	// fset, are left out, and so are imports not in goImports.
+	fset      *token.FileSet
+	goImports map[string]bool
+}
+
+// skip reports whether obj is left out of the export data.
+func (e *exporter) skip(obj types.Object) bool {
+	return e.fset != nil && strings.HasSuffix(e.fset.Position(obj.Pos()).Filename, "_test.sgo")
+}
+
+func (e *exporter) object(obj types.Object) (exportObject, error) {
+	ret := exportObject{Name: obj.Name()}
+	switch obj := obj.(type) {
+	case *types.TypeName:
+		ret.Kind = "type"
+		if obj.IsAlias() {
+			ret.Kind = "alias"
+		}
+	case *types.Func:
+		ret.Kind = "func"
+	case *types.Var:
+		ret.Kind = "var"
+	case *types.Const:
+		ret.Kind = "const"
+		val, err := exportConstant(obj.Val())
+		if err != nil {
+			return ret, fmt.Errorf("exporting %s: %v", obj.Name(), err)
+		}
+		ret.Value = val
+	default:
+		return ret, fmt.Errorf("unexpected object %v at package level", obj)
+	}
+	typ, err := e.typ(obj.Type())
+	if err != nil {
+		return ret, fmt.Errorf("exporting %s: %v", obj.Name(), err)
+	}
+	ret.Type = typ
+	return ret, nil
+}
+
+func exportConstant(v constant.Value) (*exportConst, error) {
+	ret := &exportConst{Kind: v.Kind()}
+	switch v.Kind() {
+	case constant.Bool:
+		ret.Val = fmt.Sprint(constant.BoolVal(v))
+	case constant.String:
+		ret.Val = constant.StringVal(v)
+	case constant.Int:
+		ret.Val = v.ExactString()
+	case constant.Float:
+		ret.Num = constant.Num(v).ExactString()
+		ret.Denom = constant.Denom(v).ExactString()
+	case constant.Complex:
+		re, err := exportConstant(constant.ToFloat(constant.Real(v)))
+		if err != nil {
+			return nil, err
+		}
+		im, err := exportConstant(constant.ToFloat(constant.Imag(v)))
+		if err != nil {
+			return nil, err
+		}
+		ret.Num, ret.Denom, ret.Imag = re.Num, re.Denom, im
+	default:
+		return nil, fmt.Errorf("unknown constant value %v", v)
+	}
+	return ret, nil
+}
+
+// typ returns the index in the export data's type table for typ, adding it
+// if it's not there yet.
+func (e *exporter) typ(typ types.Type) (int, error) {
+	if i, ok := e.types[typ]; ok {
+		return i, nil
+	}
+
+	// Reserve the index before exporting the type's components, so that
+	// recursive types refer to it.
+	i := len(e.data.Types)
+	e.types[typ] = i
+	e.data.Types = append(e.data.Types, exportType{})
+
+	ret, err := e.exportType(typ)
+	if err != nil {
+		return 0, err
+	}
+	e.data.Types[i] = ret
+	return i, nil
+}
+
+func (e *exporter) exportType(typ types.Type) (exportType, error) {
+	var err error
+	ret := exportType{}
+	if typ == types.Universe.Lookup("any").Type() {
+		ret.Kind = "universe"
+		ret.Name = "any"
+		return ret, nil
+	}
+	switch t := typ.(type) {
+	case *types.Basic:
+		ret.Kind = "basic"
+		ret.Basic = t.Kind()
+		ret.Name = t.Name()
+
+	case *types.Named:
+		if t.TypeArgs().Len() > 0 {
+			ret.Kind = "instance"
+			ret.Elem, err = e.typ(t.Origin())
+			if err != nil {
+				return ret, err
+			}
+			ret.TypeArgs, err = e.typeList(t.TypeArgs())
+			break
+		}
+		obj := t.Obj()
+		ret.Name = obj.Name()
+		if obj.Pkg() == nil {
+			ret.Kind = "universe"
+			break
+		}
+		if obj.Pkg() != e.pkg {
+			ret.Kind = "imported"
+			ret.Pkg = obj.Pkg().Path()
+			break
+		}
+		ret.Kind = "named"
+		ret.TypeParams, err = e.typeParams(t.TypeParams())
+		if err != nil {
+			return ret, err
+		}
+		ret.Elem, err = e.typ(t.Underlying())
+		if err != nil {
+			return ret, err
+		}
+		for i := 0; i < t.NumMethods(); i++ {
+			m := t.Method(i)
+			if e.skip(m) {
+				continue
+			}
+			sig, err := e.typ(m.Type())
+			if err != nil {
+				return ret, err
+			}
+			ret.Methods = append(ret.Methods, exportMethod{m.Name(), sig})
+		}
+
+	case *types.TypeParam:
+		ret.Kind = "typeparam"
+		ret.Name = t.Obj().Name()
+		ret.Elem, err = e.typ(t.Constraint())
+
+	case *types.Pointer:
+		ret.Kind = "pointer"
+		ret.Elem, err = e.typ(t.Elem())
+
+	case *types.Optional:
+		ret.Kind = "optional"
+		ret.Elem, err = e.typ(t.Elem())
+
+	case *types.Slice:
+		ret.Kind = "slice"
+		ret.Elem, err = e.typ(t.Elem())
+
+	case *types.Array:
+		ret.Kind = "array"
+		ret.Len = t.Len()
+		ret.Elem, err = e.typ(t.Elem())
+
+	case *types.Map:
+		ret.Kind = "map"
+		ret.Key, err = e.typ(t.Key())
+		if err != nil {
+			return ret, err
+		}
+		ret.Elem, err = e.typ(t.Elem())
+
+	case *types.Chan:
+		ret.Kind = "chan"
+		ret.Dir = t.Dir()
+		ret.Elem, err = e.typ(t.Elem())
+
+	case *types.Struct:
+		ret.Kind = "struct"
+		for i := 0; i < t.NumFields(); i++ {
+			f, err := e.variable(t.Field(i))
+			if err != nil {
+				return ret, err
+			}
+			ret.Fields = append(ret.Fields, *f)
+			ret.Tags = append(ret.Tags, t.Tag(i))
+		}
+
+	case *types.Interface:
+		ret.Kind = "interface"
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			m := t.ExplicitMethod(i)
+			sig, err := e.typ(m.Type())
+			if err != nil {
+				return ret, err
+			}
+			ret.Methods = append(ret.Methods, exportMethod{m.Name(), sig})
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			embedded, err := e.typ(t.Embedded(i))
+			if err != nil {
+				return ret, err
+			}
+			ret.Embeddeds = append(ret.Embeddeds, embedded)
+		}
+		for i := 0; i < t.NumEmbeddedUnions(); i++ {
+			u := t.EmbeddedUnion(i)
+			var terms []exportTerm
+			for j := 0; j < u.Len(); j++ {
+				term, err := e.typ(u.Term(j).Type())
+				if err != nil {
+					return ret, err
+				}
+				terms = append(terms, exportTerm{u.Term(j).Tilde(), term})
+			}
+			ret.Unions = append(ret.Unions, terms)
+		}
+
+	case *types.Signature:
+		ret.Kind = "signature"
+		ret.Variadic = t.Variadic()
+		if recv := t.Recv(); recv != nil {
+			if _, ok := recv.Type().Underlying().(*types.Interface); !ok {
+				// Interface methods get their receivers when added to
+				// the interface.
+				ret.Recv, err = e.variable(recv)
+				if err != nil {
+					return ret, err
+				}
+			}
+		}
+		ret.RecvTypeParams, err = e.typeParams(t.RecvTypeParams())
+		if err != nil {
+			return ret, err
+		}
+		ret.TypeParams, err = e.typeParams(t.TypeParams())
+		if err != nil {
+			return ret, err
+		}
+		ret.Params, err = e.tuple(t.Params())
+		if err != nil {
+			return ret, err
+		}
+		ret.Results, err = e.tuple(t.Results())
+
+	default:
+		err = fmt.Errorf("unexpected type %v", typ)
+	}
+	return ret, err
+}
+
+func (e *exporter) typeParams(l *types.TypeParamList) ([]int, error) {
+	var ret []int
+	for i := 0; i < l.Len(); i++ {
+		typ, err := e.typ(l.At(i))
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, typ)
+	}
+	return ret, nil
+}
+
+func (e *exporter) typeList(l *types.TypeList) ([]int, error) {
+	var ret []int
+	for i := 0; i < l.Len(); i++ {
+		typ, err := e.typ(l.At(i))
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, typ)
+	}
+	return ret, nil
+}
+
+func (e *exporter) variable(v *types.Var) (*exportVar, error) {
+	typ, err := e.typ(v.Type())
+	if err != nil {
+		return nil, err
+	}
+	return &exportVar{Name: v.Name(), Type: typ, Anonymous: v.Anonymous()}, nil
+}
+
+func (e *exporter) tuple(t *types.Tuple) (*exportTuple, error) {
+	if t == nil {
+		return nil, nil
+	}
+	ret := &exportTuple{}
+	for i := 0; i < t.Len(); i++ {
+		v, err := e.variable(t.At(i))
+		if err != nil {
+			return nil, err
+		}
+		ret.Vars = append(ret.Vars, *v)
+	}
+	if entangled := t.Entangled(); entangled != nil {
+		v, err := e.variable(entangled)
+		if err != nil {
+			return nil, err
+		}
+		ret.Entangled = v
+	}
+	return ret, nil
+}
+
+type exportReader struct {
+	imp    types.Importer
+	data   *exportData
+	pkg    *types.Package
+	types  []types.Type
+	pkgs   map[string]*types.Package
+	ifaces []*types.Interface
+
+	// pending holds the indices of the types being read, to detect
+	// malformed export data.
+	pending map[int]bool
+}
+
+func (d *exportReader) read() (*types.Package, error) {
+	var imports []*types.Package
+	for _, path := range d.data.Imports {
+		pkg, err := d.imported(path)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, pkg)
+	}
+	d.pkg.SetImports(imports)
+
+	scope := d.pkg.Scope()
+	for _, o := range d.data.Objects {
+		obj, err := d.object(o)
+		if err != nil {
+			return nil, err
+		}
+		scope.Insert(obj)
+	}
+
+	for _, iface := range d.ifaces {
+		iface.Complete()
+	}
+
+	d.pkg.MarkComplete()
+	return d.pkg, nil
+}
+
+func (d *exportReader) imported(path string) (*types.Package, error) {
+	if pkg, ok := d.pkgs[path]; ok {
+		return pkg, nil
+	}
+	if d.imp == nil {
+		return nil, fmt.Errorf("no importer to import %q from export data", path)
+	}
+	pkg, err := d.imp.Import(path)
+	if err != nil {
+		return nil, err
+	}
+	d.pkgs[path] = pkg
+	return pkg, nil
+}
+
+func (d *exportReader) object(o exportObject) (types.Object, error) {
+	if o.Kind == "type" {
+		typ, err := d.typ(o.Type)
+		if err != nil {
+			return nil, err
+		}
+		named, ok := typ.(*types.Named)
+		if !ok {
+			return nil, fmt.Errorf("type %s is not a named type", o.Name)
+		}
+		return named.Obj(), nil
+	}
+
+	typ, err := d.typ(o.Type)
+	if err != nil {
+		return nil, err
+	}
+	switch o.Kind {
+	case "func":
+		sig, ok := typ.(*types.Signature)
+		if !ok {
+			return nil, fmt.Errorf("func %s doesn't have a signature type", o.Name)
+		}
+		return types.NewFunc(token.NoPos, d.pkg, o.Name, sig), nil
+	case "var":
+		return types.NewVar(token.NoPos, d.pkg, o.Name, typ), nil
+	case "alias":
+		return types.NewTypeName(token.NoPos, d.pkg, o.Name, typ), nil
+	case "const":
+		if o.Value == nil {
+			return nil, fmt.Errorf("const %s has no value", o.Name)
+		}
+		return types.NewConst(token.NoPos, d.pkg, o.Name, typ, importConstant(o.Value)), nil
+	}
+	return nil, fmt.Errorf("unknown object kind %q for %s", o.Kind, o.Name)
+}
+
+func importConstant(c *exportConst) constant.Value {
+	switch c.Kind {
+	case constant.Bool:
+		return constant.MakeBool(c.Val == "true")
+	case constant.String:
+		return constant.MakeString(c.Val)
+	case constant.Int:
+		return constant.MakeFromLiteral(c.Val, token.INT, 0)
+	case constant.Float:
+		return constant.BinaryOp(
+			constant.MakeFromLiteral(c.Num, token.INT, 0),
+			token.QUO,
+			constant.MakeFromLiteral(c.Denom, token.INT, 0),
+		)
+	case constant.Complex:
+		re := importConstant(&exportConst{Kind: constant.Float, Num: c.Num, Denom: c.Denom})
+		im := importConstant(c.Imag)
+		return constant.BinaryOp(re, token.ADD, constant.MakeImag(im))
+	}
+	return constant.MakeUnknown()
+}
+
+func (d *exportReader) typ(i int) (types.Type, error) {
+	if i < 0 || i >= len(d.types) {
+		return nil, fmt.Errorf("type index %d out of range", i)
+	}
+	if typ := d.types[i]; typ != nil {
+		return typ, nil
+	}
+	if d.pending[i] {
+		return nil, fmt.Errorf("invalid recursive type at index %d", i)
+	}
+	d.pending[i] = true
+	defer delete(d.pending, i)
+
+	var err error
+	t := d.data.Types[i]
+	switch t.Kind {
+	case "basic":
+		switch t.Name {
+		case "byte":
+			d.types[i] = types.ByteType
+		case "rune":
+			d.types[i] = types.RuneType
+		default:
+			if int(t.Basic) < 0 || int(t.Basic) >= len(types.Typ) {
+				return nil, fmt.Errorf("unknown basic type %d", t.Basic)
+			}
+			d.types[i] = types.Typ[t.Basic]
+		}
+
+	case "universe":
+		tn, ok := types.Universe.Lookup(t.Name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("no type %s in universe", t.Name)
+		}
+		d.types[i] = tn.Type()
+
+	case "imported":
+		pkg, err := d.imported(t.Pkg)
+		if err != nil {
+			return nil, err
+		}
+		tn, ok := pkg.Scope().Lookup(t.Name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("no type %s in package %s", t.Name, t.Pkg)
+		}
+		d.types[i] = tn.Type()
+
+	case "named":
+		obj := types.NewTypeName(token.NoPos, d.pkg, t.Name, nil)
+		named := types.NewNamed(obj, nil, nil)
+		d.types[i] = named
+		delete(d.pending, i)
+		tparams, err := d.typeParams(t.TypeParams)
+		if err != nil {
+			return nil, err
+		}
+		named.SetTypeParams(tparams)
+		underlying, err := d.typ(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		named.SetUnderlying(underlying.Underlying())
+		for _, m := range t.Methods {
+			sig, err := d.signature(m.Type)
+			if err != nil {
+				return nil, err
+			}
+			named.AddMethod(types.NewFunc(token.NoPos, d.pkg, m.Name, sig))
+		}
+
+	case "instance":
+		orig, err := d.typ(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		targs, err := d.typeList(t.TypeArgs)
+		if err != nil {
+			return nil, err
+		}
+		d.types[i], err = types.Instantiate(orig, targs, false)
+		if err != nil {
+			return nil, err
+		}
+
+	case "typeparam":
+		tpar := types.NewTypeParam(types.NewTypeName(token.NoPos, d.pkg, t.Name, nil), nil)
+		d.types[i] = tpar
+		delete(d.pending, i)
+		constraint, err := d.typ(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		tpar.SetConstraint(constraint)
+
+	case "pointer":
+		elem, err := d.typ(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		d.types[i] = types.NewPointer(elem)
+
+	case "optional":
+		elem, err := d.typ(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		d.types[i] = types.NewOptional(elem)
+
+	case "slice":
+		elem, err := d.typ(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		d.types[i] = types.NewSlice(elem)
+
+	case "array":
+		elem, err := d.typ(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		d.types[i] = types.NewArray(elem, t.Len)
+
+	case "map":
+		key, err := d.typ(t.Key)
+		if err != nil {
+			return nil, err
+		}
+		elem, err := d.typ(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		d.types[i] = types.NewMap(key, elem)
+
+	case "chan":
+		elem, err := d.typ(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		d.types[i] = types.NewChan(t.Dir, elem)
+
+	case "struct":
+		var fields []*types.Var
+		for _, f := range t.Fields {
+			typ, err := d.typ(f.Type)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, types.NewField(token.NoPos, d.pkg, f.Name, typ, f.Anonymous))
+		}
+		d.types[i] = types.NewStruct(fields, t.Tags)
+
+	case "interface":
+		iface := types.NewInterface(nil, nil)
+		d.types[i] = iface
+		delete(d.pending, i)
+		for _, m := range t.Methods {
+			sig, err := d.signature(m.Type)
+			if err != nil {
+				return nil, err
+			}
+			iface.AddMethod(types.NewFunc(token.NoPos, d.pkg, m.Name, sig))
+		}
+		for _, e := range t.Embeddeds {
+			typ, err := d.typ(e)
+			if err != nil {
+				return nil, err
+			}
+			named, ok := typ.(*types.Named)
+			if !ok {
+				return nil, fmt.Errorf("embedded type %v is not a named type", typ)
+			}
+			iface.AddEmbedded(named)
+		}
+		for _, u := range t.Unions {
+			var terms []*types.Term
+			for _, term := range u {
+				typ, err := d.typ(term.Type)
+				if err != nil {
+					return nil, err
+				}
+				terms = append(terms, types.NewTerm(term.Tilde, typ))
+			}
+			iface.AddEmbeddedType(types.NewUnion(terms))
+		}
+		d.ifaces = append(d.ifaces, iface)
+
+	case "signature":
+		var recv *types.Var
+		if t.Recv != nil {
+			recv, err = d.variable(*t.Recv)
+			if err != nil {
+				return nil, err
+			}
+		}
+		params, err := d.tuple(t.Params)
+		if err != nil {
+			return nil, err
+		}
+		results, err := d.tuple(t.Results)
+		if err != nil {
+			return nil, err
+		}
+		rparams, err := d.typeParams(t.RecvTypeParams)
+		if err != nil {
+			return nil, err
+		}
+		tparams, err := d.typeParams(t.TypeParams)
+		if err != nil {
+			return nil, err
+		}
+		d.types[i] = types.NewSignatureType(recv, rparams, tparams, params, results, t.Variadic)
+
+	default:
+		err = fmt.Errorf("unknown type kind %q", t.Kind)
+	}
+	return d.types[i], err
+}
+
+func (d *exportReader) signature(i int) (*types.Signature, error) {
+	typ, err := d.typ(i)
+	if err != nil {
+		return nil, err
+	}
+	sig, ok := typ.(*types.Signature)
+	if !ok {
+		return nil, fmt.Errorf("method type %v is not a signature", typ)
+	}
+	return sig, nil
+}
+
+func (d *exportReader) typeParams(list []int) ([]*types.TypeParam, error) {
+	var ret []*types.TypeParam
+	for _, i := range list {
+		typ, err := d.typ(i)
+		if err != nil {
+			return nil, err
+		}
+		tpar, ok := typ.(*types.TypeParam)
+		if !ok {
+			return nil, fmt.Errorf("type %v is not a type parameter", typ)
+		}
+		ret = append(ret, tpar)
+	}
+	return ret, nil
+}
+
+func (d *exportReader) typeList(list []int) ([]types.Type, error) {
+	var ret []types.Type
+	for _, i := range list {
+		typ, err := d.typ(i)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, typ)
+	}
+	return ret, nil
+}
+
+func (d *exportReader) variable(v exportVar) (*types.Var, error) {
+	typ, err := d.typ(v.Type)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewParam(token.NoPos, d.pkg, v.Name, typ), nil
+}
+
+func (d *exportReader) tuple(t *exportTuple) (*types.Tuple, error) {
+	if t == nil {
+		return nil, nil
+	}
+	var vars []*types.Var
+	for _, v := range t.Vars {
+		param, err := d.variable(v)
+		if err != nil {
+			return nil, err
+		}
+		vars = append(vars, param)
+	}
+	if t.Entangled == nil {
+		return types.NewTuple(vars...), nil
+	}
+	entangled, err := d.variable(*t.Entangled)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewTupleEntangled(append(vars, entangled)...), nil
+}
+
+// WriteDirExportData writes the export data for pkg, translated from the SGo
+// files of buildPkg, to the ExportDataFile in its directory, along with a hash
+// of the names and contents of the package's Go and SGo files. Importers read
+// it instead of the package's Go files for as long as those are unchanged.
+//
+// pkg may have been checked along with the package's test files under any
+// path; declarations from files ending in _test.sgo, as positioned in fset,
+// and imports only they have are left out, and the export data has
+// buildPkg's import path.
+func WriteDirExportData(buildPkg *build.Package, fset *token.FileSet, pkg *types.Package) error {
+	sources, err := sourcesHash(buildPkg)
+	if err != nil {
+		return err
+	}
+	e := newExporter(pkg)
+	e.data.Path = buildPkg.ImportPath
+	e.data.Sources = sources
+	e.fset = fset
+	e.goImports = map[string]bool{}
+	for _, path := range buildPkg.Imports {
+		e.goImports[path] = true
+	}
+
+	// Written to a temporary file first, so that importers never read
+	// a partial one.
+	path := filepath.Join(buildPkg.Dir, ExportDataFile)
+	f, err := ioutil.TempFile(buildPkg.Dir, ExportDataFile+".tmp")
+	if err != nil {
+		return err
+	}
+	err = e.write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// readDirExportData reads buildPkg from the ExportDataFile in its directory.
+// It returns false if it isn't there or can't be decoded, or if it's from files
+// other than buildPkg's.
+func readDirExportData(buildPkg *build.Package, imp types.Importer) (*types.Package, bool, error) {
+	path := filepath.Join(buildPkg.Dir, ExportDataFile)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, nil
+	}
+	defer f.Close()
+
+	var data exportData
+	err = json.NewDecoder(f).Decode(&data)
+	if err != nil || data.Version != exportDataVersion || data.Sources == "" {
+		return nil, false, nil
+	}
+	sources, err := sourcesHash(buildPkg)
+	if err != nil || sources != data.Sources {
+		return nil, false, nil
+	}
+	pkg, err := readExportData(&data, imp)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %v", path, err)
+	}
+	return pkg, true, nil
+}
+
+// sourcesHash returns a hash of the names and contents of buildPkg's Go files
+// and of the SGo files they were translated from.
+func sourcesHash(buildPkg *build.Package) (string, error) {
+	names := append(append([]string{}, buildPkg.GoFiles...), sgoFileNames(buildPkg)...)
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		b, err := ioutil.ReadFile(filepath.Join(buildPkg.Dir, name))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(b))
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,219 @@
+package importer
+
+import (
+	"bytes"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/parser"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+const exportTestSrc = `
+package example
+
+type List struct {
+	Head int
+	Tail ?*List
+	tags ?map[string]?*List
+}
+
+func (l *List) Len() int { return 0 }
+
+func (l List) First() (int \ bool) { return l.Head \ }
+
+type Getter interface {
+	Get(key string) (?*List \ error)
+	embedded
+}
+
+type embedded interface {
+	private(ch <-chan []byte, fs ...func() ?error)
+}
+
+type Byte byte
+
+type (
+	MaybeList = ?*List
+	Alias     = List
+)
+
+const (
+	Big   = 1 << 100
+	Pi    = 3.14159
+	Cplx  = 1 + 2i
+	Str   = "hi\n"
+	True  = true
+	typed Byte = 'a'
+)
+
+var (
+	Default = &List{}
+	grid    [3][2]rune
+	none    ?error
+)
+
+func New(head int, tail ?*List) (l *List \ err error) { return &List{Head: head, Tail: tail} \ }
+`
+
+func TestExportData(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "example.sgo", exportTestSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &types.Config{}
+	pkg, err := cfg.Check("example.com/example", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = WriteExportData(&buf, pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ReadExportData(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported.Path() != pkg.Path() || imported.Name() != pkg.Name() {
+		t.Errorf("expected package %s %s, got %s %s", pkg.Path(), pkg.Name(), imported.Path(), imported.Name())
+	}
+
+	names := pkg.Scope().Names()
+	importedNames := imported.Scope().Names()
+	if len(names) != len(importedNames) {
+		t.Fatalf("expected objects %v, got %v", names, importedNames)
+	}
+	for _, name := range names {
+		obj := pkg.Scope().Lookup(name)
+		importedObj := imported.Scope().Lookup(name)
+		if importedObj == nil {
+			t.Errorf("missing object %s", name)
+			continue
+		}
+		if expected, got := types.ObjectString(obj, nil), types.ObjectString(importedObj, nil); expected != got {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+		if tn, ok := obj.(*types.TypeName); ok && tn.IsAlias() != importedObj.(*types.TypeName).IsAlias() {
+			t.Errorf("%s: expected alias %v", name, tn.IsAlias())
+		}
+		if c, ok := obj.(*types.Const); ok {
+			expected := c.Val().ExactString()
+			got := importedObj.(*types.Const).Val().ExactString()
+			if expected != got {
+				t.Errorf("%s: expected value %s, got %s", name, expected, got)
+			}
+		}
+		if named, ok := obj.Type().(*types.Named); ok {
+			importedNamed := importedObj.Type().(*types.Named)
+			for _, typs := range [][2]types.Type{
+				{named, importedNamed},
+				{types.NewPointer(named), types.NewPointer(importedNamed)},
+			} {
+				expected := types.NewMethodSet(typs[0]).String()
+				got := types.NewMethodSet(typs[1]).String()
+				if expected != got {
+					t.Errorf("%s: expected method set %s, got %s", name, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func TestDirExportData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sgo-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, src string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const testSrc = "package example\n\nimport \"errors\"\n\nfunc helper() error { return errors.New(\"helper\") }\n\nfunc (l List) Helper() {}\n"
+	write("example.sgo", exportTestSrc)
+	write("example_test.sgo", testSrc)
+	write("example.go", "package example\n")
+	buildPkg := &build.Package{Dir: dir, ImportPath: "example.com/example", GoFiles: []string{"example.go"}}
+
+	// Checked along with the test files, as when translating.
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, f := range []struct{ name, src string }{{"example.sgo", exportTestSrc}, {"example_test.sgo", testSrc}} {
+		file, err := parser.ParseFile(fset, f.name, f.src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	imp, err := DefaultFrom(files, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := (&types.Config{Importer: imp}).Check("translate", fset, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDirExportData(buildPkg, fset, pkg); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 4 {
+		t.Errorf("got %d files in the package directory, want 4", len(entries))
+	}
+
+	read := func(want bool) {
+		imported, ok, err := readDirExportData(buildPkg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != want {
+			t.Fatalf("read export data: %v, want %v", ok, want)
+		}
+		if !ok {
+			return
+		}
+		if imported.Path() != buildPkg.ImportPath {
+			t.Errorf("got path %q, want %q", imported.Path(), buildPkg.ImportPath)
+		}
+		list := imported.Scope().Lookup("List")
+		if list == nil {
+			t.Fatalf("List not in imported package")
+		}
+		if len(imported.Imports()) > 0 {
+			t.Errorf("got imports %v from test file in imported package", imported.Imports())
+		}
+		if imported.Scope().Lookup("helper") != nil {
+			t.Errorf("helper from test file in imported package")
+		}
+		if obj, _, _ := types.LookupFieldOrMethod(list.Type(), false, imported, "Helper"); obj != nil {
+			t.Errorf("List.Helper from test file in imported package")
+		}
+	}
+	read(true)
+
+	// Touching the files doesn't make the export data stale.
+	later := time.Now().Add(time.Hour)
+	for _, name := range []string{"example.sgo", "example.go"} {
+		if err := os.Chtimes(filepath.Join(dir, name), later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read(true)
+
+	// Changing them does, even with the same size.
+	write("example.go", "package exampl_\n")
+	read(false)
+	write("example.go", "package example\n")
+	read(true)
+}
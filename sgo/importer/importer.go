@@ -14,6 +14,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/tcard/sgo/sgo/annotations"
@@ -39,6 +40,11 @@ func Default(files []*ast.File) types.Importer {
 // DefaultFrom is like Default, with an optional whence argument for the path
 // to the directory from which the importing is done.
 func DefaultFrom(files []*ast.File, whence string) (types.Importer, error) {
+	return newImporter(importedPaths(files), whence)
+}
+
+// importedPaths returns the set of import paths imported by files.
+func importedPaths(files []*ast.File) map[string]struct{} {
 	visiblePaths := map[string]struct{}{}
 	for _, file := range files {
 		for _, decl := range file.Decls {
@@ -55,25 +61,39 @@ func DefaultFrom(files []*ast.File, whence string) (types.Importer, error) {
 			}
 		}
 	}
-
-	return newImporter(visiblePaths, whence)
+	return visiblePaths
 }
 
 type importer struct {
 	visiblePaths map[string]struct{}
 	imported     map[string]*types.Package
-	sgovendored  map[string]func() (*annotations.Annotation, error)
+	sgovendored  map[string]string // import path to sgovendor directory
 	whence       string
+
+	// compiled, if not nil, is used to import visible packages from
+	// compiled export data instead of from source.
+	compiled gotypes.Importer
+
+	// sources, if not nil, gets where the types of the objects declared by
+	// packages converted from source come from.
+	sources map[types.Object]TypeSource
+
+	// The packages that the SGo files don't import are imported with
+	// goImporter, unless compiled is set, and converted as is by
+	// unchecked into uncheckedPkgs. Both are shared by all imports, so
+	// that each package is converted once, and the types that different
+	// packages refer to are the same.
+	goImporter    gotypes.Importer
+	unchecked     *converter
+	uncheckedPkgs map[string]*types.Package
 }
 
 func newImporter(visiblePaths map[string]struct{}, whence string) (*importer, error) {
-	sgovendored := map[string]func() (*annotations.Annotation, error){}
+	sgovendored := map[string]string{}
 
-	if whence != "" {
-		err := findSgovendoredPkgs(whence, sgovendored)
-		if err != nil {
-			return nil, err
-		}
+	err := findSgovendoredPkgs(whence, sgovendored)
+	if err != nil {
+		return nil, err
 	}
 
 	return &importer{
@@ -85,7 +105,13 @@ func newImporter(visiblePaths map[string]struct{}, whence string) (*importer, er
 }
 
 func (imp *importer) fromPkg() types.Importer {
-	return fromPkg{fromSrc: imp, imp: goimporter.Default()}
+	if imp.compiled != nil {
+		return fromPkg{fromSrc: imp, imp: imp.compiled}
+	}
+	if imp.goImporter == nil {
+		imp.goImporter = goimporter.Default()
+	}
+	return fromPkg{fromSrc: imp, imp: imp.goImporter}
 }
 
 func (imp *importer) Import(path string) (*types.Package, error) {
@@ -108,23 +134,59 @@ func (imp *importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*ty
 		return conv.ret, nil
 	}
 
+	if imp.compiled != nil {
+		return imp.importCompiled(path, srcDir)
+	}
+
 	buildPkg, err := build.Import(path, srcDir, build.ImportMode(mode))
 	if err != nil {
+		// The package may still be available compiled.
+		if pkg, cerr := imp.importCompiled(path, srcDir); cerr == nil {
+			return pkg, nil
+		}
 		return nil, err
 	}
+
+	exported, ok, err := imp.importExportData(buildPkg)
+	if err != nil {
+		return nil, fmt.Errorf("reading SGo export data for %s: %v", path, err)
+	}
+	if ok {
+		imp.imported[path] = exported
+		return exported, nil
+	}
+
 	fset := token.NewFileSet()
+	pkg, _, err := imp.convertSource(fset, path, buildPkg)
+	if err != nil {
+		return nil, err
+	}
+	if len(sgoFileNames(buildPkg)) == 0 {
+		from := `"For SGo:" doc comments`
+		if src := imp.annotationSource(path); src != "" {
+			from = src + ", or " + from
+		}
+		pkg.MarkFromGo(from)
+	}
 
+	imp.imported[path] = pkg
+	return pkg, nil
+}
+
+// convertSource parses the Go files of buildPkg, the package with the given
+// import path, converts them to SGo and type-checks the result.
+func (imp *importer) convertSource(fset *token.FileSet, path string, buildPkg *build.Package) (*types.Package, []*ast.File, error) {
 	var files []*ast.File
 	for _, name := range buildPkg.GoFiles {
 		path := filepath.Join(buildPkg.Dir, name)
 		f, err := os.Open(path)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		a, err := parser.ParseFile(fset, name, f, parser.ParseComments)
 		f.Close()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		files = append(files, a)
 	}
@@ -142,38 +204,160 @@ func (imp *importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*ty
 		Importer:                imp.fromPkg(),
 		AllowUninitializedExprs: true,
 	}
-	pkg, err := cfg.Check(path, fset, files, info)
+	_, err := cfg.Check(path, fset, files, info)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// 2. Convert AST, now using the doc comment annotations and fromPkg
 	//    everything that hasn't been converted explicitly by then with the
 	//    default conversion (wrapping in optionals).
 
-	var ann *annotations.Annotation
+	ann, err := imp.annotations(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var replaced map[ast.Expr]TypeSource
+	if imp.sources != nil {
+		replaced = map[ast.Expr]TypeSource{}
+	}
+	for _, f := range files {
+		convertAST(f, info, ann, replaced)
+	}
+
+	// 3. Typecheck converted AST.
+
+	info = &types.Info{}
+	if imp.sources != nil {
+		info.Defs = map[*ast.Ident]types.Object{}
+	}
+	pkg, err := cfg.Check(path, fset, files, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	if imp.sources != nil {
+		for _, f := range files {
+			recordSources(imp.sources, f, info.Defs, replaced)
+		}
+	}
+	return pkg, files, nil
+}
+
+// SourceFiles returns the files of the package with the given import path,
+// found from srcDir, as SGo sees them. Those are the SGo files of a package
+// written in SGo, or else its Go files converted to SGo the same way importing
+// them does. Test files are left out.
+//
+// If the package is converted with annotations, either built-in or from
+// sgovendor folders or SGOANNPATH, ann tells where they come from. Otherwise,
+// it's nil.
+//
+// For SGo: func(fset *token.FileSet, path, srcDir string) (files []*ast.File, buildPkg *build.Package, ann ?*AnnotationSource \ err error)
+func SourceFiles(fset *token.FileSet, path, srcDir string) (files []*ast.File, buildPkg *build.Package, ann *AnnotationSource, err error) {
+	// Local import paths are resolved to full ones only from absolute
+	// directories.
+	srcDir, err = filepath.Abs(srcDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	buildPkg, err = build.Import(path, srcDir, build.FindOnly)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sgoNames, err := filepath.Glob(filepath.Join(buildPkg.Dir, "*.sgo"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, name := range sgoNames {
+		if strings.HasSuffix(name, "_test.sgo") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		files = append(files, f)
+	}
+	if len(files) > 0 {
+		return files, buildPkg, nil, nil
+	}
+
+	buildPkg, err = build.Import(path, srcDir, build.ImportComment)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	imp, err := newImporter(map[string]struct{}{buildPkg.ImportPath: {}}, srcDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	_, files, err = imp.convertSource(fset, buildPkg.ImportPath, buildPkg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return files, buildPkg, imp.annotationSourceOf(buildPkg.ImportPath), nil
+}
+
+// annotations returns the annotations for the package with the given import
+// path, either built-in or from sgovendor folders.
+func (imp *importer) annotations(path string) (*annotations.Annotation, error) {
 	if a, ok := defaultAnnotations[path]; ok {
-		ann = annotations.NewAnnotation(a)
-	} else if a, ok := imp.sgovendored[path]; ok {
-		ann, err = a()
+		return annotations.NewAnnotation(a), nil
+	}
+	if dir, ok := imp.sgovendored[path]; ok {
+		ann, err := readSgovendorDir(dir)
 		if err != nil {
 			return nil, fmt.Errorf("reading SGo annotations for %s: %v", path, err)
 		}
+		return ann, nil
 	}
+	return nil, nil
+}
 
-	for _, f := range files {
-		ConvertAST(f, info, ann)
+// annotationSource describes where the annotations returned by annotations
+// for the package with the given import path come from, or returns "" if
+// there are none.
+func (imp *importer) annotationSource(path string) string {
+	if _, ok := defaultAnnotations[path]; ok {
+		return "built-in annotations"
+	}
+	if dir, ok := imp.sgovendored[path]; ok {
+		return "annotations in " + dir
 	}
+	return ""
+}
 
-	// 3. Typecheck converted AST.
+// annotationSourceOf is like annotationSource, but returns an
+// AnnotationSource, or nil if there are no annotations.
+//
+// For SGo: func(path string) ?*AnnotationSource
+func (imp *importer) annotationSourceOf(path string) *AnnotationSource {
+	if _, ok := defaultAnnotations[path]; ok {
+		return &AnnotationSource{Path: path}
+	}
+	if dir, ok := imp.sgovendored[path]; ok {
+		return &AnnotationSource{Path: path, Dir: dir}
+	}
+	return nil
+}
 
-	pkg, err = cfg.Check(path, fset, files, &types.Info{})
-	if err != nil {
-		return nil, err
+// sgoFileNames returns the names of the SGo files that buildPkg's Go files
+// were translated from.
+func sgoFileNames(buildPkg *build.Package) []string {
+	var names []string
+	for _, name := range buildPkg.GoFiles {
+		sgoName := name[:len(name)-len(".go")] + ".sgo"
+		if _, err := os.Stat(filepath.Join(buildPkg.Dir, sgoName)); err == nil {
+			names = append(names, sgoName)
+		}
 	}
+	return names
+}
 
-	imp.imported[path] = pkg
-	return pkg, nil
+// importExportData imports a package from the export data that SGo keeps
+// for it, if there is any and it's up to date.
+func (imp *importer) importExportData(buildPkg *build.Package) (*types.Package, bool, error) {
+	return readDirExportData(buildPkg, imp.fromPkg())
 }
 
 type fromPkg struct {
@@ -193,13 +377,27 @@ func (c fromPkg) Import(path string) (*types.Package, error) {
 	if _, ok := c.fromSrc.visiblePaths[path]; ok {
 		return c.fromSrc.Import(path)
 	}
+	if pkg, ok := c.fromSrc.uncheckedPkgs[path]; ok {
+		return pkg, nil
+	}
 	gopkg, err := c.imp.Import(path)
 	if err != nil {
 		return nil, err
 	}
-	conv := &converter{gopkg: gopkg}
-	conv.convert()
-	return conv.ret, nil
+	// Packages not imported by the SGo files are converted as is, without
+	// making their pointers optional, so they're unchecked.
+	if c.fromSrc.unchecked == nil {
+		c.fromSrc.unchecked = &converter{
+			converted: map[interface{}]interface{}{},
+			unchecked: true,
+			importPkg: c.Import,
+		}
+		c.fromSrc.uncheckedPkgs = map[string]*types.Package{}
+	}
+	pkg := c.fromSrc.unchecked.convertImported(gopkg)
+	pkg.MarkFromGo("no annotations")
+	c.fromSrc.uncheckedPkgs[path] = pkg
+	return pkg, nil
 }
 
 type converter struct {
@@ -207,6 +405,12 @@ type converter struct {
 	ret       *types.Package
 	converted map[interface{}]interface{}
 	ifaces    []*types.Interface
+	unchecked bool
+
+	// importPkg, if not nil, imports the packages other than gopkg that
+	// the converted objects refer to, instead of converting them from
+	// the parts of them that gopkg's export data has.
+	importPkg func(path string) (*types.Package, error)
 }
 
 func (c *converter) convert() *types.Package {
@@ -214,6 +418,15 @@ func (c *converter) convert() *types.Package {
 	return c.convertPackage(c.gopkg)
 }
 
+// convertImported converts gopkg with a converter that is shared by several
+// packages.
+func (c *converter) convertImported(gopkg *gotypes.Package) *types.Package {
+	prevGopkg, prevRet := c.gopkg, c.ret
+	defer func() { c.gopkg, c.ret = prevGopkg, prevRet }()
+	c.gopkg, c.ret = gopkg, nil
+	return c.convertPackage(gopkg)
+}
+
 func (c *converter) convertPackage(v *gotypes.Package) *types.Package {
 	if v == nil {
 		return nil
@@ -221,8 +434,17 @@ func (c *converter) convertPackage(v *gotypes.Package) *types.Package {
 	if v, ok := c.converted[v]; ok {
 		return v.(*types.Package)
 	}
+	if c.importPkg != nil && v != c.gopkg {
+		if pkg, err := c.importPkg(v.Path()); err == nil {
+			c.converted[v] = pkg
+			return pkg
+		}
+	}
 
 	ret := types.NewPackage(v.Path(), v.Name())
+	if c.unchecked {
+		ret.MarkUnchecked()
+	}
 	if c.ret == nil {
 		c.ret = ret
 	}
@@ -244,10 +466,21 @@ func (c *converter) convertPackage(v *gotypes.Package) *types.Package {
 	return ret
 }
 
+// objPkg returns the package of v, converted. Objects that go/types doesn't
+// give a package to, like the parameters of methods of predeclared types, are
+// given the package being converted.
+func (c *converter) objPkg(v gotypes.Object) *types.Package {
+	if v.Pkg() == nil {
+		return c.ret
+	}
+	return c.convertPackage(v.Pkg())
+}
+
 func (c *converter) convertScope(dst *types.Scope, src *gotypes.Scope) {
 	for _, name := range src.Names() {
-		obj := src.Lookup(name)
-		dst.Insert(c.convertObject(obj))
+		if obj := c.convertObject(src.Lookup(name)); obj != nil {
+			dst.Insert(obj)
+		}
 	}
 	for i := 0; i < src.NumChildren(); i++ {
 		child := src.Child(i)
@@ -276,7 +509,11 @@ func (c *converter) convertObject(v gotypes.Object) types.Object {
 	case *gotypes.PkgName:
 		ret = c.convertPkgName(v)
 	case *gotypes.Builtin:
-		ret = c.convertBuiltin(v)
+		b := c.convertBuiltin(v)
+		if b == nil {
+			return nil
+		}
+		ret = b
 	default:
 		panic(fmt.Sprintf("unhandled Object %T", v))
 	}
@@ -293,7 +530,7 @@ func (c *converter) convertFunc(v *gotypes.Func) *types.Func {
 	}
 	ret := types.NewFunc(
 		token.Pos(v.Pos()),
-		c.ret,
+		c.objPkg(v),
 		v.Name(),
 		c.convertSignature(v.Type().(*gotypes.Signature)),
 	)
@@ -308,8 +545,10 @@ func (c *converter) convertSignature(v *gotypes.Signature) *types.Signature {
 	if v, ok := c.converted[v]; ok {
 		return v.(*types.Signature)
 	}
-	ret := types.NewSignature(
+	ret := types.NewSignatureType(
 		c.convertParamVar(v.Recv()),
+		c.convertTypeParams(v.RecvTypeParams()),
+		c.convertTypeParams(v.TypeParams()),
 		c.convertTuple(v.Params(), c.convertParamVar),
 		c.convertTuple(v.Results(), c.convertParamVar),
 		v.Variadic(),
@@ -318,6 +557,45 @@ func (c *converter) convertSignature(v *gotypes.Signature) *types.Signature {
 	return ret
 }
 
+func (c *converter) convertTypeParams(v *gotypes.TypeParamList) []*types.TypeParam {
+	var ret []*types.TypeParam
+	for i := 0; i < v.Len(); i++ {
+		ret = append(ret, c.convertTypeParam(v.At(i)))
+	}
+	return ret
+}
+
+func (c *converter) convertTypeParam(v *gotypes.TypeParam) *types.TypeParam {
+	if v == nil {
+		return nil
+	}
+	if v, ok := c.converted[v]; ok {
+		return v.(*types.TypeParam)
+	}
+	obj := v.Obj()
+	ret := types.NewTypeParam(types.NewTypeName(token.Pos(obj.Pos()), c.objPkg(obj), obj.Name(), nil), nil)
+	c.converted[v] = ret
+	ret.SetConstraint(c.convertType(v.Constraint()))
+	return ret
+}
+
+func (c *converter) convertUnion(v *gotypes.Union) *types.Union {
+	if v == nil {
+		return nil
+	}
+	if v, ok := c.converted[v]; ok {
+		return v.(*types.Union)
+	}
+	terms := make([]*types.Term, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		t := v.Term(i)
+		terms = append(terms, types.NewTerm(t.Tilde(), c.convertType(t.Type())))
+	}
+	ret := types.NewUnion(terms)
+	c.converted[v] = ret
+	return ret
+}
+
 func (c *converter) convertParamVar(v *gotypes.Var) *types.Var {
 	if v == nil {
 		return nil
@@ -327,7 +605,7 @@ func (c *converter) convertParamVar(v *gotypes.Var) *types.Var {
 	}
 	ret := types.NewParam(
 		token.Pos(v.Pos()),
-		c.ret,
+		c.objPkg(v),
 		v.Name(),
 		c.convertType(v.Type()),
 	)
@@ -344,7 +622,7 @@ func (c *converter) convertVar(v *gotypes.Var) *types.Var {
 	}
 	ret := types.NewVar(
 		token.Pos(v.Pos()),
-		c.ret,
+		c.objPkg(v),
 		v.Name(),
 		c.convertType(v.Type()),
 	)
@@ -361,7 +639,7 @@ func (c *converter) convertConst(v *gotypes.Const) *types.Const {
 	}
 	ret := types.NewConst(
 		token.Pos(v.Pos()),
-		c.ret,
+		c.objPkg(v),
 		v.Name(),
 		c.convertType(v.Type()),
 		c.convertConstantValue(v.Val()),
@@ -379,7 +657,7 @@ func (c *converter) convertPkgName(v *gotypes.PkgName) *types.PkgName {
 	}
 	ret := types.NewPkgName(
 		token.Pos(v.Pos()),
-		c.ret,
+		c.objPkg(v),
 		v.Name(),
 		c.convertPackage(v.Imported()),
 	)
@@ -387,13 +665,16 @@ func (c *converter) convertPkgName(v *gotypes.PkgName) *types.PkgName {
 	return ret
 }
 
+// convertBuiltin returns the SGo builtin corresponding to v, or nil if
+// SGo doesn't have it.
 func (c *converter) convertBuiltin(v *gotypes.Builtin) *types.Builtin {
+	scope := types.Universe
 	switch v.Name() {
-	case "Alignof", "Offsetof", "Sizeof":
-		return types.Unsafe.Scope().Lookup(v.Name()).(*types.Builtin)
-	default:
-		return types.Universe.Lookup(v.Name()).(*types.Builtin)
+	case "Add", "Alignof", "Offsetof", "Sizeof", "Slice", "SliceData", "String", "StringData":
+		scope = types.Unsafe.Scope()
 	}
+	b, _ := scope.Lookup(v.Name()).(*types.Builtin)
+	return b
 }
 
 func (c *converter) convertTuple(v *gotypes.Tuple, conv func(*gotypes.Var) *types.Var) *types.Tuple {
@@ -426,20 +707,43 @@ func (c *converter) convertTypeName(v *gotypes.TypeName) *types.TypeName {
 	// whose Type() is a *Named whose Obj() is the same *TypeName, we know it
 	// was constructed this way, so we do the same. Otherwise we get into a
 	// infinite recursion converting the *TypeName's type.
+	// Converting the package may convert v itself.
+	pkg := c.convertPackage(v.Pkg())
+	if v, ok := c.converted[v]; ok {
+		return v.(*types.TypeName)
+	}
+	if pkg != nil && v.Parent() == v.Pkg().Scope() {
+		// The package may have been imported with importPkg.
+		if tn, ok := pkg.Scope().Lookup(v.Name()).(*types.TypeName); ok {
+			c.converted[v] = tn
+			return tn
+		}
+	}
+
 	var typ types.Type
-	if named, ok := v.Type().(*gotypes.Named); !ok || named.Obj() != v {
+	named, ok := v.Type().(*gotypes.Named)
+	if !ok || named.Obj() != v {
 		typ = c.convertType(v.Type())
 	}
 
 	ret := types.NewTypeName(
 		token.Pos(v.Pos()),
-		c.convertPackage(v.Pkg()),
+		pkg,
 		v.Name(),
 		typ,
 	)
 	c.converted[v] = ret
-	named := types.NewNamed(ret, c.convertType(v.Type().Underlying()), nil)
-	c.converted[v.Type()] = named
+	if typ == nil {
+		// The type parameters must be set up before the underlying
+		// type, which refers to them, is converted.
+		ret := types.NewNamed(ret, nil, nil)
+		c.converted[named] = ret
+		ret.SetTypeParams(c.convertTypeParams(named.TypeParams()))
+		ret.SetUnderlying(c.convertType(named.Underlying()))
+		for i := 0; i < named.NumMethods(); i++ {
+			ret.AddMethod(c.convertFunc(named.Method(i)))
+		}
+	}
 	return ret
 }
 
@@ -452,6 +756,15 @@ func (c *converter) convertType(v gotypes.Type) types.Type {
 	}
 	var ret types.Type
 	switch v := v.(type) {
+	case *gotypes.Alias:
+		if v.Obj() == gotypes.Universe.Lookup("any") {
+			return types.Universe.Lookup("any").Type()
+		}
+		ret = c.convertType(gotypes.Unalias(v))
+	case *gotypes.TypeParam:
+		ret = c.convertTypeParam(v)
+	case *gotypes.Union:
+		ret = c.convertUnion(v)
 	case *gotypes.Named:
 		ret = c.convertNamed(v)
 	case *gotypes.Pointer:
@@ -486,14 +799,28 @@ func (c *converter) convertNamed(v *gotypes.Named) *types.Named {
 	if v, ok := c.converted[v]; ok {
 		return v.(*types.Named)
 	}
-	if gotypes.Universe.Lookup("error").(*gotypes.TypeName).Type().(*gotypes.Named) == v {
-		return types.Universe.Lookup("error").(*types.TypeName).Type().(*types.Named)
+	for _, name := range [...]string{"error", "comparable"} {
+		if gotypes.Universe.Lookup(name).(*gotypes.TypeName).Type().(*gotypes.Named) == v {
+			return types.Universe.Lookup(name).(*types.TypeName).Type().(*types.Named)
+		}
+	}
+	if orig := v.Origin(); orig != v {
+		// instantiated generic type
+		targs := make([]types.Type, v.TypeArgs().Len())
+		for i := range targs {
+			targs[i] = c.convertType(v.TypeArgs().At(i))
+		}
+		inst, _ := types.Instantiate(c.convertNamed(orig), targs, false)
+		ret := inst.(*types.Named)
+		c.converted[v] = ret
+		return ret
 	}
 	typeName := c.convertTypeName(v.Obj())
-	ret, ok := typeName.Type().(*types.Named)
-	if !ok {
-		ret = types.NewNamed(nil, nil, nil)
+	if ret, ok := typeName.Type().(*types.Named); ok {
+		// convertTypeName has converted the methods and underlying type.
+		return ret
 	}
+	ret := types.NewNamed(nil, nil, nil)
 	c.converted[v] = ret
 	for i := 0; i < v.NumMethods(); i++ {
 		ret.AddMethod(c.convertFunc(v.Method(i)))
@@ -572,7 +899,12 @@ func (c *converter) convertInterface(v *gotypes.Interface) *types.Interface {
 		ret.AddMethod(c.convertFunc(v.ExplicitMethod(i)))
 	}
 	for i := 0; i < v.NumEmbeddeds(); i++ {
-		ret.AddEmbedded(c.convertNamed(v.Embedded(i)))
+		e := gotypes.Unalias(v.EmbeddedType(i))
+		if named, ok := e.(*gotypes.Named); ok && gotypes.IsInterface(named) {
+			ret.AddEmbedded(c.convertNamed(named))
+			continue
+		}
+		ret.AddEmbeddedType(c.convertType(e))
 	}
 	c.ifaces = append(c.ifaces, ret)
 	return ret
@@ -650,65 +982,170 @@ func (c *converter) convertConstantValue(v goconstant.Value) constant.Value {
 	return ret
 }
 
-func findSgovendoredPkgs(whence string, sgovendored map[string]func() (*annotations.Annotation, error)) error {
-	dirPath, err := filepath.Abs(whence)
+// AnnPathEnv is the name of the environment variable holding the list of
+// shared annotation directories, separated by os.PathListSeparator.
+//
+// Each of those directories must be laid out like a sgovendor folder. They are
+// searched, in order, after all the sgovendor folders found from the importing
+// directory upwards.
+const AnnPathEnv = "SGOANNPATH"
+
+// An AnnotationSource tells where the SGo annotations for a package come from.
+type AnnotationSource struct {
+	// Path is the import path of the annotated package.
+	Path string
+	// Dir is the directory with the .sgoann files that are used for the
+	// package. It is empty if the package has built-in annotations.
+	Dir string
+	// Shadowed are other directories with .sgoann files for the package,
+	// which are ignored because Dir, or the built-in annotations, take
+	// precedence over them.
+	Shadowed []string
+}
+
+// Builtin reports whether the annotations come from the ones built into SGo.
+func (s AnnotationSource) Builtin() bool {
+	return s.Dir == ""
+}
+
+// AnnotationSources returns, sorted by import path, the sources of the
+// annotations for all packages that would be annotated when importing from the
+// directory whence.
+//
+// Built-in annotations take precedence over everything else. Then come
+// sgovendor folders, from whence upwards, and then the directories listed in
+// the SGOANNPATH environment variable, in order.
+func AnnotationSources(whence string) ([]AnnotationSource, error) {
+	annDirs, err := findAnnotationDirs(whence)
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := map[string]*AnnotationSource{}
+	for pkgPath := range defaultAnnotations {
+		byPath[pkgPath] = &AnnotationSource{Path: pkgPath}
+	}
+	for pkgPath, dirs := range annDirs {
+		src, ok := byPath[pkgPath]
+		if !ok {
+			src = &AnnotationSource{Path: pkgPath, Dir: dirs[0]}
+			byPath[pkgPath] = src
+			dirs = dirs[1:]
+		}
+		src.Shadowed = append(src.Shadowed, dirs...)
+	}
+
+	srcs := make([]AnnotationSource, 0, len(byPath))
+	for _, src := range byPath {
+		srcs = append(srcs, *src)
+	}
+	sort.Sort(annotationSourcesByPath(srcs))
+	return srcs, nil
+}
+
+type annotationSourcesByPath []AnnotationSource
+
+func (s annotationSourcesByPath) Len() int           { return len(s) }
+func (s annotationSourcesByPath) Less(i, j int) bool { return s[i].Path < s[j].Path }
+func (s annotationSourcesByPath) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func findSgovendoredPkgs(whence string, sgovendored map[string]string) error {
+	annDirs, err := findAnnotationDirs(whence)
 	if err != nil {
 		return err
 	}
 
-	annPaths := map[string]string{}
+	for pkgPath, dirs := range annDirs {
+		sgovendored[pkgPath] = dirs[0]
+	}
 
-	for {
-		dir, err := os.Open(dirPath)
-		if err != nil {
-			return err
-		}
-		fileNames, err := dir.Readdirnames(-1)
-		dir.Close()
+	return nil
+}
+
+// findAnnotationDirs maps import paths to the directories with .sgoann files
+// for them, from highest to lowest precedence.
+func findAnnotationDirs(whence string) (map[string][]string, error) {
+	annDirs := map[string][]string{}
+
+	if whence != "" {
+		dirPath, err := filepath.Abs(whence)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		for _, sgovendorPath := range fileNames {
-			if sgovendorPath != "sgovendor" {
-				continue
-			}
-			sgovendorPath = filepath.Join(dirPath, sgovendorPath)
-			info, err := os.Lstat(sgovendorPath)
+
+		for {
+			dir, err := os.Open(dirPath)
 			if err != nil {
-				return err
+				return nil, err
 			}
-			if !info.IsDir() {
-				continue
+			fileNames, err := dir.Readdirnames(-1)
+			dir.Close()
+			if err != nil {
+				return nil, err
 			}
-
-			filepath.Walk(sgovendorPath, func(path string, info os.FileInfo, err error) error {
-				if info.IsDir() || filepath.Ext(path) != ".sgoann" {
-					return nil
+			for _, sgovendorPath := range fileNames {
+				if sgovendorPath != "sgovendor" {
+					continue
 				}
-				pkgPath := filepath.Dir(path[len(sgovendorPath)+1:])
-				if _, ok := annPaths[pkgPath]; ok {
-					return nil
+				sgovendorPath = filepath.Join(dirPath, sgovendorPath)
+				info, err := os.Lstat(sgovendorPath)
+				if err != nil {
+					return nil, err
+				}
+				if !info.IsDir() {
+					continue
 				}
-				annPaths[pkgPath] = filepath.Dir(path)
-				return nil
-			})
-		}
 
-		nextDirPath := filepath.Dir(dirPath)
-		if nextDirPath == dirPath {
-			break
+				walkAnnotationDirs(sgovendorPath, annDirs)
+			}
+
+			nextDirPath := filepath.Dir(dirPath)
+			if nextDirPath == dirPath {
+				break
+			}
+			dirPath = nextDirPath
 		}
-		dirPath = nextDirPath
 	}
 
-	for pkgPath, dirPath := range annPaths {
-		pkgPath, dirPath := pkgPath, dirPath
-		sgovendored[pkgPath] = func() (*annotations.Annotation, error) {
-			return readSgovendorDir(dirPath)
+	for _, root := range filepath.SplitList(os.Getenv(AnnPathEnv)) {
+		if root == "" {
+			continue
 		}
+		root, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(root)
+		if err != nil || !info.IsDir() {
+			// Like with GOPATH, missing entries are just skipped.
+			continue
+		}
+		walkAnnotationDirs(root, annDirs)
 	}
 
-	return nil
+	return annDirs, nil
+}
+
+// walkAnnotationDirs adds to annDirs the directories under root that have
+// .sgoann files, keyed by their path relative to root.
+func walkAnnotationDirs(root string, annDirs map[string][]string) {
+	found := map[string]struct{}{}
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".sgoann" {
+			return nil
+		}
+		dirPath := filepath.Dir(path)
+		if dirPath == root {
+			return nil
+		}
+		if _, ok := found[dirPath]; ok {
+			return nil
+		}
+		found[dirPath] = struct{}{}
+		pkgPath := filepath.ToSlash(dirPath[len(root)+1:])
+		annDirs[pkgPath] = append(annDirs[pkgPath], dirPath)
+		return nil
+	})
 }
 
 func readSgovendorDir(dirPath string) (*annotations.Annotation, error) {
@@ -0,0 +1,49 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/parser"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+const sharedDepsSrc = `package p
+
+import (
+	"maps"
+	"slices"
+)
+
+func Keys(m map[string]int) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+`
+
+// TestImportSharedDependencies checks that a package imported by several
+// others, here iter by maps and slices, is converted once, so that generic
+// types from it are the same wherever they come from.
+func TestImportSharedDependencies(t *testing.T) {
+	for _, mode := range []struct {
+		name string
+		from func([]*ast.File, string) (types.Importer, error)
+	}{
+		{"source", DefaultFrom},
+		{"compiled", CompiledFrom},
+	} {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.sgo", sharedDepsSrc, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		imp, err := mode.from([]*ast.File{f}, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := &types.Config{Importer: imp}
+		if _, err := cfg.Check("p", fset, []*ast.File{f}, nil); err != nil {
+			t.Errorf("%s: %v", mode.name, err)
+		}
+	}
+}
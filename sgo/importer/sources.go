@@ -0,0 +1,228 @@
+package importer
+
+import (
+	"go/build"
+	"path/filepath"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+// A TypeSource tells where the SGo type of an imported object comes from.
+type TypeSource int
+
+const (
+	// UnknownSource is for objects of packages imported without their
+	// source, from compiled export data.
+	UnknownSource TypeSource = iota
+	// FromGo is for objects whose Go type is used as is.
+	FromGo
+	// FromOptional is for objects whose Go type is converted by default,
+	// wrapping it, or parts of it, in optionals.
+	FromOptional
+	// FromDocComment is for objects whose type is given by a "For SGo:" doc
+	// comment.
+	FromDocComment
+	// FromAnnotations is for objects whose type is given by the package's
+	// annotations, either built-in or from .sgoann files, or changed by
+	// their policies.
+	FromAnnotations
+	// FromSGo is for objects declared in SGo.
+	FromSGo
+)
+
+func (s TypeSource) String() string {
+	switch s {
+	case FromGo:
+		return "Go type"
+	case FromOptional:
+		return "automatic ? wrapping"
+	case FromDocComment:
+		return `"For SGo:" doc comment`
+	case FromAnnotations:
+		return "annotations"
+	case FromSGo:
+		return "SGo"
+	}
+	return "unknown"
+}
+
+// Sources tells where the SGo types of the objects declared by an imported
+// package come from.
+type Sources struct {
+	// Annotations is where the package's annotations come from, if it has
+	// any.
+	//
+	// For SGo: ?*AnnotationSource
+	Annotations *AnnotationSource
+
+	fromSGo bool
+	objs    map[types.Object]TypeSource
+}
+
+// Of returns where the type of obj, which must be declared by the package,
+// comes from.
+func (s *Sources) Of(obj types.Object) TypeSource {
+	if s.fromSGo {
+		return FromSGo
+	}
+	return s.objs[obj]
+}
+
+// ImportWithSources imports the package with the given import path, found
+// from srcDir, like DefaultFrom's importer does, and tells where the types of
+// its objects come from.
+//
+// For SGo: func(path, srcDir string) (*types.Package, *Sources \ error)
+func ImportWithSources(path, srcDir string) (*types.Package, *Sources, error) {
+	// Local import paths are resolved to full ones only from absolute
+	// directories.
+	srcDir, err := filepath.Abs(srcDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if buildPkg, err := build.Import(path, srcDir, build.FindOnly); err == nil {
+		path = buildPkg.ImportPath
+	}
+
+	imp, err := newImporter(map[string]struct{}{path: {}}, srcDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	imp.sources = map[types.Object]TypeSource{}
+	pkg, err := imp.ImportFrom(path, srcDir, types.ImportMode(build.ImportComment))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	srcs := &Sources{objs: imp.sources}
+	if _, ok := pkg.FromGo(); ok {
+		srcs.Annotations = imp.annotationSourceOf(path)
+	} else {
+		srcs.fromSGo = true
+	}
+	return pkg, srcs, nil
+}
+
+// recordSources records in sources where the types of the objects declared
+// in the converted file f come from, given the type expressions that were
+// replaced when converting it.
+func recordSources(sources map[types.Object]TypeSource, f *ast.File, defs map[*ast.Ident]types.Object, replaced map[ast.Expr]TypeSource) {
+	set := func(id *ast.Ident, src TypeSource) {
+		if obj := defs[id]; obj != nil {
+			sources[obj] = src
+		}
+	}
+	// fields records the fields or interface methods in list. If their
+	// struct or interface type was replaced as a whole, their source is
+	// that of the replacement.
+	fields := func(list *ast.FieldList, whole TypeSource) {
+		for _, f := range list.List {
+			src := whole
+			if src == FromGo {
+				src = exprSource(f.Type, replaced)
+			}
+			if len(f.Names) == 0 {
+				// Embedded fields are defined by their type's name.
+				if id := embeddedIdent(f.Type); id != nil {
+					set(id, src)
+				}
+			}
+			for _, id := range f.Names {
+				set(id, src)
+			}
+		}
+	}
+
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			src := exprSource(d.Type, replaced)
+			if _, ok := replaced[d.Type]; !ok && d.Recv != nil {
+				src = maxSource(src, exprSource(d.Recv, replaced))
+			}
+			set(d.Name, src)
+
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.ValueSpec:
+					src := FromGo
+					if s.Type != nil {
+						src = exprSource(s.Type, replaced)
+					}
+					for _, id := range s.Names.List {
+						set(id, src)
+					}
+				case *ast.TypeSpec:
+					set(s.Name, exprSource(s.Type, replaced))
+					whole := FromGo
+					if src, ok := replaced[s.Type]; ok {
+						whole = src
+					}
+					switch t := s.Type.(type) {
+					case *ast.StructType:
+						fields(t.Fields, whole)
+					case *ast.InterfaceType:
+						fields(t.Methods, whole)
+					}
+				}
+			}
+		}
+	}
+}
+
+// exprSource returns where the converted type expression, or field list, n
+// comes from. If different parts of it come from different sources,
+// annotations take precedence over doc comments, and those over the default
+// conversion.
+func exprSource(n ast.Node, replaced map[ast.Expr]TypeSource) TypeSource {
+	src := FromGo
+	ast.Inspect(n, func(n ast.Node) bool {
+		if e, ok := n.(ast.Expr); ok {
+			if r, ok := replaced[e]; ok {
+				src = maxSource(src, r)
+				return false
+			}
+		}
+		switch n := n.(type) {
+		case *ast.OptionalType:
+			// Optionals written in Go source can only come from the
+			// default conversion.
+			if !n.Mark.IsValid() {
+				src = maxSource(src, FromOptional)
+			}
+		case *ast.FieldList:
+			// Likewise, entangled results come from the errors-entangled
+			// policy.
+			if n.Entangled != nil {
+				src = maxSource(src, FromAnnotations)
+			}
+		}
+		return true
+	})
+	return src
+}
+
+func maxSource(a, b TypeSource) TypeSource {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// embeddedIdent returns the identifier of the type name embedded by the type
+// expression typ, or nil.
+func embeddedIdent(typ ast.Expr) *ast.Ident {
+	switch t := typ.(type) {
+	case *ast.Ident:
+		return t
+	case *ast.StarExpr:
+		return embeddedIdent(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel
+	case *ast.OptionalType:
+		return embeddedIdent(t.Elt)
+	}
+	return nil
+}
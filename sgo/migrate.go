@@ -0,0 +1,570 @@
+package sgo
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/constant"
+	"github.com/tcard/sgo/sgo/format"
+	"github.com/tcard/sgo/sgo/importer"
+	"github.com/tcard/sgo/sgo/importpaths"
+	"github.com/tcard/sgo/sgo/parser"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+// MigratePaths migrates the Go packages from the given import paths to SGo.
+// It returns the paths to the created SGo files.
+//
+// For SGo: func(paths []string) (created []string, warnings []error, errs []error)
+func MigratePaths(paths []string) (created []string, warnings []error, errs []error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		errs = append(errs, err)
+		return
+	}
+
+	paths, warnings = importpaths.ImportPaths(paths)
+	for _, path := range paths {
+		pkg, err := build.Default.Import(path, cwd, build.FindOnly|build.IgnoreVendor)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		migCreated, migWarnings, migErrs := MigrateDir(pkg.Dir)
+		created = append(created, migCreated...)
+		warnings = append(warnings, migWarnings...)
+		errs = append(errs, migErrs...)
+	}
+	return created, warnings, errs
+}
+
+// MigrateDir migrates the Go package in the given directory to SGo. For each
+// Go file that isn't translated from SGo already, it creates an SGo file with
+// the same name and the .sgo extension. As the next translation overwrites the
+// Go files, each of them is first copied to a backup file with the .go.orig
+// extension, and the package isn't migrated if a backup file exists already.
+// It returns the paths to the created SGo files.
+//
+// The migration:
+//
+//   - Makes the functions that return (T, error) return (T \ error) instead,
+//     if they return either a nil error or zero values and an error, and
+//     their callers in the package check the error right away.
+//   - Makes all declared types that can be nil optional, like importing a Go
+//     package without annotations does.
+//   - Makes each of those types non-optional again if that doesn't cause type
+//     errors in new places, until there's no more of them to change.
+//
+// The SGo type errors left in the package are returned as warnings.
+//
+// For SGo: func(dirName string) (created []string, warnings []error, errs []error)
+func MigrateDir(dirName string) (created []string, warnings []error, errs []error) {
+	buildPkg, err := build.ImportDir(dirName, 0)
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+
+	m := &migration{
+		fset: token.NewFileSet(),
+		path: buildPkg.ImportPath,
+	}
+
+	sgoNames, err := filepath.Glob(filepath.Join(dirName, "*.sgo"))
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+	for _, path := range sgoNames {
+		if strings.HasSuffix(path, "_test.sgo") {
+			continue
+		}
+		file, err := parser.ParseFile(m.fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, nil, []error{err}
+		}
+		m.files = append(m.files, file)
+	}
+
+	// Go files are parsed with the name of the SGo files they become, so
+	// that errors point there.
+	var paths []string
+	var srcs [][]byte
+	var backups []string
+	for _, name := range buildPkg.GoFiles {
+		path := filepath.Join(dirName, name[:len(name)-len(".go")]+".sgo")
+		if _, err := os.Stat(path); err == nil {
+			// Translated from SGo.
+			continue
+		}
+		backup := filepath.Join(dirName, name+".orig")
+		if _, err := os.Stat(backup); err == nil {
+			return nil, nil, []error{fmt.Errorf("%s: backup file exists already", backup)}
+		}
+		src, err := ioutil.ReadFile(filepath.Join(dirName, name))
+		if err != nil {
+			return nil, nil, []error{err}
+		}
+		file, err := parser.ParseFile(m.fset, path, src, parser.ParseComments)
+		if err != nil {
+			return nil, nil, []error{err}
+		}
+		m.files = append(m.files, file)
+		m.migrated = append(m.migrated, file)
+		paths = append(paths, path)
+		srcs = append(srcs, src)
+		backups = append(backups, backup)
+	}
+	if len(m.migrated) == 0 {
+		return nil, []error{fmt.Errorf("%s: no Go files to migrate", dirName)}, nil
+	}
+
+	m.imp, err = importer.DefaultFrom(m.files, dirName)
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+
+	info, typeErrs := m.check()
+	typeErrs = m.entangleErrors(info, typeErrs)
+	for _, file := range m.migrated {
+		importer.ConvertAST(file, info, nil)
+		convertLocalDecls(file, info)
+	}
+	_, typeErrs = m.check()
+	typeErrs = m.removeOptionals(typeErrs)
+	if len(typeErrs) > 0 {
+		warnings = append(warnings, makeErrList(m.fset, typeErrs))
+	}
+
+	for i, file := range m.migrated {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, m.fset, file); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := writeBackup(backups[i], srcs[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := ioutil.WriteFile(paths[i], buf.Bytes(), 0666); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		created = append(created, paths[i])
+	}
+	return created, warnings, errs
+}
+
+// writeBackup writes src to the backup file path, unless it exists already.
+func writeBackup(path string, src []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(src)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// A migration holds the state of the migration of a package.
+type migration struct {
+	fset     *token.FileSet
+	path     string
+	imp      types.Importer
+	files    []*ast.File // all files in the package
+	migrated []*ast.File // the files being migrated
+}
+
+// check type-checks the package as it is now. It returns the resulting Info,
+// and the type errors found.
+func (m *migration) check() (*types.Info, []error) {
+	var errs []error
+	cfg := &types.Config{
+		Error: func(err error) {
+			errs = append(errs, err)
+		},
+		Importer: m.imp,
+	}
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Defs:  map[*ast.Ident]types.Object{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+	cfg.Check(m.path, m.fset, m.files, info)
+	return info, errs
+}
+
+// try applies a change to the package, and keeps it if it doesn't cause type
+// errors in places where there were none. It returns the type errors of the
+// package after the attempt.
+func (m *migration) try(errs []error, apply, undo func()) (newErrs []error, ok bool) {
+	apply()
+	_, newErrs = m.check()
+	if hasNewErrors(errs, newErrs) {
+		undo()
+		return errs, false
+	}
+	return newErrs, true
+}
+
+// hasNewErrors reports whether errs has more errors than base at some
+// position.
+func hasNewErrors(base, errs []error) bool {
+	count := map[token.Pos]int{}
+	for _, err := range base {
+		count[errorPos(err)]++
+	}
+	for _, err := range errs {
+		pos := errorPos(err)
+		if count[pos] == 0 {
+			return true
+		}
+		count[pos]--
+	}
+	return false
+}
+
+func errorPos(err error) token.Pos {
+	if err, ok := err.(types.Error); ok {
+		return err.Pos
+	}
+	return token.NoPos
+}
+
+// convertLocalDecls makes the types of the variables and types declared in
+// function bodies optional, as importer.ConvertAST does for package-level
+// declarations.
+func convertLocalDecls(file *ast.File, info *types.Info) {
+	ast.Inspect(file, func(node ast.Node) bool {
+		if decl, ok := node.(*ast.DeclStmt); ok {
+			importer.ConvertAST(&ast.File{Name: file.Name, Decls: []ast.Decl{decl.Decl}}, info, nil)
+		}
+		return true
+	})
+}
+
+// removeOptionals makes non-optional the optional types added by the
+// migration that don't need to be optional, trying them one by one until
+// none can be changed. It returns the type errors left in the package.
+func (m *migration) removeOptionals(errs []error) []error {
+	var slots []optionalSlot
+	for _, file := range m.migrated {
+		slots = append(slots, addedOptionals(file)...)
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := range slots {
+			s := &slots[i]
+			if s.removed {
+				continue
+			}
+			var ok bool
+			errs, ok = m.try(errs, func() { s.set(s.opt.Elt) }, func() { s.set(s.opt) })
+			if ok {
+				s.removed = true
+				changed = true
+			}
+		}
+	}
+	return errs
+}
+
+// An optionalSlot is a place in the AST with an optional type.
+type optionalSlot struct {
+	opt     *ast.OptionalType
+	set     func(ast.Expr)
+	removed bool
+}
+
+// addedOptionals returns the places in file with optional types added by the
+// migration, which, unlike those written in the source, have no position.
+func addedOptionals(file *ast.File) []optionalSlot {
+	var slots []optionalSlot
+	add := func(e ast.Expr, set func(ast.Expr)) {
+		if opt, ok := e.(*ast.OptionalType); ok && !opt.Mark.IsValid() {
+			slots = append(slots, optionalSlot{opt: opt, set: set})
+		}
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Field:
+			add(n.Type, func(e ast.Expr) { n.Type = e })
+		case *ast.ValueSpec:
+			add(n.Type, func(e ast.Expr) { n.Type = e })
+		case *ast.ArrayType:
+			add(n.Elt, func(e ast.Expr) { n.Elt = e })
+		case *ast.MapType:
+			add(n.Key, func(e ast.Expr) { n.Key = e })
+			add(n.Value, func(e ast.Expr) { n.Value = e })
+		case *ast.ChanType:
+			add(n.Value, func(e ast.Expr) { n.Value = e })
+		case *ast.StarExpr:
+			add(n.X, func(e ast.Expr) { n.X = e })
+		case *ast.IndexExpr:
+			add(n.Index, func(e ast.Expr) { n.Index = e })
+		case *ast.IndexListExpr:
+			for i := range n.Indices {
+				i := i
+				add(n.Indices[i], func(e ast.Expr) { n.Indices[i] = e })
+			}
+		}
+		return true
+	})
+	return slots
+}
+
+// entangleErrors makes the functions that return (T, error) in the idiomatic
+// way, and whose callers all check the error right away, return (T \ error)
+// instead. It returns the type errors of the package afterwards.
+func (m *migration) entangleErrors(info *types.Info, errs []error) []error {
+	// The calls in the package that check the error right away.
+	checked := map[*ast.Ident]*ast.ExprList{}
+	for _, file := range m.files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.BlockStmt:
+				checkedCalls(info, n.List, checked)
+			case *ast.CaseClause:
+				checkedCalls(info, n.Body, checked)
+			case *ast.CommClause:
+				checkedCalls(info, n.Body, checked)
+			case *ast.IfStmt:
+				if n.Init != nil {
+					checkedCalls(info, []ast.Stmt{n.Init, &ast.IfStmt{Cond: n.Cond, Body: n.Body, Else: n.Else}}, checked)
+				}
+			}
+			return true
+		})
+	}
+	unchecked := map[types.Object]bool{}
+	for id, obj := range info.Uses {
+		if _, ok := checked[id]; !ok {
+			unchecked[obj] = true
+		}
+	}
+
+	for _, file := range m.migrated {
+		for _, decl := range file.Decls {
+			fun, ok := decl.(*ast.FuncDecl)
+			if !ok || fun.Recv != nil || fun.Body == nil {
+				continue
+			}
+			obj := info.Defs[fun.Name]
+			if obj == nil || unchecked[obj] {
+				continue
+			}
+			var calls []*ast.ExprList
+			for id, lhs := range checked {
+				if info.Uses[id] == obj {
+					calls = append(calls, lhs)
+				}
+			}
+			apply, undo, ok := entangleFunc(info, fun, calls)
+			if !ok {
+				continue
+			}
+			errs, _ = m.try(errs, apply, undo)
+		}
+	}
+	return errs
+}
+
+// checkedCalls adds to checked the calls in stmts whose error result is
+// assigned to a variable that is checked against nil in the next statement,
+// which returns or panics if it isn't nil. The calls are keyed by the
+// identifier of the called function, and map to the left-hand side of the
+// assignment.
+func checkedCalls(info *types.Info, stmts []ast.Stmt, checked map[*ast.Ident]*ast.ExprList) {
+	for i := 0; i+1 < len(stmts); i++ {
+		assign, ok := stmts[i].(*ast.AssignStmt)
+		if !ok || assign.Lhs.Len() < 2 || assign.Lhs.EntangledPos != 0 || assign.Rhs.Len() != 1 {
+			continue
+		}
+		call, ok := assign.Rhs.List[0].(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		fun, ok := call.Fun.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		errID, ok := assign.Lhs.List[len(assign.Lhs.List)-1].(*ast.Ident)
+		if !ok || errID.Name == "_" {
+			continue
+		}
+		errVar := info.Defs[errID]
+		if errVar == nil {
+			errVar = info.Uses[errID]
+		}
+		ifStmt, ok := stmts[i+1].(*ast.IfStmt)
+		if !ok || ifStmt.Init != nil || ifStmt.Else != nil || !isNotNilCheck(info, ifStmt.Cond, errVar) || !exits(ifStmt.Body) {
+			continue
+		}
+		checked[fun] = assign.Lhs
+	}
+}
+
+// isNotNilCheck reports whether e is v != nil.
+func isNotNilCheck(info *types.Info, e ast.Expr, v types.Object) bool {
+	bin, ok := e.(*ast.BinaryExpr)
+	if !ok || bin.Op != token.NEQ {
+		return false
+	}
+	x, ok := bin.X.(*ast.Ident)
+	return ok && info.Uses[x] == v && info.Types[bin.Y].IsNil()
+}
+
+// exits reports whether body ends with a return statement or a call to panic.
+func exits(body *ast.BlockStmt) bool {
+	if len(body.List) == 0 {
+		return false
+	}
+	switch s := body.List[len(body.List)-1].(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		id, ok := call.Fun.(*ast.Ident)
+		return ok && id.Name == "panic"
+	}
+	return false
+}
+
+// entangleFunc returns functions to entangle the error result of fun, and to
+// undo that, and reports whether fun can be entangled: it must return
+// (T, error) with explicit return values, which are either a nil error or
+// zero values and an error. calls are the left-hand sides of the assignments
+// from calls to fun.
+func entangleFunc(info *types.Info, fun *ast.FuncDecl, calls []*ast.ExprList) (apply, undo func(), ok bool) {
+	results := fun.Type.Results
+	if results == nil || results.Entangled != nil || results.NumFields() < 2 {
+		return nil, nil, false
+	}
+	last := results.List[len(results.List)-1]
+	if id, ok := last.Type.(*ast.Ident); !ok || info.Uses[id] != types.Universe.Lookup("error") {
+		return nil, nil, false
+	}
+	n := results.NumFields()
+
+	var returns []*ast.ExprList
+	var entangled []ast.ExprList
+	ok = true
+	ast.Inspect(fun.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			r := node.Results
+			if r.Len() != n || r.EntangledPos != 0 {
+				ok = false
+				return false
+			}
+			errExpr := r.List[n-1]
+			vals := r.List[:n-1]
+			switch {
+			case info.Types[errExpr].IsNil():
+				entangled = append(entangled, ast.ExprList{List: vals, EntangledPos: n})
+			case allZero(info, vals):
+				entangled = append(entangled, ast.ExprList{List: []ast.Expr{errExpr}, EntangledPos: 1})
+			default:
+				ok = false
+				return false
+			}
+			returns = append(returns, r)
+		}
+		return ok
+	})
+	if !ok {
+		return nil, nil, false
+	}
+
+	origList := results.List
+	origReturns := make([]ast.ExprList, len(returns))
+	for i, r := range returns {
+		origReturns[i] = *r
+	}
+	apply = func() {
+		results.List = append([]*ast.Field(nil), origList...)
+		entangleResults(results)
+		for i, r := range returns {
+			*r = entangled[i]
+		}
+		for _, lhs := range calls {
+			lhs.EntangledPos = len(lhs.List)
+		}
+	}
+	undo = func() {
+		results.List = origList
+		results.Entangled = nil
+		for i, r := range returns {
+			*r = origReturns[i]
+		}
+		for _, lhs := range calls {
+			lhs.EntangledPos = 0
+		}
+	}
+	return apply, undo, true
+}
+
+// entangleResults makes the last result in results entangled with the
+// others.
+func entangleResults(results *ast.FieldList) {
+	last := results.List[len(results.List)-1]
+	results.List = results.List[:len(results.List)-1]
+	if len(last.Names) > 1 {
+		// (a, err error) becomes (a error \ err error).
+		results.List = append(results.List, &ast.Field{
+			Names: last.Names[:len(last.Names)-1],
+			Type:  last.Type,
+		})
+		last = &ast.Field{
+			Names: last.Names[len(last.Names)-1:],
+			Type:  last.Type,
+		}
+	}
+	results.Entangled = last
+}
+
+// allZero reports whether exprs are all zero values: nil, zero constants, or
+// empty composite literals.
+func allZero(info *types.Info, exprs []ast.Expr) bool {
+	for _, e := range exprs {
+		if lit, ok := e.(*ast.CompositeLit); ok && len(lit.Elts) == 0 {
+			continue
+		}
+		tv := info.Types[e]
+		if tv.IsNil() {
+			continue
+		}
+		if tv.Value == nil {
+			return false
+		}
+		switch tv.Value.Kind() {
+		case constant.Bool:
+			if constant.BoolVal(tv.Value) {
+				return false
+			}
+		case constant.String:
+			if constant.StringVal(tv.Value) != "" {
+				return false
+			}
+		case constant.Int, constant.Float, constant.Complex:
+			if constant.Sign(tv.Value) != 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
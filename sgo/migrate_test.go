@@ -0,0 +1,167 @@
+package sgo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const migrateSrc = `package p
+
+import "errors"
+
+type Node struct {
+	Value int
+	Next  *Node
+}
+
+func Find(n *Node, v int) (*Node, error) {
+	if n == nil {
+		return nil, errors.New("not found")
+	}
+	if n.Value == v {
+		return n, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func Value(n *Node, v int) (int, error) {
+	m, err := Find(n, v)
+	if err != nil {
+		return 0, err
+	}
+	return m.Value, nil
+}
+
+func Push(n *Node, v int) *Node {
+	return &Node{Value: v, Next: n}
+}
+
+func One() *Node {
+	var n *Node
+	return Push(n, 1)
+}
+
+func Zero(n *Node) (int, error) {
+	return Value(n, 0)
+}
+`
+
+const migrateWant = `package p
+
+import "errors"
+
+type Node struct {
+	Value int
+	Next  ?*Node
+}
+
+func Find(n ?*Node, v int) (*Node \ error) {
+	if n == nil {
+		return \ errors.New("not found")
+	}
+	if n.Value == v {
+		return n \
+	}
+	return \ errors.New("not found")
+}
+
+func Value(n *Node, v int) (int, ?error) {
+	m \ err := Find(n, v)
+	if err != nil {
+		return 0, err
+	}
+	return m.Value, nil
+}
+
+func Push(n ?*Node, v int) *Node {
+	return &Node{Value: v, Next: n}
+}
+
+func One() *Node {
+	var n ?*Node
+	return Push(n, 1)
+}
+
+func Zero(n *Node) (int, ?error) {
+	return Value(n, 0)
+}
+`
+
+func TestMigrateDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sgo-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "p.go"), []byte(migrateSrc), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	created, warnings, errs := MigrateDir(dir)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if want := filepath.Join(dir, "p.sgo"); len(created) != 1 || created[0] != want {
+		t.Fatalf("created: got %v, want [%s]", created, want)
+	}
+	got, err := ioutil.ReadFile(created[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != migrateWant {
+		t.Errorf("got:\n%s\nwant:\n%s", got, migrateWant)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("warnings: %v", warnings)
+	}
+	backup, err := ioutil.ReadFile(filepath.Join(dir, "p.go.orig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != migrateSrc {
+		t.Errorf("backup: got:\n%s\nwant:\n%s", backup, migrateSrc)
+	}
+
+	// Files translated from SGo aren't migrated again.
+	_, warnings, errs = MigrateDir(dir)
+	if len(errs) > 0 || len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "no Go files to migrate") {
+		t.Errorf("migrating again: got warnings %v, errors %v", warnings, errs)
+	}
+}
+
+func TestMigrateDirBackupExists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sgo-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, src := range map[string]string{
+		"p.go":      migrateSrc,
+		"p.go.orig": "older",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	created, _, errs := MigrateDir(dir)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "backup file exists already") {
+		t.Errorf("got errors %v, want one about the backup file", errs)
+	}
+	if len(created) > 0 {
+		t.Errorf("created %v", created)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "p.sgo")); err == nil {
+		t.Errorf("p.sgo was written")
+	}
+	backup, err := ioutil.ReadFile(filepath.Join(dir, "p.go.orig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != "older" {
+		t.Errorf("backup overwritten: %q", backup)
+	}
+}
@@ -261,6 +261,34 @@ func ParseExpr(x string) (ast.Expr, error) {
 	return ParseExprFrom(token.NewFileSet(), "", []byte(x), 0)
 }
 
+// ParseTypeParams parses a type parameter list, including its brackets.
+func ParseTypeParams(x string) (list *ast.FieldList, err error) {
+	var p *parser
+	var recoverer func(*error)
+	p, recoverer, err = prepareExprParser(token.NewFileSet(), "", []byte(x), 0)
+	if err != nil {
+		return nil, err
+	}
+	defer recoverer(&err)
+
+	p.openScope()
+	p.pkgScope = p.topScope
+	list = p.parseTypeParams(p.topScope, p.expect(token.LBRACK), nil)
+	p.closeScope()
+
+	if p.tok == token.SEMICOLON && p.lit == "\n" {
+		p.next()
+	}
+	p.expect(token.EOF)
+
+	if p.errors.Len() > 0 {
+		p.errors.Sort()
+		return nil, p.errors.Err()
+	}
+
+	return list, nil
+}
+
 // ParseMethodExprs parses a receiver ast.Expr and a FuncType.
 func ParseMethodExprs(x string) (fun *ast.FuncType, recv ast.Expr, err error) {
 	return ParseMethodExprsFrom(token.NewFileSet(), "", []byte(x), 0)
@@ -671,6 +671,42 @@ func (p *parser) parseTypeName() ast.Expr {
 	return ident
 }
 
+// parseTypeInstance parses the type arguments list that instantiates the
+// generic type typ.
+func (p *parser) parseTypeInstance(typ ast.Expr) ast.Expr {
+	if p.trace {
+		defer un(trace(p, "TypeInstance"))
+	}
+
+	lbrack := p.expect(token.LBRACK)
+	p.exprLev++
+	var list []ast.Expr
+	for p.tok != token.RBRACK && p.tok != token.EOF {
+		list = append(list, p.parseType())
+		if !p.atComma("type argument list", token.RBRACK) {
+			break
+		}
+		p.next()
+	}
+	p.exprLev--
+	rbrack := p.expectClosing(token.RBRACK, "type argument list")
+
+	if len(list) == 0 {
+		p.errorExpected(rbrack, "type argument list")
+		return &ast.IndexExpr{X: typ, Lbrack: lbrack, Index: &ast.BadExpr{From: lbrack + 1, To: rbrack}, Rbrack: rbrack}
+	}
+	return packIndexExpr(typ, lbrack, list, rbrack)
+}
+
+// packIndexExpr returns an IndexExpr for a single index, or an
+// IndexListExpr otherwise.
+func packIndexExpr(x ast.Expr, lbrack token.Pos, list []ast.Expr, rbrack token.Pos) ast.Expr {
+	if len(list) == 1 {
+		return &ast.IndexExpr{X: x, Lbrack: lbrack, Index: list[0], Rbrack: rbrack}
+	}
+	return &ast.IndexListExpr{X: x, Lbrack: lbrack, Indices: list, Rbrack: rbrack}
+}
+
 func (p *parser) parseArrayType() ast.Expr {
 	if p.trace {
 		defer un(trace(p, "ArrayType"))
@@ -693,6 +729,56 @@ func (p *parser) parseArrayType() ast.Expr {
 	return &ast.ArrayType{Lbrack: lbrack, Len: len, Elt: elt}
 }
 
+// parseArrayFieldOrTypeInstance parses what follows name in a field or
+// parameter list when it's followed by "[". That's either the array or slice
+// type of a field or parameter called name, in which case name is returned
+// with the type, or the instantiation of a generic type called name, which is
+// then returned alone.
+func (p *parser) parseArrayFieldOrTypeInstance(name *ast.Ident) (*ast.Ident, ast.Expr) {
+	if p.trace {
+		defer un(trace(p, "ArrayFieldOrTypeInstance"))
+	}
+
+	lbrack := p.expect(token.LBRACK)
+	var args []ast.Expr
+	if p.tok == token.ELLIPSIS {
+		// name [...]T
+		args = append(args, &ast.Ellipsis{Ellipsis: p.pos})
+		p.next()
+	} else if p.tok != token.RBRACK {
+		p.exprLev++
+		for p.tok != token.RBRACK && p.tok != token.EOF {
+			args = append(args, p.parseRhsOrType())
+			if !p.atComma("type argument list", token.RBRACK) {
+				break
+			}
+			p.next()
+		}
+		p.exprLev--
+	}
+	rbrack := p.expect(token.RBRACK)
+
+	if len(args) == 0 {
+		// name []T
+		elt := p.parseType()
+		return name, &ast.ArrayType{Lbrack: lbrack, Elt: elt}
+	}
+
+	if len(args) == 1 {
+		if elt := p.tryType(); elt != nil {
+			// name [N]T
+			return name, &ast.ArrayType{Lbrack: lbrack, Len: args[0], Elt: elt}
+		}
+		if _, ok := args[0].(*ast.Ellipsis); ok {
+			p.errorExpected(p.pos, "array element type")
+		}
+	}
+
+	// name[T1, T2, ...]
+	p.resolve(name)
+	return nil, packIndexExpr(name, lbrack, args, rbrack)
+}
+
 func (p *parser) makeIdentList(list *ast.ExprList) []*ast.Ident {
 	idents := make([]*ast.Ident, len(list.List))
 	for i, x := range list.List {
@@ -719,15 +805,20 @@ func (p *parser) parseFieldDecl(scope *ast.Scope) *ast.Field {
 	// 1st FieldDecl
 	// A type name used as an anonymous field looks like a field identifier.
 	list := ast.NewExprList()
+	var typ ast.Expr
 	for {
-		list.List = append(list.List, p.parseVarType(false))
-		if p.tok != token.COMMA {
+		var x ast.Expr
+		x, typ = p.parseVarTypeOrField(false)
+		list.List = append(list.List, x)
+		if typ != nil || p.tok != token.COMMA {
 			break
 		}
 		p.next()
 	}
 
-	typ := p.tryVarType(false)
+	if typ == nil {
+		typ = p.tryVarType(false)
+	}
 
 	// analyze case
 	var idents []*ast.Ident
@@ -740,7 +831,7 @@ func (p *parser) parseFieldDecl(scope *ast.Scope) *ast.Field {
 		if n := len(list.List); n > 1 {
 			p.errorExpected(p.pos, "type")
 			typ = &ast.BadExpr{From: p.pos, To: p.pos}
-		} else if !isTypeName(deref(deopt(typ))) {
+		} else if t := deref(deopt(typ)); !isTypeName(t) && !isTypeInstance(t) {
 			p.errorExpected(typ.Pos(), "anonymous field")
 			typ = &ast.BadExpr{From: typ.Pos(), To: p.safePos(typ.End())}
 		}
@@ -840,6 +931,29 @@ func (p *parser) parseVarType(isParam bool) ast.Expr {
 	return typ
 }
 
+// parseVarTypeOrField parses an element of a field or parameter list, which
+// may be a name or a type. If it's a name followed by its array or slice type,
+// both are returned; "[" after a name may also start the instantiation of a
+// generic type, which is returned as x.
+// If x is an identifier, it is not resolved.
+func (p *parser) parseVarTypeOrField(isParam bool) (x, typ ast.Expr) {
+	if p.tok != token.IDENT {
+		return p.parseVarType(isParam), nil
+	}
+	x = p.parseTypeName()
+	if p.tok != token.LBRACK {
+		return x, nil
+	}
+	if name, isIdent := x.(*ast.Ident); isIdent {
+		name, typ := p.parseArrayFieldOrTypeInstance(name)
+		if name == nil {
+			return typ, nil
+		}
+		return name, typ
+	}
+	return p.parseTypeInstance(x), nil
+}
+
 func (p *parser) parseParameterList(scope *ast.Scope, ellipsisOk bool) (params []*ast.Field) {
 	if p.trace {
 		defer un(trace(p, "ParameterList"))
@@ -848,8 +962,14 @@ func (p *parser) parseParameterList(scope *ast.Scope, ellipsisOk bool) (params [
 	// 1st ParameterDecl
 	// A list of identifiers looks like a list of type names.
 	var list = ast.NewExprList()
+	var typ ast.Expr
 	for {
-		list.List = append(list.List, p.parseVarType(ellipsisOk))
+		var x ast.Expr
+		x, typ = p.parseVarTypeOrField(ellipsisOk)
+		list.List = append(list.List, x)
+		if typ != nil {
+			break
+		}
 		if p.tok != token.COMMA {
 			if list.EntangledPos == 0 && p.tok == token.BACKSL {
 				list.EntangledPos = len(list.List) + 1
@@ -863,7 +983,10 @@ func (p *parser) parseParameterList(scope *ast.Scope, ellipsisOk bool) (params [
 	}
 
 	// analyze case
-	if typ := p.tryVarType(ellipsisOk); typ != nil {
+	if typ == nil {
+		typ = p.tryVarType(ellipsisOk)
+	}
+	if typ != nil {
 		// IdentifierList Type
 		idents := p.makeIdentList(list)
 		field := &ast.Field{Names: idents, Type: typ}
@@ -996,17 +1119,27 @@ func (p *parser) parseMethodSpec(scope *ast.Scope) *ast.Field {
 	doc := p.leadComment
 	var idents []*ast.Ident
 	var typ ast.Expr
-	x := p.parseTypeName()
-	if ident, isIdent := x.(*ast.Ident); isIdent && p.tok == token.LPAREN {
-		// method
-		idents = []*ast.Ident{ident}
-		scope := ast.NewScope(nil) // method scope
-		params, results := p.parseSignature(scope)
-		typ = &ast.FuncType{Func: token.NoPos, Params: params, Results: results}
+	if p.tok != token.IDENT {
+		// embedded type constraint
+		typ = p.parseConstraint(nil)
+	} else if x := p.parseTypeName(); p.tok == token.LPAREN {
+		if ident, isIdent := x.(*ast.Ident); isIdent {
+			// method
+			idents = []*ast.Ident{ident}
+			scope := ast.NewScope(nil) // method scope
+			params, results := p.parseSignature(scope)
+			typ = &ast.FuncType{Func: token.NoPos, Params: params, Results: results}
+		} else {
+			p.errorExpected(p.pos, "method name")
+			typ = &ast.BadExpr{From: x.Pos(), To: p.pos}
+		}
 	} else {
-		// embedded interface
-		typ = x
-		p.resolve(typ)
+		// embedded interface or type constraint
+		p.resolve(x)
+		if p.tok == token.LBRACK {
+			x = p.parseTypeInstance(x)
+		}
+		typ = p.parseConstraint(x)
 	}
 	p.expectSemi() // call before accessing p.linecomment
 
@@ -1025,7 +1158,7 @@ func (p *parser) parseInterfaceType() *ast.InterfaceType {
 	lbrace := p.expect(token.LBRACE)
 	scope := ast.NewScope(nil) // interface scope
 	var list []*ast.Field
-	for p.tok == token.IDENT {
+	for p.tok != token.RBRACE && p.tok != token.EOF {
 		list = append(list, p.parseMethodSpec(scope))
 	}
 	rbrace := p.expect(token.RBRACE)
@@ -1040,6 +1173,81 @@ func (p *parser) parseInterfaceType() *ast.InterfaceType {
 	}
 }
 
+// parseConstraint parses a type constraint, which is a union of one or more
+// types, each of them optionally prefixed by "~" to stand for all types with
+// it as underlying type. If x isn't nil, it's the already parsed first type.
+func (p *parser) parseConstraint(x ast.Expr) ast.Expr {
+	if p.trace {
+		defer un(trace(p, "Constraint"))
+	}
+
+	if x == nil {
+		x = p.parseConstraintTerm()
+	}
+	for p.tok == token.OR {
+		pos := p.pos
+		p.next()
+		y := p.parseConstraintTerm()
+		x = &ast.BinaryExpr{X: x, OpPos: pos, Op: token.OR, Y: y}
+	}
+	return x
+}
+
+func (p *parser) parseConstraintTerm() ast.Expr {
+	if p.tok == token.TILDE {
+		pos := p.pos
+		p.next()
+		return &ast.UnaryExpr{OpPos: pos, Op: token.TILDE, X: p.parseType()}
+	}
+	return p.parseType()
+}
+
+// parseTypeParams parses a type parameter list, whose opening "[" is at
+// lbrack, and has already been consumed. If name isn't nil, it's the already
+// parsed name of the first type parameter.
+func (p *parser) parseTypeParams(scope *ast.Scope, lbrack token.Pos, name *ast.Ident) *ast.FieldList {
+	if p.trace {
+		defer un(trace(p, "TypeParams"))
+	}
+
+	p.exprLev++
+	var list []*ast.Field
+	for p.tok != token.RBRACK && p.tok != token.EOF {
+		var idents []*ast.Ident
+		if name != nil {
+			idents = append(idents, name)
+			name = nil
+		} else {
+			idents = append(idents, p.parseIdent())
+		}
+		for p.tok == token.COMMA {
+			p.next()
+			idents = append(idents, p.parseIdent())
+		}
+		var constraint ast.Expr
+		if p.tok == token.RBRACK {
+			p.error(p.pos, "missing type constraint")
+			constraint = &ast.BadExpr{From: p.pos, To: p.pos}
+		} else {
+			constraint = p.parseConstraint(nil)
+		}
+		field := &ast.Field{Names: idents, Type: constraint}
+		p.declare(field, nil, scope, ast.Typ, idents...)
+		list = append(list, field)
+		if !p.atComma("type parameter list", token.RBRACK) {
+			break
+		}
+		p.next()
+	}
+	p.exprLev--
+	rbrack := p.expectClosing(token.RBRACK, "type parameter list")
+
+	if len(list) == 0 {
+		p.error(rbrack, "empty type parameter list")
+	}
+	return &ast.FieldList{Opening: lbrack, List: list, Closing: rbrack}
+}
+
 func (p *parser) parseMapType() *ast.MapType {
 	if p.trace {
 		defer un(trace(p, "MapType"))
@@ -1083,7 +1291,12 @@ func (p *parser) parseChanType() *ast.ChanType {
 func (p *parser) tryIdentOrType() ast.Expr {
 	switch p.tok {
 	case token.IDENT:
-		return p.parseTypeName()
+		typ := p.parseTypeName()
+		if p.tok == token.LBRACK {
+			p.resolve(typ)
+			typ = p.parseTypeInstance(typ)
+		}
+		return typ
 	case token.LBRACK:
 		return p.parseArrayType()
 	case token.STRUCT:
@@ -1283,7 +1496,22 @@ func (p *parser) parseIndexOrSlice(x ast.Expr) ast.Expr {
 	var index [N]ast.Expr
 	var colons [N - 1]token.Pos
 	if p.tok != token.COLON {
-		index[0] = p.parseRhs()
+		// may be a type argument: f[[]int]
+		index[0] = p.parseRhsOrType()
+	}
+	if p.tok == token.COMMA {
+		// instantiation with multiple type arguments: f[int, string]
+		list := []ast.Expr{index[0]}
+		for p.tok == token.COMMA {
+			p.next()
+			if p.tok == token.RBRACK {
+				break
+			}
+			list = append(list, p.parseType())
+		}
+		p.exprLev--
+		rbrack := p.expectClosing(token.RBRACK, "type argument list")
+		return packIndexExpr(x, lbrack, list, rbrack)
 	}
 	ncolons := 0
 	for p.tok == token.COLON && ncolons < len(colons) {
@@ -1447,6 +1675,7 @@ func (p *parser) checkExpr(x ast.Expr) ast.Expr {
 	case *ast.SelectorExpr:
 	case *ast.ForceExpr:
 	case *ast.IndexExpr:
+	case *ast.IndexListExpr:
 	case *ast.SliceExpr:
 	case *ast.TypeAssertExpr:
 		// If t.Type == nil we have a type assertion of the form
@@ -1473,13 +1702,24 @@ func isTypeName(x ast.Expr) bool {
 	case *ast.Ident:
 	case *ast.SelectorExpr:
 		_, isIdent := t.X.(*ast.Ident)
-		return isIdent
+		return isIdent && !t.Quest.IsValid()
 	default:
 		return false // all other nodes are not type names
 	}
 	return true
 }
 
+// isTypeInstance reports whether x may be an instantiated generic type name.
+func isTypeInstance(x ast.Expr) bool {
+	switch t := x.(type) {
+	case *ast.IndexExpr:
+		return isTypeName(t.X)
+	case *ast.IndexListExpr:
+		return isTypeName(t.X)
+	}
+	return false
+}
+
 // isLiteralType reports whether x is a legal composite literal type.
 func isLiteralType(x ast.Expr) bool {
 	switch t := x.(type) {
@@ -1487,7 +1727,9 @@ func isLiteralType(x ast.Expr) bool {
 	case *ast.Ident:
 	case *ast.SelectorExpr:
 		_, isIdent := t.X.(*ast.Ident)
-		return isIdent
+		return isIdent && !t.Quest.IsValid()
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		return isTypeInstance(t)
 	case *ast.ArrayType:
 	case *ast.StructType:
 	case *ast.MapType:
@@ -1546,7 +1788,12 @@ func (p *parser) parsePrimaryExpr(lhs bool) ast.Expr {
 		defer un(trace(p, "PrimaryExpr"))
 	}
 
-	x := p.parseOperand(lhs)
+	return p.parsePrimaryExprFrom(p.parseOperand(lhs), lhs)
+}
+
+// parsePrimaryExprFrom parses the rest of a primary expression whose operand
+// x has already been parsed.
+func (p *parser) parsePrimaryExprFrom(x ast.Expr, lhs bool) ast.Expr {
 L:
 	for {
 		switch p.tok {
@@ -1567,6 +1814,14 @@ L:
 				sel := &ast.Ident{NamePos: pos, Name: "_"}
 				x = &ast.SelectorExpr{X: x, Sel: sel}
 			}
+		case token.QUEST_PERIOD:
+			quest := p.pos
+			p.next()
+			if lhs {
+				p.resolve(x)
+			}
+			x = p.parseSelector(p.checkExpr(x))
+			x.(*ast.SelectorExpr).Quest = quest
 		case token.LBRACK:
 			if lhs {
 				p.resolve(x)
@@ -1578,7 +1833,7 @@ L:
 			}
 			x = p.parseCallOrConversion(p.checkExprOrType(x))
 		case token.LBRACE:
-			if isLiteralType(x) && (p.exprLev >= 0 || !isTypeName(x)) {
+			if isLiteralType(x) && (p.exprLev >= 0 || !isTypeName(x) && !isTypeInstance(x)) {
 				if lhs {
 					p.resolve(x)
 				}
@@ -1681,7 +1936,12 @@ func (p *parser) parseBinaryExpr(lhs bool, prec1 int) ast.Expr {
 		defer un(trace(p, "BinaryExpr"))
 	}
 
-	x := p.parseUnaryExpr(lhs)
+	return p.parseBinaryExprFrom(p.parseUnaryExpr(lhs), lhs, prec1)
+}
+
+// parseBinaryExprFrom parses the rest of a binary expression whose first
+// operand x has already been parsed.
+func (p *parser) parseBinaryExprFrom(x ast.Expr, lhs bool, prec1 int) ast.Expr {
 	for {
 		op, oprec := p.tokPrec()
 		if oprec < prec1 {
@@ -2426,11 +2686,52 @@ func (p *parser) parseTypeSpec(doc *ast.CommentGroup, _ token.Token, _ int) ast.
 	// (Global identifiers are resolved in a separate phase after parsing.)
 	spec := &ast.TypeSpec{Doc: doc, Name: ident}
 	p.declare(spec, nil, p.topScope, ast.Typ, ident)
-	if p.tok == token.ASSIGN {
-		spec.Assign = p.pos
+	if p.tok == token.LBRACK {
+		// Either a type parameter list or an array type. If what follows
+		// "[" is a name followed by something that can start a
+		// constraint, it's the former.
+		lbrack := p.pos
 		p.next()
+		if p.tok == token.IDENT {
+			name := p.parseIdent()
+			switch p.tok {
+			case token.IDENT, token.COMMA, token.TILDE, token.QUEST, token.LBRACK,
+				token.INTERFACE, token.FUNC, token.MAP, token.CHAN, token.ARROW, token.STRUCT:
+				spec.TypeParams = p.parseTypeParams(ast.NewScope(p.topScope), lbrack, name)
+				if p.tok == token.ASSIGN {
+					p.error(p.pos, "generic type cannot be an alias")
+					p.next()
+				}
+				spec.Type = p.parseType()
+			default:
+				// array length expression starting with name
+				p.resolve(name)
+				p.exprLev++
+				x := p.parseBinaryExprFrom(p.parsePrimaryExprFrom(name, false), false, token.LowestPrec+1)
+				p.exprLev--
+				p.expect(token.RBRACK)
+				spec.Type = &ast.ArrayType{Lbrack: lbrack, Len: x, Elt: p.parseType()}
+			}
+		} else {
+			p.exprLev++
+			var len ast.Expr
+			if p.tok == token.ELLIPSIS {
+				len = &ast.Ellipsis{Ellipsis: p.pos}
+				p.next()
+			} else if p.tok != token.RBRACK {
+				len = p.parseRhs()
+			}
+			p.exprLev--
+			p.expect(token.RBRACK)
+			spec.Type = &ast.ArrayType{Lbrack: lbrack, Len: len, Elt: p.parseType()}
+		}
+	} else {
+		if p.tok == token.ASSIGN {
+			spec.Assign = p.pos
+			p.next()
+		}
+		spec.Type = p.parseType()
 	}
-	spec.Type = p.parseType()
 	p.expectSemi() // call before accessing p.linecomment
 	spec.Comment = p.lineComment
 
@@ -2484,6 +2785,14 @@ func (p *parser) parseFuncDecl() *ast.FuncDecl {
 
 	ident := p.parseIdent()
 
+	var tparams *ast.FieldList
+	if p.tok == token.LBRACK {
+		tparams = p.parseTypeParams(scope, p.expect(token.LBRACK), nil)
+		if recv != nil {
+			p.error(tparams.Opening, "method must have no type parameters")
+		}
+	}
+
 	params, results := p.parseSignature(scope)
 
 	var body *ast.BlockStmt
@@ -2497,9 +2806,10 @@ func (p *parser) parseFuncDecl() *ast.FuncDecl {
 		Recv: recv,
 		Name: ident,
 		Type: &ast.FuncType{
-			Func:    pos,
-			Params:  params,
-			Results: results,
+			Func:       pos,
+			TypeParams: tparams,
+			Params:     params,
+			Results:    results,
 		},
 		Body: body,
 	}
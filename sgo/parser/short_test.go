@@ -48,6 +48,25 @@ var valids = []string{
 	`package p; var _ = map[*P]int{&P{}:0, {}:1}`,
 	`package p; type T = int`,
 	`package p; type (T = p.T; _ = struct{}; x = *T)`,
+	`package p; type (M = ?*T; N = ?map[K]?*T)`,
+	`package p; func _() { for range 10 {}; for i := range n {} }`,
+	`package p; func _() { for k, v := range seq {} }`,
+	`package p; const (_ = 0b1011; _ = 0o660; _ = 1_000_000; _ = 0x1p-2; _ = 0x_1.8p+1i)`,
+	`package p; var _ = a?.b?.c; var _ = f()?.b; var _ = (a?.b)?.c`,
+	`package p; var _ = a ?? b ?? c; var _ = a ?? b == c; var _ = f(a ?? b, c ?? d+e)`,
+	`package p; type T[P any] struct { next *T[P]; v P }`,
+	`package p; type T[K comparable, V any] map[K]V`,
+	`package p; type T[P interface{ ~int | ~string }] []P`,
+	`package p; type T[P ?C, Q []P] struct{}`,
+	`package p; type T [N]int; type U [N * 2]int; type V [len(x)]int`,
+	`package p; type N interface { ~int | ~float64; String() string; comparable }`,
+	`package p; type T struct { a [N]int; b []int; List[int]; *q.T[P] }`,
+	`package p; func f[P any, Q optionable](x P, y ?Q) (P \ bool) {}`,
+	`package p; func f[S ~[]E, E any](s S) {}`,
+	`package p; func (l *List[T]) Push(x T) {}`,
+	`package p; func (m Map[K, V]) f(a [2]K, b List[V]) {}`,
+	`package p; var _ = f[int]; var _ = f[int, string](x); var _ = T[[]int]{}`,
+	`package p; func _() { if T[int] == nil {} }`,
 }
 
 func TestValid(t *testing.T) {
@@ -79,7 +98,10 @@ var invalids = []string{
 	`package p; var a = chan /* ERROR "expected expression" */ int;`,
 	`package p; var a = []int{[ /* ERROR "expected expression" */ ]int};`,
 	`package p; var a = ( /* ERROR "expected expression" */ []int);`,
-	`package p; var a = a[[ /* ERROR "expected expression" */ ]int:[]int];`,
+	`package p; var a = a[[]int:[ /* ERROR "expected expression" */ ]int];`,
+	`package p; func f[] /* ERROR "empty type parameter list" */ () {}`,
+	`package p; func f[P] /* ERROR "missing type constraint" */ () {}`,
+	`package p; func (T) m[ /* ERROR "method must have no type parameters" */ P any]() {}`,
 	`package p; var a = <- /* ERROR "expected expression" */ chan int;`,
 	`package p; func f() { select { case _ <- chan /* ERROR "expected expression" */ int: } };`,
 	`package p; func f() { _ = (<-<- /* ERROR "expected 'chan'" */ chan int)(nil) };`,
@@ -127,6 +149,12 @@ var invalids = []string{
 	// issue 13475
 	`package p; func f() { if true {} else ; /* ERROR "expected if statement or block" */ }`,
 	`package p; func f() { if true {} else defer /* ERROR "expected if statement or block" */ f() }`,
+
+	// safe navigation selectors
+	`package p; var _ = a?.( /* ERROR "expected 'IDENT', found '\('" */ T)`,
+
+	// nil-coalescing expressions
+	`package p; var _ = a ?? ; /* ERROR "expected operand" */`,
 }
 
 func TestInvalid(t *testing.T) {
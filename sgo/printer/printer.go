@@ -1274,6 +1274,16 @@ const (
 	SourcePos                  // emit //line directives to preserve original source positions
 )
 
+// The mode below is not included in printer's public API because
+// editing code text is deemed out of scope.
+const (
+	// normalizeNumbers means to canonicalize number
+	// literal prefixes and exponents while printing.
+	//
+	// This value is known in and used by sgo/format and sgofmt.
+	normalizeNumbers Mode = 1 << 30
+)
+
 // A Config node controls the output of Fprint.
 type Config struct {
 	Mode     Mode // default: 0
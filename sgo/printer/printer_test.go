@@ -34,6 +34,7 @@ type checkMode uint
 const (
 	export checkMode = 1 << iota
 	rawFormat
+	normNumber
 	idempotent
 )
 
@@ -58,6 +59,9 @@ func format(src []byte, mode checkMode) ([]byte, error) {
 	if mode&rawFormat != 0 {
 		cfg.Mode |= RawFormat
 	}
+	if mode&normNumber != 0 {
+		cfg.Mode |= normalizeNumbers
+	}
 
 	// print AST
 	var buf bytes.Buffer
@@ -195,6 +199,9 @@ var data = []entry{
 	{"declarations.input", "declarations.golden", 0},
 	{"statements.input", "statements.golden", 0},
 	{"slow.input", "slow.golden", idempotent},
+	{"generics.input", "generics.golden", idempotent},
+	{"numbers.input", "numbers.golden", idempotent},
+	{"numbers.input", "numbers.norm", normNumber | idempotent},
 }
 
 func TestFiles(t *testing.T) {
@@ -60,6 +60,10 @@ var tokens = [...]elt{
 	{token.INT, "123456789012345678890", literal},
 	{token.INT, "01234567", literal},
 	{token.INT, "0xcafebabe", literal},
+	{token.INT, "0b1011", literal},
+	{token.INT, "0o660", literal},
+	{token.INT, "1_000_000", literal},
+	{token.INT, "0x_67_7a_2f", literal},
 	{token.FLOAT, "0.", literal},
 	{token.FLOAT, ".0", literal},
 	{token.FLOAT, "3.14159265", literal},
@@ -67,6 +71,9 @@ var tokens = [...]elt{
 	{token.FLOAT, "1e+100", literal},
 	{token.FLOAT, "1e-100", literal},
 	{token.FLOAT, "2.71828e-1000", literal},
+	{token.FLOAT, "0x1p-2", literal},
+	{token.FLOAT, "0x1.8p+1", literal},
+	{token.FLOAT, "0X.8p0", literal},
 	{token.IMAG, "0i", literal},
 	{token.IMAG, "1i", literal},
 	{token.IMAG, "012345678901234567889i", literal},
@@ -78,6 +85,8 @@ var tokens = [...]elt{
 	{token.IMAG, "1e+100i", literal},
 	{token.IMAG, "1e-100i", literal},
 	{token.IMAG, "2.71828e-1000i", literal},
+	{token.IMAG, "0b1i", literal},
+	{token.IMAG, "0x1p-2i", literal},
 	{token.CHAR, "'a'", literal},
 	{token.CHAR, "'\\000'", literal},
 	{token.CHAR, "'\\xFF'", literal},
@@ -148,6 +157,7 @@ var tokens = [...]elt{
 	{token.RBRACE, "}", operator},
 	{token.SEMICOLON, ";", operator},
 	{token.COLON, ":", operator},
+	{token.TILDE, "~", operator},
 
 	// Keywords
 	{token.BREAK, "break", keyword},
@@ -442,6 +452,10 @@ var lines = []string{
 	"type\n",
 	"var\n",
 
+	"?\n",
+	"?.\n",
+	"??\n",
+
 	"foo$//comment\n",
 	"foo$//comment",
 	"foo$/*comment*/\n",
@@ -717,11 +731,17 @@ var errors = []struct {
 	{"078.", token.FLOAT, 0, "078.", ""},
 	{"07801234567.", token.FLOAT, 0, "07801234567.", ""},
 	{"078e0", token.FLOAT, 0, "078e0", ""},
-	{"0E", token.FLOAT, 0, "0E", "illegal floating-point exponent"}, // issue 17621
-	{"078", token.INT, 0, "078", "illegal octal number"},
-	{"07800000009", token.INT, 0, "07800000009", "illegal octal number"},
-	{"0x", token.INT, 0, "0x", "illegal hexadecimal number"},
-	{"0X", token.INT, 0, "0X", "illegal hexadecimal number"},
+	{"0E", token.FLOAT, 2, "0E", "exponent has no digits"}, // issue 17621
+	{"078", token.INT, 2, "078", "invalid digit '8' in octal literal"},
+	{"07090000008", token.INT, 3, "07090000008", "invalid digit '9' in octal literal"},
+	{"0x", token.INT, 2, "0x", "hexadecimal literal has no digits"},
+	{"0X", token.INT, 2, "0X", "hexadecimal literal has no digits"},
+	{"0b12", token.INT, 3, "0b12", "invalid digit '2' in binary literal"},
+	{"0o.1", token.FLOAT, 2, "0o.1", "invalid radix point in octal literal"},
+	{"0x1.0", token.FLOAT, 5, "0x1.0", "hexadecimal mantissa requires a 'p' exponent"},
+	{"1p2", token.FLOAT, 1, "1p2", "'p' exponent requires hexadecimal mantissa"},
+	{"1__0", token.INT, 2, "1__0", "'_' must separate successive digits"},
+	{"1_", token.INT, 1, "1_", "'_' must separate successive digits"},
 	{"\"abc\x00def\"", token.STRING, 4, "\"abc\x00def\"", "illegal character NUL"},
 	{"\"abc\x80def\"", token.STRING, 4, "\"abc\x80def\"", "illegal UTF-8 encoding"},
 	{"\ufeff\ufeff", token.ILLEGAL, 3, "\ufeff\ufeff", "illegal byte order mark"},                        // only first BOM is ignored
@@ -810,3 +830,22 @@ func BenchmarkScanFile(b *testing.B) {
 		}
 	}
 }
+
+func TestQuestTokens(t *testing.T) {
+	const src = "a?.b?.c ?*T x??y ? ?"
+	expected := []token.Token{
+		token.IDENT, token.QUEST_PERIOD, token.IDENT, token.QUEST_PERIOD, token.IDENT,
+		token.QUEST, token.MUL, token.IDENT,
+		token.IDENT, token.QUEST_QUEST, token.IDENT,
+		token.QUEST, token.QUEST,
+	}
+
+	var s Scanner
+	s.Init(fset.AddFile("", fset.Base(), len(src)), []byte(src), nil, dontInsertSemis)
+	for i, want := range expected {
+		_, tok, lit := s.Scan()
+		if tok != want {
+			t.Errorf("token %d: expected %s, got %s (%q)", i, want, tok, lit)
+		}
+	}
+}
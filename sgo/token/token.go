@@ -87,6 +87,7 @@ const (
 	RBRACE    // }
 	SEMICOLON // ;
 	COLON     // :
+	TILDE     // ~
 	operator_end
 
 	keyword_beg
@@ -121,8 +122,10 @@ const (
 	TYPE
 	VAR
 
-	QUEST  // ?
-	BACKSL // \
+	QUEST        // ?
+	QUEST_PERIOD // ?.
+	QUEST_QUEST  // ??
+	BACKSL       // \
 
 	keyword_end
 )
@@ -195,6 +198,7 @@ var tokens = [...]string{
 	RBRACE:    "}",
 	SEMICOLON: ";",
 	COLON:     ":",
+	TILDE:     "~",
 
 	BREAK:    "break",
 	CASE:     "case",
@@ -226,8 +230,10 @@ var tokens = [...]string{
 	TYPE:   "type",
 	VAR:    "var",
 
-	QUEST:  "?",
-	BACKSL: `\`,
+	QUEST:        "?",
+	QUEST_PERIOD: "?.",
+	QUEST_QUEST:  "??",
+	BACKSL:       `\`,
 }
 
 // String returns the string corresponding to the token tok.
@@ -269,7 +275,7 @@ func (op Token) Precedence() int {
 		return 1
 	case LAND:
 		return 2
-	case EQL, NEQ, LSS, LEQ, GTR, GEQ:
+	case EQL, NEQ, LSS, LEQ, GTR, GEQ, QUEST_QUEST:
 		return 3
 	case ADD, SUB, OR, XOR:
 		return 4
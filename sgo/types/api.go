@@ -44,6 +44,8 @@ type Error struct {
 	Pos  token.Pos      // error position
 	Msg  string         // error message
 	Soft bool           // if set, error is "soft"
+
+	Fixes []SuggestedFix // machine-applicable fixes for the error, if any
 }
 
 // Error returns an error string formatted as follows:
@@ -119,6 +121,13 @@ type Config struct {
 	// error found.
 	Error func(err error)
 
+	// If Warn != nil, it is called with each warning found during type
+	// checking; err has dynamic type Error, and is soft. Unlike errors,
+	// warnings don't make type checking fail. Warnings are reported for
+	// switch statements marked with an //sgo:exhaustive comment that
+	// are missing cases. If Warn == nil, those checks aren't done.
+	Warn func(err error)
+
 	// An importer is used to import packages referred to from
 	// import declarations.
 	// If the installed importer implements ImporterFrom, the type
@@ -144,8 +153,28 @@ type Config struct {
 	// used as if they were initialized. This can cause unexpected nil
 	// dereferences.
 	AllowUseUninitializedVars bool
+
+	// UncheckedNil controls what happens when a pointer from an unchecked
+	// package is converted to a non-optional interface. If the pointer is
+	// nil, the interface isn't, but it holds a nil pointer.
+	UncheckedNil UncheckedNilMode
 }
 
+// An UncheckedNilMode tells what to do with conversions of pointers from
+// unchecked packages (see Package.Unchecked) to non-optional interfaces.
+type UncheckedNilMode int
+
+const (
+	// UncheckedNilAllow allows the conversions, as Go does.
+	UncheckedNilAllow UncheckedNilMode = iota
+	// UncheckedNilError reports the conversions as errors. The pointer must
+	// be converted to an optional and checked for nil first.
+	UncheckedNilError
+	// UncheckedNilAssert allows the conversions, and records them in
+	// Info.NilChecks so that they are checked for nil at runtime.
+	UncheckedNilAssert
+)
+
 // Info holds result type information for a type-checked package.
 // Only the information for which a map is provided is collected.
 // If the package has type errors, the collected information may
@@ -212,6 +241,22 @@ type Info struct {
 	// For SGo: ?map[*ast.SelectorExpr]*Selection
 	Selections map[*ast.SelectorExpr]*Selection
 
+	// Instances maps identifiers denoting generic types or functions to
+	// their type arguments and instantiated type.
+	//
+	// For instance, given
+	//
+	//     type T[P any] struct{ x P }
+	//     func f[P any](x P)
+	//     var _ = f(T[int]{})
+	//
+	// Instances maps the identifier T in T[int] to the type arguments
+	// [int] and the type T[int], and the identifier f to the inferred
+	// type argument [T[int]] and the signature func(x T[int]).
+	//
+	// For SGo: ?map[*ast.Ident]Instance
+	Instances map[*ast.Ident]Instance
+
 	// Scopes maps ast.Nodes to the scopes they define. Package scopes are not
 	// associated with a specific node but with all files belonging to a package.
 	// Thus, the package scope can be found in the type-checked Package object.
@@ -246,6 +291,34 @@ type Info struct {
 	//
 	// For SGo: []*Initializer
 	InitOrder []*Initializer
+
+	// NilChecks maps the expressions that must be checked for nil at runtime,
+	// with UncheckedNilAssert, to the field, variable or function from an
+	// unchecked package that they come from.
+	//
+	// For SGo: ?map[ast.Expr]Object
+	NilChecks map[ast.Expr]Object
+
+	// Narrowings maps identifiers denoting a variable where a nil check of
+	// the variable applies, like the x in *x after if x != nil, to what the
+	// nil check did: narrow the variable's type or, if it didn't, why not.
+	//
+	// For SGo: ?map[*ast.Ident]*Narrowing
+	Narrowings map[*ast.Ident]*Narrowing
+
+	// Aliases maps identifiers that alias a variable, by taking its address
+	// or by using it from a closure, to the variable. Nil checks don't narrow
+	// aliased variables.
+	//
+	// For SGo: ?map[*ast.Ident]*Var
+	Aliases map[*ast.Ident]*Var
+}
+
+// An Instance reports the type arguments and instantiated type for
+// the instantiation of a generic type or function.
+type Instance struct {
+	TypeArgs *TypeList
+	Type     Type
 }
 
 // TypeOf returns the type of expression e, or nil if not found.
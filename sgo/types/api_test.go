@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"testing"
 
@@ -1432,3 +1433,199 @@ func f(x T) T { return foo.F(x) }
 		}
 	}
 }
+
+func TestUncheckedNil(t *testing.T) {
+	const libSrc = `
+package lib
+type T struct{}
+func (*T) M() {}
+type S struct{ P *T }
+func F() *T { return &T{} }
+var V = &T{}
+`
+	const mainSrc = `
+package main
+import "lib"
+type I interface{ M() }
+func f(s lib.S) {
+	var i I = s.P
+	i = lib.F()
+	i = (lib.V)
+	i = I(s.P)
+	var o ?I = s.P
+	var p *lib.T = s.P
+	var q ?*lib.T = s.P
+	if q != nil {
+		i = q
+	}
+	_, _, _, _ = i, o, p, q
+}
+`
+
+	fset := token.NewFileSet()
+	makeFile := func(path, src string) *ast.File {
+		f, err := parser.ParseFile(fset, path+".go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	lib, err := (&Config{}).Check("lib", fset, []*ast.File{makeFile("lib", libSrc)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib.MarkUnchecked()
+	mainFile := makeFile("main", mainSrc)
+
+	want := []string{"s.P", "lib.F()", "(lib.V)", "s.P"}
+
+	var errs []string
+	conf := Config{
+		Importer:     testImporter{"lib": lib},
+		UncheckedNil: UncheckedNilError,
+		Error: func(err error) {
+			errs = append(errs, err.Error())
+		},
+	}
+	conf.Check("main", fset, []*ast.File{mainFile}, nil)
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, err := range errs {
+		if !strings.Contains(err, want[i]+" comes from unchecked package lib") {
+			t.Errorf("error %d: got %q, want it about %s", i, err, want[i])
+		}
+	}
+
+	info := &Info{NilChecks: map[ast.Expr]Object{}}
+	conf = Config{
+		Importer:     testImporter{"lib": lib},
+		UncheckedNil: UncheckedNilAssert,
+	}
+	if _, err := conf.Check("main", fset, []*ast.File{mainFile}, info); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for e, obj := range info.NilChecks {
+		got = append(got, ExprString(e)+" "+obj.Name())
+	}
+	sort.Strings(got)
+	if got, want := strings.Join(got, ", "), "(lib.V) V, lib.F() F, s.P P, s.P P"; got != want {
+		t.Errorf("NilChecks: got %s, want %s", got, want)
+	}
+
+	conf = Config{Importer: testImporter{"lib": lib}}
+	if _, err := conf.Check("main", fset, []*ast.File{mainFile}, nil); err != nil {
+		t.Errorf("with UncheckedNilAllow: %s", err)
+	}
+}
+
+func TestNarrowings(t *testing.T) {
+	const src = `
+package p
+func f(x, y ?*int) {
+	if x != nil {
+		_ = *x
+	}
+	p := &y
+	_ = p
+	if y != nil {
+		_ = y
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{
+		Narrowings: map[*ast.Ident]*Narrowing{},
+		Aliases:    map[*ast.Ident]*Var{},
+	}
+	conf := Config{Error: func(error) {}}
+	conf.Check("p", fset, []*ast.File{f}, info)
+
+	var got []string
+	for id, n := range info.Narrowings {
+		got = append(got, fmt.Sprintf("%s: %s", fset.Position(id.Pos()), n.Explain(fset, nil)))
+	}
+	sort.Strings(got)
+	want := []string{
+		"p.go:10:7: y is not narrowed by the nil check at p.go:9:5 because its address is taken at p.go:7:8",
+		"p.go:5:8: x was narrowed to *int by the nil check at p.go:4:5",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Narrowings: got %q, want %q", got, want)
+	}
+
+	if len(info.Aliases) != 1 {
+		t.Fatalf("Aliases: got %d, want 1", len(info.Aliases))
+	}
+	for id, v := range info.Aliases {
+		if id.Name != "y" || v.Name() != "y" || fset.Position(id.Pos()).Line != 7 {
+			t.Errorf("Aliases: got %s at %s", v.Name(), fset.Position(id.Pos()))
+		}
+	}
+}
+
+func TestSuggestedFixes(t *testing.T) {
+	const src = `
+package p
+type T struct{ N int }
+func g() (*T \ error) { return &T{} \ }
+func f(x ?*T) int {
+	var y *T
+	y = x
+	t \ err := g()
+	_ = err
+	return x.N + y.N + t.N
+}
+func h(x ?*T) (*T \ error) {
+	n := x.N
+	return &T{N: n} \
+}
+func k(x ?*T) (int, ?error) {
+	t \ err := g()
+	return t.N + x.N, nil
+}
+func m() {
+	var a, b *T
+	a = nil
+	_, _ = a, b
+}
+func n(x ?*T) *T {
+	_ = x.N
+	return &T{}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	conf := Config{Error: func(err error) {
+		for _, fix := range err.(Error).Fixes {
+			for _, edit := range fix.Edits {
+				got = append(got, fmt.Sprintf("%s: %s: %s %q", fset.Position(err.(Error).Pos), fix.Message, fset.Position(edit.Pos), edit.NewText))
+			}
+		}
+	}}
+	conf.Check("p", fset, []*ast.File{f}, nil)
+
+	want := []string{
+		`p.go:7:6: check x for nil: p.go:7:2 "if x == nil {\n\t\treturn 0\n\t}\n\t"`,
+		`p.go:7:6: change the type of y to ?*T: p.go:6:8 "?"`,
+		`p.go:10:9: check x for nil: p.go:10:2 "if x == nil {\n\t\treturn 0\n\t}\n\t"`,
+		`p.go:10:21: check err before using t: p.go:10:2 "if err != nil {\n\t\treturn 0\n\t}\n\t"`,
+		`p.go:13:7: check x for nil: p.go:2:10 "\n\nimport \"errors\""`,
+		`p.go:13:7: check x for nil: p.go:13:2 "if x == nil {\n\t\treturn \\ errors.New(\"x is nil\")\n\t}\n\t"`,
+		`p.go:18:9: check err before using t: p.go:18:2 "if err != nil {\n\t\treturn 0, err\n\t}\n\t"`,
+		`p.go:18:15: check x for nil: p.go:2:10 "\n\nimport \"errors\""`,
+		`p.go:18:15: check x for nil: p.go:18:2 "if x == nil {\n\t\treturn 0, errors.New(\"x is nil\")\n\t}\n\t"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -61,13 +61,16 @@ func (check *Checker) assignment(x *operand, T Type, context string) {
 	}
 
 	if reason := ""; !x.assignableTo(check.conf, T, &reason) {
+		note := check.assigningNote()
 		if reason != "" {
-			check.errorf(x.pos(), "cannot use %s as %s value in %s: %s", x, T, context, reason)
+			check.errorf(x.pos(), "cannot use %s as %s value in %s: %s%s", x, T, context, reason, note)
 		} else {
-			check.errorf(x.pos(), "cannot use %s as %s value in %s", x, T, context)
+			check.errorf(x.pos(), "cannot use %s as %s value in %s%s", x, T, context, note)
 		}
 		x.mode = invalid
+		return
 	}
+	check.uncheckedNil(x, T)
 }
 
 func (check *Checker) initConst(lhs *Const, x *operand) {
@@ -128,7 +131,7 @@ func (check *Checker) initVar(lhs *Var, x *operand, context string) Type {
 		}
 	}
 
-	check.assignment(x, lhs.typ, context)
+	check.assignmentTo(x, lhs, lhs.typ, context)
 	if x.mode == invalid {
 		return nil
 	}
@@ -177,7 +180,10 @@ func (check *Checker) assignVar(lhs ast.Expr, x *operand) Type {
 
 	var z operand
 	z.lhs = true
+	assigning := check.assigning
+	check.assigning = unparen(lhs)
 	check.expr(&z, lhs)
+	check.assigning = assigning
 	if v != nil {
 		v.used = v_used // restore v.used
 	}
@@ -206,10 +212,13 @@ func (check *Checker) assignVar(lhs ast.Expr, x *operand) Type {
 		return nil
 	}
 
-	check.assignment(x, z.typ, "assignment")
+	check.assigning = unparen(lhs)
+	check.assignmentTo(x, v, z.typ, "assignment")
+	check.assigning = assigning
 	if x.mode == invalid {
 		return nil
 	}
+	check.assignPart(lhs)
 
 	return x.typ
 }
@@ -329,7 +338,12 @@ func (check *Checker) checkVars(lhs []*Var, rhs *ast.ExprList, returnPos token.P
 		} else if rhs.EntangledPos == len(lhs)+1 && i == len(lhs) {
 			continue
 		}
-		get(&x, i)
+		if rhs.EntangledPos == 1 {
+			// \ z: z is the only value
+			get(&x, 0)
+		} else {
+			get(&x, i)
+		}
 		setVar(i, v, &x, context)
 	}
 }
@@ -82,7 +82,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		// of S and the respective parameter passing rules apply."
 		S := x.typ
 		var T Type
-		if s, _ := S.Underlying().(*Slice); s != nil {
+		if s, _ := coreType(S).(*Slice); s != nil {
 			T = s.elem
 		} else {
 			check.invalidArg(x.pos(), "%s is not a slice", x)
@@ -139,7 +139,16 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		mode := invalid
 		var typ Type
 		var val constant.Value
-		switch typ = implicitArrayDeref(x.typ.Underlying()); t := typ.(type) {
+		if tp, _ := x.typ.(*TypeParam); tp != nil {
+			// the result is never constant for type parameters
+			typ = tp
+			if tp.allTypes(func(t Type) bool { return hasLen(t, id) }) {
+				mode = value
+			}
+		} else {
+			typ = implicitArrayDeref(x.typ.Underlying())
+		}
+		switch t := typ.(type) {
 		case *Basic:
 			if isString(t) && id == _Len {
 				if x.mode == constant_ {
@@ -184,7 +193,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 
 	case _Close:
 		// close(c)
-		c, _ := x.typ.Underlying().(*Chan)
+		c, _ := coreType(x.typ).(*Chan)
 		if c == nil {
 			check.invalidArg(x.pos(), "%s is not a channel", x)
 			return
@@ -294,7 +303,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 	case _Copy:
 		// copy(x, y []T) int
 		var dst Type
-		if t, _ := x.typ.Underlying().(*Slice); t != nil {
+		if t, _ := coreType(x.typ).(*Slice); t != nil {
 			dst = t.elem
 		}
 
@@ -304,7 +313,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			return
 		}
 		var src Type
-		switch t := y.typ.Underlying().(type) {
+		switch t := coreType(y.typ).(type) {
 		case *Basic:
 			if isString(y.typ) {
 				src = universeByte
@@ -331,7 +340,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 
 	case _Delete:
 		// delete(m, k)
-		m, _ := x.typ.Underlying().(*Map)
+		m, _ := coreType(x.typ).(*Map)
 		if m == nil {
 			check.invalidArg(x.pos(), "%s is not a map", x)
 			return
@@ -424,7 +433,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 
 		var min int // minimum number of arguments
-		switch T.Underlying().(type) {
+		switch coreType(T).(type) {
 		case *Slice:
 			min = 2
 		case *Map, *Chan:
@@ -529,7 +538,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		// (no argument evaluated yet)
 		arg0 := call.Args[0]
 		selx, _ := unparen(arg0).(*ast.SelectorExpr)
-		if selx == nil {
+		if selx == nil || selx.Quest.IsValid() {
 			check.invalidArg(arg0.Pos(), "%s is not a selector expression", arg0)
 			check.use(arg0)
 			return
@@ -664,3 +673,17 @@ func unparen(e ast.Expr) ast.Expr {
 		e = p.X
 	}
 }
+
+// hasLen reports whether len, or cap if id is _Cap, accepts values of
+// type t.
+func hasLen(t Type, id builtinId) bool {
+	switch t := implicitArrayDeref(t.Underlying()).(type) {
+	case *Basic:
+		return isString(t) && id == _Len
+	case *Array, *Slice, *Chan:
+		return true
+	case *Map:
+		return id == _Len
+	}
+	return false
+}
@@ -12,7 +12,7 @@ import (
 )
 
 func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
-	check.exprOrType(x, e.Fun)
+	targs, xlist := check.funExpr(x, e.Fun)
 
 	switch x.mode {
 	case invalid:
@@ -53,7 +53,7 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 
 	default:
 		// function/method call
-		sig, _ := x.typ.Underlying().(*Signature)
+		sig, _ := coreType(x.typ).(*Signature)
 		if sig == nil {
 			check.invalidOp(x.pos(), "cannot call non-function %s", x)
 			x.mode = invalid
@@ -62,6 +62,24 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 		}
 
 		arg, n, _ := unpack(func(x *operand, i int) { check.multiExpr(x, e.Args[i]) }, len(e.Args), false)
+		if arg != nil && sig.tparams != nil {
+			// Evaluate the arguments once to infer the missing type
+			// arguments from them, then check them against the
+			// instantiated signature.
+			args := make([]*operand, n)
+			for i := range args {
+				args[i] = new(operand)
+				arg(args[i], i)
+			}
+			arg = func(x *operand, i int) { *x = *args[i] }
+			sig = check.inferCall(e, sig, targs, xlist, args)
+			if sig == nil {
+				x.mode = invalid
+				x.expr = e
+				return statement
+			}
+			check.recordTypeAndValue(e.Fun, value, sig, nil)
+		}
 		if arg != nil {
 			check.arguments(x, e, sig, arg, n)
 		} else {
@@ -84,6 +102,9 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 			x.typ = sig.results
 		}
 
+		if obj := check.uncheckedExprs[unparen(e.Fun)]; obj != nil {
+			check.recordUnchecked(e, obj)
+		}
 		x.expr = e
 		check.hasCallOrRecv = true
 
@@ -91,6 +112,52 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 	}
 }
 
+// funExpr type-checks the function expression fun of a call and
+// initializes x with it. A generic function may be called without type
+// arguments or with only some of them, since the remaining ones can be
+// inferred from the call arguments; the explicit type arguments and the
+// corresponding expressions are returned.
+func (check *Checker) funExpr(x *operand, fun ast.Expr) (targs []Type, xlist []ast.Expr) {
+	var X ast.Expr
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		X, xlist = f.X, []ast.Expr{f.Index}
+	case *ast.IndexListExpr:
+		X, xlist = f.X, f.Indices
+	default:
+		check.genericRawExpr(x, fun, nil, true)
+		switch x.mode {
+		case novalue:
+			check.errorf(x.pos(), "%s used as value or type", x)
+			x.mode = invalid
+		case typexpr:
+			check.nonGeneric(x)
+		}
+		check.singleValue(x)
+		return nil, nil
+	}
+
+	generic, targs := check.indexedGeneric(x, fun, X, xlist, true)
+	if !generic {
+		if ix, _ := fun.(*ast.IndexExpr); ix != nil {
+			check.indexValue(x, ix)
+		} else {
+			if x.mode != invalid {
+				check.invalidOp(x.pos(), "cannot index %s with more than one index", x)
+			}
+			check.use(xlist...)
+			x.mode = invalid
+		}
+	}
+	if x.mode != invalid {
+		check.recordTypeAndValue(fun, x.mode, x.typ, x.val)
+	}
+	if x.mode == typexpr {
+		return nil, nil
+	}
+	return targs, xlist
+}
+
 // use type-checks each argument.
 // Useful to make sure expressions are evaluated
 // (and variables are "used") in the presence of other errors.
@@ -279,9 +346,11 @@ func (check *Checker) argument(fun ast.Expr, sig *Signature, i int, x *operand,
 
 	// determine parameter type
 	var typ Type
+	var param *Var
 	switch {
 	case i < n:
-		typ = sig.params.vars[i].typ
+		param = sig.params.vars[i]
+		typ = param.typ
 	case sig.variadic:
 		typ = sig.params.vars[n-1].typ
 		if debug {
@@ -307,9 +376,10 @@ func (check *Checker) argument(fun ast.Expr, sig *Signature, i int, x *operand,
 	} else if sig.variadic && i >= n-1 {
 		// use the variadic parameter slice's element type
 		typ = typ.(*Slice).elem
+		param = nil
 	}
 
-	check.assignment(x, typ, check.sprintf("argument to %s", fun))
+	check.assignmentTo(x, param, typ, check.sprintf("argument to %s", fun))
 }
 
 func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
@@ -331,6 +401,10 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 			assert(pname.pkg == check.pkg)
 			check.recordUse(ident, pname)
 			pname.used = true
+			if e.Quest.IsValid() {
+				check.invalidOp(e.Quest, "cannot use ?. with package %s", pname.name)
+				goto Error
+			}
 			pkg := pname.imported
 			exp := pkg.scope.Lookup(sel)
 			if exp == nil {
@@ -344,6 +418,7 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 				// ok to continue
 			}
 			check.recordUse(e.Sel, exp)
+			check.recordUnchecked(e, exp)
 
 			// Simplified version of the code for *ast.Idents:
 			// - imported objects are always fully initialized
@@ -380,6 +455,11 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 		goto Error
 	}
 
+	if e.Quest.IsValid() {
+		check.optionalSelector(x, e)
+		return
+	}
+
 	obj, index, indirect = LookupFieldOrMethod(x.typ, x.mode == variable, check.pkg, sel)
 	if obj == nil {
 		switch {
@@ -488,3 +568,89 @@ Error:
 	x.mode = invalid
 	x.expr = e
 }
+
+// optionalSelector type-checks the safe navigation selector e, x?.f, whose
+// operand x has already been evaluated. x must be an optional, and f a field
+// of the optional's element type. The result is an optional of f's type, or
+// f's type if it's already optional, which is nil if x is.
+func (check *Checker) optionalSelector(x *operand, e *ast.SelectorExpr) {
+	// these must be declared before the "goto Error" statements
+	var (
+		obj      Object
+		index    []int
+		indirect bool
+		field    *Var
+		typ      Type
+	)
+
+	sel := e.Sel.Name
+	opt, _ := x.typ.(*Optional)
+	switch {
+	case x.mode == typexpr:
+		check.invalidOp(e.Quest, "cannot use ?. with type %s", x.typ)
+		goto Error
+	case opt == nil:
+		check.invalidOp(e.Quest, "%s is not an optional; use . to select %s", x, sel)
+		goto Error
+	}
+
+	obj, index, indirect = LookupFieldOrMethod(opt.elem, false, check.pkg, sel)
+	if obj == nil {
+		if index != nil {
+			check.invalidOp(e.Sel.Pos(), "ambiguous selector %s", sel)
+		} else {
+			check.invalidOp(e.Sel.Pos(), "%s has no field %s", x, sel)
+		}
+		goto Error
+	}
+	field, _ = obj.(*Var)
+	if field == nil {
+		check.invalidOp(e.Sel.Pos(), "%s is a method; ?. can only select fields", sel)
+		goto Error
+	}
+	if !check.optionalSelectorPath(opt.elem, index, e) {
+		goto Error
+	}
+	check.recordSelection(e, FieldVal, opt.elem, field, index, indirect)
+
+	typ = field.typ
+	if _, isOpt := typ.(*Optional); !isOpt {
+		if !IsOptionable(typ) {
+			check.invalidOp(e.Sel.Pos(), "%s has type %s, which can't be optional", ExprString(e), typ)
+			goto Error
+		}
+		typ = NewOptional(typ)
+	}
+	x.mode = value
+	x.typ = typ
+	x.expr = e
+	return
+
+Error:
+	x.mode = invalid
+	x.expr = e
+}
+
+// optionalSelectorPath reports whether the embedded fields through which the
+// field at index is selected from typ can be named, in case they need to be
+// checked for nil.
+func (check *Checker) optionalSelectorPath(typ Type, index []int, e *ast.SelectorExpr) bool {
+	var embedded []*Var
+	for _, i := range index[:len(index)-1] {
+		typ, _ = deref(typ)
+		f := typ.Underlying().(*Struct).Field(i)
+		embedded = append(embedded, f)
+		if _, isPtr := f.typ.Underlying().(*Pointer); !isPtr {
+			typ = f.typ
+			continue
+		}
+		for _, f := range embedded {
+			if !f.Exported() && f.pkg != check.pkg {
+				check.invalidOp(e.Sel.Pos(), "cannot use ?. to select %s through unexported embedded field %s", e.Sel.Name, f.name)
+				return false
+			}
+		}
+		typ = f.typ
+	}
+	return true
+}
@@ -54,6 +54,7 @@ type context struct {
 	scope         *Scope         // top-most scope for lookups
 	iota          constant.Value // value of iota in a constant declaration; nil otherwise
 	sig           *Signature     // function signature if inside a function; nil otherwise
+	listStmt      ast.Stmt       // innermost statement of a statement list being checked; nil otherwise
 	hasLabel      bool           // set if a function makes use of labels (only ~1% of functions); unused outside functions
 	hasCallOrRecv bool           // set if an expression contains a function call or channel receive operation
 }
@@ -92,6 +93,8 @@ type Checker struct {
 	funcs    []funcInfo            // list of functions to type-check
 	delayed  []func()              // delayed checks requiring fully setup types
 
+	recvTParamMap map[*ast.Ident]*TypeParam // maps blank receiver type parameters to their type
+
 	// context within which the current object is type-checked
 	// (valid only for the duration of type-checking a specific object)
 	context
@@ -101,6 +104,15 @@ type Checker struct {
 	indent int // indentation for tracing
 
 	reportedErrs map[string]struct{}
+
+	exhaustiveLines map[*token.File]map[int]bool // lines with an //sgo:exhaustive comment, by file
+	uncheckedExprs  map[ast.Expr]Object          // expressions whose values come from unchecked packages
+	partialUses     map[*ast.Ident]bool          // variables used only by their initialized parts
+	assigning       ast.Expr                     // left-hand side being assigned to
+	narrowings      map[*Var][]*Narrowing        // nil checks that apply to the uses of a variable
+	narrowedUses    map[*ast.Ident]*Narrowing    // the nil check that applies to each use of a variable
+	target          *Var                         // variable being assigned to
+	declTypes       map[*Var]ast.Expr            // type expressions that declare the types of variables
 }
 
 // addUnusedImport adds the position of a dot-imported package
@@ -192,6 +204,12 @@ func (check *Checker) initFiles(files []*ast.File) {
 	check.untyped = nil
 	check.funcs = nil
 	check.delayed = nil
+	check.exhaustiveLines = nil
+	check.uncheckedExprs = nil
+	check.partialUses = nil
+	check.narrowings = nil
+	check.narrowedUses = nil
+	check.declTypes = nil
 
 	// determine package name and collect valid files
 	pkg := check.pkg
@@ -360,11 +378,33 @@ func (check *Checker) recordImplicit(node ast.Node, obj Object) {
 func (check *Checker) recordSelection(x *ast.SelectorExpr, kind SelectionKind, recv Type, obj Object, index []int, indirect bool) {
 	assert(obj != nil && (recv == nil || len(index) > 0))
 	check.recordUse(x.Sel, obj)
+	check.recordUnchecked(x, obj)
 	if m := check.Selections; m != nil {
 		m[x] = &Selection{kind, recv, obj, index, indirect}
 	}
 }
 
+func (check *Checker) recordInstance(x ast.Expr, targs []Type, typ Type) {
+	var id *ast.Ident
+	switch x := unparen(x).(type) {
+	case *ast.IndexExpr:
+		check.recordInstance(x.X, targs, typ)
+		return
+	case *ast.IndexListExpr:
+		check.recordInstance(x.X, targs, typ)
+		return
+	case *ast.Ident:
+		id = x
+	case *ast.SelectorExpr:
+		id = x.Sel
+	default:
+		return
+	}
+	if m := check.Instances; m != nil {
+		m[id] = Instance{NewTypeList(targs), typ}
+	}
+}
+
 func (check *Checker) recordScope(node ast.Node, scope *Scope) {
 	assert(node != nil)
 	assert(scope != nil)
@@ -90,6 +90,12 @@ var tests = [][]string{
 	{"testdata/labels.src"},
 	{"testdata/issues.src"},
 	{"testdata/sgoissues.src"},
+	{"testdata/generics.src"},
+	{"testdata/safenav.src"},
+	{"testdata/coalesce.src"},
+	{"testdata/exhaustive.src"},
+	{"testdata/fieldinit.src"},
+	{"testdata/narrowing.src"},
 	{"testdata/blank.src"},
 }
 
@@ -115,7 +121,7 @@ func parseFiles(t *testing.T, filenames []string) ([]*ast.File, []error) {
 	var files []*ast.File
 	var errlist []error
 	for _, filename := range filenames {
-		file, err := parser.ParseFile(fset, filename, nil, parser.AllErrors)
+		file, err := parser.ParseFile(fset, filename, nil, parser.AllErrors|parser.ParseComments)
 		if file == nil {
 			t.Fatalf("%s: %s", filename, err)
 		}
@@ -257,7 +263,7 @@ func checkFiles(t *testing.T, testfiles []string) {
 	if len(testfiles) == 1 && testfiles[0] == "testdata/importC.src" {
 		conf.FakeImportC = true
 	}
-	if len(testfiles) == 1 && testfiles[0] == "testdata/sgoissues.src" {
+	if len(testfiles) == 1 && (testfiles[0] == "testdata/sgoissues.src" || testfiles[0] == "testdata/fieldinit.src" || testfiles[0] == "testdata/narrowing.src") {
 		conf.AllowUseUninitializedVars = false
 		conf.AllowUninitializedExprs = false
 	}
@@ -273,6 +279,8 @@ func checkFiles(t *testing.T, testfiles []string) {
 			errlist = append(errlist, err)
 		}
 	}
+	// Warnings are expected like errors.
+	conf.Warn = conf.Error
 	conf.Check(pkgName, fset, files, nil)
 
 	if *listErrors {
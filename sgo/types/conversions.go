@@ -31,6 +31,18 @@ func (check *Checker) conversion(x *operand, T Type) {
 			x.val = constant.MakeString(string(codepoint))
 			ok = true
 		}
+	case constArg && isTypeParam(T):
+		// constant conversion to a type parameter; the constant must be
+		// convertible to each type in the type set of T, and the result
+		// is not constant
+		ok = T.(*TypeParam).allTypes(func(t Type) bool {
+			if !isConstType(t) {
+				return x.convertibleTo(check.conf, t)
+			}
+			return representableConst(x.val, check.conf, t.Underlying().(*Basic), nil) ||
+				isInteger(x.typ) && isString(t)
+		})
+		x.mode = value
 	case x.convertibleTo(check.conf, T):
 		// non-constant conversion
 		x.mode = value
@@ -63,6 +75,7 @@ func (check *Checker) conversion(x *operand, T Type) {
 		check.updateExprType(x.expr, final, true)
 	}
 
+	check.uncheckedNil(x, T)
 	x.typ = T
 }
 
@@ -82,6 +95,28 @@ func (x *operand) convertibleTo(conf *Config, T Type) bool {
 		return true
 	}
 
+	// "x's type V or T are type parameters and x is convertible to each
+	// type in their type sets"
+	Vp, _ := x.typ.(*TypeParam)
+	Tp, _ := T.(*TypeParam)
+	if Vp != nil || Tp != nil {
+		convertible := func(V, T Type) bool {
+			y := *x
+			y.typ = V
+			return y.convertibleTo(conf, T)
+		}
+		switch {
+		case Vp != nil && Tp != nil:
+			return Vp.allTypes(func(V Type) bool {
+				return Tp.allTypes(func(T Type) bool { return convertible(V, T) })
+			})
+		case Vp != nil:
+			return Vp.allTypes(func(V Type) bool { return convertible(V, T) })
+		default:
+			return Tp.allTypes(func(T Type) bool { return convertible(x.typ, T) })
+		}
+	}
+
 	// "x's type and T have identical underlying types if tags are ignored"
 	V := x.typ
 	Vu := V.Underlying()
@@ -96,7 +96,7 @@ func (check *Checker) objDecl(obj Object, def *Named, path []*TypeName) {
 		check.varDecl(obj, d.lhs, d.entangledLhs, d.typ, d.init)
 	case *TypeName:
 		// invalid recursive types are detected via path
-		check.typeDecl(obj, d.typ, def, path, d.alias)
+		check.typeDecl(obj, d.typ, d.tparams, def, path, d.alias)
 	case *Func:
 		// functions may be recursive - no need to track dependencies
 		check.funcDecl(obj, d)
@@ -159,7 +159,8 @@ func (check *Checker) varDecl(obj *Var, lhs []*Var, entangledLhs *Var, typ, init
 
 	// determine type, if any
 	if typ != nil {
-		obj.setType(check.typ(typ))
+		obj.setType(check.varType(typ))
+		check.recordDeclType(obj, typ)
 		// We cannot spread the type to all lhs variables if there
 		// are more than one since that would mark them as checked
 		// (see Checker.objDecl) and the assignment of init exprs,
@@ -178,6 +179,10 @@ func (check *Checker) varDecl(obj *Var, lhs []*Var, entangledLhs *Var, typ, init
 		}
 		if has, _ := check.hasZeroValue(obj.typ); !has {
 			obj.usable = false
+			switch obj.typ.Underlying().(type) {
+			case *Struct, *Array:
+				obj.fieldInit = map[string]bool{}
+			}
 		}
 		return
 	}
@@ -224,6 +229,7 @@ func (check *Checker) varDecl(obj *Var, lhs []*Var, entangledLhs *Var, typ, init
 			for _, lhs := range append(lhs, entangledLhs) {
 				if lhs != nil {
 					lhs.typ = obj.typ
+					check.recordDeclType(lhs, typ)
 				}
 			}
 		}
@@ -251,7 +257,7 @@ func underlying(typ Type) Type {
 		if n == nil {
 			break
 		}
-		typ = n.underlying
+		typ = n.expand().underlying
 	}
 	return typ
 }
@@ -262,7 +268,7 @@ func (n *Named) setUnderlying(typ Type) {
 	}
 }
 
-func (check *Checker) typeDecl(obj *TypeName, typ ast.Expr, def *Named, path []*TypeName, alias bool) {
+func (check *Checker) typeDecl(obj *TypeName, typ ast.Expr, tparams *ast.FieldList, def *Named, path []*TypeName, alias bool) {
 	assert(obj.typ == nil)
 
 	// type declarations cannot use iota
@@ -279,6 +285,15 @@ func (check *Checker) typeDecl(obj *TypeName, typ ast.Expr, def *Named, path []*
 		def.setUnderlying(named)
 		obj.typ = named // make sure recursive type declarations terminate
 
+		// the type parameters of a generic type are only visible
+		// within its declaration
+		outer := check.scope
+		if tparams != nil {
+			check.scope = NewScope(check.scope, tparams.Pos(), typ.End(), "type parameters", nil)
+			named.resolving = true
+			named.tparams = bindTParams(check.collectTypeParams(tparams))
+		}
+
 		// determine underlying type of named
 		check.typExpr(typ, named, append(path, obj))
 
@@ -296,6 +311,13 @@ func (check *Checker) typeDecl(obj *TypeName, typ ast.Expr, def *Named, path []*
 		// Determine the (final, unnamed) underlying type by resolving
 		// any forward chain (they always end in an unnamed type).
 		named.underlying = underlying(named.underlying)
+		if _, ok := named.underlying.(*TypeParam); ok {
+			check.errorf(typ.Pos(), "cannot use a type parameter as RHS in type declaration")
+			named.underlying = Typ[Invalid]
+		}
+
+		named.resolving = false
+		check.scope = outer
 
 	}
 
@@ -320,7 +342,10 @@ func (check *Checker) addMethodDecls(obj *TypeName) {
 
 	// spec: "If the base type is a struct type, the non-blank method
 	// and field names must be distinct."
-	base, _ := obj.typ.(*Named) // nil if receiver base type is type alias
+	// An alias may denote the receiver type as *T or ?*T.
+	typ, _ := deopt(obj.typ)
+	typ, _ = deref(typ)
+	base, _ := typ.(*Named) // nil if receiver base type is alias of an unnamed type
 	if base != nil {
 		if t, _ := base.underlying.(*Struct); t != nil {
 			for _, fld := range t.fields {
@@ -509,7 +534,10 @@ func (check *Checker) declStmt(decl ast.Decl) {
 				// the innermost containing block."
 				scopePos := s.Name.Pos()
 				check.declare(check.scope, s.Name, obj, scopePos)
-				check.typeDecl(obj, s.Type, nil, nil, s.Assign.IsValid())
+				if s.TypeParams != nil {
+					check.errorf(s.TypeParams.Pos(), "generic type cannot be declared inside a function")
+				}
+				check.typeDecl(obj, s.Type, nil, nil, nil, s.Assign.IsValid())
 
 			default:
 				check.invalidAST(s.Pos(), "const, type, or var declaration expected")
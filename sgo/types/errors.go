@@ -67,8 +67,8 @@ func (check *Checker) dump(format string, args ...interface{}) {
 	fmt.Println(check.sprintf(format, args...))
 }
 
-func (check *Checker) err(pos token.Pos, msg string, soft bool) {
-	err := Error{check.fset, pos, msg, soft}
+func (check *Checker) err(pos token.Pos, msg string, soft bool, fixes ...SuggestedFix) {
+	err := Error{Fset: check.fset, Pos: pos, Msg: msg, Soft: soft, Fixes: fixes}
 	if check.firstErr == nil {
 		check.firstErr = err
 	}
@@ -93,7 +93,9 @@ func (check *Checker) error(pos token.Pos, msg string) {
 }
 
 func (check *Checker) errorf(pos token.Pos, format string, args ...interface{}) {
-	check.err(pos, check.sprintf(format, args...), false)
+	note := check.narrowingNote(args)
+	fixes := check.suggestedFixes(args)
+	check.err(pos, check.sprintf(format, args...)+note, false, fixes...)
 }
 
 func (check *Checker) errorHasZeroValuePaths(pos token.Pos, paths [][]string) {
@@ -110,6 +112,14 @@ func (check *Checker) softErrorf(pos token.Pos, format string, args ...interface
 	check.err(pos, check.sprintf(format, args...), true)
 }
 
+// warnf reports a warning, which doesn't make type checking fail, if there's
+// a Config.Warn function.
+func (check *Checker) warnf(pos token.Pos, format string, args ...interface{}) {
+	if f := check.conf.Warn; f != nil {
+		f(Error{Fset: check.fset, Pos: pos, Msg: check.sprintf(format, args...), Soft: true})
+	}
+}
+
 func (check *Checker) invalidAST(pos token.Pos, format string, args ...interface{}) {
 	check.errorf(pos, "invalid AST: "+format, args...)
 }
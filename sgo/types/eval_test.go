@@ -111,7 +111,7 @@ func TestEvalPos(t *testing.T) {
 			x = a + len(s)
 			return float64(x)
 			/* true => true, untyped bool */
-			/* fmt.Println => , func(a ...interface{}) (n int, err ?error) */
+			/* fmt.Println => , func(a ...any) (n int, err ?error) */
 			/* c => 3, untyped float */
 			/* T => , p.T */
 			/* a => , int */
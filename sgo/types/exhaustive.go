@@ -0,0 +1,177 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the opt-in exhaustiveness checks for switch
+// statements.
+
+package types
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/token"
+)
+
+// exhaustiveDirective is the comment that marks the switch statement it
+// precedes, or whose line it ends, as exhaustive.
+const exhaustiveDirective = "//sgo:exhaustive"
+
+// markedExhaustive reports whether s is marked with exhaustiveDirective.
+func (check *Checker) markedExhaustive(s ast.Stmt) bool {
+	if check.conf.Warn == nil {
+		return false
+	}
+	if check.exhaustiveLines == nil {
+		check.exhaustiveLines = map[*token.File]map[int]bool{}
+		for _, file := range check.files {
+			for _, group := range file.Comments {
+				for _, c := range group.List {
+					if strings.TrimSpace(c.Text) != exhaustiveDirective {
+						continue
+					}
+					f := check.fset.File(c.Pos())
+					lines := check.exhaustiveLines[f]
+					if lines == nil {
+						lines = map[int]bool{}
+						check.exhaustiveLines[f] = lines
+					}
+					lines[f.Line(c.Pos())] = true
+				}
+			}
+		}
+	}
+	f := check.fset.File(s.Pos())
+	if f == nil {
+		return false
+	}
+	line := f.Line(s.Pos())
+	return check.exhaustiveLines[f][line-1] || check.exhaustiveLines[f][line]
+}
+
+// exhaustiveTypeSwitch warns about the cases missing from the type switch s
+// on x, whose case types are seen. The switch must have a nil case if x is an
+// optional, and, if x's interface is sealed, a case for each of its variants.
+func (check *Checker) exhaustiveTypeSwitch(s *ast.TypeSwitchStmt, x *operand, xtyp *Interface, seen map[Type]token.Pos) {
+	if hasDefault(s.Body.List) {
+		return
+	}
+
+	// A ?T case matches a nil T, not a nil interface, so only a nil case
+	// covers the latter.
+	if _, isOpt := x.typ.Underlying().(*Optional); isOpt {
+		if _, ok := seen[nil]; !ok {
+			check.warnf(s.Switch, "non-exhaustive type switch on %s: missing case nil", x.expr)
+		}
+	}
+
+	pkg, sealed := sealedPackage(xtyp)
+	if !sealed {
+		check.warnf(s.Switch, "non-exhaustive type switch on %s: %s is not sealed, so it needs a default case", x.expr, x.typ)
+		return
+	}
+
+	var missing []string
+	for _, V := range sealedVariants(pkg, xtyp) {
+		covered := false
+		for T := range seen {
+			if T == nil {
+				continue
+			}
+			T = unwrapped(T)
+			if Identical(T, V) {
+				covered = true
+			} else if ityp, _ := T.Underlying().(*Interface); ityp != nil && Implements(V, ityp) {
+				covered = true
+			}
+			if covered {
+				break
+			}
+		}
+		if !covered {
+			missing = append(missing, TypeString(V, RelativeTo(check.pkg)))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		plural := ""
+		if len(missing) > 1 {
+			plural = "s"
+		}
+		check.warnf(s.Switch, "non-exhaustive type switch on %s: missing case%s %s", x.expr, plural, strings.Join(missing, ", "))
+	}
+}
+
+// exhaustiveSwitch warns if the switch s on x, an optional, doesn't have a
+// nil case. For other types, the values can't be listed, so it only warns
+// that s can't be checked.
+func (check *Checker) exhaustiveSwitch(s *ast.SwitchStmt, x *operand) {
+	if hasDefault(s.Body.List) {
+		return
+	}
+	if _, isOpt := x.typ.Underlying().(*Optional); !isOpt || s.Tag == nil {
+		check.warnf(s.Switch, "only type switches and switches on optionals can be checked for exhaustiveness")
+		return
+	}
+	for _, c := range s.Body.List {
+		clause, _ := c.(*ast.CaseClause)
+		if clause == nil {
+			continue
+		}
+		for _, e := range clause.List.List {
+			if id, _ := unparen(e).(*ast.Ident); id != nil && id.Name == "nil" {
+				if _, obj := check.scope.LookupParent("nil", id.Pos()); obj != nil {
+					if _, isNil := obj.(*Nil); isNil {
+						return
+					}
+				}
+			}
+		}
+	}
+	check.warnf(s.Switch, "non-exhaustive switch on %s: missing case nil", x.expr)
+}
+
+func hasDefault(list []ast.Stmt) bool {
+	for _, c := range list {
+		if clause, _ := c.(*ast.CaseClause); clause != nil && len(clause.List.List) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sealedPackage returns the package of an unexported method of ityp. Only
+// types declared in that package can implement ityp, so it's sealed.
+func sealedPackage(ityp *Interface) (*Package, bool) {
+	for _, m := range ityp.allMethods {
+		if !m.Exported() {
+			return m.pkg, true
+		}
+	}
+	return nil, false
+}
+
+// sealedVariants returns the types declared in pkg that implement ityp: each
+// non-interface named type T if T implements it, or *T if only *T does.
+// Generic types are left out, as their instantiations can't be listed.
+func sealedVariants(pkg *Package, ityp *Interface) []Type {
+	var variants []Type
+	for _, name := range pkg.scope.Names() {
+		obj, _ := pkg.scope.Lookup(name).(*TypeName)
+		if obj == nil || obj.IsAlias() {
+			continue
+		}
+		named, _ := obj.typ.(*Named)
+		if named == nil || named.TypeParams().Len() > 0 || IsInterface(named) {
+			continue
+		}
+		if Implements(named, ityp) {
+			variants = append(variants, named)
+		} else if ptr := NewPointer(named); Implements(ptr, ityp) {
+			variants = append(variants, ptr)
+		}
+	}
+	return variants
+}
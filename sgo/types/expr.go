@@ -96,7 +96,7 @@ func (check *Checker) unary(x *operand, e *ast.UnaryExpr, op token.Token) {
 		return
 
 	case token.ARROW:
-		typ, ok := x.typ.Underlying().(*Chan)
+		typ, ok := coreType(x.typ).(*Chan)
 		if !ok {
 			check.invalidOp(x.pos(), "cannot receive from non-channel %s", x)
 			x.mode = invalid
@@ -465,6 +465,10 @@ func (check *Checker) updateExprType(x ast.Expr, typ Type, final bool) {
 	}
 
 	// Everything's fine, record final type and value for x.
+	// Values of type parameter type are never constant.
+	if isTypeParam(typ) {
+		old.mode, old.val = value, nil
+	}
 	check.recordTypeAndValue(x, old.mode, typ, old.val)
 }
 
@@ -559,11 +563,31 @@ func (check *Checker) convertUntyped(x *operand, target Type) {
 	case *Optional:
 		if x.isNil() {
 			// keep nil untyped - see comment for interfaces, above
-			target = Typ[UntypedNil]
+			x.typ = Typ[UntypedNil]
+			check.updateExprType(x.expr, x.typ, true)
+			if isTypeParam(t.elem) {
+				// Go has no nil for type parameters; record the
+				// optional type so that translators can spell
+				// the zero value.
+				check.recordTypeAndValue(x.expr, value, t, nil)
+			}
+			return
 		} else {
 			check.convertUntyped(x, t.elem)
 			return
 		}
+	case *TypeParam:
+		// x must be representable by each type in the type set of t.
+		if !t.allTypes(func(u Type) bool {
+			y := *x
+			return y.assignableTo(check.conf, u, nil)
+		}) {
+			goto Error
+		}
+		// values of type parameter type are never constant
+		if x.mode == constant_ {
+			x.mode = value
+		}
 	case *Slice:
 		if !x.isNil() {
 			goto Error
@@ -792,6 +816,11 @@ func (check *Checker) binary(x *operand, e *ast.BinaryExpr, lhs, rhs ast.Expr, o
 		return
 	}
 
+	if op == token.QUEST_QUEST {
+		check.coalesce(x, &y)
+		return
+	}
+
 	check.convertUntyped(x, y.typ)
 	if x.mode == invalid {
 		return
@@ -866,6 +895,32 @@ func (check *Checker) binary(x *operand, e *ast.BinaryExpr, lhs, rhs ast.Expr, o
 	// x.typ is unchanged
 }
 
+// coalesce checks x ?? y, which is x if it isn't nil and y otherwise. If y is
+// an optional, it must be assignable to x's type, and so is the result;
+// otherwise, y must be assignable to x's element type, which is then the
+// result type.
+func (check *Checker) coalesce(x, y *operand) {
+	opt, _ := x.typ.(*Optional)
+	if opt == nil {
+		check.invalidOp(x.pos(), "%s is not an optional", x)
+		x.mode = invalid
+		return
+	}
+
+	typ := opt.elem
+	if _, isOpt := y.typ.(*Optional); isOpt {
+		typ = x.typ
+	}
+	check.assignment(y, typ, "right operand of ??")
+	if y.mode == invalid {
+		x.mode = invalid
+		return
+	}
+
+	x.mode = value
+	x.typ = typ
+}
+
 // index checks an index expression for validity.
 // If max >= 0, it is the upper bound for index.
 // If index is valid and the result i >= 0, then i is the constant value of index.
@@ -969,6 +1024,12 @@ const (
 // If hint != nil, it is the type of a composite literal element.
 //
 func (check *Checker) rawExpr(x *operand, e ast.Expr, hint Type) exprKind {
+	return check.genericRawExpr(x, e, hint, false)
+}
+
+// genericRawExpr is like rawExpr, but if allowGeneric is set, e may
+// denote a generic function or type that isn't instantiated.
+func (check *Checker) genericRawExpr(x *operand, e ast.Expr, hint Type, allowGeneric bool) exprKind {
 	if trace {
 		check.trace(e.Pos(), "%s", e)
 		check.indent++
@@ -980,6 +1041,10 @@ func (check *Checker) rawExpr(x *operand, e ast.Expr, hint Type) exprKind {
 
 	kind := check.exprInternal(x, e, hint)
 
+	if !allowGeneric {
+		check.nonGeneric(x)
+	}
+
 	// convert x into a user-friendly set of values
 	// TODO(gri) this code can be simplified
 	var typ Type
@@ -1008,6 +1073,80 @@ func (check *Checker) rawExpr(x *operand, e ast.Expr, hint Type) exprKind {
 	return kind
 }
 
+// nonGeneric reports an error if x denotes a generic function or type
+// that isn't instantiated.
+func (check *Checker) nonGeneric(x *operand) {
+	if x.mode == invalid || x.mode == novalue {
+		return
+	}
+	var what string
+	switch t := x.typ.(type) {
+	case *Named:
+		if x.mode == typexpr && isGeneric(t) {
+			what = "type"
+		}
+	case *Signature:
+		if t.tparams != nil {
+			what = "function"
+		}
+	}
+	if what != "" {
+		check.errorf(x.pos(), "cannot use generic %s %s without instantiation", what, x.expr)
+		x.mode = invalid
+		x.typ = Typ[Invalid]
+	}
+}
+
+// indexedGeneric type-checks the operand X of the index expression e.
+// If X denotes a generic function or type, it is instantiated with the
+// type arguments in indices, x is set to the result, and generic is
+// true. If partial is set, a generic function may be instantiated
+// partially, to be completed by inference of the remaining type
+// arguments; targs are the explicit type arguments. Otherwise, x is
+// set to the value of X, as by check.expr.
+func (check *Checker) indexedGeneric(x *operand, e, X ast.Expr, indices []ast.Expr, partial bool) (generic bool, targs []Type) {
+	check.genericRawExpr(x, X, nil, true)
+	switch x.mode {
+	case invalid:
+		return false, nil
+
+	case typexpr:
+		x.expr = e
+		orig, _ := x.typ.(*Named)
+		if !isGeneric(orig) {
+			check.errorf(X.Pos(), "%s is not a generic type", x.typ)
+			check.use(indices...)
+			x.mode = invalid
+			return true, nil
+		}
+		targs := check.typeList(indices)
+		if targs == nil {
+			x.mode = invalid
+			return true, nil
+		}
+		x.typ = check.instance(indices[0].Pos(), orig, targs, indices)
+		if x.typ == Typ[Invalid] {
+			x.mode = invalid
+			return true, nil
+		}
+		check.recordInstance(X, targs, x.typ)
+		return true, targs
+	}
+
+	if sig, _ := x.typ.(*Signature); sig != nil && sig.tparams != nil {
+		targs = check.funcInst(x, e, indices)
+		if !partial && x.mode != invalid && x.typ.(*Signature).tparams != nil {
+			check.errorf(x.pos(), "cannot use generic function %s without instantiation", X)
+			x.mode = invalid
+		}
+		return true, targs
+	}
+
+	check.multiValue(x)
+	check.singleValue(x)
+	return false, nil
+}
+
 // exprInternal contains the core of type checking of expressions.
 // Must only be called by rawExpr.
 //
@@ -1097,7 +1236,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 			}
 		}
 
-		switch utyp := base.Underlying().(type) {
+		switch utyp := coreType(base).(type) {
 		case *Struct:
 			if len(e.Elts) == 0 {
 				if has, paths := check.hasZeroValue(typ); !has {
@@ -1135,7 +1274,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 					visited[i] = true
 					check.expr(x, kv.Value)
 					etyp := fld.typ
-					check.assignment(x, etyp, "struct literal")
+					check.assignmentTo(x, fld, etyp, "struct literal")
 				}
 				for i, v := range visited {
 					if !v {
@@ -1168,7 +1307,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 						continue
 					}
 					etyp := fld.typ
-					check.assignment(x, etyp, "struct literal")
+					check.assignmentTo(x, fld, etyp, "struct literal")
 				}
 				if len(e.Elts) < len(fields) {
 					check.error(e.Rbrace, "too few values in struct literal")
@@ -1277,78 +1416,31 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		return kind
 
 	case *ast.SelectorExpr:
+		check.partialVarUse(e)
 		check.selector(x, e)
 
 	case *ast.IndexExpr:
-		check.expr(x, e.X)
-		if x.mode == invalid {
-			check.use(e.Index)
-			goto Error
-		}
-
-		valid := false
-		length := int64(-1) // valid if >= 0
-		switch typ := x.typ.Underlying().(type) {
-		case *Basic:
-			if isString(typ) {
-				valid = true
-				if x.mode == constant_ {
-					length = int64(len(constant.StringVal(x.val)))
-				}
-				// an indexed string always yields a byte value
-				// (not a constant) even if the string and the
-				// index are constant
-				x.mode = value
-				x.typ = universeByte // use 'byte' name
-			}
-
-		case *Array:
-			valid = true
-			length = typ.len
-			if x.mode != variable {
-				x.mode = value
-			}
-			x.typ = typ.elem
-
-		case *Pointer:
-			if typ, _ := typ.base.Underlying().(*Array); typ != nil {
-				valid = true
-				length = typ.len
-				x.mode = variable
-				x.typ = typ.elem
-			}
-
-		case *Slice:
-			valid = true
-			x.mode = variable
-			x.typ = typ.elem
-
-		case *Map:
-			var key operand
-			check.expr(&key, e.Index)
-			check.assignment(&key, typ.key, "map index")
+		check.partialVarUse(e)
+		if generic, _ := check.indexedGeneric(x, e, e.X, []ast.Expr{e.Index}, false); generic {
 			if x.mode == invalid {
 				goto Error
 			}
-			x.mode = mapindex
-			x.typ = typ.elem
-			x.expr = e
-			return expression
+			break
 		}
+		return check.indexValue(x, e)
 
-		if !valid {
-			check.invalidOp(x.pos(), "cannot index %s", x)
+	case *ast.IndexListExpr:
+		if generic, _ := check.indexedGeneric(x, e, e.X, e.Indices, false); !generic {
+			if x.mode != invalid {
+				check.invalidOp(x.pos(), "cannot index %s with more than one index", x)
+			}
+			check.use(e.Indices...)
 			goto Error
 		}
-
-		if e.Index == nil {
-			check.invalidAST(e.Pos(), "missing index for %s", x)
+		if x.mode == invalid {
 			goto Error
 		}
 
-		check.index(e.Index, length)
-		// ok to continue
-
 	case *ast.SliceExpr:
 		check.expr(x, e.X)
 		if x.mode == invalid {
@@ -1358,7 +1450,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 
 		valid := false
 		length := int64(-1) // valid if >= 0
-		switch typ := x.typ.Underlying().(type) {
+		switch typ := coreType(x.typ).(type) {
 		case *Basic:
 			if isString(typ) {
 				if e.Slice3 {
@@ -1473,10 +1565,14 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 			check.invalidAST(e.Pos(), "use of .(type) outside type switch")
 			goto Error
 		}
-		T := check.typ(e.Type)
+		T := check.varType(e.Type)
 		if T == Typ[Invalid] {
 			goto Error
 		}
+		if tp, _ := T.(*TypeParam); tp != nil && !tp.allTypes(assertableType) {
+			check.errorf(e.Type.Pos(), "cannot assert to type parameter %s: its type set may include types with unwrapped optionables", T)
+			goto Error
+		}
 		check.typeAssertion(x.pos(), x, xtyp, T)
 		x.mode = commaok
 		x.typ = T
@@ -1492,7 +1588,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		case typexpr:
 			x.typ = &Pointer{base: x.typ}
 		default:
-			if typ, ok := x.typ.Underlying().(*Pointer); ok {
+			if typ, ok := coreType(x.typ).(*Pointer); ok {
 				x.mode = variable
 				x.typ = typ.base
 			} else {
@@ -1506,10 +1602,10 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		if x.mode == invalid {
 			goto Error
 		}
-		if e.Op == token.AND && x.mode == variable {
-			_, obj := check.scope.LookupParent(e.X.(*ast.Ident).Name, check.pos)
+		if id, ok := unparen(e.X).(*ast.Ident); ok && e.Op == token.AND && x.mode == variable {
+			_, obj := check.scope.LookupParent(id.Name, check.pos)
 			if v, ok := obj.(*Var); ok {
-				v.aliased = true
+				check.markAliased(v, id, false)
 			}
 		}
 		check.unary(x, e, e.Op)
@@ -1569,6 +1665,129 @@ Error:
 	return statement // avoid follow-up errors
 }
 
+// indexValue type-checks the index expression e, whose operand x has
+// already been evaluated.
+func (check *Checker) indexValue(x *operand, e *ast.IndexExpr) exprKind {
+	valid := false
+	length := int64(-1) // valid if >= 0
+
+	if x.mode == invalid {
+		check.use(e.Index)
+		goto Error
+	}
+
+	if tp, _ := x.typ.(*TypeParam); tp != nil && coreType(tp) == nil {
+		// All types in the type set of tp must be indexable
+		// with identical element types; maps are excluded.
+		if elem := indexElem(tp); elem != nil {
+			valid = true
+			x.mode = value
+			x.typ = elem
+		}
+	}
+
+	switch typ := coreType(x.typ).(type) {
+	case *Basic:
+		if isString(typ) {
+			valid = true
+			if x.mode == constant_ {
+				length = int64(len(constant.StringVal(x.val)))
+			}
+			// an indexed string always yields a byte value
+			// (not a constant) even if the string and the
+			// index are constant
+			x.mode = value
+			x.typ = universeByte // use 'byte' name
+		}
+
+	case *Array:
+		valid = true
+		length = typ.len
+		if x.mode != variable {
+			x.mode = value
+		}
+		x.typ = typ.elem
+
+	case *Pointer:
+		if typ, _ := typ.base.Underlying().(*Array); typ != nil {
+			valid = true
+			length = typ.len
+			x.mode = variable
+			x.typ = typ.elem
+		}
+
+	case *Slice:
+		valid = true
+		x.mode = variable
+		x.typ = typ.elem
+
+	case *Map:
+		var key operand
+		check.expr(&key, e.Index)
+		check.assignment(&key, typ.key, "map index")
+		if x.mode == invalid {
+			goto Error
+		}
+		x.mode = mapindex
+		x.typ = typ.elem
+		x.expr = e
+		return expression
+	}
+
+	if !valid {
+		check.invalidOp(x.pos(), "cannot index %s", x)
+		goto Error
+	}
+
+	if e.Index == nil {
+		check.invalidAST(e.Pos(), "missing index for %s", x)
+		goto Error
+	}
+
+	check.index(e.Index, length)
+	// ok to continue
+
+	x.expr = e
+	return expression
+
+Error:
+	x.mode = invalid
+	x.expr = e
+	return statement // avoid follow-up errors
+}
+
+// indexElem returns the element type of the values obtained by
+// indexing a value of type parameter type tp, or nil if not all types
+// in its type set can be indexed or their element types differ.
+func indexElem(tp *TypeParam) Type {
+	var elem Type
+	if !tp.allTypes(func(t Type) bool {
+		var e Type
+		switch u := t.Underlying().(type) {
+		case *Basic:
+			if isString(u) {
+				e = universeByte
+			}
+		case *Array:
+			e = u.elem
+		case *Slice:
+			e = u.elem
+		case *Pointer:
+			if a, _ := u.base.Underlying().(*Array); a != nil {
+				e = a.elem
+			}
+		}
+		if e == nil || elem != nil && !Identical(e, elem) {
+			return false
+		}
+		elem = e
+		return true
+	}) {
+		return nil
+	}
+	return elem
+}
+
 func keyVal(x constant.Value) interface{} {
 	switch x.Kind() {
 	case constant.Bool:
@@ -1639,6 +1858,12 @@ func (check *Checker) expr(x *operand, e ast.Expr) {
 // multiExpr is like expr but the result may be a multi-value.
 func (check *Checker) multiExpr(x *operand, e ast.Expr) {
 	check.rawExpr(x, e, nil)
+	check.multiValue(x)
+}
+
+// multiValue checks that the operand x of an expression denotes a
+// (possibly multi-) value.
+func (check *Checker) multiValue(x *operand) {
 	var msg string
 	switch x.mode {
 	default:
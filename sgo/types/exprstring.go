@@ -63,7 +63,11 @@ func WriteExpr(buf *bytes.Buffer, x ast.Expr) {
 
 	case *ast.SelectorExpr:
 		WriteExpr(buf, x.X)
-		buf.WriteByte('.')
+		if x.Quest.IsValid() {
+			buf.WriteString("?.")
+		} else {
+			buf.WriteByte('.')
+		}
 		buf.WriteString(x.Sel.Name)
 
 	case *ast.ForceExpr:
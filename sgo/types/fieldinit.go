@@ -0,0 +1,280 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the tracking of the initialization of the fields and
+// elements of variables declared without a value whose types have no zero
+// value.
+
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/token"
+)
+
+// A field path is the sequence of selections of fields and of indexings of
+// arrays with constants that leads from a variable to one of its parts, like
+// ".a.b" or "[1].c". The empty path is the whole variable.
+
+// varPath returns the variable that e selects a part of, the longest field
+// path in e from it, and the type at that path. If the whole of e is the path,
+// full is true. Selections through pointers aren't part of a path.
+func (check *Checker) varPath(e ast.Expr) (root *ast.Ident, v *Var, path string, typ Type, full bool) {
+	var steps []ast.Expr
+	for {
+		e = unparen(e)
+		if x, ok := e.(*ast.Ident); ok {
+			root = x
+			break
+		}
+		steps = append(steps, e)
+		switch x := e.(type) {
+		case *ast.SelectorExpr:
+			e = x.X
+		case *ast.IndexExpr:
+			e = x.X
+		default:
+			return nil, nil, "", nil, false
+		}
+	}
+	_, obj := check.scope.LookupParent(root.Name, check.pos)
+	v, _ = obj.(*Var)
+	if v == nil || v.typ == nil {
+		return nil, nil, "", nil, false
+	}
+
+	typ = v.typ
+	for i := len(steps) - 1; i >= 0; i-- {
+		switch s := steps[i].(type) {
+		case *ast.SelectorExpr:
+			if _, ok := typ.Underlying().(*Struct); !ok {
+				return root, v, path, typ, false
+			}
+			f, index, indirect := LookupFieldOrMethod(typ, false, check.pkg, s.Sel.Name)
+			if _, ok := f.(*Var); !ok || indirect {
+				return root, v, path, typ, false
+			}
+			for _, i := range index {
+				f := typ.Underlying().(*Struct).Field(i)
+				path += "." + f.name
+				typ = f.typ
+			}
+		case *ast.IndexExpr:
+			arr, ok := typ.Underlying().(*Array)
+			if !ok {
+				return root, v, path, typ, false
+			}
+			lit, ok := unparen(s.Index).(*ast.BasicLit)
+			if !ok || lit.Kind != token.INT {
+				return root, v, path, typ, false
+			}
+			i, err := strconv.ParseInt(lit.Value, 0, 64)
+			if err != nil || i < 0 || i >= arr.len {
+				return root, v, path, typ, false
+			}
+			path += "[" + strconv.FormatInt(i, 10) + "]"
+			typ = arr.elem
+		}
+	}
+	return root, v, path, typ, true
+}
+
+// partialVarUse checks the use of e, if it's a part of a variable whose
+// initialization is tracked by parts, and the variable isn't fully initialized
+// yet. Then it reports each uninitialized part that e needs, unless e is being
+// assigned to, and makes the variable's identifier not report that the whole
+// variable may be uninitialized.
+func (check *Checker) partialVarUse(e ast.Expr) {
+	if check.conf.AllowUseUninitializedVars {
+		return
+	}
+	root, v, path, typ, full := check.varPath(e)
+	if v == nil || v.usable || v.fieldInit == nil || path == "" || check.partialUses[root] {
+		return
+	}
+	if check.partialUses == nil {
+		check.partialUses = map[*ast.Ident]bool{}
+	}
+	check.partialUses[root] = true
+	if full && e == check.assigning {
+		return
+	}
+	for _, p := range uninitParts(typ, path, v.fieldInit) {
+		check.errorf(e.Pos(), "possibly uninitialized %s", partName(root.Name, p))
+	}
+}
+
+// assignPart records that lhs, a part of a variable whose initialization is
+// tracked by parts, has been assigned to. The variable becomes usable once
+// all its parts without zero value are initialized.
+func (check *Checker) assignPart(lhs ast.Expr) {
+	_, v, path, _, full := check.varPath(lhs)
+	if v == nil || v.usable || v.fieldInit == nil || path == "" || !full {
+		return
+	}
+	v.fieldInit[path] = true
+	if len(uninitParts(v.typ, "", v.fieldInit)) == 0 {
+		v.usable = true
+		if debugUsable {
+			fmt.Println("USABLE assignPart:", v.name, fmt.Sprintf("%p", v), v.usable)
+		}
+	}
+}
+
+// uninitParts returns the paths to the parts of typ, found at path, that have
+// no zero value and haven't been initialized.
+func uninitParts(typ Type, path string, init map[string]bool) []string {
+	if partInitialized(init, path) || hasZeroValue2(typ, nil, func([]string) {}) {
+		return nil
+	}
+	var paths []string
+	switch t := typ.Underlying().(type) {
+	case *Struct:
+		for _, f := range t.fields {
+			paths = append(paths, uninitParts(f.typ, path+"."+f.name, init)...)
+		}
+	case *Array:
+		if t.len > maxTrackedElems {
+			return []string{path}
+		}
+		for i := int64(0); i < t.len; i++ {
+			paths = append(paths, uninitParts(t.elem, path+"["+strconv.FormatInt(i, 10)+"]", init)...)
+		}
+	default:
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// maxTrackedElems is the maximum length of the arrays whose elements are
+// tracked one by one.
+const maxTrackedElems = 64
+
+// partInitialized reports whether path, or a path that contains it, is in
+// init.
+func partInitialized(init map[string]bool, path string) bool {
+	for p := range init {
+		if p == path || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeParts returns the paths initialized both in a and in b.
+func mergeParts(a, b map[string]bool) map[string]bool {
+	merged := map[string]bool{}
+	for p := range a {
+		if partInitialized(b, p) {
+			merged[p] = true
+		}
+	}
+	for p := range b {
+		if partInitialized(a, p) {
+			merged[p] = true
+		}
+	}
+	return merged
+}
+
+// partialVars returns the variables in scope whose parts are being initialized
+// one by one, with the parts initialized so far.
+func (check *Checker) partialVars() map[*Var]map[string]bool {
+	vars := map[*Var]map[string]bool{}
+	for sc := check.scope; sc != nil; sc = sc.Parent() {
+		for _, name := range sc.Names() {
+			if v, ok := sc.Lookup(name).(*Var); ok && !v.usable && v.fieldInit != nil {
+				vars[v] = copyParts(v.fieldInit)
+			}
+		}
+	}
+	return vars
+}
+
+// endBranch returns the parts of the variables in vars initialized at the end
+// of a branch, and restores the variables as they are in vars for the next
+// one.
+func endBranch(vars map[*Var]map[string]bool) map[*Var]map[string]bool {
+	ends := map[*Var]map[string]bool{}
+	for v, init := range vars {
+		if v.usable {
+			ends[v] = map[string]bool{"": true}
+		} else {
+			ends[v] = v.fieldInit
+		}
+		v.usable = false
+		v.fieldInit = copyParts(init)
+	}
+	return ends
+}
+
+// joinBranches leaves initialized the parts of the variables in vars that are
+// initialized at the end of all of branches. With no branches, the variables
+// are left as they are in vars.
+func joinBranches(vars map[*Var]map[string]bool, branches []map[*Var]map[string]bool) {
+	for v := range vars {
+		for i, ends := range branches {
+			if i == 0 {
+				v.fieldInit = copyParts(ends[v])
+			} else {
+				v.fieldInit = mergeParts(v.fieldInit, ends[v])
+			}
+		}
+		v.usable = len(uninitParts(v.typ, "", v.fieldInit)) == 0
+	}
+}
+
+// endClause ends the branch of a switch or select clause with body list, and
+// appends to branches how the variables in vars may be left after it.
+func (check *Checker) endClause(vars map[*Var]map[string]bool, branches []map[*Var]map[string]bool, list []ast.Stmt) []map[*Var]map[string]bool {
+	ends := endBranch(vars)
+	if mayBreak(list) {
+		branches = append(branches, vars)
+	}
+	if !check.isTerminatingList(list, "") {
+		branches = append(branches, ends)
+	}
+	return branches
+}
+
+// mayBreak reports whether the clause body list may break out of its
+// statement, so that the rest of it may not run.
+func mayBreak(list []ast.Stmt) bool {
+	if hasBreakList(list, "", true) {
+		return true
+	}
+	labeled := false
+	for _, s := range list {
+		ast.Inspect(s, func(n ast.Node) bool {
+			if b, ok := n.(*ast.BranchStmt); ok && b.Tok == token.BREAK && b.Label != nil {
+				labeled = true
+			}
+			return !labeled
+		})
+	}
+	return labeled
+}
+
+func copyParts(init map[string]bool) map[string]bool {
+	c := make(map[string]bool, len(init))
+	for p := range init {
+		c[p] = true
+	}
+	return c
+}
+
+// partName describes the part of the variable name at path.
+func partName(name, path string) string {
+	switch {
+	case path == "":
+		return "variable: " + name
+	case strings.HasSuffix(path, "]"):
+		return "element: " + name + path
+	}
+	return "field: " + name + path
+}
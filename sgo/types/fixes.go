@@ -0,0 +1,269 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the fixes suggested for common SGo type errors.
+
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/token"
+)
+
+// A SuggestedFix is a change to the source code that fixes an error.
+type SuggestedFix struct {
+	Message string     // what the fix does
+	Edits   []TextEdit // the changes, in source order
+}
+
+// A TextEdit replaces the source code from Pos to End with NewText. If Pos
+// and End are the same, NewText is inserted at Pos.
+type TextEdit struct {
+	Pos, End token.Pos
+	NewText  string
+}
+
+// assignmentTo is like assignment, for an assignment to the variable v, whose
+// declaration the fixes suggested for errors may change.
+func (check *Checker) assignmentTo(x *operand, v *Var, T Type, context string) {
+	target := check.target
+	check.target = v
+	check.assignment(x, T, context)
+	check.target = target
+}
+
+// recordDeclType records that the type of v is declared by the type
+// expression e.
+func (check *Checker) recordDeclType(v *Var, e ast.Expr) {
+	if check.declTypes == nil {
+		check.declTypes = map[*Var]ast.Expr{}
+	}
+	check.declTypes[v] = e
+}
+
+// suggestedFixes returns the fixes for an error about the operands in args.
+func (check *Checker) suggestedFixes(args []interface{}) []SuggestedFix {
+	var fixes []SuggestedFix
+	for _, arg := range args {
+		x, ok := arg.(*operand)
+		if !ok {
+			continue
+		}
+		if fix, ok := check.nilCheckFix(x); ok {
+			fixes = append(fixes, fix)
+		}
+		if fix, ok := check.optionalTargetFix(x); ok {
+			fixes = append(fixes, fix)
+		}
+	}
+	return fixes
+}
+
+// nilCheckFix returns a fix that returns early if x is nil, when x is an
+// optional local variable that a nil check would narrow.
+func (check *Checker) nilCheckFix(x *operand) (SuggestedFix, bool) {
+	if x.mode != variable || !isOptional(x.typ) {
+		return SuggestedFix{}, false
+	}
+	id, ok := unparen(x.expr).(*ast.Ident)
+	if !ok {
+		return SuggestedFix{}, false
+	}
+	v := check.lookupVar(id)
+	if v == nil || aliasReason(v) != Narrowed {
+		return SuggestedFix{}, false
+	}
+	if n := check.narrowedUses[id]; n != nil && n.Reason != Narrowed {
+		return SuggestedFix{}, false
+	}
+	return check.earlyExitFix("check "+id.Name+" for nil", id.Name+" == nil", id.Name+" is nil", "")
+}
+
+// entangledCheckFix returns a fix that returns early if the error or bool
+// that v is entangled with says that v has no value.
+func (check *Checker) entangledCheckFix(v *Var) (SuggestedFix, bool) {
+	for s := check.scope; s != nil && s != Universe; s = s.parent {
+		for _, obj := range s.elems {
+			e, ok := obj.(*Var)
+			if !ok {
+				continue
+			}
+			for _, c := range e.collapses {
+				if c != v {
+					continue
+				}
+				cond := e.name + " != nil"
+				err := e.name
+				if isBoolean(e.typ) {
+					cond = "!" + e.name
+					err = ""
+				}
+				return check.earlyExitFix("check "+e.name+" before using "+v.name, cond, v.name+" has no value", err)
+			}
+		}
+	}
+	return SuggestedFix{}, false
+}
+
+// earlyExitFix returns a fix that inserts, before the statement being
+// checked, an if statement with the condition cond that leaves the function,
+// as exitStmt does.
+func (check *Checker) earlyExitFix(msg, cond, why, err string) (SuggestedFix, bool) {
+	s := check.listStmt
+	if s == nil || check.sig == nil {
+		return SuggestedFix{}, false
+	}
+	exit, edits, ok := check.exitStmt(s.Pos(), why, err)
+	if !ok {
+		return SuggestedFix{}, false
+	}
+	indent := strings.Repeat("\t", check.fset.Position(s.Pos()).Column-1)
+	text := "if " + cond + " {\n" + indent + "\t" + exit + "\n" + indent + "}\n" + indent
+	return SuggestedFix{
+		Message: msg,
+		Edits:   append(edits, TextEdit{Pos: s.Pos(), End: s.Pos(), NewText: text}),
+	}, true
+}
+
+// exitStmt returns a statement at pos that leaves the function being checked,
+// and the edits it needs elsewhere. Error results are err, the name of an
+// error that says why the function is left, or else a new error with the
+// message why, importing package errors if needed; an entangled bool is false;
+// and other results are zero values. It returns false if some result has no
+// zero value, or the error can't be made.
+func (check *Checker) exitStmt(pos token.Pos, why, err string) (string, []TextEdit, bool) {
+	res := check.sig.results
+	if res == nil {
+		return "return", nil, true
+	}
+
+	var edits []TextEdit
+	errValue := func() (string, bool) {
+		if err == "" {
+			name, edit, ok := check.errorsPackage(pos)
+			if !ok {
+				return "", false
+			}
+			err = fmt.Sprintf("%s.New(%q)", name, why)
+			if edit != nil {
+				edits = append(edits, *edit)
+			}
+		}
+		return err, true
+	}
+
+	if e := res.entangled; e != nil {
+		if isBoolean(e.typ) {
+			return `return \ false`, nil, true
+		}
+		if !isError(e.typ) {
+			return "", nil, false
+		}
+		value, ok := errValue()
+		return `return \ ` + value, edits, ok
+	}
+
+	var values []string
+	for _, v := range res.vars {
+		value, ok := check.zeroValue(v.typ)
+		if isError(v.typ) {
+			value, ok = errValue()
+		}
+		if !ok {
+			return "", nil, false
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return "return", nil, true
+	}
+	return "return " + strings.Join(values, ", "), edits, true
+}
+
+// isError reports whether typ is error or ?error.
+func isError(typ Type) bool {
+	if opt, ok := typ.(*Optional); ok {
+		typ = opt.elem
+	}
+	return Identical(typ, Universe.Lookup("error").Type())
+}
+
+// errorsPackage returns the name with which package errors is referred to at
+// pos and, if the file doesn't import it, an edit that does. It returns false
+// if the name errors is something else at pos.
+func (check *Checker) errorsPackage(pos token.Pos) (string, *TextEdit, bool) {
+	_, obj := check.scope.LookupParent("errors", pos)
+	if pkgName, ok := obj.(*PkgName); ok && pkgName.imported.path == "errors" {
+		return "errors", nil, true
+	}
+	if obj != nil {
+		return "", nil, false
+	}
+	for _, file := range check.files {
+		if file.Pos() <= pos && pos <= file.End() {
+			return "errors", &TextEdit{Pos: file.Name.End(), End: file.Name.End(), NewText: "\n\nimport \"errors\""}, true
+		}
+	}
+	return "", nil, false
+}
+
+// zeroValue returns an expression for the zero value of typ, if it has one.
+func (check *Checker) zeroValue(typ Type) (string, bool) {
+	if isOptional(typ) {
+		return "nil", true
+	}
+	if has, _ := check.hasZeroValue(typ); !has {
+		return "", false
+	}
+	switch t := typ.Underlying().(type) {
+	case *Basic:
+		switch {
+		case t.info&IsBoolean != 0:
+			return "false", true
+		case t.info&IsString != 0:
+			return `""`, true
+		case t.info&IsNumeric != 0:
+			return "0", true
+		}
+	case *Slice:
+		return "nil", true
+	case *Struct, *Array:
+		return TypeString(typ, check.qualifier) + "{}", true
+	}
+	return "", false
+}
+
+// optionalTargetFix returns a fix that makes the variable being assigned to
+// optional, when x is nil or optional and the variable's type is declared in
+// the package.
+func (check *Checker) optionalTargetFix(x *operand) (SuggestedFix, bool) {
+	v := check.target
+	if v == nil || !IsOptionable(v.typ) || isOptional(v.typ) {
+		return SuggestedFix{}, false
+	}
+	if x.typ != Typ[UntypedNil] && !isOptional(x.typ) {
+		return SuggestedFix{}, false
+	}
+	e, ok := check.declTypes[v]
+	if !ok {
+		return SuggestedFix{}, false
+	}
+	for other, otherExpr := range check.declTypes {
+		if otherExpr == e && other != v {
+			// As in var a, b *T; changing it would change both.
+			return SuggestedFix{}, false
+		}
+	}
+	what := "the type of " + v.name
+	if v.name == "" || v.name == "_" {
+		what = "the type " + ExprString(e)
+	}
+	return SuggestedFix{
+		Message: "change " + what + " to ?" + ExprString(e),
+		Edits:   []TextEdit{{Pos: e.Pos(), End: e.Pos(), NewText: "?"}},
+	}, true
+}
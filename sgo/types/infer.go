@@ -0,0 +1,274 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements type argument inference for calls of generic
+// functions.
+
+package types
+
+import (
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/token"
+)
+
+// inferCall infers the type arguments of a call of the generic function
+// with signature sig that aren't given explicitly in targs, and returns
+// the instantiated signature. The explicit type arguments correspond to
+// the expressions in xlist. It returns nil if inference failed; in that
+// case an error has been reported.
+func (check *Checker) inferCall(call *ast.CallExpr, sig *Signature, targs []Type, xlist []ast.Expr, args []*operand) *Signature {
+	tparams := sig.tparams.list()
+	targs = check.infer(call.Rparen, tparams, targs, sig.params, sig.variadic && !call.Ellipsis.IsValid(), args)
+	if targs == nil {
+		return nil
+	}
+	return check.instantiateSignature(call.Pos(), call.Fun, sig, targs, xlist)
+}
+
+// infer returns the complete list of type arguments for the type
+// parameters tparams, given the explicit type arguments targs (which may
+// be fewer than the type parameters) and the arguments args passed to
+// the parameters params. Inference proceeds in the following steps:
+//
+//	1) Typed arguments are unified with their parameter types.
+//	2) Type parameters that are still unknown and that are used as
+//	   parameter types of untyped arguments get the default type of
+//	   the first such argument.
+//	3) Known type arguments are unified with the core types of the
+//	   constraints of their type parameters, and type parameters
+//	   constrained to a single non-tilde type are inferred as that type.
+//
+// Parameters of optional type ?T accept arguments of optional type ?X
+// and of non-optional type X; in both cases T is inferred as X.
+//
+// If a type argument can't be inferred, infer reports an error at pos
+// and returns nil.
+func (check *Checker) infer(pos token.Pos, tparams []*TypeParam, targs []Type, params *Tuple, variadic bool, args []*operand) []Type {
+	u := newUnifier(check, tparams, targs)
+
+	// paramType returns the type of the parameter for the i'th argument.
+	paramType := func(i int) Type {
+		n := params.Len()
+		switch {
+		case variadic && i >= n-1:
+			return params.vars[n-1].typ.(*Slice).elem
+		case i < n:
+			return params.vars[i].typ
+		}
+		return nil
+	}
+
+	// 1) unify typed arguments
+	for i, arg := range args {
+		if arg.mode == invalid {
+			return nil
+		}
+		par := paramType(i)
+		if par == nil || !isTyped(arg.typ) {
+			continue
+		}
+		if !u.unify(par, arg.typ) {
+			check.errorf(arg.pos(), "type %s of %s does not match %s", arg.typ, arg.expr, u.subst(par))
+			return nil
+		}
+	}
+
+	// 2) use the default types of untyped arguments
+	for i, arg := range args {
+		par := paramType(i)
+		if par == nil || isTyped(arg.typ) {
+			continue
+		}
+		if o, _ := par.(*Optional); o != nil {
+			par = o.elem
+		}
+		if tpar, _ := par.(*TypeParam); tpar != nil {
+			if j := u.index(tpar); j >= 0 && u.types[j] == nil && !arg.isNil() {
+				u.types[j] = Default(arg.typ)
+			}
+		}
+	}
+
+	// 3) use the core types of constraints
+	for progress := true; progress; {
+		progress = false
+		for i, tpar := range tparams {
+			iface := tpar.iface()
+			core := coreType(tpar)
+			switch {
+			case u.types[i] == nil:
+				if iface.restricted && len(iface.terms) == 1 && !iface.terms[0].tilde {
+					u.types[i] = iface.terms[0].typ
+					progress = true
+				}
+			case core != nil && !isTypeParam(u.types[i]):
+				if u.unknown(core) && u.unify(core, u.types[i].Underlying()) {
+					progress = true
+				}
+			}
+		}
+	}
+
+	for i, typ := range u.types {
+		if typ == nil {
+			check.errorf(pos, "cannot infer %s", tparams[i].obj.name)
+			return nil
+		}
+	}
+
+	// Type arguments may refer to other type parameters; substitute
+	// until no more references are left.
+	res := make([]Type, len(u.types))
+	for i, typ := range u.types {
+		res[i] = u.subst(typ)
+	}
+	return res
+}
+
+// A unifier collects the types inferred for a list of type parameters
+// by unifying parameter types with argument types.
+type unifier struct {
+	check   *Checker
+	tparams []*TypeParam
+	types   []Type // inferred types, or nil if not inferred yet
+}
+
+func newUnifier(check *Checker, tparams []*TypeParam, targs []Type) *unifier {
+	types := make([]Type, len(tparams))
+	copy(types, targs)
+	return &unifier{check, tparams, types}
+}
+
+// index returns the index of the type parameter tpar in the type
+// parameter list, or -1.
+func (u *unifier) index(tpar *TypeParam) int {
+	for i, t := range u.tparams {
+		if t == tpar {
+			return i
+		}
+	}
+	return -1
+}
+
+// unknown reports whether typ refers to a type parameter that hasn't
+// been inferred yet.
+func (u *unifier) unknown(typ Type) bool {
+	smap := make(substMap)
+	for i, tpar := range u.tparams {
+		if u.types[i] == nil {
+			smap[tpar] = Typ[Invalid]
+		}
+	}
+	return subst(typ, smap) != typ
+}
+
+// subst substitutes the inferred types in typ, repeatedly, so that
+// inferred types referring to other type parameters are resolved too.
+func (u *unifier) subst(typ Type) Type {
+	smap := make(substMap)
+	for i, tpar := range u.tparams {
+		if u.types[i] != nil {
+			smap[tpar] = u.types[i]
+		}
+	}
+	for i := 0; i < len(u.tparams)+1; i++ {
+		next := subst(typ, smap)
+		if next == typ {
+			break
+		}
+		typ = next
+	}
+	return typ
+}
+
+// unify unifies the parameter type x, which may refer to type parameters
+// to be inferred, with the argument type y. It reports whether x and y
+// don't contradict the types inferred so far; structural mismatches are
+// left to the assignability checks of the arguments.
+func (u *unifier) unify(x, y Type) bool {
+	if tpar, _ := x.(*TypeParam); tpar != nil {
+		i := u.index(tpar)
+		if i < 0 {
+			return true
+		}
+		if t := u.types[i]; t != nil {
+			return Identical(t, y) || !isNamed(y) && Identical(t.Underlying(), y) || !isNamed(t) && Identical(t, y.Underlying())
+		}
+		u.types[i] = y
+		return true
+	}
+
+	// A non-optional argument may be passed to an optional parameter.
+	if xo, _ := x.(*Optional); xo != nil {
+		if yo, _ := y.Underlying().(*Optional); yo != nil && !isNamed(y) {
+			return u.unify(xo.elem, yo.elem)
+		}
+		return u.unify(xo.elem, y)
+	}
+
+	// Named types unify if they are instances of the same generic type.
+	if xn, _ := x.(*Named); xn != nil {
+		if yn, _ := y.(*Named); yn != nil && xn.targs != nil && xn.Origin() == yn.Origin() {
+			xargs, yargs := xn.targs.list(), yn.targs.list()
+			for i := range xargs {
+				if !u.unify(xargs[i], yargs[i]) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	// Unnamed types unify with the underlying type of named types.
+	switch x := x.(type) {
+	case *Array:
+		if y, _ := y.Underlying().(*Array); y != nil {
+			return u.unify(x.elem, y.elem)
+		}
+	case *Slice:
+		if y, _ := y.Underlying().(*Slice); y != nil {
+			return u.unify(x.elem, y.elem)
+		}
+	case *Pointer:
+		if y, _ := y.Underlying().(*Pointer); y != nil {
+			return u.unify(x.base, y.base)
+		}
+	case *Map:
+		if y, _ := y.Underlying().(*Map); y != nil {
+			return u.unify(x.key, y.key) && u.unify(x.elem, y.elem)
+		}
+	case *Chan:
+		if y, _ := y.Underlying().(*Chan); y != nil {
+			return u.unify(x.elem, y.elem)
+		}
+	case *Struct:
+		if y, _ := y.Underlying().(*Struct); y != nil && len(x.fields) == len(y.fields) {
+			for i, f := range x.fields {
+				if !u.unify(f.typ, y.fields[i].typ) {
+					return false
+				}
+			}
+		}
+	case *Signature:
+		if y, _ := y.Underlying().(*Signature); y != nil {
+			return u.unifyTuple(x.params, y.params) && u.unifyTuple(x.results, y.results)
+		}
+	}
+	return true
+}
+
+func (u *unifier) unifyTuple(x, y *Tuple) bool {
+	if x.Len() != y.Len() {
+		return true
+	}
+	for i := 0; i < x.Len(); i++ {
+		if !u.unify(x.vars[i].typ, y.vars[i].typ) {
+			return false
+		}
+	}
+	if x != nil && y != nil && x.entangled != nil && y.entangled != nil {
+		return u.unify(x.entangled.typ, y.entangled.typ)
+	}
+	return true
+}
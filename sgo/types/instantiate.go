@@ -0,0 +1,318 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements instantiation of generic types and functions.
+
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/token"
+)
+
+// Instantiate instantiates the generic type or function orig with the
+// given type arguments. orig must be a *Named or a *Signature type with
+// type parameters. If validate is set, Instantiate verifies that the
+// number of type arguments and parameters match and that the type
+// arguments satisfy their corresponding type constraints; otherwise
+// the behavior for mismatched arguments is undefined.
+func Instantiate(orig Type, targs []Type, validate bool) (Type, error) {
+	var tparams []*TypeParam
+	switch orig := orig.(type) {
+	case *Named:
+		tparams = orig.TypeParams().list()
+	case *Signature:
+		tparams = orig.tparams.list()
+	}
+	if len(tparams) == 0 {
+		return nil, fmt.Errorf("%s is not a generic type", orig)
+	}
+	if validate {
+		if len(targs) != len(tparams) {
+			return nil, fmt.Errorf("got %d type arguments but %s has %d type parameters", len(targs), orig, len(tparams))
+		}
+		if i, err := verify(tparams, targs); err != nil {
+			return nil, fmt.Errorf("%s does not satisfy %s (%s)", targs[i], tparams[i].bound, err)
+		}
+	}
+	return instantiate(orig, tparams, targs), nil
+}
+
+func instantiate(orig Type, tparams []*TypeParam, targs []Type) Type {
+	switch orig := orig.(type) {
+	case *Named:
+		return orig.Origin().instance(targs)
+	case *Signature:
+		sig := subst(orig, makeSubstMap(tparams, targs)).(*Signature)
+		if sig == orig {
+			copy := *sig
+			sig = &copy
+		}
+		sig.tparams = nil
+		return sig
+	}
+	unreachable()
+	return nil
+}
+
+// verify checks that each type argument satisfies the constraint of its
+// corresponding type parameter, after substitution of the type arguments.
+// If not, it returns the index of the offending type argument.
+func verify(tparams []*TypeParam, targs []Type) (int, error) {
+	smap := makeSubstMap(tparams, targs)
+	for i, tpar := range tparams {
+		if ok, reason := satisfies(targs[i], subst(tpar.bound, smap)); !ok {
+			return i, errors.New(reason)
+		}
+	}
+	return -1, nil
+}
+
+// instance returns the instance of the generic type orig for targs,
+// creating it if it doesn't exist yet.
+func (orig *Named) instance(targs []Type) *Named {
+	for _, inst := range orig.instances {
+		if identicalTypes(inst.targs.list(), targs) {
+			return inst
+		}
+	}
+	inst := &Named{obj: orig.obj, underlying: Typ[Invalid], orig: orig, targs: NewTypeList(targs)}
+	orig.instances = append(orig.instances, inst)
+	return inst
+}
+
+// expand computes the underlying type of the instance t, if it hasn't
+// been computed yet and the underlying type of the generic type it
+// originates from is complete. It returns t.
+func (t *Named) expand() *Named {
+	if t.targs == nil || t.expanded {
+		return t
+	}
+	orig := t.orig
+	if orig.resolving || orig.underlying == nil {
+		return t // not set up yet; try again later
+	}
+	if _, ok := orig.underlying.(*Named); ok {
+		return t // forward chain during setup
+	}
+	t.expanded = true
+	smap := makeSubstMap(orig.tparams.list(), t.targs.list())
+	t.underlying = subst(orig.underlying, smap)
+	return t
+}
+
+// method returns the i'th method of t, with type arguments substituted
+// if t is an instance.
+func (t *Named) method(i int) *Func {
+	orig := t.Origin()
+	m := orig.methods[i]
+	if t == orig {
+		return m
+	}
+	if f := t.imethods[i]; f != nil {
+		return f
+	}
+	sig, _ := m.typ.(*Signature)
+	if sig == nil || sig.rparams.Len() != t.targs.Len() {
+		return m // not type-checked yet, or invalid receiver
+	}
+	nsig := subst(sig, makeSubstMap(sig.rparams.list(), t.targs.list())).(*Signature)
+	if nsig == sig {
+		copy := *sig
+		nsig = &copy
+	}
+	nsig.rparams = nil
+	f := NewFunc(m.pos, m.pkg, m.name, nsig)
+	f.orig = m
+	if t.imethods == nil {
+		t.imethods = make(map[int]*Func)
+	}
+	t.imethods[i] = f
+	return f
+}
+
+// methodList returns the methods declared for t, with type arguments
+// substituted if t is an instance.
+func (t *Named) methodList() []*Func {
+	orig := t.Origin()
+	if t == orig {
+		return t.methods
+	}
+	list := make([]*Func, len(orig.methods))
+	for i := range list {
+		list[i] = t.method(i)
+	}
+	return list
+}
+
+// lookupMethod looks up the method with the given package and name in
+// the methods declared for t.
+func (t *Named) lookupMethod(pkg *Package, name string) (int, *Func) {
+	i, m := lookupMethod(t.Origin().methods, pkg, name)
+	if m != nil {
+		m = t.method(i)
+	}
+	return i, m
+}
+
+func identicalTypes(x, y []Type) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !Identical(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// instantiatedType type-checks the instantiation of the generic type
+// denoted by x with the type arguments in indices.
+func (check *Checker) instantiatedType(x ast.Expr, indices []ast.Expr, def *Named, path []*TypeName) Type {
+	var op operand
+	check.genericTypExpr(&op, x, path)
+	if op.mode == invalid {
+		check.use(indices...)
+		return Typ[Invalid]
+	}
+	orig, _ := op.typ.(*Named)
+	if orig == nil || orig.tparams == nil {
+		check.errorf(x.Pos(), "%s is not a generic type", op.typ)
+		check.use(indices...)
+		return Typ[Invalid]
+	}
+
+	targs := check.typeList(indices)
+	if targs == nil {
+		return Typ[Invalid]
+	}
+	pos := x.Pos()
+	if len(indices) > 0 {
+		pos = indices[0].Pos()
+	}
+	typ := check.instance(pos, orig, targs, indices)
+	if typ != Typ[Invalid] {
+		check.recordInstance(x, targs, typ)
+	}
+	return typ
+}
+
+// instance instantiates the generic type orig, verifying the type
+// arguments once all types involved are set up.
+func (check *Checker) instance(pos token.Pos, orig *Named, targs []Type, indices []ast.Expr) Type {
+	tparams := orig.tparams.list()
+	if len(targs) != len(tparams) {
+		qual := "not enough"
+		if len(targs) > len(tparams) {
+			qual = "too many"
+		}
+		check.errorf(pos, "%s type arguments for %s: have %d, want %d", qual, orig, len(targs), len(tparams))
+		return Typ[Invalid]
+	}
+	inst := orig.instance(targs)
+	check.delay(func() {
+		if i, err := verify(tparams, targs); err != nil {
+			if i < len(indices) {
+				pos = indices[i].Pos()
+			}
+			check.errorf(pos, "%s does not satisfy %s (%s)", targs[i], tparams[i].bound, err)
+		}
+	})
+	return inst
+}
+
+// genericTypExpr type-checks the type expression e, which may denote a
+// generic type, and initializes x with it.
+func (check *Checker) genericTypExpr(x *operand, e ast.Expr, path []*TypeName) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		check.ident(x, e, nil, path)
+	case *ast.SelectorExpr:
+		check.selector(x, e)
+	case *ast.ParenExpr:
+		check.genericTypExpr(x, e.X, path)
+		return
+	default:
+		check.errorf(e.Pos(), "%s is not a generic type", e)
+		x.mode = invalid
+		return
+	}
+	switch x.mode {
+	case typexpr, invalid:
+		if x.mode == typexpr {
+			check.recordTypeAndValue(e, typexpr, x.typ, nil)
+		}
+	case novalue:
+		check.errorf(x.pos(), "%s used as type", x)
+		x.mode = invalid
+	default:
+		check.errorf(x.pos(), "%s is not a type", x)
+		x.mode = invalid
+	}
+}
+
+// typeList type-checks the types in list. It returns nil if any of
+// them is invalid.
+func (check *Checker) typeList(list []ast.Expr) []Type {
+	res := make([]Type, len(list))
+	for i, e := range list {
+		t := check.varType(e)
+		if t == Typ[Invalid] {
+			res = nil
+		}
+		if res != nil {
+			res[i] = t
+		}
+	}
+	return res
+}
+
+// funcInst instantiates the generic function x with the explicit type
+// arguments in indices, which may be fewer than the function's type
+// parameters if the remaining ones can be inferred from the arguments
+// of a call. It returns the explicit type arguments, or nil if an
+// error occurred. If the function is completely instantiated, x is
+// updated accordingly.
+func (check *Checker) funcInst(x *operand, inst ast.Expr, indices []ast.Expr) []Type {
+	sig := x.typ.(*Signature)
+	targs := check.typeList(indices)
+	if targs == nil {
+		x.mode = invalid
+		x.expr = inst
+		return nil
+	}
+	tparams := sig.tparams.list()
+	if len(targs) > len(tparams) {
+		check.errorf(indices[len(tparams)].Pos(), "got %d type arguments but %s has %d type parameters", len(targs), x.expr, len(tparams))
+		x.mode = invalid
+		x.expr = inst
+		return nil
+	}
+	if len(targs) == len(tparams) {
+		x.typ = check.instantiateSignature(inst.Pos(), x.expr, sig, targs, indices)
+	}
+	x.expr = inst
+	return targs
+}
+
+// instantiateSignature instantiates the generic signature sig with the
+// complete list of type arguments targs, and records the instance.
+func (check *Checker) instantiateSignature(pos token.Pos, fun ast.Expr, sig *Signature, targs []Type, indices []ast.Expr) *Signature {
+	tparams := sig.tparams.list()
+	inst := instantiate(sig, tparams, targs).(*Signature)
+	check.recordInstance(fun, targs, inst)
+	check.delay(func() {
+		if i, err := verify(tparams, targs); err != nil {
+			if i < len(indices) {
+				pos = indices[i].Pos()
+			}
+			check.errorf(pos, "%s does not satisfy %s (%s)", targs[i], tparams[i].bound, err)
+		}
+	})
+	return inst
+}
@@ -80,11 +80,16 @@ func lookupFieldOrMethod(T Type, addressable bool, pkg *Package, name string) (o
 	typ, isOpt := deopt(T)
 	typ, isPtr := deref(typ)
 
-	// *typ where typ is an interface has no methods.
-	if isPtr && IsInterface(typ) {
+	// *typ where typ is an interface or a type parameter has no methods.
+	if isPtr && (IsInterface(typ) || isTypeParam(typ)) {
 		return
 	}
 
+	// The methods of a type parameter are those of its constraint.
+	if tp, _ := typ.(*TypeParam); tp != nil {
+		typ = tp.iface()
+	}
+
 	// Start with typ as single entry at shallowest depth.
 	current := []embeddedType{{typ, nil, isPtr, isOpt, false}}
 
@@ -122,7 +127,7 @@ func lookupFieldOrMethod(T Type, addressable bool, pkg *Package, name string) (o
 				seen[named] = true
 
 				// look for a matching attached method
-				if i, m := lookupMethod(named.methods, pkg, name); m != nil {
+				if i, m := named.lookupMethod(pkg, name); m != nil {
 					// potential match
 					assert(m.typ != nil)
 					index = concat(e.index, i)
@@ -138,7 +143,7 @@ func lookupFieldOrMethod(T Type, addressable bool, pkg *Package, name string) (o
 				}
 
 				// continue with underlying type
-				typ = named.underlying
+				typ = named.Underlying()
 			}
 
 			switch t := typ.(type) {
@@ -324,6 +329,8 @@ func assertableTo(V *Interface, T Type) (method *Func, wrongType bool, needsOpti
 	if len(needsOptional) > 0 {
 		return
 	}
+	// an optional case in a type switch has the methods of its element
+	T = unwrapped(T)
 	// no static check is required if T is an interface
 	// spec: "If T is an interface type, x.(T) asserts that the
 	//        dynamic type of x implements the interface T."
@@ -530,3 +537,10 @@ func lookupMethod(methods []*Func, pkg *Package, name string) (int, *Func) {
 	}
 	return -1, nil
 }
+
+// assertableType reports whether all optionables in T can be checked by
+// a type assertion to T at run time.
+func assertableType(T Type) bool {
+	_, needsOptional := FindOptionables(T)
+	return len(needsOptional) == 0
+}
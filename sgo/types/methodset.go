@@ -74,11 +74,16 @@ func NewMethodSet(T Type) *MethodSet {
 	typ, isOpt := deopt(T)
 	typ, isPtr := deref(T)
 
-	// *typ where typ is an interface has no methods.
-	if isPtr && IsInterface(typ) {
+	// *typ where typ is an interface or a type parameter has no methods.
+	if isPtr && (IsInterface(typ) || isTypeParam(typ)) {
 		return &emptyMethodSet
 	}
 
+	// The methods of a type parameter are those of its constraint.
+	if tp, _ := typ.(*TypeParam); tp != nil {
+		typ = tp.iface()
+	}
+
 	// Start with typ as single entry at shallowest depth.
 	current := []embeddedType{{typ, nil, isPtr, isOpt, false}}
 
@@ -120,18 +125,18 @@ func NewMethodSet(T Type) *MethodSet {
 
 				var methods []*Func
 				if isOpt {
-					for _, m := range named.methods {
+					for _, m := range named.methodList() {
 						if _, ok := m.typ.(*Signature).recv.typ.(*Optional); ok {
 							methods = append(methods, m)
 						}
 					}
 				} else {
-					methods = named.methods
+					methods = named.methodList()
 				}
 				mset = mset.add(methods, e.index, e.indirect, e.multiples)
 
 				// continue with underlying type
-				typ = named.underlying
+				typ = named.Underlying()
 			}
 
 			switch t := typ.(type) {
@@ -0,0 +1,213 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the bookkeeping of the narrowing of optional variables
+// by nil checks, which is used to explain why a variable isn't narrowed.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/token"
+)
+
+// A NarrowReason tells whether a nil check narrowed a variable, and why not
+// if it didn't.
+type NarrowReason int
+
+const (
+	Narrowed         NarrowReason = iota // the check narrowed the variable
+	NarrowAddressed                      // the variable's address is taken
+	NarrowCaptured                       // the variable is used from a closure
+	NarrowPackageVar                     // the variable is declared at package level
+	NarrowCompound                       // the check is part of a compound condition
+	NarrowNoExit                         // the if body doesn't end with a return or a panic
+)
+
+// A Narrowing records what a nil check of a variable in an if condition,
+// like x != nil, did to the variable's type where the check applies: in the
+// if body for x != nil, and in the else branch or, if the if body returns or
+// panics, after the if statement for x == nil.
+type Narrowing struct {
+	Var    *Var         // the checked variable
+	Check  ast.Expr     // the nil check
+	Type   Type         // the narrowed type, if Reason is Narrowed
+	Reason NarrowReason // why Var isn't narrowed, if it isn't
+	Alias  *ast.Ident   // where Var is aliased, for NarrowAddressed and NarrowCaptured
+
+	pos, end token.Pos // extent of the code where the check applies
+}
+
+// Explain returns a sentence that tells what the check did, or why it didn't
+// narrow its variable. Positions are resolved with fset, and types are
+// qualified with qf.
+func (n *Narrowing) Explain(fset *token.FileSet, qf Qualifier) string {
+	name := n.Var.name
+	check := fset.Position(n.Check.Pos())
+	switch n.Reason {
+	case Narrowed:
+		return fmt.Sprintf("%s was narrowed to %s by the nil check at %s", name, TypeString(n.Type, qf), check)
+	case NarrowAddressed:
+		return fmt.Sprintf("%s is not narrowed by the nil check at %s because its address is taken at %s", name, check, fset.Position(n.Alias.Pos()))
+	case NarrowCaptured:
+		return fmt.Sprintf("%s is not narrowed by the nil check at %s because it is used from a closure at %s", name, check, fset.Position(n.Alias.Pos()))
+	case NarrowPackageVar:
+		return fmt.Sprintf("%s is not narrowed by the nil check at %s because it is a package-level variable", name, check)
+	case NarrowCompound:
+		return fmt.Sprintf("%s is not narrowed by the nil check at %s because the check is part of a compound condition", name, check)
+	case NarrowNoExit:
+		return fmt.Sprintf("%s is not narrowed after the nil check at %s because the if body doesn't end with a return or a panic", name, check)
+	}
+	return ""
+}
+
+// markAliased records that id aliases v, by taking its address or by using it
+// from a closure.
+func (check *Checker) markAliased(v *Var, id *ast.Ident, captured bool) {
+	if !v.aliased {
+		v.aliased = true
+		v.aliasedBy = id
+		v.captured = captured
+	}
+	if m := check.Aliases; m != nil {
+		m[id] = v
+	}
+}
+
+// addNarrowing records that n applies to the uses of v from pos to end. v is
+// the variable that the uses refer to, which for narrowed variables is a new
+// variable that shadows n.Var.
+func (check *Checker) addNarrowing(v *Var, n Narrowing, pos, end token.Pos) {
+	if check.narrowings == nil {
+		check.narrowings = map[*Var][]*Narrowing{}
+	}
+	n.pos, n.end = pos, end
+	check.narrowings[v] = append(check.narrowings[v], &n)
+}
+
+// recordNarrowing records the narrowing, if any, that applies to the use of v
+// at id.
+func (check *Checker) recordNarrowing(id *ast.Ident, v *Var) {
+	ns := check.narrowings[v]
+	// Later narrowings are nested in earlier ones.
+	for i := len(ns) - 1; i >= 0; i-- {
+		n := ns[i]
+		if n.pos <= id.Pos() && id.Pos() < n.end {
+			if check.narrowedUses == nil {
+				check.narrowedUses = map[*ast.Ident]*Narrowing{}
+			}
+			check.narrowedUses[id] = n
+			if m := check.Narrowings; m != nil {
+				m[id] = n
+			}
+			return
+		}
+	}
+}
+
+// narrowingNote returns an explanation to append to an error about the
+// operands in args, if one of them is an optional variable that a nil check
+// didn't narrow.
+func (check *Checker) narrowingNote(args []interface{}) string {
+	for _, arg := range args {
+		x, ok := arg.(*operand)
+		if !ok || x.mode != variable || !isOptional(x.typ) {
+			continue
+		}
+		id, ok := unparen(x.expr).(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if n := check.narrowedUses[id]; n != nil && n.Reason != Narrowed {
+			return "; " + n.Explain(check.fset, check.qualifier)
+		}
+	}
+	return ""
+}
+
+// assigningNote returns an explanation to append to an error about the
+// assignment to a variable narrowed by a nil check.
+func (check *Checker) assigningNote() string {
+	id, ok := check.assigning.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	if n := check.narrowedUses[id]; n != nil && n.Reason == Narrowed {
+		return "; " + n.Explain(check.fset, check.qualifier)
+	}
+	return ""
+}
+
+// compoundNilChecks returns the side effects that the nil checks of
+// variables in a compound condition like x != nil && y != nil would have if
+// they were on their own, with Reason NarrowCompound.
+func (check *Checker) compoundNilChecks(e ast.Expr, negated bool) []ifCondSideEffect {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return check.compoundNilChecks(e.X, negated)
+	case *ast.UnaryExpr:
+		if e.Op == token.NOT {
+			return check.compoundNilChecks(e.X, !negated)
+		}
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.LAND, token.LOR:
+			return append(check.compoundNilChecks(e.X, negated), check.compoundNilChecks(e.Y, negated)...)
+		case token.EQL, token.NEQ:
+			id, other := e.X, e.Y
+			if check.isNilIdent(id) {
+				id, other = other, id
+			}
+			ident, ok := unparen(id).(*ast.Ident)
+			if !ok || !check.isNilIdent(other) {
+				return nil
+			}
+			v := check.lookupVar(ident)
+			if v == nil || !isOptional(v.typ) {
+				return nil
+			}
+			return []ifCondSideEffect{{
+				ident:       ident,
+				typ:         v.typ.Underlying().(*Optional).elem,
+				isNilOrTrue: (e.Op == token.EQL) != negated,
+				check:       e,
+				reason:      NarrowCompound,
+			}}
+		}
+	}
+	return nil
+}
+
+// isNilIdent reports whether e is the predeclared nil in the current scope.
+func (check *Checker) isNilIdent(e ast.Expr) bool {
+	id, ok := unparen(e).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, obj := check.scope.LookupParent(id.Name, token.NoPos)
+	_, isNil := obj.(*Nil)
+	return isNil
+}
+
+// lookupVar returns the variable id refers to in the current scope, if any.
+func (check *Checker) lookupVar(id *ast.Ident) *Var {
+	_, obj := check.scope.LookupParent(id.Name, token.NoPos)
+	v, _ := obj.(*Var)
+	return v
+}
+
+// aliasReason returns why a nil check doesn't narrow v, if v is aliased.
+func aliasReason(v *Var) NarrowReason {
+	switch {
+	case !v.aliased:
+		return Narrowed
+	case v.pkg != nil && v.parent == v.pkg.scope:
+		return NarrowPackageVar
+	case v.captured:
+		return NarrowCaptured
+	}
+	return NarrowAddressed
+}
@@ -188,6 +188,8 @@ func (obj *TypeName) IsAlias() bool {
 		return obj.pkg != nil || t.name != obj.name || t == universeByte || t == universeRune
 	case *Named:
 		return obj != t.obj
+	case *TypeParam:
+		return obj != t.obj
 	default:
 		return true
 	}
@@ -196,13 +198,16 @@ func (obj *TypeName) IsAlias() bool {
 // A Variable represents a declared variable (including function parameters and results, and struct fields).
 type Var struct {
 	object
-	anonymous bool // if set, the variable is an anonymous struct field, and name is the type name
-	visited   bool // for initialization cycle detection
-	isField   bool // var is struct field
-	used      bool // set if the variable was used
-	usable    bool // true; but false for refs and left-hand entangled, and then set to true when assigned or collaped
-	aliased   bool // referenced by a pointer, or captured by closure
+	anonymous bool       // if set, the variable is an anonymous struct field, and name is the type name
+	visited   bool       // for initialization cycle detection
+	isField   bool       // var is struct field
+	used      bool       // set if the variable was used
+	usable    bool       // true; but false for refs and left-hand entangled, and then set to true when assigned or collaped
+	aliased   bool       // referenced by a pointer, or captured by closure
+	aliasedBy *ast.Ident // where the variable was first aliased
+	captured  bool       // aliased by a closure
 	collapses []*Var
+	fieldInit map[string]bool // if not nil, the field paths assigned to so far while not usable
 }
 
 // NewVar returns a new variable.
@@ -253,6 +258,7 @@ func (*Var) isDependency() {} // a variable may be a dependency of an initializa
 // An abstract method may belong to many interfaces due to embedding.
 type Func struct {
 	object
+	orig *Func // generic method if this is a method of an instantiated type, or nil
 }
 
 // NewFunc returns a new function with the given signature, representing
@@ -263,7 +269,17 @@ func NewFunc(pos token.Pos, pkg *Package, name string, sig *Signature) *Func {
 	if sig != nil {
 		typ = sig
 	}
-	return &Func{object{nil, pos, pkg, name, typ, 0, token.NoPos}}
+	return &Func{object: object{nil, pos, pkg, name, typ, 0, token.NoPos}}
+}
+
+// Origin returns the canonical Func for obj: for a method of an
+// instantiated type, the method of the generic type it is derived
+// from; otherwise, obj itself.
+func (obj *Func) Origin() *Func {
+	if obj.orig != nil {
+		return obj.orig
+	}
+	return obj
 }
 
 // FullName returns the package- or receiver-type-qualified name of
@@ -369,6 +385,12 @@ func writeObject(buf *bytes.Buffer, obj Object, qf Qualifier) {
 		return
 	}
 
+	if tname != nil && !tname.IsAlias() {
+		if named, _ := typ.(*Named); named != nil && named.TypeParams().Len() > 0 {
+			writeTParamList(buf, named.TypeParams().list(), qf, nil)
+		}
+	}
+
 	if tname != nil {
 		// We have a type object: Don't print anything more for
 		// basic types since there's no more information (names
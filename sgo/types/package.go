@@ -18,6 +18,10 @@ type Package struct {
 	complete bool
 	imports  []*Package
 	fake     bool // scope lookup errors are silently dropped if package is fake (internal use only)
+
+	unchecked   bool
+	fromGo      bool
+	annotations string
 }
 
 // NewPackage returns a new Package for the given package path and name.
@@ -48,6 +52,27 @@ func (pkg *Package) Complete() bool { return pkg.complete }
 // MarkComplete marks a package as complete.
 func (pkg *Package) MarkComplete() { pkg.complete = true }
 
+// A package is unchecked if it was converted from Go as is, without the
+// conversions SGo does by default, so values of its non-optional types may
+// still be nil.
+func (pkg *Package) Unchecked() bool { return pkg.unchecked }
+
+// MarkUnchecked marks a package as unchecked.
+func (pkg *Package) MarkUnchecked() { pkg.unchecked = true }
+
+// FromGo reports whether the package was written in Go, rather than in SGo,
+// and, if so, where the SGo annotations that its types come from are.
+func (pkg *Package) FromGo() (annotations string, ok bool) {
+	return pkg.annotations, pkg.fromGo
+}
+
+// MarkFromGo marks a package as written in Go, with SGo annotations from the
+// given source.
+func (pkg *Package) MarkFromGo(annotations string) {
+	pkg.fromGo = true
+	pkg.annotations = annotations
+}
+
 // Imports returns the list of packages directly imported by
 // pkg; the list is in source order.
 //
@@ -16,39 +16,42 @@ func isNamed(typ Type) bool {
 	return ok
 }
 
-func isBoolean(typ Type) bool {
+// isBasic reports whether typ is a basic type with the given properties.
+// For a type parameter, each type in its type set must be such a type.
+func isBasic(typ Type, info BasicInfo) bool {
+	if tp, _ := typ.(*TypeParam); tp != nil {
+		return tp.allTypes(func(t Type) bool { return isBasic(t, info) })
+	}
 	t, ok := typ.Underlying().(*Basic)
-	return ok && t.info&IsBoolean != 0
+	return ok && t.info&info != 0
+}
+
+func isBoolean(typ Type) bool {
+	return isBasic(typ, IsBoolean)
 }
 
 func isInteger(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.info&IsInteger != 0
+	return isBasic(typ, IsInteger)
 }
 
 func isUnsigned(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.info&IsUnsigned != 0
+	return isBasic(typ, IsUnsigned)
 }
 
 func isFloat(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.info&IsFloat != 0
+	return isBasic(typ, IsFloat)
 }
 
 func isComplex(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.info&IsComplex != 0
+	return isBasic(typ, IsComplex)
 }
 
 func isNumeric(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.info&IsNumeric != 0
+	return isBasic(typ, IsNumeric)
 }
 
 func isString(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.info&IsString != 0
+	return isBasic(typ, IsString)
 }
 
 func isTyped(typ Type) bool {
@@ -62,8 +65,7 @@ func isUntyped(typ Type) bool {
 }
 
 func isOrdered(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.info&IsOrdered != 0
+	return isBasic(typ, IsOrdered)
 }
 
 func isConstType(typ Type) bool {
@@ -77,11 +79,20 @@ func isOptional(typ Type) bool {
 }
 
 // IsOptionable reports whether typ is a type that may be found wrapped in an
-// optional: an interface, map, pointer, function or channel type.
+// optional: an interface, map, pointer, function or channel type, or a type
+// parameter whose constraint only admits such types.
 func IsOptionable(typ Type) bool {
+	if tp, _ := typ.(*TypeParam); tp != nil {
+		return tp.iface().IsOptionable()
+	}
 	return IsInterface(typ) || isMap(typ) || isPointer(typ) || isSignature(typ) || isChan(typ)
 }
 
+func isTypeParam(typ Type) bool {
+	_, ok := typ.(*TypeParam)
+	return ok
+}
+
 func isChan(typ Type) bool {
 	_, ok := typ.Underlying().(*Chan)
 	return ok
@@ -113,6 +124,8 @@ func Comparable(T Type) bool {
 		return Comparable(t.elem)
 	case *Optional:
 		return Comparable(t.elem)
+	case *TypeParam:
+		return t.iface().IsComparable()
 	}
 	return false
 }
@@ -241,7 +254,8 @@ func identical(x, y Type, cmpTags bool, p *ifacePair) bool {
 		// Two interface types are identical if they have the same set of methods with
 		// the same names and identical function types. Lower-case method names from
 		// different packages are always different. The order of the methods is irrelevant.
-		if y, ok := y.(*Interface); ok {
+		// Constraint interfaces must also have identical type sets.
+		if y, ok := y.(*Interface); ok && identicalTypeSets(x, y) {
 			a := x.allMethods
 			b := y.allMethods
 			if len(a) == len(b) {
@@ -304,8 +318,24 @@ func identical(x, y Type, cmpTags bool, p *ifacePair) bool {
 	case *Named:
 		// Two named types are identical if their type names originate
 		// in the same type declaration.
+		// Two instantiated types are identical if they are instances
+		// of the same generic type with identical type arguments.
 		if y, ok := y.(*Named); ok {
-			return x.obj == y.obj
+			return x.obj == y.obj && identicalTypes(x.targs.list(), y.targs.list())
+		}
+
+	case *TypeParam:
+		// Type parameters are only identical to themselves.
+
+	case *Union:
+		// Two unions are identical if they have the same terms, in any order.
+		if y, ok := y.(*Union); ok && len(x.terms) == len(y.terms) {
+			for _, t := range x.terms {
+				if !includesTerm(y.terms, t) {
+					return false
+				}
+			}
+			return true
 		}
 
 	case nil:
@@ -317,6 +347,21 @@ func identical(x, y Type, cmpTags bool, p *ifacePair) bool {
 	return false
 }
 
+func identicalTypeSets(x, y *Interface) bool {
+	if x.comparable != y.comparable || x.optionable != y.optionable || x.nonoptionable != y.nonoptionable || x.restricted != y.restricted {
+		return false
+	}
+	if len(x.terms) != len(y.terms) {
+		return false
+	}
+	for _, t := range x.terms {
+		if !includesTerm(y.terms, t) {
+			return false
+		}
+	}
+	return true
+}
+
 // Default returns the default "typed" type for an "untyped" type;
 // it returns the incoming type for all other types. The default type
 // for untyped nil is untyped nil.
@@ -358,6 +403,13 @@ func hasZeroValue2(typ Type, namestack []string, found func([]string)) bool {
 	}
 	has := true
 	switch t := typ.Underlying().(type) {
+	case *TypeParam:
+		// A type parameter has a zero value if all types in its type set do.
+		iface := t.iface()
+		if !iface.nonoptionable && !t.allTypes(func(t Type) bool { return hasZeroValue2(t, nil, func([]string) {}) }) {
+			found(namestack)
+			has = false
+		}
 	case *Struct:
 		for i := 0; i < t.NumFields(); i++ {
 			f := t.Field(i)
@@ -370,3 +422,10 @@ func hasZeroValue2(typ Type, namestack []string, found func([]string)) bool {
 	}
 	return has
 }
+
+// isGeneric reports whether typ is a generic type that hasn't been
+// instantiated.
+func isGeneric(typ Type) bool {
+	named, _ := typ.(*Named)
+	return named != nil && named.tparams != nil && named.targs == nil
+}
@@ -17,12 +17,13 @@ import (
 
 // A declInfo describes a package-level const, type, var, or func declaration.
 type declInfo struct {
-	file  *Scope        // scope of file containing this declaration
-	lhs   []*Var        // lhs of n:1 variable declarations, or nil
-	typ   ast.Expr      // type, or nil
-	init  ast.Expr      // init/orig expression, or nil
-	fdecl *ast.FuncDecl // func declaration, or nil
-	alias bool          // type alias declaration
+	file    *Scope         // scope of file containing this declaration
+	lhs     []*Var         // lhs of n:1 variable declarations, or nil
+	typ     ast.Expr       // type, or nil
+	tparams *ast.FieldList // type parameters of a type declaration, or nil
+	init    ast.Expr       // init/orig expression, or nil
+	fdecl   *ast.FuncDecl  // func declaration, or nil
+	alias   bool           // type alias declaration
 
 	// The deps field tracks initialization expression dependencies.
 	// As a special (overloaded) case, it also tracks dependencies of
@@ -401,7 +402,7 @@ func (check *Checker) collectObjects() {
 
 					case *ast.TypeSpec:
 						obj := NewTypeName(s.Name.Pos(), pkg, s.Name.Name, nil)
-						check.declarePkgObj(s.Name, obj, &declInfo{file: fileScope, typ: s.Type, alias: s.Assign.IsValid()})
+						check.declarePkgObj(s.Name, obj, &declInfo{file: fileScope, typ: s.Type, tparams: s.TypeParams, alias: s.Assign.IsValid()})
 
 					default:
 						check.invalidAST(s.Pos(), "unknown ast.Spec node %T", s)
@@ -439,6 +440,13 @@ func (check *Checker) collectObjects() {
 						if ptr, _ := typ.(*ast.StarExpr); ptr != nil {
 							typ = unparen(ptr.X)
 						}
+						// strip type parameters of a generic receiver type
+						switch inst := typ.(type) {
+						case *ast.IndexExpr:
+							typ = unparen(inst.X)
+						case *ast.IndexListExpr:
+							typ = unparen(inst.X)
+						}
 						if base, _ := typ.(*ast.Ident); base != nil && base.Name != "_" {
 							check.assocMethod(base.Name, obj)
 						}
@@ -24,9 +24,14 @@ func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body
 		defer fmt.Println("--- <end>")
 	}
 
-	// set function scope extent
+	// set function scope extent, and that of the scope of the type
+	// parameters enclosing it
 	sig.scope.pos = body.Pos()
 	sig.scope.end = body.End()
+	if sig.tparams.Len() > 0 || sig.rparams.Len() > 0 {
+		sig.scope.parent.pos = body.Pos()
+		sig.scope.parent.end = body.End()
+	}
 
 	// We need to clone the scope here so that, when changing the type of a
 	// parameter inside the function body (e. g.. when doing `if x != nil { return }`
@@ -43,7 +48,11 @@ func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body
 			if _, ok := scope.elems[param.name]; ok {
 				delete(scope.elems, param.name)
 			}
-			scope.Insert(NewParam(param.pos, param.pkg, param.name, param.typ))
+			v := NewParam(param.pos, param.pkg, param.name, param.typ)
+			if e, ok := check.declTypes[param]; ok {
+				check.recordDeclType(v, e)
+			}
+			scope.Insert(v)
 		}
 	}
 	if sig.results != nil {
@@ -149,11 +158,20 @@ func (check *Checker) stmtList(ctxt stmtContext, list []ast.Stmt) {
 	ok := ctxt&fallthroughOk != 0
 	inner := ctxt &^ fallthroughOk
 	list = trimTrailingEmptyStmts(list) // trailing empty statements are "invisible" to fallthrough analysis
+	defer func(listStmt ast.Stmt) {
+		check.listStmt = listStmt
+	}(check.listStmt)
 	for i, s := range list {
 		inner := inner
 		if ok && i+1 == len(list) {
 			inner |= fallthroughOk
 		}
+		switch s.(type) {
+		case *ast.CaseClause, *ast.CommClause:
+			// Nothing can be inserted before a clause.
+		default:
+			check.listStmt = s
+		}
 		check.stmt(inner, s)
 	}
 }
@@ -390,7 +408,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			return
 		}
 
-		tch, ok := ch.typ.Underlying().(*Chan)
+		tch, ok := coreType(ch.typ).(*Chan)
 		if !ok {
 			check.invalidOp(s.Arrow, "cannot send to non-chan type %s", ch.typ)
 			return
@@ -553,19 +571,23 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		effs := check.ifCondSideEffects(x)
 
 		wereUsable := map[*Var]bool{}
+		wereInit := map[*Var]map[string]bool{}
 		sc := check.scope.Parent()
 		for sc != nil {
 			names := sc.Names()
 			for _, name := range names {
 				if v, ok := sc.Lookup(name).(*Var); ok {
 					wereUsable[v] = v.usable
+					if v.fieldInit != nil {
+						wereInit[v] = copyParts(v.fieldInit)
+					}
 				}
 			}
 			sc = sc.Parent()
 		}
 
 		check.openScope(&ast.BadStmt{}, "ifBody")
-		collapsed := check.handleEffs(effs, false, check.scope)
+		collapsed := check.handleEffs(effs, false, check.scope, s.Body.Pos(), s.Body.End())
 		check.stmt(inner, s.Body)
 		check.closeScope()
 
@@ -581,10 +603,15 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		}
 
 		usableAfterBody := map[*Var]bool{}
+		initAfterBody := map[*Var]map[string]bool{}
 		for v, wasUsable := range wereUsable {
 			if v.usable {
 				usableAfterBody[v] = true
 			}
+			if init, ok := wereInit[v]; ok {
+				initAfterBody[v] = v.fieldInit
+				v.fieldInit = copyParts(init)
+			}
 			v.usable = wasUsable
 			if debugUsable {
 				fmt.Println("USABLE if restore usable after body:", v.name, fmt.Sprintf("%p", v), v.usable)
@@ -600,12 +627,26 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 		if s.Else != nil {
 			check.openScope(&ast.BadStmt{}, "elseBody")
-			collapsed = check.handleEffs(effs, true, check.scope)
+			collapsed = check.handleEffs(effs, true, check.scope, s.Else.Pos(), s.Else.End())
 			check.stmt(inner, s.Else)
 			check.closeScope()
 
 			for v, wasUsable := range wereUsable {
 				if !(v.usable && usableAfterBody[v]) {
+					// Parts of v initialized in both branches are
+					// initialized after them.
+					if _, ok := wereInit[v]; ok && !wasUsable {
+						bodyInit, elseInit := initAfterBody[v], v.fieldInit
+						if usableAfterBody[v] {
+							bodyInit = map[string]bool{"": true}
+						}
+						if v.usable {
+							elseInit = map[string]bool{"": true}
+						}
+						v.fieldInit = mergeParts(bodyInit, elseInit)
+						v.usable = len(uninitParts(v.typ, "", v.fieldInit)) == 0
+						continue
+					}
 					v.usable = wasUsable
 					if debugUsable {
 						fmt.Println("USABLE else restore usable after body:", v.name, fmt.Sprintf("%p", v), v.usable)
@@ -621,6 +662,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			}
 		}
 
+		exits := false
 		if len(s.Body.List) > 0 {
 			lastStmt := s.Body.List[len(s.Body.List)-1]
 			switch lastStmt := lastStmt.(type) {
@@ -628,7 +670,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				if debugUsable {
 					fmt.Println("USABLE if.body returns, so simulate that rest of the statements are in else")
 				}
-				check.handleEffs(effs, true, check.scope.parent)
+				exits = true
 			case *ast.ExprStmt:
 				call, ok := lastStmt.X.(*ast.CallExpr)
 				if !ok {
@@ -641,9 +683,14 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				if debugUsable {
 					fmt.Println("USABLE if.body panics, so simulate that rest of the statements are in else")
 				}
-				check.handleEffs(effs, true, check.scope.parent)
+				exits = true
 			}
 		}
+		if exits {
+			check.handleEffs(effs, true, check.scope.parent, s.End(), check.scope.parent.end)
+		} else {
+			check.noExitNarrowings(effs, s.End(), check.scope.parent.end)
+		}
 
 	case *ast.SwitchStmt:
 		inner |= breakOk
@@ -668,6 +715,13 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 		check.multipleDefaults(s.Body.List)
 
+		// Parts of variables are initialized after the switch if they are
+		// after every clause that completes, and before it if no clause may
+		// run.
+		partial := check.partialVars()
+		var branches []map[*Var]map[string]bool
+		hasDefault := false
+
 		seen := make(valueMap) // map of seen case values to positions and types
 		for i, c := range s.Body.List {
 			clause, _ := c.(*ast.CaseClause)
@@ -685,6 +739,18 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			}
 			check.stmtList(inner, clause.Body)
 			check.closeScope()
+			branches = check.endClause(partial, branches, clause.Body)
+			if clause.List.Len() == 0 {
+				hasDefault = true
+			}
+		}
+		if !hasDefault {
+			branches = append(branches, partial)
+		}
+		joinBranches(partial, branches)
+
+		if check.markedExhaustive(s) {
+			check.exhaustiveSwitch(s, &x)
 		}
 
 	case *ast.TypeSwitchStmt:
@@ -761,6 +827,10 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 		check.multipleDefaults(s.Body.List)
 
+		partial := check.partialVars()
+		var branches []map[*Var]map[string]bool
+		hasDefault := false
+
 		var lhsVars []*Var               // list of implicitly declared lhs variables
 		seen := make(map[Type]token.Pos) // map of seen types to positions
 		for _, s := range s.Body.List {
@@ -800,6 +870,18 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			}
 			check.stmtList(inner, clause.Body)
 			check.closeScope()
+			branches = check.endClause(partial, branches, clause.Body)
+			if clause.List.Len() == 0 {
+				hasDefault = true
+			}
+		}
+		if !hasDefault {
+			branches = append(branches, partial)
+		}
+		joinBranches(partial, branches)
+
+		if check.markedExhaustive(s) {
+			check.exhaustiveTypeSwitch(s, &x, xtyp, seen)
 		}
 
 		// If lhs exists, we must have at least one lhs variable that was used.
@@ -821,6 +903,10 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 		check.multipleDefaults(s.Body.List)
 
+		// One of the clauses always runs.
+		partial := check.partialVars()
+		var branches []map[*Var]map[string]bool
+
 		for _, s := range s.Body.List {
 			clause, _ := s.(*ast.CommClause)
 			if clause == nil {
@@ -859,7 +945,9 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			}
 			check.stmtList(inner, clause.Body)
 			check.closeScope()
+			branches = check.endClause(partial, branches, clause.Body)
 		}
+		joinBranches(partial, branches)
 
 	case *ast.ForStmt:
 		inner |= breakOk | continueOk
@@ -884,7 +972,11 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			// these lhs variables being declared but not used.
 			check.use(s.Lhs.List...) // avoid follow-up errors
 		}
+		// The body may not run, so parts of variables initialized in it
+		// aren't after the loop.
+		partial := check.partialVars()
 		check.stmt(inner, s.Body)
+		endBranch(partial)
 
 	case *ast.RangeStmt:
 		inner |= breakOk | continueOk
@@ -897,12 +989,20 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 		// determine key/value types
 		var key, val Type
+		rangeOverInt := false
 		if x.mode != invalid {
-			switch typ := x.typ.Underlying().(type) {
+			switch typ := coreType(x.typ).(type) {
 			case *Basic:
 				if isString(typ) {
 					key = Typ[Int]
 					val = universeRune // use 'rune' name
+				} else if isInteger(typ) {
+					key = x.typ
+					rangeOverInt = true
+					if s.Value != nil {
+						check.errorf(s.Value.Pos(), "range over %s permits only one iteration variable", &x)
+						// ok to continue
+					}
 				}
 			case *Array:
 				key = Typ[Int]
@@ -934,6 +1034,8 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 					check.errorf(s.Value.Pos(), "iteration over %s permits only one iteration variable", &x)
 					// ok to continue
 				}
+			case *Signature:
+				key, val = check.rangeFuncKeyVal(&x, typ, s)
 			}
 		}
 
@@ -976,7 +1078,10 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				}
 
 				// initialize lhs variable
-				if typ := rhs[i]; typ != nil {
+				if rangeOverInt && i == 0 {
+					// untyped constants get their default type
+					check.initVar(obj, &x, "range clause")
+				} else if typ := rhs[i]; typ != nil {
 					x.mode = value
 					x.expr = lhs // we don't have a better rhs expression to use here
 					x.typ = typ
@@ -1006,7 +1111,9 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				if lhs == nil {
 					continue
 				}
-				if typ := rhs[i]; typ != nil {
+				if rangeOverInt && i == 0 {
+					check.assignVar(lhs, &x)
+				} else if typ := rhs[i]; typ != nil {
 					x.mode = value
 					x.expr = lhs // we don't have a better rhs expression to use here
 					x.typ = typ
@@ -1015,17 +1122,61 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			}
 		}
 
+		// The body may not run, so parts of variables initialized in it
+		// aren't after the loop.
+		partial := check.partialVars()
 		check.stmt(inner, s.Body)
+		endBranch(partial)
 
 	default:
 		check.error(s.Pos(), "invalid statement")
 	}
 }
 
+// rangeFuncKeyVal returns the key and value types for ranging over x, of the
+// function type sig, or nil if sig isn't an iterator function.
+func (check *Checker) rangeFuncKeyVal(x *operand, sig *Signature, s *ast.RangeStmt) (key, val Type) {
+	bad := func(cause string) (Type, Type) {
+		check.errorf(x.pos(), "cannot range over %s: func must be func(yield func(...) bool): %s", x, cause)
+		return Typ[Invalid], Typ[Invalid]
+	}
+	switch {
+	case sig.TypeParams().Len() > 0:
+		return bad("generic function without instantiation")
+	case sig.Params().Len() != 1:
+		return bad("wrong argument count")
+	case sig.Results().Len() != 0:
+		return bad("unexpected results")
+	}
+	yield, _ := coreType(sig.Params().At(0).Type()).(*Signature)
+	switch {
+	case yield == nil:
+		return bad("argument is not func")
+	case yield.Params().Len() > 2:
+		return bad("yield func has too many parameters")
+	case yield.Results().Len() != 1 || !Identical(yield.Results().At(0).Type(), Typ[Bool]):
+		return bad("yield func does not return bool")
+	}
+	key, val = Typ[Invalid], Typ[Invalid]
+	if yield.Params().Len() >= 1 {
+		key = yield.Params().At(0).Type()
+	}
+	if yield.Params().Len() >= 2 {
+		val = yield.Params().At(1).Type()
+	}
+	if s.Key != nil && yield.Params().Len() < 1 || s.Value != nil && yield.Params().Len() < 2 {
+		check.errorf(x.pos(), "range over %s permits only %d iteration variables", x, yield.Params().Len())
+		// ok to continue
+	}
+	return key, val
+}
+
 type ifCondSideEffect struct {
 	ident       *ast.Ident
 	typ         Type
 	isNilOrTrue bool
+	check       ast.Expr     // the nil check, if it's one
+	reason      NarrowReason // why the nil check doesn't narrow ident, if it doesn't
 }
 
 // unwrappedOptionals looks up in a boolean expression all the variables of
@@ -1064,6 +1215,9 @@ func (checker *Checker) ifCondSideEffects(x operand) []ifCondSideEffect {
 			})
 		}
 	case *ast.BinaryExpr:
+		if v.Op == token.LAND || v.Op == token.LOR {
+			return checker.compoundNilChecks(v, false)
+		}
 		if v.Op != token.EQL && v.Op != token.NEQ {
 			return effs
 		}
@@ -1089,10 +1243,14 @@ func (checker *Checker) ifCondSideEffects(x operand) []ifCondSideEffect {
 			xOp, yOp, xId, yId = yOp, xOp, yId, xId
 		}
 
-		if (isReversedOptionalUnwrap || (isOptional(xOp.typ) && yOp.isNil())) && !checker.isAliasedVar(xId) {
+		if isReversedOptionalUnwrap || (isOptional(xOp.typ) && yOp.isNil()) {
 			eff.ident = xId
 			eff.typ = xOp.typ.Underlying().(*Optional).elem
 			eff.isNilOrTrue = v.Op == token.EQL
+			eff.check = v
+			if v := checker.lookupVar(xId); v != nil {
+				eff.reason = aliasReason(v)
+			}
 		} else if isReversedBoolCollapse || (isBooleanConst(yOp) && checker.isCollapserVar(xId)) {
 			eff.ident = xId
 			eff.typ = xOp.typ.Underlying()
@@ -1106,9 +1264,22 @@ func (checker *Checker) ifCondSideEffects(x operand) []ifCondSideEffect {
 	return effs
 }
 
-func (check *Checker) handleEffs(effs []ifCondSideEffect, inElse bool, sc *Scope) []*Var {
+// handleEffs applies the side effects of an if condition to the code from
+// pos to end, which is in the if body, or in the else branch or after the if
+// statement if inElse.
+func (check *Checker) handleEffs(effs []ifCondSideEffect, inElse bool, sc *Scope, pos, end token.Pos) []*Var {
 	var collapsed []*Var
 	for _, eff := range effs {
+		if eff.reason != Narrowed {
+			if inElse == eff.isNilOrTrue {
+				if _, obj := sc.LookupParent(eff.ident.Name, token.NoPos); obj != nil {
+					if v, ok := obj.(*Var); ok {
+						check.addNarrowing(v, Narrowing{Var: v, Check: eff.check, Reason: eff.reason, Alias: v.aliasedBy}, pos, end)
+					}
+				}
+			}
+			continue
+		}
 		if (!inElse && eff.isNilOrTrue) || (inElse && !eff.isNilOrTrue) {
 			_, v := sc.LookupParent(eff.ident.Name, token.NoPos)
 			if v, ok := v.(*Var); ok {
@@ -1123,6 +1294,7 @@ func (check *Checker) handleEffs(effs []ifCondSideEffect, inElse bool, sc *Scope
 				}
 			}
 		} else {
+			_, checked := sc.LookupParent(eff.ident.Name, token.NoPos)
 			var va *Var
 			if v, ok := sc.Lookup(eff.ident.Name).(*Var); ok {
 				v.setType(eff.typ)
@@ -1137,23 +1309,32 @@ func (check *Checker) handleEffs(effs []ifCondSideEffect, inElse bool, sc *Scope
 			if debugUsable {
 				fmt.Println("USABLE if-else unwrapped var:", fmt.Sprintf("(inElse: %v)", inElse), va.name, fmt.Sprintf("%p", va), va.usable)
 			}
+			if checked, ok := checked.(*Var); ok && eff.check != nil {
+				check.addNarrowing(va, Narrowing{Var: checked, Check: eff.check, Type: eff.typ}, pos, end)
+			}
 		}
 	}
 	return collapsed
 }
 
-func (c *Checker) isCollapserVar(id *ast.Ident) bool {
-	_, v := c.scope.LookupParent(id.Name, token.NoPos)
-	if v, ok := v.(*Var); ok {
-		return len(v.collapses) > 0
+// noExitNarrowings records that the nil checks in effs that would narrow
+// their variables after an if statement, from pos to end, don't, because the
+// if body doesn't return or panic.
+func (check *Checker) noExitNarrowings(effs []ifCondSideEffect, pos, end token.Pos) {
+	for _, eff := range effs {
+		if eff.check == nil || !eff.isNilOrTrue || eff.reason != Narrowed {
+			continue
+		}
+		if v := check.lookupVar(eff.ident); v != nil {
+			check.addNarrowing(v, Narrowing{Var: v, Check: eff.check, Reason: NarrowNoExit}, pos, end)
+		}
 	}
-	return false
 }
 
-func (c *Checker) isAliasedVar(id *ast.Ident) bool {
+func (c *Checker) isCollapserVar(id *ast.Ident) bool {
 	_, v := c.scope.LookupParent(id.Name, token.NoPos)
 	if v, ok := v.(*Var); ok {
-		return v.aliased
+		return len(v.collapses) > 0
 	}
 	return false
 }
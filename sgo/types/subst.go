@@ -0,0 +1,228 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements type parameter substitution.
+
+package types
+
+// A substMap maps type parameters to the types substituted for them.
+type substMap map[*TypeParam]Type
+
+// makeSubstMap creates a new substitution map mapping tpars[i] to targs[i].
+// If targs[i] is nil, tpars[i] is not substituted.
+func makeSubstMap(tpars []*TypeParam, targs []Type) substMap {
+	assert(len(tpars) == len(targs))
+	smap := make(substMap, len(tpars))
+	for i, tpar := range tpars {
+		if targs[i] != nil {
+			smap[tpar] = targs[i]
+		}
+	}
+	return smap
+}
+
+// subst returns the type typ with its type parameters tparams replaced by
+// the corresponding type arguments targs, recursively. Types that don't
+// refer to any substituted type parameter are returned unchanged.
+func subst(typ Type, smap substMap) Type {
+	if len(smap) == 0 {
+		return typ
+	}
+	s := subster{smap: smap}
+	return s.typ(typ)
+}
+
+type subster struct {
+	smap substMap
+}
+
+func (s *subster) typ(typ Type) Type {
+	switch t := typ.(type) {
+	case nil, *Basic:
+		// nothing to do
+
+	case *TypeParam:
+		if u := s.smap[t]; u != nil {
+			return u
+		}
+
+	case *Array:
+		if elem := s.typ(t.elem); elem != t.elem {
+			return &Array{len: t.len, elem: elem}
+		}
+
+	case *Optional:
+		if elem := s.typ(t.elem); elem != t.elem {
+			return &Optional{elem: elem}
+		}
+
+	case *Slice:
+		if elem := s.typ(t.elem); elem != t.elem {
+			return &Slice{elem: elem}
+		}
+
+	case *Pointer:
+		if base := s.typ(t.base); base != t.base {
+			return &Pointer{base: base}
+		}
+
+	case *Struct:
+		if fields, copied := s.varList(t.fields); copied {
+			return &Struct{fields: fields, tags: t.tags}
+		}
+
+	case *Tuple:
+		return s.tuple(t)
+
+	case *Signature:
+		recv := t.recv
+		if recv != nil {
+			recv = s.variable(recv)
+		}
+		params := s.tuple(t.params)
+		results := s.tuple(t.results)
+		if recv != t.recv || params != t.params || results != t.results {
+			return &Signature{
+				scope:    t.scope,
+				recv:     recv,
+				tparams:  t.tparams,
+				rparams:  t.rparams,
+				params:   params,
+				results:  results,
+				variadic: t.variadic,
+			}
+		}
+
+	case *Interface:
+		return s.iface(t)
+
+	case *Union:
+		if terms, copied := s.termList(t.terms); copied {
+			return &Union{terms}
+		}
+
+	case *Map:
+		key := s.typ(t.key)
+		elem := s.typ(t.elem)
+		if key != t.key || elem != t.elem {
+			return &Map{key: key, elem: elem}
+		}
+
+	case *Chan:
+		if elem := s.typ(t.elem); elem != t.elem {
+			return &Chan{dir: t.dir, elem: elem}
+		}
+
+	case *Named:
+		if t.targs == nil {
+			// not an instance; cannot refer to type parameters
+			break
+		}
+		targs := t.targs.list()
+		var newTargs []Type
+		for i, targ := range targs {
+			if new := s.typ(targ); new != targ {
+				if newTargs == nil {
+					newTargs = make([]Type, len(targs))
+					copy(newTargs, targs)
+				}
+				newTargs[i] = new
+			}
+		}
+		if newTargs != nil {
+			return t.orig.instance(newTargs)
+		}
+
+	default:
+		unreachable()
+	}
+
+	return typ
+}
+
+func (s *subster) variable(v *Var) *Var {
+	if v == nil {
+		return nil
+	}
+	if typ := s.typ(v.typ); typ != v.typ {
+		w := *v
+		w.typ = typ
+		return &w
+	}
+	return v
+}
+
+func (s *subster) varList(in []*Var) (out []*Var, copied bool) {
+	out = in
+	for i, v := range in {
+		if w := s.variable(v); w != v {
+			if !copied {
+				out = make([]*Var, len(in))
+				copy(out, in)
+				copied = true
+			}
+			out[i] = w
+		}
+	}
+	return
+}
+
+func (s *subster) tuple(t *Tuple) *Tuple {
+	if t == nil {
+		return nil
+	}
+	vars, copied := s.varList(t.vars)
+	entangled := s.variable(t.entangled)
+	if copied || entangled != t.entangled {
+		return &Tuple{vars: vars, entangled: entangled}
+	}
+	return t
+}
+
+func (s *subster) funcList(in []*Func) (out []*Func, copied bool) {
+	out = in
+	for i, f := range in {
+		if typ := s.typ(f.typ); typ != f.typ {
+			if !copied {
+				out = make([]*Func, len(in))
+				copy(out, in)
+				copied = true
+			}
+			g := *f
+			g.typ = typ
+			out[i] = &g
+		}
+	}
+	return
+}
+
+func (s *subster) termList(in []*Term) (out []*Term, copied bool) {
+	out = in
+	for i, t := range in {
+		if typ := s.typ(t.typ); typ != t.typ {
+			if !copied {
+				out = make([]*Term, len(in))
+				copy(out, in)
+				copied = true
+			}
+			out[i] = &Term{t.tilde, typ}
+		}
+	}
+	return
+}
+
+func (s *subster) iface(t *Interface) Type {
+	methods, mcopied := s.funcList(t.methods)
+	allMethods, acopied := s.funcList(t.allMethods)
+	terms, tcopied := s.termList(t.terms)
+	if !mcopied && !acopied && !tcopied {
+		return t
+	}
+	iface := *t
+	iface.mset = nil
+	iface.methods = methods
+	iface.allMethods = allMethods
+	iface.terms = terms
+	return &iface
+}
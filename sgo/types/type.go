@@ -223,11 +223,13 @@ type Signature struct {
 	// and store it in the Func Object) because when type-checking a function
 	// literal we call the general type checker which returns a general Type.
 	// We then unpack the *Signature and use the scope for the literal body.
-	scope    *Scope // function scope, present for package-local signatures
-	recv     *Var   // nil if not a method
-	params   *Tuple // (incoming) parameters from left to right; or nil
-	results  *Tuple // (outgoing) results from left to right; or nil
-	variadic bool   // true if the last parameter's type is of the form ...T (or string, for append built-in only)
+	scope    *Scope         // function scope, present for package-local signatures
+	recv     *Var           // nil if not a method
+	tparams  *TypeParamList // type parameters from left to right; or nil
+	rparams  *TypeParamList // receiver type parameters from left to right; or nil
+	params   *Tuple         // (incoming) parameters from left to right; or nil
+	results  *Tuple         // (outgoing) results from left to right; or nil
+	variadic bool           // true if the last parameter's type is of the form ...T (or string, for append built-in only)
 }
 
 // NewSignature returns a new function type for the given receiver, parameters,
@@ -244,7 +246,24 @@ func NewSignature(recv *Var, params, results *Tuple, variadic bool) *Signature {
 			panic("types.NewSignature: variadic parameter must be of unnamed slice type")
 		}
 	}
-	return &Signature{nil, recv, params, results, variadic}
+	return &Signature{recv: recv, params: params, results: results, variadic: variadic}
+}
+
+// NewSignatureType creates a new function type for the given receiver,
+// receiver type parameters, type parameters, parameters, and results.
+// Only methods may have receiver type parameters, and only functions
+// may have type parameters. Otherwise it behaves like NewSignature.
+func NewSignatureType(recv *Var, recvTypeParams, typeParams []*TypeParam, params, results *Tuple, variadic bool) *Signature {
+	if len(recvTypeParams) != 0 && recv == nil {
+		panic("function with receiver type parameters must have a receiver")
+	}
+	if len(typeParams) != 0 && recv != nil {
+		panic("function with type parameters cannot have a receiver")
+	}
+	sig := NewSignature(recv, params, results, variadic)
+	sig.rparams = bindTParams(recvTypeParams)
+	sig.tparams = bindTParams(typeParams)
+	return sig
 }
 
 // Recv returns the receiver of signature s (if a method), or nil if a
@@ -264,13 +283,28 @@ func (s *Signature) Results() *Tuple { return s.results }
 // Variadic reports whether the signature s is variadic.
 func (s *Signature) Variadic() bool { return s.variadic }
 
+// TypeParams returns the type parameters of signature s, or nil.
+func (s *Signature) TypeParams() *TypeParamList { return s.tparams }
+
+// RecvTypeParams returns the receiver type parameters of signature s, or nil.
+func (s *Signature) RecvTypeParams() *TypeParamList { return s.rparams }
+
 // An Interface represents an interface type.
 type Interface struct {
 	mset      objset
 	methods   []*Func  // ordered list of explicitly declared methods
 	embeddeds []*Named // ordered list of explicitly embedded types
+	unions    []*Union // explicitly embedded unions and single type terms, in source order
 
 	allMethods []*Func // ordered list of methods declared with or embedded in this interface (TODO(gri): replace with mset)
+
+	// Type set restrictions, computed together with allMethods. Interfaces
+	// with restrictions may only be used as type constraints.
+	terms         []*Term // permitted types if restricted is set
+	restricted    bool    // the type set is limited to terms
+	comparable    bool    // only comparable types are permitted
+	optionable    bool    // only optionable, non-optional types are permitted
+	nonoptionable bool    // only non-optional types with a zero value that aren't optionable are permitted
 }
 
 // emptyInterface represents the empty (completed) interface
@@ -352,8 +386,18 @@ func (t *Interface) Complete() *Interface {
 	sort.Sort(byUniqueMethodName(t.methods))
 	sort.Sort(byUniqueTypeName(t.embeddeds))
 
+	var embeddeds []*Interface
+	for _, et := range t.embeddeds {
+		embeddeds = append(embeddeds, et.Underlying().(*Interface))
+	}
+	for _, u := range t.unions {
+		if it := u.embeddedInterface(); it != nil {
+			embeddeds = append(embeddeds, it)
+		}
+	}
+
 	var allMethods []*Func
-	if t.embeddeds == nil {
+	if embeddeds == nil {
 		if t.methods == nil {
 			allMethods = make([]*Func, 0, 1)
 		} else {
@@ -361,8 +405,7 @@ func (t *Interface) Complete() *Interface {
 		}
 	} else {
 		allMethods = append(allMethods, t.methods...)
-		for _, et := range t.embeddeds {
-			it := et.Underlying().(*Interface)
+		for _, it := range embeddeds {
 			it.Complete()
 			for _, tm := range it.allMethods {
 				// Make a copy of the method and adjust its receiver type.
@@ -376,6 +419,7 @@ func (t *Interface) Complete() *Interface {
 		sort.Sort(byUniqueMethodName(allMethods))
 	}
 	t.allMethods = allMethods
+	t.completeTypeSet()
 
 	return t
 }
@@ -424,10 +468,23 @@ func (c *Chan) Dir() ChanDir { return c.dir }
 func (c *Chan) Elem() Type { return c.elem }
 
 // A Named represents a named type.
+//
+// A generic named type has type parameters. Instantiating it yields
+// a new Named whose underlying type and methods are those of the
+// generic (original) type, with the type arguments substituted for
+// the type parameters; they are computed lazily on first use.
 type Named struct {
-	obj        *TypeName // corresponding declared object
-	underlying Type      // possibly a *Named during setup; never a *Named once set up completely
-	methods    []*Func   // methods declared for this type (not the method set of this type)
+	obj        *TypeName      // corresponding declared object
+	underlying Type           // possibly a *Named during setup; never a *Named once set up completely
+	methods    []*Func        // methods declared for this type (not the method set of this type)
+	tparams    *TypeParamList // type parameters, or nil
+	targs      *TypeList      // type arguments (after instantiation), or nil
+	orig       *Named         // original, uninstantiated type; t itself if not an instance
+
+	resolving bool          // set while the underlying type is being determined (originals only)
+	expanded  bool          // set once underlying has been substituted (instances only)
+	instances []*Named      // cache of instances of a generic type (originals only)
+	imethods  map[int]*Func // lazily substituted methods (instances only)
 }
 
 // NewNamed returns a new named type for the given type name, underlying type, and associated methods.
@@ -438,6 +495,7 @@ func NewNamed(obj *TypeName, underlying Type, methods []*Func) *Named {
 		panic("types.NewNamed: underlying type must not be *Named")
 	}
 	typ := &Named{obj: obj, underlying: underlying, methods: methods}
+	typ.orig = typ
 	typ.SetObj(obj)
 	return typ
 }
@@ -451,11 +509,40 @@ func (t *Named) SetObj(obj *TypeName) {
 	t.obj = obj
 }
 
+// Origin returns the generic type from which the named type t is
+// instantiated. If t is not an instantiated type, the result is t.
+func (t *Named) Origin() *Named {
+	if t.orig == nil {
+		return t
+	}
+	return t.orig
+}
+
+// TypeParams returns the type parameters of the named type t, or nil.
+// The result is non-nil for an (originally) generic type even if it
+// is instantiated.
+func (t *Named) TypeParams() *TypeParamList { return t.Origin().tparams }
+
+// SetTypeParams sets the type parameters of the named type t.
+// t must not have type arguments.
+func (t *Named) SetTypeParams(tparams []*TypeParam) {
+	if t.targs != nil {
+		panic("types.Named.SetTypeParams: cannot set type parameters of an instance")
+	}
+	t.tparams = bindTParams(tparams)
+}
+
+// TypeArgs returns the type arguments used to instantiate the named
+// type t, or nil if t is not an instantiated type.
+func (t *Named) TypeArgs() *TypeList { return t.targs }
+
 // NumMethods returns the number of explicit methods whose receiver is named type t.
-func (t *Named) NumMethods() int { return len(t.methods) }
+func (t *Named) NumMethods() int { return len(t.Origin().methods) }
 
 // Method returns the i'th method of named type t for 0 <= i < t.NumMethods().
-func (t *Named) Method(i int) *Func { return t.methods[i] }
+// For an instantiated type, the receiver and signature of the method are
+// those of the generic type's method, with type arguments substituted.
+func (t *Named) Method(i int) *Func { return t.method(i) }
 
 // SetUnderlying sets the underlying type and marks t as complete.
 func (t *Named) SetUnderlying(underlying Type) {
@@ -488,7 +575,7 @@ func (t *Signature) Underlying() Type { return t }
 func (t *Interface) Underlying() Type { return t }
 func (t *Map) Underlying() Type       { return t }
 func (t *Chan) Underlying() Type      { return t }
-func (t *Named) Underlying() Type     { return t.underlying }
+func (t *Named) Underlying() Type     { return t.expand().underlying }
 
 func (t *Basic) String() string     { return TypeString(t, nil) }
 func (t *Array) String() string     { return TypeString(t, nil) }
@@ -0,0 +1,171 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements type parameters and the type lists that
+// accompany generic declarations and their instantiations.
+
+package types
+
+import "sync/atomic"
+
+// A TypeParam represents a type parameter of a generic function or type.
+//
+// The underlying type of a type parameter is the type parameter itself;
+// operations on values of type parameter type are permitted if they are
+// permitted on every type in the type set of its constraint.
+type TypeParam struct {
+	id    uint64    // unique id, for debugging only
+	obj   *TypeName // corresponding type name
+	index int       // type parameter index in source order, starting at 0
+	bound Type      // constraint; *Named or *Interface
+}
+
+var lastTypeParamId uint64
+
+// NewTypeParam returns a new type parameter for the given type name and
+// constraint. If the type name doesn't have a type yet, its type is set
+// to the returned type parameter.
+func NewTypeParam(obj *TypeName, constraint Type) *TypeParam {
+	typ := &TypeParam{id: atomic.AddUint64(&lastTypeParamId, 1), obj: obj, index: -1, bound: constraint}
+	if obj != nil && obj.typ == nil {
+		obj.typ = typ
+	}
+	return typ
+}
+
+// Obj returns the type name for the type parameter t.
+func (t *TypeParam) Obj() *TypeName { return t.obj }
+
+// Index returns the index of the type parameter in its type parameter
+// list, or -1 if the type parameter has not yet been bound to a list.
+func (t *TypeParam) Index() int { return t.index }
+
+// Constraint returns the type constraint specified for t.
+func (t *TypeParam) Constraint() Type { return t.bound }
+
+// SetConstraint sets the type constraint for t.
+func (t *TypeParam) SetConstraint(bound Type) {
+	if bound == nil {
+		panic("types.TypeParam.SetConstraint: bound must not be nil")
+	}
+	t.bound = bound
+}
+
+// iface returns the constraint interface of t. If the constraint is
+// not (yet) an interface, the result is the empty interface.
+func (t *TypeParam) iface() *Interface {
+	if t.bound != nil {
+		if iface, _ := t.bound.Underlying().(*Interface); iface != nil {
+			return iface
+		}
+	}
+	return &emptyInterface
+}
+
+// A TypeParamList holds a list of type parameters.
+type TypeParamList struct{ tparams []*TypeParam }
+
+// Len returns the number of type parameters in the list.
+// It is safe to call on a nil receiver.
+func (l *TypeParamList) Len() int { return len(l.list()) }
+
+// At returns the i'th type parameter in the list.
+func (l *TypeParamList) At(i int) *TypeParam { return l.tparams[i] }
+
+func (l *TypeParamList) list() []*TypeParam {
+	if l == nil {
+		return nil
+	}
+	return l.tparams
+}
+
+// bindTParams binds the given type parameters to a new list, setting
+// their indices.
+func bindTParams(list []*TypeParam) *TypeParamList {
+	if len(list) == 0 {
+		return nil
+	}
+	for i, t := range list {
+		if t.index >= 0 {
+			panic("type parameter bound more than once")
+		}
+		t.index = i
+	}
+	return &TypeParamList{tparams: list}
+}
+
+// A TypeList holds a list of types, such as the type arguments of an
+// instantiated type.
+type TypeList struct{ types []Type }
+
+// NewTypeList returns a new TypeList with the types in list.
+func NewTypeList(list []Type) *TypeList {
+	if len(list) == 0 {
+		return nil
+	}
+	return &TypeList{list}
+}
+
+// Len returns the number of types in the list.
+// It is safe to call on a nil receiver.
+func (l *TypeList) Len() int { return len(l.list()) }
+
+// At returns the i'th type in the list.
+func (l *TypeList) At(i int) Type { return l.types[i] }
+
+func (l *TypeList) list() []Type {
+	if l == nil {
+		return nil
+	}
+	return l.types
+}
+
+// A Union represents a union of terms embedded in an interface.
+type Union struct {
+	terms []*Term
+}
+
+// NewUnion returns a new Union type with the given terms.
+// It is an error to create an empty union; they are syntactically not possible.
+func NewUnion(terms []*Term) *Union {
+	if len(terms) == 0 {
+		panic("empty union")
+	}
+	return &Union{terms}
+}
+
+// Len returns the number of terms in the union.
+func (u *Union) Len() int { return len(u.terms) }
+
+// Term returns the i'th term of the union.
+func (u *Union) Term(i int) *Term { return u.terms[i] }
+
+// A Term represents a term in a Union: either a type T or, if tilde
+// is set, all types whose underlying type is T.
+type Term struct {
+	tilde bool
+	typ   Type
+}
+
+// NewTerm returns a new union term.
+func NewTerm(tilde bool, typ Type) *Term { return &Term{tilde, typ} }
+
+// Tilde reports whether the term has the form ~T.
+func (t *Term) Tilde() bool { return t.tilde }
+
+// Type returns the type of the term.
+func (t *Term) Type() Type { return t.typ }
+
+func (t *Term) String() string {
+	if t.tilde {
+		return "~" + t.typ.String()
+	}
+	return t.typ.String()
+}
+
+func (t *TypeParam) Underlying() Type { return t }
+func (t *Union) Underlying() Type     { return t }
+
+func (t *TypeParam) String() string { return TypeString(t, nil) }
+func (t *Union) String() string     { return TypeString(t, nil) }
@@ -0,0 +1,265 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements type sets of constraint interfaces and the
+// satisfaction of constraints by type arguments.
+
+package types
+
+// IsMethodSet reports whether the interface t is fully described by its
+// method set; that is, whether it is an ordinary interface rather than a
+// constraint that may only be used for type parameters.
+func (t *Interface) IsMethodSet() bool {
+	return !t.restricted && !t.comparable && !t.optionable && !t.nonoptionable
+}
+
+// IsComparable reports whether each type in the type set of t is comparable.
+func (t *Interface) IsComparable() bool {
+	return t.comparable || t.restricted && allTerms(t.terms, Comparable)
+}
+
+// IsOptionable reports whether each type in the type set of t may be
+// wrapped in an optional.
+func (t *Interface) IsOptionable() bool {
+	return t.optionable || t.restricted && allTerms(t.terms, isOptionableTerm)
+}
+
+// NumEmbeddedUnions returns the number of unions and single type terms
+// embedded in t.
+func (t *Interface) NumEmbeddedUnions() int { return len(t.unions) }
+
+// EmbeddedUnion returns the i'th union embedded in t, in source order.
+func (t *Interface) EmbeddedUnion(i int) *Union { return t.unions[i] }
+
+// NewInterfaceType returns a new (incomplete) interface for the given
+// methods and embedded types. Embedded types may be interfaces, unions
+// or any other type, which is treated as a union with a single term.
+// To compute the method and type sets of the interface, Complete must
+// be called.
+func NewInterfaceType(methods []*Func, embeddeds []Type) *Interface {
+	typ := NewInterface(methods, nil)
+	for _, e := range embeddeds {
+		typ.AddEmbeddedType(e)
+	}
+	return typ
+}
+
+// AddEmbeddedType adds an embedded type to an interface. Named
+// interfaces are embedded as by AddEmbedded; unions and any other type
+// restrict the type set of the interface.
+func (t *Interface) AddEmbeddedType(e Type) {
+	switch e := e.(type) {
+	case *Named:
+		if IsInterface(e) {
+			t.AddEmbedded(e)
+			return
+		}
+	case *Union:
+		t.unions = append(t.unions, e)
+		return
+	}
+	t.unions = append(t.unions, &Union{[]*Term{{false, e}}})
+}
+
+// completeTypeSet adds the type set restrictions of the unions and
+// embedded interfaces of t to the restrictions of t. The embedded
+// interfaces must be complete.
+func (t *Interface) completeTypeSet() {
+	for _, e := range t.embeddeds {
+		if embed, _ := e.Underlying().(*Interface); embed != nil {
+			t.restrictTo(embed)
+		}
+	}
+	for _, u := range t.unions {
+		if embed := u.embeddedInterface(); embed != nil {
+			t.restrictTo(embed.Complete())
+			continue
+		}
+		t.intersect(u.terms)
+	}
+}
+
+// embeddedInterface returns the interface if u consists of a single
+// unnamed interface term, such as the one any stands for. Such unions
+// represent embedded interfaces rather than type terms.
+func (u *Union) embeddedInterface() *Interface {
+	if len(u.terms) == 1 && !u.terms[0].tilde {
+		if it, _ := u.terms[0].typ.(*Interface); it != nil {
+			return it
+		}
+	}
+	return nil
+}
+
+// restrictTo adds the restrictions of the (complete) interface embed to t.
+func (t *Interface) restrictTo(embed *Interface) {
+	t.comparable = t.comparable || embed.comparable
+	t.optionable = t.optionable || embed.optionable
+	t.nonoptionable = t.nonoptionable || embed.nonoptionable
+	if embed.restricted {
+		t.intersect(embed.terms)
+	}
+}
+
+// intersect restricts the type set of t to the types in terms.
+func (t *Interface) intersect(terms []*Term) {
+	if !t.restricted {
+		t.terms = terms
+		t.restricted = true
+		return
+	}
+	var res []*Term
+	for _, x := range t.terms {
+		for _, y := range terms {
+			if z := x.intersect(y); z != nil && !includesTerm(res, z) {
+				res = append(res, z)
+			}
+		}
+	}
+	t.terms = res
+}
+
+// intersect returns the intersection of the terms x and y, or nil if
+// it is empty.
+func (x *Term) intersect(y *Term) *Term {
+	switch {
+	case x.tilde && y.tilde:
+		if Identical(x.typ, y.typ) {
+			return x
+		}
+	case x.tilde:
+		if Identical(x.typ, y.typ.Underlying()) {
+			return y
+		}
+	case y.tilde:
+		if Identical(x.typ.Underlying(), y.typ) {
+			return x
+		}
+	default:
+		if Identical(x.typ, y.typ) {
+			return x
+		}
+	}
+	return nil
+}
+
+// includes reports whether the term x includes the type typ.
+func (x *Term) includes(typ Type) bool {
+	if x.tilde {
+		return Identical(x.typ, typ.Underlying())
+	}
+	return Identical(x.typ, typ)
+}
+
+// subsetOf reports whether the types of term x are included in term y.
+func (x *Term) subsetOf(y *Term) bool {
+	if x.tilde {
+		return y.tilde && Identical(x.typ, y.typ)
+	}
+	return y.includes(x.typ)
+}
+
+func includesTerm(list []*Term, x *Term) bool {
+	for _, y := range list {
+		if x.subsetOf(y) {
+			return true
+		}
+	}
+	return false
+}
+
+// allTerms reports whether pred holds for the types of all terms.
+// It is false for an empty list of terms.
+func allTerms(terms []*Term, pred func(Type) bool) bool {
+	for _, t := range terms {
+		if !pred(t.typ) {
+			return false
+		}
+	}
+	return len(terms) > 0
+}
+
+func isOptionableTerm(typ Type) bool {
+	return IsOptionable(typ) && !isOptional(typ)
+}
+
+// allTypes reports whether pred holds for every type in the type set
+// of the type parameter t. It is false if the type set isn't restricted
+// to a set of terms.
+func (t *TypeParam) allTypes(pred func(Type) bool) bool {
+	iface := t.iface()
+	return iface.restricted && allTerms(iface.terms, pred)
+}
+
+// coreType returns the core type of typ: its underlying type, or for a
+// type parameter, the single underlying type of all the types in its
+// type set. If there is no such type, the result is nil.
+func coreType(typ Type) Type {
+	tp, _ := typ.(*TypeParam)
+	if tp == nil {
+		return typ.Underlying()
+	}
+	iface := tp.iface()
+	if !iface.restricted {
+		return nil
+	}
+	var core Type
+	for _, t := range iface.terms {
+		u := t.typ.Underlying()
+		if core == nil {
+			core = u
+		} else if !Identical(core, u) {
+			return nil
+		}
+	}
+	return core
+}
+
+// satisfies reports whether the type argument targ satisfies the
+// constraint bound. If not, it returns a description of the failure.
+func satisfies(targ Type, bound Type) (ok bool, reason string) {
+	iface, _ := bound.Underlying().(*Interface)
+	if iface == nil || targ == Typ[Invalid] {
+		return true, ""
+	}
+	iface.Complete()
+
+	if isOptional(targ) && (iface.optionable || iface.nonoptionable) {
+		return false, "optional type"
+	}
+	if iface.optionable && !IsOptionable(targ) {
+		return false, "not optionable"
+	}
+	if iface.nonoptionable && (IsOptionable(targ) || !hasZeroValue2(targ, nil, func([]string) {})) {
+		if IsOptionable(targ) {
+			return false, "optionable"
+		}
+		return false, "has no zero value"
+	}
+	if iface.comparable && !Comparable(targ) {
+		return false, "not comparable"
+	}
+	if iface.restricted {
+		if tp, _ := targ.(*TypeParam); tp != nil {
+			tiface := tp.iface()
+			if !tiface.restricted {
+				return false, "type set of " + tp.obj.name + " is not restricted to types in " + bound.String()
+			}
+			for _, t := range tiface.terms {
+				if !includesTerm(iface.terms, t) {
+					return false, t.String() + " missing in " + bound.String()
+				}
+			}
+		} else if !includesTerm(iface.terms, &Term{false, targ}) {
+			return false, "missing in " + bound.String()
+		}
+	}
+	if m, wrongType := MissingMethod(targ, iface, true); m != nil {
+		if wrongType {
+			return false, "wrong type for method " + m.name
+		}
+		return false, "missing method " + m.name
+	}
+	return true, ""
+}
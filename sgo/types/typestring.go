@@ -148,6 +148,10 @@ func writeType(buf *bytes.Buffer, typ Type, qf Qualifier, visited []Type) {
 		writeSignature(buf, t, qf, visited)
 
 	case *Interface:
+		if t == universeAny {
+			buf.WriteString("any")
+			break
+		}
 		// We write the source-level methods and embedded types rather
 		// than the actual method set since resolved method signatures
 		// may have non-printable cycles if parameters have anonymous
@@ -189,6 +193,13 @@ func writeType(buf *bytes.Buffer, typ Type, qf Qualifier, visited []Type) {
 				writeType(buf, typ, qf, visited)
 				empty = false
 			}
+			for _, u := range t.unions {
+				if !empty {
+					buf.WriteString("; ")
+				}
+				writeType(buf, u, qf, visited)
+				empty = false
+			}
 		}
 		if t.allMethods == nil || len(t.methods) > len(t.allMethods) {
 			if !empty {
@@ -242,6 +253,27 @@ func writeType(buf *bytes.Buffer, typ Type, qf Qualifier, visited []Type) {
 			s = obj.name
 		}
 		buf.WriteString(s)
+		if t.targs != nil {
+			writeTypeList(buf, t.targs.list(), qf, visited)
+		}
+
+	case *TypeParam:
+		s := "<TypeParam w/o object>"
+		if t.obj != nil {
+			s = t.obj.name
+		}
+		buf.WriteString(s)
+
+	case *Union:
+		for i, term := range t.terms {
+			if i > 0 {
+				buf.WriteString(" | ")
+			}
+			if term.tilde {
+				buf.WriteByte('~')
+			}
+			writeType(buf, term.typ, qf, visited)
+		}
 
 	default:
 		// For externally defined implementations of Type.
@@ -299,6 +331,9 @@ func WriteSignature(buf *bytes.Buffer, sig *Signature, qf Qualifier) {
 }
 
 func writeSignature(buf *bytes.Buffer, sig *Signature, qf Qualifier, visited []Type) {
+	if sig.tparams != nil {
+		writeTParamList(buf, sig.tparams.list(), qf, visited)
+	}
 	writeTuple(buf, sig.params, sig.variadic, qf, visited)
 
 	n := sig.results.Len()
@@ -317,3 +352,32 @@ func writeSignature(buf *bytes.Buffer, sig *Signature, qf Qualifier, visited []T
 	// multiple or named result(s)
 	writeTuple(buf, sig.results, false, qf, visited)
 }
+
+func writeTypeList(buf *bytes.Buffer, list []Type, qf Qualifier, visited []Type) {
+	buf.WriteByte('[')
+	for i, typ := range list {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		writeType(buf, typ, qf, visited)
+	}
+	buf.WriteByte(']')
+}
+
+func writeTParamList(buf *bytes.Buffer, list []*TypeParam, qf Qualifier, visited []Type) {
+	buf.WriteByte('[')
+	for i, tpar := range list {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		writeType(buf, tpar, qf, visited)
+		// Type parameters with the same constraint are grouped,
+		// as in [K, V any].
+		if i+1 < len(list) && Identical(list[i+1].bound, tpar.bound) {
+			continue
+		}
+		buf.WriteByte(' ')
+		writeType(buf, tpar.bound, qf, visited)
+	}
+	buf.WriteByte(']')
+}
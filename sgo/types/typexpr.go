@@ -34,12 +34,24 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 	}
 
 	if v, ok := obj.(*Var); ok {
-		if !check.conf.AllowUseUninitializedVars && !v.usable {
-			check.errorf(e.Pos(), "possibly uninitialized variable: %s", e.Name)
+		if !check.conf.AllowUseUninitializedVars && !v.usable && !check.partialUses[e] {
+			if len(v.fieldInit) > 0 {
+				for _, p := range uninitParts(v.typ, "", v.fieldInit) {
+					check.errorf(e.Pos(), "possibly uninitialized %s", partName(e.Name, p))
+				}
+			} else {
+				fix, ok := check.entangledCheckFix(v)
+				var fixes []SuggestedFix
+				if ok {
+					fixes = append(fixes, fix)
+				}
+				check.err(e.Pos(), check.sprintf("possibly uninitialized variable: %s", e.Name), false, fixes...)
+			}
 		}
 		if scope.sig != check.scope.sig {
-			v.aliased = true
+			check.markAliased(v, e, scope.sig != nil)
 		}
+		check.recordNarrowing(e, v)
 	}
 
 	check.recordUse(e, obj)
@@ -154,8 +166,48 @@ func (check *Checker) typ(e ast.Expr) Type {
 	return check.typExpr(e, nil, nil)
 }
 
+// varType type-checks the type expression e and returns its type, or
+// Typ[Invalid]. The type must be usable for variables: constraint
+// interfaces are only permitted as type parameter constraints.
+func (check *Checker) varType(e ast.Expr) Type {
+	typ := check.typ(e)
+	check.validVarType(e, typ)
+	return typ
+}
+
+// validVarType reports an error if typ, the type of e, is a constraint
+// interface.
+func (check *Checker) validVarType(e ast.Expr, typ Type) {
+	// Delay this check because it requires fully set up types.
+	check.delay(func() {
+		if t, _ := typ.Underlying().(*Interface); t != nil && !t.Complete().IsMethodSet() {
+			check.errorf(e.Pos(), "cannot use type %s outside a type constraint: interface contains type constraints", typ)
+		}
+	})
+}
+
 // funcType type-checks a function or method type.
 func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast.FuncType) {
+	// Type parameters, including those of a generic receiver type,
+	// are declared in a scope enclosing the function scope.
+	var recvTParams []*ast.Ident
+	var recvBase *ast.Ident
+	if recvPar != nil && len(recvPar.List) > 0 {
+		recvBase, recvTParams = unpackRecv(recvPar.List[0].Type)
+	}
+	if ftyp.TypeParams != nil || len(recvTParams) > 0 {
+		defer func(outer *Scope) {
+			check.scope = outer
+		}(check.scope)
+		check.scope = NewScope(check.scope, token.NoPos, token.NoPos, "type parameters", nil)
+	}
+	if len(recvTParams) > 0 {
+		sig.rparams = bindTParams(check.recvTypeParams(recvBase, recvTParams))
+	}
+	if ftyp.TypeParams != nil {
+		sig.tparams = bindTParams(check.collectTypeParams(ftyp.TypeParams))
+	}
+
 	scope := NewScope(check.scope, token.NoPos, token.NoPos, "function", sig)
 	scope.isFunc = true
 	check.recordScope(ftyp, scope)
@@ -200,9 +252,11 @@ func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast
 				// as the method."
 				if T.obj.pkg != check.pkg {
 					err = "type not defined in this package"
+				} else if T.TypeParams().Len() != len(recvTParams) && T.targs == nil {
+					err = "generic type without instantiation"
 				} else {
 					// TODO(gri) This is not correct if the underlying type is unknown yet.
-					switch u := T.underlying.(type) {
+					switch u := T.Origin().underlying.(type) {
 					case *Basic:
 						// unsafe.Pointer is treated like a regular pointer
 						if u.kind == UnsafePointer {
@@ -229,6 +283,146 @@ func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast
 	sig.variadic = variadic
 }
 
+// unpackRecv unpacks a receiver type expression of the form T, *T or ?*T,
+// where T may be instantiated as T[P1, P2, ...], and returns the base
+// type name T and the receiver type parameters P1, P2, ..., if any.
+func unpackRecv(rtyp ast.Expr) (base *ast.Ident, tparams []*ast.Ident) {
+	rtyp = unparen(rtyp)
+	if opt, _ := rtyp.(*ast.OptionalType); opt != nil {
+		rtyp = unparen(opt.Elt)
+	}
+	if ptr, _ := rtyp.(*ast.StarExpr); ptr != nil {
+		rtyp = unparen(ptr.X)
+	}
+	var indices []ast.Expr
+	switch inst := rtyp.(type) {
+	case *ast.IndexExpr:
+		rtyp, indices = unparen(inst.X), []ast.Expr{inst.Index}
+	case *ast.IndexListExpr:
+		rtyp, indices = unparen(inst.X), inst.Indices
+	}
+	base, _ = rtyp.(*ast.Ident)
+	for _, e := range indices {
+		if id, _ := e.(*ast.Ident); id != nil {
+			tparams = append(tparams, id)
+		}
+	}
+	return
+}
+
+// recvTypeParams declares the receiver type parameters names in the
+// current scope, bound by the constraints of the corresponding type
+// parameters of the receiver base type.
+func (check *Checker) recvTypeParams(base *ast.Ident, names []*ast.Ident) []*TypeParam {
+	var tparams []*TypeParam
+	for _, name := range names {
+		tpar := NewTypeParam(NewTypeName(name.Pos(), check.pkg, name.Name, nil), &emptyInterface)
+		check.declare(check.scope, name, tpar.obj, check.scope.pos)
+		if name.Name == "_" {
+			if check.recvTParamMap == nil {
+				check.recvTParamMap = make(map[*ast.Ident]*TypeParam)
+			}
+			check.recvTParamMap[name] = tpar
+		}
+		tparams = append(tparams, tpar)
+	}
+
+	// Determine the type parameters of the receiver base type. Errors
+	// are reported when the receiver type is type-checked.
+	var baseTParams []*TypeParam
+	if base != nil {
+		if _, obj := check.scope.LookupParent(base.Name, token.NoPos); obj != nil {
+			if tname, _ := obj.(*TypeName); tname != nil {
+				check.objDecl(tname, nil, nil)
+				if named, _ := tname.typ.(*Named); named != nil {
+					baseTParams = named.TypeParams().list()
+				}
+			}
+		}
+	}
+	if len(baseTParams) != len(tparams) {
+		if len(baseTParams) > 0 {
+			check.errorf(names[0].Pos(), "got %d type parameters, but receiver base type declares %d", len(tparams), len(baseTParams))
+		}
+		return tparams
+	}
+
+	targs := make([]Type, len(tparams))
+	for i, tpar := range tparams {
+		targs[i] = tpar
+	}
+	smap := makeSubstMap(baseTParams, targs)
+	for i, tpar := range tparams {
+		tpar.bound = subst(baseTParams[i].bound, smap)
+	}
+	return tparams
+}
+
+// collectTypeParams declares the type parameters in list in the current
+// scope and type-checks their constraints.
+func (check *Checker) collectTypeParams(list *ast.FieldList) []*TypeParam {
+	var tparams []*TypeParam
+	// Declare all type parameters first, so that constraints may
+	// refer to any of them.
+	for _, f := range list.List {
+		for _, name := range f.Names {
+			tpar := NewTypeParam(NewTypeName(name.Pos(), check.pkg, name.Name, nil), &emptyInterface)
+			check.declare(check.scope, name, tpar.obj, check.scope.pos)
+			tparams = append(tparams, tpar)
+		}
+	}
+
+	i := 0
+	for _, f := range list.List {
+		bound := check.bound(f.Type)
+		for range f.Names {
+			tparams[i].bound = bound
+			i++
+		}
+	}
+	return tparams
+}
+
+// bound type-checks the type constraint e. Constraints that aren't
+// interfaces, such as ~int | string, stand for an implicit interface
+// embedding them.
+func (check *Checker) bound(e ast.Expr) Type {
+	implicit := func() Type {
+		return check.typ(&ast.InterfaceType{
+			Interface: e.Pos(),
+			Methods: &ast.FieldList{
+				Opening: e.Pos(),
+				List:    []*ast.Field{{Type: e}},
+				Closing: e.End(),
+			},
+		})
+	}
+
+	switch op := unparen(e).(type) {
+	case *ast.UnaryExpr:
+		if op.Op == token.TILDE {
+			return implicit()
+		}
+	case *ast.BinaryExpr:
+		if op.Op == token.OR {
+			return implicit()
+		}
+	}
+
+	typ := check.typ(e)
+	if typ == Typ[Invalid] {
+		return typ
+	}
+	if _, ok := underlying(typ).(*Interface); !ok {
+		if _, ok := typ.(*TypeParam); ok {
+			check.errorf(e.Pos(), "cannot use a type parameter as constraint")
+			return Typ[Invalid]
+		}
+		return implicit()
+	}
+	return typ
+}
+
 // typExprInternal drives type checking of types.
 // Must only be called by typExpr.
 //
@@ -238,12 +432,20 @@ func (check *Checker) typExprInternal(e ast.Expr, def *Named, path []*TypeName)
 		// ignore - error reported before
 
 	case *ast.Ident:
+		if tpar := check.recvTParamMap[e]; tpar != nil {
+			// blank receiver type parameter
+			return tpar
+		}
 		var x operand
 		check.ident(&x, e, def, path)
 
 		switch x.mode {
 		case typexpr:
 			typ := x.typ
+			if isGeneric(typ) {
+				check.errorf(x.pos(), "cannot use generic type %s without instantiation", typ)
+				break
+			}
 			def.setUnderlying(typ)
 			return typ
 		case invalid:
@@ -261,6 +463,10 @@ func (check *Checker) typExprInternal(e ast.Expr, def *Named, path []*TypeName)
 		switch x.mode {
 		case typexpr:
 			typ := x.typ
+			if isGeneric(typ) {
+				check.errorf(x.pos(), "cannot use generic type %s without instantiation", typ)
+				break
+			}
 			def.setUnderlying(typ)
 			return typ
 		case invalid:
@@ -271,6 +477,16 @@ func (check *Checker) typExprInternal(e ast.Expr, def *Named, path []*TypeName)
 			check.errorf(x.pos(), "%s is not a type", &x)
 		}
 
+	case *ast.IndexExpr:
+		typ := check.instantiatedType(e.X, []ast.Expr{e.Index}, def, path)
+		def.setUnderlying(typ)
+		return typ
+
+	case *ast.IndexListExpr:
+		typ := check.instantiatedType(e.X, e.Indices, def, path)
+		def.setUnderlying(typ)
+		return typ
+
 	case *ast.ParenExpr:
 		return check.typExpr(e.X, def, path)
 
@@ -443,7 +659,7 @@ func (check *Checker) collectParams(scope *Scope, list *ast.FieldList, variadicO
 				// ignore ... and continue
 			}
 		}
-		typ := check.typ(ftype)
+		typ := check.varType(ftype)
 		if isEntangled {
 			if IsOptionable(typ) {
 				typ = NewOptional(typ)
@@ -466,6 +682,7 @@ func (check *Checker) collectParams(scope *Scope, list *ast.FieldList, variadicO
 					entangled = par
 				} else {
 					params = append(params, par)
+					check.recordDeclType(par, ftype)
 				}
 			}
 			named = true
@@ -477,6 +694,7 @@ func (check *Checker) collectParams(scope *Scope, list *ast.FieldList, variadicO
 				entangled = par
 			} else {
 				params = append(params, par)
+				check.recordDeclType(par, ftype)
 			}
 			anonymous = true
 		}
@@ -578,19 +796,40 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 
 	for _, e := range embedded {
 		pos := e.Pos()
+		// Type terms, such as ~int | string, restrict the type set of
+		// the interface.
+		if isUnionExpr(e) {
+			if u := check.union(e); u != nil {
+				iface.unions = append(iface.unions, u)
+			}
+			continue
+		}
 		typ := check.typExpr(e, nil, path)
+		if typ == Typ[Invalid] {
+			continue
+		}
+		if _, ok := typ.(*TypeParam); ok {
+			check.errorf(pos, "cannot embed a type parameter")
+			continue
+		}
 		// Determine underlying embedded (possibly incomplete) type
 		// by following its forward chain.
 		named, _ := typ.(*Named)
-		under := underlying(named)
-		embed, _ := under.(*Interface)
+		var embed *Interface
+		if named != nil {
+			embed, _ = underlying(named).(*Interface)
+		} else if embed, _ = typ.(*Interface); embed != nil {
+			// An unnamed interface, such as the one any stands for.
+			iface.unions = append(iface.unions, &Union{[]*Term{{false, embed}}})
+		}
 		if embed == nil {
-			if typ != Typ[Invalid] {
-				check.errorf(pos, "%s is not an interface", typ)
-			}
+			// A single type term.
+			iface.unions = append(iface.unions, &Union{[]*Term{{false, typ}}})
 			continue
 		}
-		iface.embeddeds = append(iface.embeddeds, named)
+		if named != nil {
+			iface.embeddeds = append(iface.embeddeds, named)
+		}
 		// collect embedded methods
 		if embed.allMethods == nil {
 			check.errorf(pos, "internal error: incomplete embedded interface %s (issue #18395)", named)
@@ -639,6 +878,68 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 	} else {
 		sort.Sort(byUniqueMethodName(iface.allMethods))
 	}
+	iface.completeTypeSet()
+}
+
+// isUnionExpr reports whether e is a union of terms or a ~T term.
+func isUnionExpr(e ast.Expr) bool {
+	switch e := unparen(e).(type) {
+	case *ast.UnaryExpr:
+		return e.Op == token.TILDE
+	case *ast.BinaryExpr:
+		return e.Op == token.OR
+	}
+	return false
+}
+
+// union type-checks the union of terms e, such as ~int | string. It
+// returns nil if none of the terms is valid.
+func (check *Checker) union(e ast.Expr) *Union {
+	var terms []*Term
+	for _, x := range flattenUnion(nil, e) {
+		tilde := false
+		if u, _ := x.(*ast.UnaryExpr); u != nil && u.Op == token.TILDE {
+			tilde = true
+			x = u.X
+		}
+		typ := check.typ(x)
+		if typ == Typ[Invalid] {
+			continue
+		}
+		if _, ok := typ.(*TypeParam); ok {
+			check.errorf(x.Pos(), "term cannot be a type parameter")
+			continue
+		}
+		if IsInterface(typ) {
+			check.errorf(x.Pos(), "cannot use interface %s in union", typ)
+			continue
+		}
+		if tilde {
+			if u := underlying(typ); !Identical(typ, u) {
+				check.errorf(x.Pos(), "invalid use of ~ (underlying type of %s is %s)", typ, u)
+				continue
+			}
+		}
+		term := &Term{tilde, typ}
+		if includesTerm(terms, term) {
+			check.errorf(x.Pos(), "overlapping term %s in union", term)
+			continue
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return &Union{terms}
+}
+
+// flattenUnion appends the terms of the union e to list.
+func flattenUnion(list []ast.Expr, e ast.Expr) []ast.Expr {
+	if b, _ := unparen(e).(*ast.BinaryExpr); b != nil && b.Op == token.OR {
+		list = flattenUnion(list, b.X)
+		e = b.Y
+	}
+	return append(list, e)
 }
 
 // byUniqueTypeName named type lists can be sorted by their unique type names.
@@ -680,8 +981,9 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType, path []*TypeNa
 	// for double-declaration checks
 	var fset objset
 
-	// current field typ and tag
+	// current field typ, its type expression, and tag
 	var typ Type
+	var typExpr ast.Expr
 	var tag string
 	add := func(ident *ast.Ident, anonymous bool, pos token.Pos) {
 		if tag != "" && tags == nil {
@@ -697,11 +999,16 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType, path []*TypeNa
 		if name == "_" || check.declareInSet(&fset, pos, fld) {
 			fields = append(fields, fld)
 			check.recordDef(ident, fld)
+			if !anonymous {
+				check.recordDeclType(fld, typExpr)
+			}
 		}
 	}
 
 	for _, f := range list.List {
+		typExpr = f.Type
 		typ = check.typExpr(f.Type, nil, path)
+		check.validVarType(f.Type, typ)
 		tag = check.tag(f.Tag)
 		if len(f.Names) > 0 {
 			// named fields
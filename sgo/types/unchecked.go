@@ -0,0 +1,49 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the checks for pointers from unchecked packages.
+
+package types
+
+import "github.com/tcard/sgo/sgo/ast"
+
+// recordUnchecked records that the value of e comes from obj, if obj belongs
+// to an unchecked package.
+func (check *Checker) recordUnchecked(e ast.Expr, obj Object) {
+	if obj.Pkg() == nil || !obj.Pkg().unchecked {
+		return
+	}
+	if check.uncheckedExprs == nil {
+		check.uncheckedExprs = map[ast.Expr]Object{}
+	}
+	check.uncheckedExprs[e] = obj
+}
+
+// uncheckedNil applies Config.UncheckedNil to x, which is being converted to
+// T, if x is a pointer from an unchecked package and T is a non-optional
+// interface.
+func (check *Checker) uncheckedNil(x *operand, T Type) {
+	if check.conf.UncheckedNil == UncheckedNilAllow || x.mode == invalid {
+		return
+	}
+	if _, isOpt := T.(*Optional); isOpt || !IsInterface(T) {
+		return
+	}
+	if _, isPtr := x.typ.Underlying().(*Pointer); !isPtr {
+		return
+	}
+	obj := check.uncheckedExprs[unparen(x.expr)]
+	if obj == nil {
+		return
+	}
+
+	switch check.conf.UncheckedNil {
+	case UncheckedNilError:
+		check.errorf(x.pos(), "%s comes from unchecked package %s and may be nil; convert it to ?%s and check it before using it as %s", x.expr, obj.Pkg().Path(), x.typ, T)
+	case UncheckedNilAssert:
+		if m := check.NilChecks; m != nil {
+			m[x.expr] = obj
+		}
+	}
+}
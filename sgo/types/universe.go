@@ -19,6 +19,9 @@ var (
 	universeIota *Const
 	universeByte *Basic // uint8 alias, but has name "byte"
 	universeRune *Basic // int32 alias, but has name "rune"
+
+	// universeAny is the empty interface any stands for; it is printed as "any".
+	universeAny = &Interface{allMethods: markComplete}
 )
 
 // Typ contains the predeclared *Basic types indexed by their
@@ -81,6 +84,17 @@ func defPredeclaredTypes() {
 	typ := &Named{underlying: NewInterface([]*Func{err}, nil).Complete()}
 	sig.recv = NewVar(token.NoPos, nil, "", typ)
 	def(NewTypeName(token.NoPos, nil, "error", typ))
+
+	// type any = interface{}
+	def(NewTypeName(token.NoPos, nil, "any", universeAny))
+
+	// Predeclared constraints, which may only be used as type constraints.
+	// type comparable interface{ /* comparable types */ }
+	// type optionable interface{ /* non-optional types that may be wrapped in an optional */ }
+	// type nonoptionable interface{ /* non-optional, non-optionable types with a zero value */ }
+	def(NewTypeName(token.NoPos, nil, "comparable", &Named{underlying: &Interface{allMethods: markComplete, comparable: true}}))
+	def(NewTypeName(token.NoPos, nil, "optionable", &Named{underlying: &Interface{allMethods: markComplete, optionable: true}}))
+	def(NewTypeName(token.NoPos, nil, "nonoptionable", &Named{underlying: &Interface{allMethods: markComplete, nonoptionable: true}}))
 }
 
 var predeclaredConsts = [...]struct {
@@ -0,0 +1,92 @@
+package sgo
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/tcard/sgo/sgo/importer"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+// WriteTypes writes to w the exported objects of the package with the given
+// import path, found from srcDir, with the SGo types that importing it gives
+// them: its package-level declarations, and the fields and methods of its
+// types. Each one is followed by where its type comes from: the package's
+// annotations, built-in or from a sgovendor folder or SGOANNPATH, a "For SGo:"
+// doc comment, the automatic wrapping of Go types in optionals, or the Go type
+// itself.
+//
+// For SGo: func(w io.Writer, path, srcDir string) ?error
+func WriteTypes(w io.Writer, path, srcDir string) error {
+	pkg, srcs, err := importer.ImportWithSources(path, srcDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "package %s // import %q\n", pkg.Name(), pkg.Path())
+	switch ann := srcs.Annotations; {
+	case ann == nil:
+	case ann.Builtin():
+		fmt.Fprintf(w, "// With built-in annotations.\n")
+	default:
+		fmt.Fprintf(w, "// With annotations in %s.\n", ann.Dir)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	qf := types.RelativeTo(pkg)
+	source := func(obj types.Object) string {
+		src := srcs.Of(obj)
+		if src == importer.FromAnnotations && srcs.Annotations != nil && !srcs.Annotations.Builtin() {
+			return "annotations in " + srcs.Annotations.Dir
+		}
+		if src == importer.FromAnnotations {
+			return "built-in annotations"
+		}
+		return src.String()
+	}
+	line := func(indent, s string, obj types.Object) {
+		fmt.Fprintf(tw, "%s%s\t// %s\n", indent, s, source(obj))
+	}
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		tn, ok := obj.(*types.TypeName)
+		if !ok {
+			line("", types.ObjectString(obj, qf), obj)
+			continue
+		}
+
+		switch u := tn.Type().Underlying().(type) {
+		case *types.Struct:
+			line("", "type "+name+" struct", obj)
+			for i := 0; i < u.NumFields(); i++ {
+				if f := u.Field(i); f.Exported() {
+					line("    ", f.Name()+" "+types.TypeString(f.Type(), qf), f)
+				}
+			}
+		case *types.Interface:
+			line("", "type "+name+" interface", obj)
+			for i := 0; i < u.NumExplicitMethods(); i++ {
+				if m := u.ExplicitMethod(i); m.Exported() {
+					line("    ", types.ObjectString(m, qf), m)
+				}
+			}
+		default:
+			line("", types.ObjectString(obj, qf), obj)
+		}
+		if named, ok := tn.Type().(*types.Named); ok && !tn.IsAlias() {
+			for i := 0; i < named.NumMethods(); i++ {
+				if m := named.Method(i); m.Exported() {
+					line("    ", types.ObjectString(m, qf), m)
+				}
+			}
+		}
+	}
+	return tw.Flush()
+}
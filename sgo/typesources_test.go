@@ -0,0 +1,111 @@
+package sgo
+
+import (
+	"bytes"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteTypes(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "sgo-types")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	oldGOPATH := build.Default.GOPATH
+	build.Default.GOPATH = gopath
+	defer func() {
+		build.Default.GOPATH = oldGOPATH
+	}()
+
+	files := map[string]string{
+		"src/example.com/s/s.sgo": `package s
+
+func Get() ?*int {
+	return nil
+}
+`,
+		"src/example.com/g/g.go": `package g
+
+type Node struct {
+	Next *Node
+	N    int
+	name string
+}
+
+// Root returns the root node.
+//
+// For SGo: func() *Node
+func Root() *Node { return nil }
+
+func Find(n *Node) *Node { return n }
+
+func Len(n Node) int { return 0 }
+`,
+		"src/example.com/app/sgovendor/example.com/g/g.sgoann": "Find func(n *Node) *Node\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(gopath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// SGo packages are imported once translated.
+	if _, errs := TranslateDir(filepath.Join(gopath, "src", "example.com", "s")); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	app := filepath.Join(gopath, "src", "example.com", "app")
+	annDir := filepath.Join(app, "sgovendor", "example.com", "g")
+
+	for _, tt := range []struct {
+		path, srcDir string
+		want         []string
+	}{
+		{"example.com/g", app, []string{
+			`package g // import "example.com/g"`,
+			`// With annotations in ` + annDir + `.`,
+			``,
+			`func Find(n *Node) *Node // annotations in ` + annDir,
+			`func Len(n Node) int // Go type`,
+			`type Node struct // automatic ? wrapping`,
+			`Next ?*Node // automatic ? wrapping`,
+			`N int // Go type`,
+			`func Root() *Node // "For SGo:" doc comment`,
+		}},
+		{"example.com/g", gopath, []string{
+			`package g // import "example.com/g"`,
+			``,
+			`func Find(n ?*Node) ?*Node // automatic ? wrapping`,
+			`func Len(n Node) int // Go type`,
+			`type Node struct // automatic ? wrapping`,
+			`Next ?*Node // automatic ? wrapping`,
+			`N int // Go type`,
+			`func Root() *Node // "For SGo:" doc comment`,
+		}},
+		{"example.com/s", app, []string{
+			`package s // import "example.com/s"`,
+			``,
+			`func Get() ?*int // SGo`,
+		}},
+	} {
+		var buf bytes.Buffer
+		if err := WriteTypes(&buf, tt.path, tt.srcDir); err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		var got []string
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			got = append(got, strings.Join(strings.Fields(line), " "))
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s from %s: got:\n%s\nwant:\n%s", tt.path, tt.srcDir, buf.String(), strings.Join(tt.want, "\n"))
+		}
+	}
+}
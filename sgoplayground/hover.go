@@ -0,0 +1,136 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package main
+
+/* sgoplayground/hover.sgo:3 */ import (
+/* sgoplayground/hover.sgo:4 */ 	"strings"
+
+/* sgoplayground/hover.sgo:6 */ 	"github.com/tcard/sgo/sgo/ast"
+/* sgoplayground/hover.sgo:7 */ 	"github.com/tcard/sgo/sgo/importer"
+/* sgoplayground/hover.sgo:8 */ 	"github.com/tcard/sgo/sgo/parser"
+/* sgoplayground/hover.sgo:9 */ 	"github.com/tcard/sgo/sgo/token"
+/* sgoplayground/hover.sgo:10 */ 	"github.com/tcard/sgo/sgo/types"
+/* sgoplayground/hover.sgo:11 */ )
+
+// A typesResponse is the value of the "types" message sent back for one from
+// the page, for the position it asks about. Expr is the innermost expression
+// there, Type its SGo type, and Object the declaration of what it refers to.
+// They're empty if there's nothing to tell.
+/* sgoplayground/hover.sgo:17 */ type typesResponse struct {
+	// For SGo: int
+	Line   int
+	// For SGo: int
+	Column int
+	// For SGo: string
+	Expr   string
+	// For SGo: string
+	Type   string
+	// For SGo: string
+	Object string
+/* sgoplayground/hover.sgo:23 */ }
+
+// typesAt returns the SGo types at the given 1-based line and column, in
+// bytes, of the program code.
+//
+// The types are those that type-checking the program records, where it can be
+// type-checked. Otherwise, the innermost expression is evaluated in the scope
+// it's in.
+/* sgoplayground/hover.sgo:31 */ func typesAt(code string, line, column int) typesResponse {
+/* sgoplayground/hover.sgo:32 */ 	resp := typesResponse{Line: line, Column: column}
+
+/* sgoplayground/hover.sgo:34 */ 	fset := token.NewFileSet()
+/* sgoplayground/hover.sgo:35 */ 	f, err := parser.ParseFile(fset, progFilename, code, 0)
+/* sgoplayground/hover.sgo:36 */ 	if err != nil {
+/* sgoplayground/hover.sgo:37 */ 		return resp
+/* sgoplayground/hover.sgo:38 */ 	}
+/* sgoplayground/hover.sgo:39 */ 	off, ok := offset(code, line, column)
+/* sgoplayground/hover.sgo:40 */ 	if !ok {
+/* sgoplayground/hover.sgo:41 */ 		return resp
+/* sgoplayground/hover.sgo:42 */ 	}
+/* sgoplayground/hover.sgo:43 */ 	tf := fset.File(f.Pos())
+/* sgoplayground/hover.sgo:44 */ 	if tf == nil {
+/* sgoplayground/hover.sgo:45 */ 		return resp
+/* sgoplayground/hover.sgo:46 */ 	}
+/* sgoplayground/hover.sgo:47 */ 	pos := tf.Pos(off)
+
+/* sgoplayground/hover.sgo:49 */ 	typeOf := map[ast.Expr]types.TypeAndValue{}
+/* sgoplayground/hover.sgo:50 */ 	defs := map[*ast.Ident]types.Object{}
+/* sgoplayground/hover.sgo:51 */ 	uses := map[*ast.Ident]types.Object{}
+/* sgoplayground/hover.sgo:52 */ 	conf := &types.Config{
+/* sgoplayground/hover.sgo:53 */ 		Importer: importer.Default([]*ast.File{f}),
+		// Errors are reported by translating; get as many types as possible.
+/* sgoplayground/hover.sgo:55 */ 		Error: func(err error) {},
+/* sgoplayground/hover.sgo:56 */ 	}
+/* sgoplayground/hover.sgo:57 */ 	pkg, _ := conf.Check("main", fset, []*ast.File{f}, &types.Info{
+/* sgoplayground/hover.sgo:58 */ 		Types: typeOf,
+/* sgoplayground/hover.sgo:59 */ 		Defs:  defs,
+/* sgoplayground/hover.sgo:60 */ 		Uses:  uses,
+/* sgoplayground/hover.sgo:61 */ 	})
+/* sgoplayground/hover.sgo:62 */ 	qf := types.RelativeTo(pkg)
+
+/* sgoplayground/hover.sgo:64 */ 	var innermost ast.Expr
+/* sgoplayground/hover.sgo:65 */ 	ast.Inspect(f, func(n ast.Node) bool {
+/* sgoplayground/hover.sgo:66 */ 		if n == nil {
+/* sgoplayground/hover.sgo:67 */ 			return false
+/* sgoplayground/hover.sgo:68 */ 		}
+/* sgoplayground/hover.sgo:69 */ 		if pos < n.Pos() || pos > n.End() {
+/* sgoplayground/hover.sgo:70 */ 			return false
+/* sgoplayground/hover.sgo:71 */ 		}
+/* sgoplayground/hover.sgo:72 */ 		if e, ok := n.(ast.Expr); ok {
+/* sgoplayground/hover.sgo:73 */ 			innermost = e
+/* sgoplayground/hover.sgo:74 */ 		}
+/* sgoplayground/hover.sgo:75 */ 		return true
+/* sgoplayground/hover.sgo:76 */ 	})
+/* sgoplayground/hover.sgo:77 */ 	e := innermost
+/* sgoplayground/hover.sgo:78 */ 	if e == nil {
+/* sgoplayground/hover.sgo:79 */ 		return resp
+/* sgoplayground/hover.sgo:80 */ 	}
+/* sgoplayground/hover.sgo:81 */ 	resp.Expr = types.ExprString(e)
+
+/* sgoplayground/hover.sgo:83 */ 	if tv, ok := typeOf[e]; ok {
+/* sgoplayground/hover.sgo:84 */ 		if t := tv.Type; t != nil {
+/* sgoplayground/hover.sgo:85 */ 			resp.Type = types.TypeString(t, qf)
+/* sgoplayground/hover.sgo:86 */ 		}
+/* sgoplayground/hover.sgo:87 */ 	}
+/* sgoplayground/hover.sgo:88 */ 	if id, ok := e.(*ast.Ident); ok && id != f.Name {
+/* sgoplayground/hover.sgo:89 */ 		var obj types.Object
+/* sgoplayground/hover.sgo:90 */ 		if used, ok := uses[id]; ok {
+/* sgoplayground/hover.sgo:91 */ 			obj = used
+/* sgoplayground/hover.sgo:92 */ 		} else if defined, ok := defs[id]; ok {
+/* sgoplayground/hover.sgo:93 */ 			obj = defined
+/* sgoplayground/hover.sgo:94 */ 		}
+/* sgoplayground/hover.sgo:95 */ 		if obj != nil {
+/* sgoplayground/hover.sgo:96 */ 			resp.Object = types.ObjectString(obj, qf)
+/* sgoplayground/hover.sgo:97 */ 			if resp.Type == "" {
+/* sgoplayground/hover.sgo:98 */ 				resp.Type = types.TypeString(obj.Type(), qf)
+/* sgoplayground/hover.sgo:99 */ 			}
+/* sgoplayground/hover.sgo:100 */ 		}
+/* sgoplayground/hover.sgo:101 */ 	}
+/* sgoplayground/hover.sgo:102 */ 	if resp.Type == "" {
+/* sgoplayground/hover.sgo:103 */ 		tv, err := types.Eval(fset, pkg, e.Pos(), resp.Expr)
+/* sgoplayground/hover.sgo:104 */ 		if t := tv.Type; err == nil && t != nil {
+/* sgoplayground/hover.sgo:105 */ 			resp.Type = types.TypeString(t, qf)
+/* sgoplayground/hover.sgo:106 */ 		}
+/* sgoplayground/hover.sgo:107 */ 	}
+/* sgoplayground/hover.sgo:108 */ 	return resp
+/* sgoplayground/hover.sgo:109 */ }
+
+// offset returns the offset in code of the given 1-based line and column, in
+// bytes.
+/* sgoplayground/hover.sgo:113 */ func offset(code string, line, column int) (off int, ok bool) {
+/* sgoplayground/hover.sgo:114 */ 	if line < 1 || column < 1 {
+/* sgoplayground/hover.sgo:115 */ 		return 0, false
+/* sgoplayground/hover.sgo:116 */ 	}
+/* sgoplayground/hover.sgo:117 */ 	for i := 1; i < line; i++ {
+/* sgoplayground/hover.sgo:118 */ 		nl := strings.IndexByte(code[off:], '\n')
+/* sgoplayground/hover.sgo:119 */ 		if nl < 0 {
+/* sgoplayground/hover.sgo:120 */ 			return 0, false
+/* sgoplayground/hover.sgo:121 */ 		}
+/* sgoplayground/hover.sgo:122 */ 		off += nl + 1
+/* sgoplayground/hover.sgo:123 */ 	}
+/* sgoplayground/hover.sgo:124 */ 	off += column - 1
+/* sgoplayground/hover.sgo:125 */ 	if off > len(code) {
+/* sgoplayground/hover.sgo:126 */ 		return 0, false
+/* sgoplayground/hover.sgo:127 */ 	}
+/* sgoplayground/hover.sgo:128 */ 	return off, true
+/* sgoplayground/hover.sgo:129 */ }
@@ -4,206 +4,353 @@ package main
 
 /* sgoplayground/main.sgo:3 */ import (
 /* sgoplayground/main.sgo:4 */ 	"bytes"
-/* sgoplayground/main.sgo:5 */ 	"encoding/json"
-/* sgoplayground/main.sgo:6 */ 	"errors"
-/* sgoplayground/main.sgo:7 */ 	"flag"
-/* sgoplayground/main.sgo:8 */ 	"fmt"
-/* sgoplayground/main.sgo:9 */ 	"html/template"
-/* sgoplayground/main.sgo:10 */ 	"io"
-/* sgoplayground/main.sgo:11 */ 	"log"
-/* sgoplayground/main.sgo:12 */ 	"net/http"
-/* sgoplayground/main.sgo:13 */ 	"net/url"
-/* sgoplayground/main.sgo:14 */ 	"runtime"
-/* sgoplayground/main.sgo:15 */ 	"strings"
-
-/* sgoplayground/main.sgo:17 */ 	"github.com/gorilla/websocket"
-/* sgoplayground/main.sgo:18 */ 	"github.com/tcard/sgo/sgo"
-/* sgoplayground/main.sgo:19 */ 	"github.com/tcard/sgo/sgo/format"
-/* sgoplayground/main.sgo:20 */ 	"github.com/tcard/sgo/sgo/scanner"
-/* sgoplayground/main.sgo:21 */ )
-
-/* sgoplayground/main.sgo:23 */ var (
-/* sgoplayground/main.sgo:24 */ 	httpAddr = flag.String("http", ":5600", "HTTP server address")
-
-/* sgoplayground/main.sgo:26 */ 	upgrader = websocket.Upgrader{}
-/* sgoplayground/main.sgo:27 */ )
-
-/* sgoplayground/main.sgo:29 */ const defaultHost = "fanyare.tcardenas.me:5600"
-
-/* sgoplayground/main.sgo:31 */ func handleMsg(msg msgType) {
-/* sgoplayground/main.sgo:32 */ 	c := msg.c
-/* sgoplayground/main.sgo:33 */ 	if c == nil {
-/* sgoplayground/main.sgo:34 */ 		log.Println("c shouldn't be nil")
-/* sgoplayground/main.sgo:35 */ 		return
-/* sgoplayground/main.sgo:36 */ 	}
-/* sgoplayground/main.sgo:37 */ 	switch msg.Type {
-/* sgoplayground/main.sgo:38 */ 	case "format":
-/* sgoplayground/main.sgo:39 */ 		resp := &msgType{
-/* sgoplayground/main.sgo:40 */ 			Type: "format",
-/* sgoplayground/main.sgo:41 */ 		}
-/* sgoplayground/main.sgo:42 */ 		func() {
-/* sgoplayground/main.sgo:43 */ 			defer func() {
-/* sgoplayground/main.sgo:44 */ 				if r := recover(); r != nil {
-/* sgoplayground/main.sgo:45 */ 					value := fmt.Sprintln(r)
-/* sgoplayground/main.sgo:46 */ 					stack := make([]byte, 99999)
-/* sgoplayground/main.sgo:47 */ 					runtime.Stack(stack, false)
-/* sgoplayground/main.sgo:48 */ 					value += string(stack)
-/* sgoplayground/main.sgo:49 */ 					resp.Value = value
-/* sgoplayground/main.sgo:50 */ 				}
-/* sgoplayground/main.sgo:51 */ 			}()
-/* sgoplayground/main.sgo:52 */ 			formatted, err := format.Source([]byte(msg.Value.(string)))
-/* sgoplayground/main.sgo:53 */ 			if err == nil {
-/* sgoplayground/main.sgo:54 */ 				resp.Value = string(formatted)
-/* sgoplayground/main.sgo:55 */ 			}
-/* sgoplayground/main.sgo:56 */ 		}()
-/* sgoplayground/main.sgo:57 */ 		c.WriteJSON(resp)
-/* sgoplayground/main.sgo:58 */ 	case "translate":
-/* sgoplayground/main.sgo:59 */ 		resp := &msgType{
-/* sgoplayground/main.sgo:60 */ 			Type: "translate",
-/* sgoplayground/main.sgo:61 */ 		}
-/* sgoplayground/main.sgo:62 */ 		func() {
-/* sgoplayground/main.sgo:63 */ 			defer func() {
-/* sgoplayground/main.sgo:64 */ 				if r := recover(); r != nil {
-/* sgoplayground/main.sgo:65 */ 					value := fmt.Sprintln(r)
-/* sgoplayground/main.sgo:66 */ 					stack := make([]byte, 99999)
-/* sgoplayground/main.sgo:67 */ 					runtime.Stack(stack, false)
-/* sgoplayground/main.sgo:68 */ 					value += string(stack)
-/* sgoplayground/main.sgo:69 */ 					resp.Value = value
-/* sgoplayground/main.sgo:70 */ 				}
-/* sgoplayground/main.sgo:71 */ 			}()
-/* sgoplayground/main.sgo:72 */ 			w := &bytes.Buffer{}
-/* sgoplayground/main.sgo:73 */ 			errs := sgo.TranslateFile(func() (io.Writer, error) { return w, nil }, strings.NewReader(msg.Value.(string)), "name")
-/* sgoplayground/main.sgo:74 */ 			if errs != nil {
-/* sgoplayground/main.sgo:75 */ 				var errMsgs []string
-/* sgoplayground/main.sgo:76 */ 				for _, err := range errs {
-/* sgoplayground/main.sgo:77 */ 					if errs, ok := err.(scanner.ErrorList); ok {
-/* sgoplayground/main.sgo:78 */ 						for _, err := range errs {
-/* sgoplayground/main.sgo:79 */ 							errMsgs = append(errMsgs, err.Error())
-/* sgoplayground/main.sgo:80 */ 						}
-/* sgoplayground/main.sgo:81 */ 					} else {
-/* sgoplayground/main.sgo:82 */ 						errMsgs = append(errMsgs, err.Error())
-/* sgoplayground/main.sgo:83 */ 					}
-/* sgoplayground/main.sgo:84 */ 				}
-/* sgoplayground/main.sgo:85 */ 				resp.Value = strings.Join(errMsgs, "\n")
-/* sgoplayground/main.sgo:86 */ 			} else {
-/* sgoplayground/main.sgo:87 */ 				resp.Value = w.String()
-/* sgoplayground/main.sgo:88 */ 			}
-/* sgoplayground/main.sgo:89 */ 		}()
-/* sgoplayground/main.sgo:90 */ 		c.WriteJSON(resp)
-/* sgoplayground/main.sgo:91 */ 	case "execute":
-/* sgoplayground/main.sgo:92 */ 		resp := &msgType{
-/* sgoplayground/main.sgo:93 */ 			Type: "execute",
-/* sgoplayground/main.sgo:94 */ 		}
-/* sgoplayground/main.sgo:95 */ 		body := url.Values{}
-/* sgoplayground/main.sgo:96 */ 		body.Add("version", "2")
-/* sgoplayground/main.sgo:97 */ 		var errs []error
-/* sgoplayground/main.sgo:98 */ 		w := &bytes.Buffer{}
-/* sgoplayground/main.sgo:99 */ 		func() {
-/* sgoplayground/main.sgo:100 */ 			defer func() {
-/* sgoplayground/main.sgo:101 */ 				if r := recover(); r != nil {
-/* sgoplayground/main.sgo:102 */ 					value := fmt.Sprintln(r)
-/* sgoplayground/main.sgo:103 */ 					stack := make([]byte, 99999)
-/* sgoplayground/main.sgo:104 */ 					runtime.Stack(stack, false)
-/* sgoplayground/main.sgo:105 */ 					value += string(stack)
-/* sgoplayground/main.sgo:106 */ 					errs = append(errs, errors.New(value))
-/* sgoplayground/main.sgo:107 */ 				}
-/* sgoplayground/main.sgo:108 */ 			}()
-
-/* sgoplayground/main.sgo:110 */ 			errs = sgo.TranslateFile(func() (io.Writer, error) { return w, nil }, strings.NewReader(msg.Value.(string)), "name")
-/* sgoplayground/main.sgo:111 */ 		}()
-/* sgoplayground/main.sgo:112 */ 		if errs != nil {
-/* sgoplayground/main.sgo:113 */ 			var errMsgs []string
-/* sgoplayground/main.sgo:114 */ 			for _, err := range errs {
-/* sgoplayground/main.sgo:115 */ 				if errs, ok := err.(scanner.ErrorList); ok {
-/* sgoplayground/main.sgo:116 */ 					for _, err := range errs {
-/* sgoplayground/main.sgo:117 */ 						errMsgs = append(errMsgs, err.Error())
-/* sgoplayground/main.sgo:118 */ 					}
-/* sgoplayground/main.sgo:119 */ 				} else {
-/* sgoplayground/main.sgo:120 */ 					errMsgs = append(errMsgs, err.Error())
-/* sgoplayground/main.sgo:121 */ 				}
-/* sgoplayground/main.sgo:122 */ 			}
-/* sgoplayground/main.sgo:123 */ 			resp.Value = strings.Join(errMsgs, "\n")
-/* sgoplayground/main.sgo:124 */ 		} else {
-/* sgoplayground/main.sgo:125 */ 			body.Add("body", w.String())
-/* sgoplayground/main.sgo:126 */ 			postResp, err := http.PostForm("https://play.golang.org/compile", body)
-/* sgoplayground/main.sgo:127 */ 			if err != nil {
-/* sgoplayground/main.sgo:128 */ 				resp.Value = err.Error()
-/* sgoplayground/main.sgo:129 */ 			} else {
-/* sgoplayground/main.sgo:130 */ 				var v interface{}
-/* sgoplayground/main.sgo:131 */ 				err := json.NewDecoder(postResp.Body).Decode(&v)
-/* sgoplayground/main.sgo:132 */ 				postResp.Body.Close()
-/* sgoplayground/main.sgo:133 */ 				if err != nil {
-/* sgoplayground/main.sgo:134 */ 					resp.Value = err.Error()
-/* sgoplayground/main.sgo:135 */ 				} else {
-/* sgoplayground/main.sgo:136 */ 					resp.Value = v
-/* sgoplayground/main.sgo:137 */ 				}
-/* sgoplayground/main.sgo:138 */ 			}
-/* sgoplayground/main.sgo:139 */ 		}
-/* sgoplayground/main.sgo:140 */ 		c.WriteJSON(resp)
-/* sgoplayground/main.sgo:141 */ 	}
-/* sgoplayground/main.sgo:142 */ }
-
-/* sgoplayground/main.sgo:144 */ func main() {
-/* sgoplayground/main.sgo:145 */ 	flag.Parse()
-
-/* sgoplayground/main.sgo:147 */ 	http.HandleFunc("/ws", func(w http.ResponseWriter, req *http.Request) {
-/* sgoplayground/main.sgo:148 */ 		c, err := upgrader.Upgrade(w, req, nil)
-/* sgoplayground/main.sgo:149 */ 		if err != nil {
-/* sgoplayground/main.sgo:150 */ 			log.Println("upgrade:", err)
-/* sgoplayground/main.sgo:151 */ 			return
-/* sgoplayground/main.sgo:152 */ 		}
-/* sgoplayground/main.sgo:153 */ 		defer c.Close()
-/* sgoplayground/main.sgo:154 */ 		for {
-/* sgoplayground/main.sgo:155 */ 			var recvMsg msgType
-/* sgoplayground/main.sgo:156 */ 			err := c.ReadJSON(&recvMsg)
-/* sgoplayground/main.sgo:157 */ 			if err != nil {
-/* sgoplayground/main.sgo:158 */ 				log.Println("read:", err)
-/* sgoplayground/main.sgo:159 */ 				break
+/* sgoplayground/main.sgo:5 */ 	"context"
+/* sgoplayground/main.sgo:6 */ 	"encoding/json"
+/* sgoplayground/main.sgo:7 */ 	"errors"
+/* sgoplayground/main.sgo:8 */ 	"flag"
+/* sgoplayground/main.sgo:9 */ 	"fmt"
+/* sgoplayground/main.sgo:10 */ 	"html/template"
+/* sgoplayground/main.sgo:11 */ 	"io"
+/* sgoplayground/main.sgo:12 */ 	"log"
+/* sgoplayground/main.sgo:13 */ 	"net/http"
+/* sgoplayground/main.sgo:14 */ 	"net/url"
+/* sgoplayground/main.sgo:15 */ 	"runtime"
+/* sgoplayground/main.sgo:16 */ 	"strings"
+/* sgoplayground/main.sgo:17 */ 	"sync"
+/* sgoplayground/main.sgo:18 */ 	"time"
+
+/* sgoplayground/main.sgo:20 */ 	"github.com/gorilla/websocket"
+/* sgoplayground/main.sgo:21 */ 	"github.com/tcard/sgo/sgo"
+/* sgoplayground/main.sgo:22 */ 	"github.com/tcard/sgo/sgo/format"
+/* sgoplayground/main.sgo:23 */ 	"github.com/tcard/sgo/sgo/scanner"
+/* sgoplayground/main.sgo:24 */ )
+
+/* sgoplayground/main.sgo:26 */ var (
+/* sgoplayground/main.sgo:27 */ 	httpAddr   = flag.String("http", ":5600", "HTTP server address")
+/* sgoplayground/main.sgo:28 */ 	host       = flag.String("host", "", "host and port at which browsers reach the server (default: the ones requested)")
+/* sgoplayground/main.sgo:29 */ 	compileURL = flag.String("compile", "", "URL of a Go playground compile endpoint, such as https://play.golang.org/compile, to run programs with instead of locally")
+/* sgoplayground/main.sgo:30 */ 	shareDir   = flag.String("share", "shared", "directory to store shared programs in")
+/* sgoplayground/main.sgo:31 */ 	timeout    = flag.Duration("timeout", 10*time.Second, "maximum time a program can run for")
+/* sgoplayground/main.sgo:32 */ 	memory     = flag.Int("memory", 256, "maximum memory a program can use, in MiB")
+/* sgoplayground/main.sgo:33 */ 	maxOutput  = flag.Int("output", 1<<20, "maximum output a program can write, in bytes")
+/* sgoplayground/main.sgo:34 */ 	procs      = flag.Int("procs", 256, "maximum number of processes and threads the user running programs can have; run the server as a user dedicated to it, as all of the user's processes count")
+/* sgoplayground/main.sgo:35 */ 	runs       = flag.Int("runs", runtime.NumCPU(), "maximum number of programs run at once, by all connections")
+
+/* sgoplayground/main.sgo:37 */ 	upgrader = websocket.Upgrader{}
+/* sgoplayground/main.sgo:38 */ )
+
+// handleMsg handles a message from the page. If ctx is done before a program
+// it runs ends, the program is stopped, and nothing else is sent about it.
+/* sgoplayground/main.sgo:42 */ func handleMsg(ctx context.Context, msg msgType) {
+/* sgoplayground/main.sgo:43 */ 	defer func() {
+		// Unlike in HTTP handlers, a panic here would stop the server.
+/* sgoplayground/main.sgo:45 */ 		if r := recover(); r != nil {
+/* sgoplayground/main.sgo:46 */ 			log.Println("handling", msg.Type, "message:", r)
+/* sgoplayground/main.sgo:47 */ 		}
+/* sgoplayground/main.sgo:48 */ 	}()
+/* sgoplayground/main.sgo:49 */ 	c := msg.c
+/* sgoplayground/main.sgo:50 */ 	if c == nil {
+/* sgoplayground/main.sgo:51 */ 		log.Println("c shouldn't be nil")
+/* sgoplayground/main.sgo:52 */ 		return
+/* sgoplayground/main.sgo:53 */ 	}
+/* sgoplayground/main.sgo:54 */ 	switch msg.Type {
+/* sgoplayground/main.sgo:55 */ 	case "format":
+/* sgoplayground/main.sgo:56 */ 		resp := &msgType{
+/* sgoplayground/main.sgo:57 */ 			Type: "format",
+/* sgoplayground/main.sgo:58 */ 		}
+/* sgoplayground/main.sgo:59 */ 		func() {
+/* sgoplayground/main.sgo:60 */ 			defer func() {
+/* sgoplayground/main.sgo:61 */ 				if r := recover(); r != nil {
+/* sgoplayground/main.sgo:62 */ 					value := fmt.Sprintln(r)
+/* sgoplayground/main.sgo:63 */ 					stack := make([]byte, 99999)
+/* sgoplayground/main.sgo:64 */ 					runtime.Stack(stack, false)
+/* sgoplayground/main.sgo:65 */ 					value += string(stack)
+/* sgoplayground/main.sgo:66 */ 					resp.Value = value
+/* sgoplayground/main.sgo:67 */ 				}
+/* sgoplayground/main.sgo:68 */ 			}()
+/* sgoplayground/main.sgo:69 */ 			formatted, err := format.Source([]byte(msg.Value.(string)))
+/* sgoplayground/main.sgo:70 */ 			if err == nil {
+/* sgoplayground/main.sgo:71 */ 				resp.Value = string(formatted)
+/* sgoplayground/main.sgo:72 */ 			}
+/* sgoplayground/main.sgo:73 */ 		}()
+/* sgoplayground/main.sgo:74 */ 		c.send(resp)
+/* sgoplayground/main.sgo:75 */ 	case "translate":
+/* sgoplayground/main.sgo:76 */ 		resp := &msgType{
+/* sgoplayground/main.sgo:77 */ 			Type: "translate",
+/* sgoplayground/main.sgo:78 */ 		}
+/* sgoplayground/main.sgo:79 */ 		var errs []error
+/* sgoplayground/main.sgo:80 */ 		func() {
+/* sgoplayground/main.sgo:81 */ 			defer func() {
+/* sgoplayground/main.sgo:82 */ 				if r := recover(); r != nil {
+/* sgoplayground/main.sgo:83 */ 					value := fmt.Sprintln(r)
+/* sgoplayground/main.sgo:84 */ 					stack := make([]byte, 99999)
+/* sgoplayground/main.sgo:85 */ 					runtime.Stack(stack, false)
+/* sgoplayground/main.sgo:86 */ 					value += string(stack)
+/* sgoplayground/main.sgo:87 */ 					resp.Value = value
+/* sgoplayground/main.sgo:88 */ 				}
+/* sgoplayground/main.sgo:89 */ 			}()
+/* sgoplayground/main.sgo:90 */ 			w := &bytes.Buffer{}
+/* sgoplayground/main.sgo:91 */ 			errs = sgo.TranslateFile(func() (io.Writer, error) { return w, nil }, strings.NewReader(msg.Value.(string)), progFilename)
+/* sgoplayground/main.sgo:92 */ 			if errs != nil {
+/* sgoplayground/main.sgo:93 */ 				var errMsgs []string
+/* sgoplayground/main.sgo:94 */ 				for _, err := range errs {
+/* sgoplayground/main.sgo:95 */ 					if errs, ok := err.(scanner.ErrorList); ok {
+/* sgoplayground/main.sgo:96 */ 						for _, err := range errs {
+/* sgoplayground/main.sgo:97 */ 							errMsgs = append(errMsgs, err.Error())
+/* sgoplayground/main.sgo:98 */ 						}
+/* sgoplayground/main.sgo:99 */ 					} else {
+/* sgoplayground/main.sgo:100 */ 						errMsgs = append(errMsgs, err.Error())
+/* sgoplayground/main.sgo:101 */ 					}
+/* sgoplayground/main.sgo:102 */ 				}
+/* sgoplayground/main.sgo:103 */ 				resp.Value = strings.Join(errMsgs, "\n")
+/* sgoplayground/main.sgo:104 */ 			} else {
+/* sgoplayground/main.sgo:105 */ 				resp.Value = w.String()
+/* sgoplayground/main.sgo:106 */ 			}
+/* sgoplayground/main.sgo:107 */ 		}()
+/* sgoplayground/main.sgo:108 */ 		c.send(&msgType{Type: "diagnostics", Value: diagnostics(errs)})
+/* sgoplayground/main.sgo:109 */ 		c.send(resp)
+/* sgoplayground/main.sgo:110 */ 	case "execute":
+/* sgoplayground/main.sgo:111 */ 		resp := &msgType{
+/* sgoplayground/main.sgo:112 */ 			Type: "execute",
+/* sgoplayground/main.sgo:113 */ 		}
+/* sgoplayground/main.sgo:114 */ 		body := url.Values{}
+/* sgoplayground/main.sgo:115 */ 		body.Add("version", "2")
+/* sgoplayground/main.sgo:116 */ 		var errs []error
+/* sgoplayground/main.sgo:117 */ 		w := &bytes.Buffer{}
+/* sgoplayground/main.sgo:118 */ 		func() {
+/* sgoplayground/main.sgo:119 */ 			defer func() {
+/* sgoplayground/main.sgo:120 */ 				if r := recover(); r != nil {
+/* sgoplayground/main.sgo:121 */ 					value := fmt.Sprintln(r)
+/* sgoplayground/main.sgo:122 */ 					stack := make([]byte, 99999)
+/* sgoplayground/main.sgo:123 */ 					runtime.Stack(stack, false)
+/* sgoplayground/main.sgo:124 */ 					value += string(stack)
+/* sgoplayground/main.sgo:125 */ 					errs = append(errs, errors.New(value))
+/* sgoplayground/main.sgo:126 */ 				}
+/* sgoplayground/main.sgo:127 */ 			}()
+
+/* sgoplayground/main.sgo:129 */ 			errs = sgo.TranslateFile(func() (io.Writer, error) { return w, nil }, strings.NewReader(msg.Value.(string)), progFilename)
+/* sgoplayground/main.sgo:130 */ 		}()
+/* sgoplayground/main.sgo:131 */ 		c.send(&msgType{Type: "diagnostics", Value: diagnostics(errs)})
+/* sgoplayground/main.sgo:132 */ 		if errs != nil {
+/* sgoplayground/main.sgo:133 */ 			var errMsgs []string
+/* sgoplayground/main.sgo:134 */ 			for _, err := range errs {
+/* sgoplayground/main.sgo:135 */ 				if errs, ok := err.(scanner.ErrorList); ok {
+/* sgoplayground/main.sgo:136 */ 					for _, err := range errs {
+/* sgoplayground/main.sgo:137 */ 						errMsgs = append(errMsgs, err.Error())
+/* sgoplayground/main.sgo:138 */ 					}
+/* sgoplayground/main.sgo:139 */ 				} else {
+/* sgoplayground/main.sgo:140 */ 					errMsgs = append(errMsgs, err.Error())
+/* sgoplayground/main.sgo:141 */ 				}
+/* sgoplayground/main.sgo:142 */ 			}
+/* sgoplayground/main.sgo:143 */ 			resp.Value = strings.Join(errMsgs, "\n")
+/* sgoplayground/main.sgo:144 */ 		} else if *compileURL == "" {
+/* sgoplayground/main.sgo:145 */ 			resp.Value = runLocal(ctx, c, w.Bytes())
+/* sgoplayground/main.sgo:146 */ 		} else {
+/* sgoplayground/main.sgo:147 */ 			body.Add("body", w.String())
+/* sgoplayground/main.sgo:148 */ 			postResp, err := http.PostForm(*compileURL, body)
+/* sgoplayground/main.sgo:149 */ 			if err != nil {
+/* sgoplayground/main.sgo:150 */ 				resp.Value = err.Error()
+/* sgoplayground/main.sgo:151 */ 			} else {
+/* sgoplayground/main.sgo:152 */ 				var v interface{}
+/* sgoplayground/main.sgo:153 */ 				err := json.NewDecoder(postResp.Body).Decode(&v)
+/* sgoplayground/main.sgo:154 */ 				postResp.Body.Close()
+/* sgoplayground/main.sgo:155 */ 				if err != nil {
+/* sgoplayground/main.sgo:156 */ 					resp.Value = err.Error()
+/* sgoplayground/main.sgo:157 */ 				} else {
+/* sgoplayground/main.sgo:158 */ 					resp.Value = v
+/* sgoplayground/main.sgo:159 */ 				}
 /* sgoplayground/main.sgo:160 */ 			}
-/* sgoplayground/main.sgo:161 */ 			recvMsg.c = c
-/* sgoplayground/main.sgo:162 */ 			handleMsg(recvMsg)
-/* sgoplayground/main.sgo:163 */ 		}
-/* sgoplayground/main.sgo:164 */ 	})
-
-/* sgoplayground/main.sgo:166 */ 	buf := &bytes.Buffer{}
-/* sgoplayground/main.sgo:167 */ 	indexTpl.Execute(buf, map[string]interface{}{
-/* sgoplayground/main.sgo:168 */ 		"Gist":          "",
-/* sgoplayground/main.sgo:169 */ 		"WSURL":         "ws://" + defaultHost + "/ws",
-/* sgoplayground/main.sgo:170 */ 		"PreloadedCode": defaultPreloadedCode,
-/* sgoplayground/main.sgo:171 */ 	})
-/* sgoplayground/main.sgo:172 */ 	preexecutedTpl := buf.Bytes()
-
-/* sgoplayground/main.sgo:174 */ 	http.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
-/* sgoplayground/main.sgo:175 */ 		gist := req.URL.Query().Get("gist")
-/* sgoplayground/main.sgo:176 */ 		if gist == "" && req.Host == defaultHost {
-/* sgoplayground/main.sgo:177 */ 			w.Write(preexecutedTpl)
-/* sgoplayground/main.sgo:178 */ 			return
-/* sgoplayground/main.sgo:179 */ 		}
-
-/* sgoplayground/main.sgo:181 */ 		preloadedCode := ""
-/* sgoplayground/main.sgo:182 */ 		if gist == "" {
-/* sgoplayground/main.sgo:183 */ 			preloadedCode = defaultPreloadedCode
-/* sgoplayground/main.sgo:184 */ 		}
-/* sgoplayground/main.sgo:185 */ 		indexTpl.Execute(w, map[string]interface{}{
-/* sgoplayground/main.sgo:186 */ 			"Gist":          gist,
-/* sgoplayground/main.sgo:187 */ 			"WSURL":         "ws://" + req.Host + "/ws",
-/* sgoplayground/main.sgo:188 */ 			"PreloadedCode": preloadedCode,
-/* sgoplayground/main.sgo:189 */ 		})
-/* sgoplayground/main.sgo:190 */ 	})
-
-/* sgoplayground/main.sgo:192 */ 	fmt.Println("Serving on", *httpAddr)
-/* sgoplayground/main.sgo:193 */ 	log.Fatal(http.ListenAndServe(*httpAddr, nil))
-/* sgoplayground/main.sgo:194 */ }
-
-/* sgoplayground/main.sgo:196 */ type msgType struct {
+/* sgoplayground/main.sgo:161 */ 		}
+/* sgoplayground/main.sgo:162 */ 		if ctx.Err() != nil {
+/* sgoplayground/main.sgo:163 */ 			return
+/* sgoplayground/main.sgo:164 */ 		}
+/* sgoplayground/main.sgo:165 */ 		c.send(resp)
+/* sgoplayground/main.sgo:166 */ 	case "types":
+/* sgoplayground/main.sgo:167 */ 		resp := &msgType{
+/* sgoplayground/main.sgo:168 */ 			Type: "types",
+/* sgoplayground/main.sgo:169 */ 		}
+/* sgoplayground/main.sgo:170 */ 		func() {
+/* sgoplayground/main.sgo:171 */ 			defer func() {
+/* sgoplayground/main.sgo:172 */ 				if r := recover(); r != nil {
+/* sgoplayground/main.sgo:173 */ 					log.Println("types:", r)
+/* sgoplayground/main.sgo:174 */ 				}
+/* sgoplayground/main.sgo:175 */ 			}()
+/* sgoplayground/main.sgo:176 */ 			resp.Value = typesAt(msg.Value.(string), msg.Line, msg.Column)
+/* sgoplayground/main.sgo:177 */ 		}()
+/* sgoplayground/main.sgo:178 */ 		c.send(resp)
+/* sgoplayground/main.sgo:179 */ 	}
+/* sgoplayground/main.sgo:180 */ }
+
+// progFilename is the name programs have in the positions of diagnostics.
+/* sgoplayground/main.sgo:183 */ const progFilename = "prog.sgo"
+
+// A diagnostic is an error in a program, at a 1-based line and column, in
+// bytes. A "diagnostics" message has all of a program's, which are none if it
+// translates.
+/* sgoplayground/main.sgo:188 */ type diagnostic struct {
+	// For SGo: string
+	File    string
+	// For SGo: int
+	Line    int
+	// For SGo: int
+	Column  int
+	// For SGo: string
+	Message string
+/* sgoplayground/main.sgo:193 */ }
+
+// diagnostics returns the diagnostics for the errors translating a program.
+/* sgoplayground/main.sgo:196 */ func diagnostics(errs []error) []diagnostic {
+/* sgoplayground/main.sgo:197 */ 	diags := []diagnostic{}
+/* sgoplayground/main.sgo:198 */ 	for _, err := range errs {
+/* sgoplayground/main.sgo:199 */ 		list, ok := err.(scanner.ErrorList)
+/* sgoplayground/main.sgo:200 */ 		if !ok {
+/* sgoplayground/main.sgo:201 */ 			diags = append(diags, diagnostic{Message: err.Error()})
+/* sgoplayground/main.sgo:202 */ 			continue
+/* sgoplayground/main.sgo:203 */ 		}
+/* sgoplayground/main.sgo:204 */ 		for _, e := range list {
+/* sgoplayground/main.sgo:205 */ 			diags = append(diags, diagnostic{
+/* sgoplayground/main.sgo:206 */ 				File:    e.Pos.Filename,
+/* sgoplayground/main.sgo:207 */ 				Line:    e.Pos.Line,
+/* sgoplayground/main.sgo:208 */ 				Column:  e.Pos.Column,
+/* sgoplayground/main.sgo:209 */ 				Message: e.Msg,
+/* sgoplayground/main.sgo:210 */ 			})
+/* sgoplayground/main.sgo:211 */ 		}
+/* sgoplayground/main.sgo:212 */ 	}
+/* sgoplayground/main.sgo:213 */ 	return diags
+/* sgoplayground/main.sgo:214 */ }
+
+/* sgoplayground/main.sgo:216 */ func main() {
+/* sgoplayground/main.sgo:217 */ 	flag.Parse()
+
+	// A slot is taken by each program being run.
+/* sgoplayground/main.sgo:220 */ 	runSlots := make(chan struct{}, *runs)
+
+/* sgoplayground/main.sgo:222 */ 	http.HandleFunc("/ws", func(w http.ResponseWriter, req *http.Request) {
+/* sgoplayground/main.sgo:223 */ 		ws, err := upgrader.Upgrade(w, req, nil)
+/* sgoplayground/main.sgo:224 */ 		if err != nil {
+/* sgoplayground/main.sgo:225 */ 			log.Println("upgrade:", err)
+/* sgoplayground/main.sgo:226 */ 			return
+/* sgoplayground/main.sgo:227 */ 		}
+/* sgoplayground/main.sgo:228 */ 		defer ws.Close()
+/* sgoplayground/main.sgo:229 */ 		c := &conn{ws: ws}
+
+		// stopRun stops the program last run from the page, if it's still
+		// running or waiting for a slot, and waits for it to end.
+/* sgoplayground/main.sgo:233 */ 		stopRun := func() {}
+/* sgoplayground/main.sgo:234 */ 		defer func() { stopRun() }()
+
+/* sgoplayground/main.sgo:236 */ 		for {
+/* sgoplayground/main.sgo:237 */ 			var recvMsg msgType
+/* sgoplayground/main.sgo:238 */ 			err := ws.ReadJSON(&recvMsg)
+/* sgoplayground/main.sgo:239 */ 			if err != nil {
+/* sgoplayground/main.sgo:240 */ 				log.Println("read:", err)
+/* sgoplayground/main.sgo:241 */ 				break
+/* sgoplayground/main.sgo:242 */ 			}
+/* sgoplayground/main.sgo:243 */ 			recvMsg.c = c
+/* sgoplayground/main.sgo:244 */ 			if recvMsg.Type != "execute" {
+/* sgoplayground/main.sgo:245 */ 				handleMsg(context.Background(), recvMsg)
+/* sgoplayground/main.sgo:246 */ 				continue
+/* sgoplayground/main.sgo:247 */ 			}
+
+			// Running a program takes a while; keep reading messages,
+			// like the ones asking for types, meanwhile. A page runs a
+			// program at a time, so running another stops the previous
+			// one.
+/* sgoplayground/main.sgo:253 */ 			stopRun()
+/* sgoplayground/main.sgo:254 */ 			ctx, cancel := context.WithCancel(context.Background())
+/* sgoplayground/main.sgo:255 */ 			done := make(chan struct{})
+/* sgoplayground/main.sgo:256 */ 			stopRun = func() {
+/* sgoplayground/main.sgo:257 */ 				cancel()
+/* sgoplayground/main.sgo:258 */ 				<-done
+/* sgoplayground/main.sgo:259 */ 			}
+/* sgoplayground/main.sgo:260 */ 			go func() {
+/* sgoplayground/main.sgo:261 */ 				defer close(done)
+/* sgoplayground/main.sgo:262 */ 				select {
+/* sgoplayground/main.sgo:263 */ 				case runSlots <- struct{}{}:
+/* sgoplayground/main.sgo:264 */ 					defer func() { <-runSlots }()
+/* sgoplayground/main.sgo:265 */ 					handleMsg(ctx, recvMsg)
+/* sgoplayground/main.sgo:266 */ 				case <-ctx.Done():
+/* sgoplayground/main.sgo:267 */ 				}
+/* sgoplayground/main.sgo:268 */ 			}()
+/* sgoplayground/main.sgo:269 */ 		}
+/* sgoplayground/main.sgo:270 */ 	})
+
+/* sgoplayground/main.sgo:272 */ 	shares := shareStore{dir: *shareDir}
+/* sgoplayground/main.sgo:273 */ 	http.HandleFunc("/share", shares.handleShare)
+/* sgoplayground/main.sgo:274 */ 	http.HandleFunc("/p/", func(w http.ResponseWriter, req *http.Request) {
+/* sgoplayground/main.sgo:275 */ 		code, err := shares.get(strings.TrimPrefix(req.URL.Path, "/p/"))
+/* sgoplayground/main.sgo:276 */ 		if err != nil {
+/* sgoplayground/main.sgo:277 */ 			http.NotFound(w, req)
+/* sgoplayground/main.sgo:278 */ 			return
+/* sgoplayground/main.sgo:279 */ 		}
+/* sgoplayground/main.sgo:280 */ 		serveIndex(w, req, string(code), "")
+/* sgoplayground/main.sgo:281 */ 	})
+
+/* sgoplayground/main.sgo:283 */ 	http.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		// Programs used to be shared as GitHub gists, which the page loads.
+/* sgoplayground/main.sgo:285 */ 		gist := req.URL.Query().Get("gist")
+/* sgoplayground/main.sgo:286 */ 		preloadedCode := ""
+/* sgoplayground/main.sgo:287 */ 		if gist == "" {
+/* sgoplayground/main.sgo:288 */ 			preloadedCode = defaultPreloadedCode
+/* sgoplayground/main.sgo:289 */ 		}
+/* sgoplayground/main.sgo:290 */ 		serveIndex(w, req, preloadedCode, gist)
+/* sgoplayground/main.sgo:291 */ 	})
+
+/* sgoplayground/main.sgo:293 */ 	fmt.Println("Serving on", *httpAddr)
+/* sgoplayground/main.sgo:294 */ 	log.Fatal(http.ListenAndServe(*httpAddr, nil))
+/* sgoplayground/main.sgo:295 */ }
+
+// serveIndex serves the playground's page, with code in the editor, or the
+// code in the given GitHub gist if it isn't empty.
+/* sgoplayground/main.sgo:299 */ func serveIndex(w http.ResponseWriter, req *http.Request, code, gist string) {
+/* sgoplayground/main.sgo:300 */ 	wsHost := *host
+/* sgoplayground/main.sgo:301 */ 	if wsHost == "" {
+/* sgoplayground/main.sgo:302 */ 		wsHost = req.Host
+/* sgoplayground/main.sgo:303 */ 	}
+/* sgoplayground/main.sgo:304 */ 	indexTpl.Execute(w, map[string]interface{}{
+/* sgoplayground/main.sgo:305 */ 		"Gist":          gist,
+/* sgoplayground/main.sgo:306 */ 		"WSURL":         "ws://" + wsHost + "/ws",
+/* sgoplayground/main.sgo:307 */ 		"PreloadedCode": code,
+/* sgoplayground/main.sgo:308 */ 	})
+/* sgoplayground/main.sgo:309 */ }
+
+/* sgoplayground/main.sgo:311 */ type msgType struct {
 	// For SGo: string
 	Type  string       `json:"type"`
 	// For SGo: ?interface{}
 	Value interface{} `json:"value"`
-/* sgoplayground/main.sgo:199 */ 	c     *websocket.Conn
-/* sgoplayground/main.sgo:200 */ }
 
-/* sgoplayground/main.sgo:202 */ const defaultPreloadedCode = `package main
+	// Line and Column are the position a "types" message from the page asks
+	// about.
+	// For SGo: int
+	Line   int `json:"line,omitempty"`
+	// For SGo: int
+	Column int `json:"column,omitempty"`
+
+/* sgoplayground/main.sgo:320 */ 	c sender
+/* sgoplayground/main.sgo:321 */ }
+
+// A sender sends messages to the page.
+/* sgoplayground/main.sgo:324 */ type sender interface {
+/* sgoplayground/main.sgo:325 */ 	send(msg *msgType)
+/* sgoplayground/main.sgo:326 */ }
+
+// A conn is a websocket connection to the page, which messages can be sent
+// to from several goroutines at once.
+/* sgoplayground/main.sgo:330 */ type conn struct {
+/* sgoplayground/main.sgo:331 */ 	ws *websocket.Conn
+
+/* sgoplayground/main.sgo:333 */ 	mu sync.Mutex
+/* sgoplayground/main.sgo:334 */ }
+
+// For SGo: (*conn) func(msg *msgType)
+func (c *conn) send(msg *msgType) {
+/* sgoplayground/main.sgo:337 */ 	c.mu.Lock()
+/* sgoplayground/main.sgo:338 */ 	defer c.mu.Unlock()
+/* sgoplayground/main.sgo:339 */ 	c.ws.WriteJSON(msg)
+/* sgoplayground/main.sgo:340 */ }
+
+/* sgoplayground/main.sgo:342 */ const defaultPreloadedCode = `package main
 
 import (
 	"fmt"
@@ -243,22 +390,71 @@ func giveMeSomethingMaybe() (*Something \ error) {
 }
 `
 
-/* sgoplayground/main.sgo:242 */ var indexTpl = template.Must(template.New("index").Parse(`
+/* sgoplayground/main.sgo:382 */ var indexTpl = template.Must(template.New("index").Parse(`
 <!DOCTYPE html>
 <html lang="en">
 
 <head>
   <meta charset="utf-8">
   <title>SGo playground</title>
+  <style>
+    .editor {
+      box-sizing: border-box;
+      width: 90%;
+      height: 32em;
+      margin: 0;
+      padding: 2px;
+      border: 1px solid #aaa;
+      font-family: monospace;
+      font-size: 13px;
+      line-height: 1.2;
+      white-space: pre-wrap;
+      word-wrap: break-word;
+      tab-size: 8;
+      -moz-tab-size: 8;
+    }
+    #highlights {
+      position: absolute;
+      top: 0;
+      left: 0;
+      overflow: hidden;
+      color: transparent;
+      border-color: transparent;
+    }
+    #highlights mark {
+      color: transparent;
+      background: none;
+      text-decoration: underline wavy red;
+    }
+    #input-code {
+      position: relative;
+      background: transparent;
+      resize: none;
+    }
+    #diagnostics {
+      color: #c00;
+      font-family: monospace;
+      cursor: pointer;
+    }
+    #type-info {
+      font-family: monospace;
+      min-height: 1.2em;
+    }
+  </style>
 </head>
 
 <body>
 
 <div style="width: 50%; float: left;">
-<textarea id="input-code" style="width: 90%; font-family: monospace;" rows="30">
+<div style="position: relative;">
+<div id="highlights" class="editor"></div>
+<textarea id="input-code" class="editor" spellcheck="false">
 {{.PreloadedCode}}
 </textarea>
 </div>
+<div id="type-info"></div>
+<div id="diagnostics"></div>
+</div>
 
 <div>
 <pre id="translated" style="height: 100%; max-height: 390px; overflow: scroll;">
@@ -287,6 +483,129 @@ window.addEventListener("load", function(evt) {
 	var shareButton = document.getElementById("share-button");
 	var shareInput = document.getElementById("share-input");
 	var executed = document.getElementById("executed");
+	var highlights = document.getElementById("highlights");
+	var diagnosticsList = document.getElementById("diagnostics");
+	var typeInfo = document.getElementById("type-info");
+	var diags = [];
+
+	// Positions from the server are 1-based lines and columns in bytes of
+	// UTF-8; the textarea's are offsets in UTF-16 code units.
+	var encoder = new TextEncoder();
+	var lineStart = function(code, line) {
+		var off = 0;
+		for (var i = 1; i < line; i++) {
+			var nl = code.indexOf("\n", off);
+			if (nl < 0) {
+				return -1;
+			}
+			off = nl + 1;
+		}
+		return off;
+	};
+	var offsetOf = function(code, line, column) {
+		var start = lineStart(code, line);
+		if (start < 0) {
+			return -1;
+		}
+		var end = code.indexOf("\n", start);
+		if (end < 0) {
+			end = code.length;
+		}
+		var off = start;
+		for (var bytes = 0; off < end && bytes < column - 1; off++) {
+			bytes += encoder.encode(code[off]).length;
+		}
+		return off;
+	};
+	var positionOf = function(code, off) {
+		var lines = code.substring(0, off).split("\n");
+		var last = lines[lines.length - 1];
+		return {line: lines.length, column: encoder.encode(last).length + 1};
+	};
+
+	var escapeHTML = function(s) {
+		return s.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;");
+	};
+	var showDiagnostics = function() {
+		var code = inputCode.value;
+		var marks = [];
+		diagnosticsList.innerHTML = "";
+		diags.forEach(function(d) {
+			var item = document.createElement("div");
+			item.textContent = d.Line ? d.Line + ":" + d.Column + ": " + d.Message : d.Message;
+			diagnosticsList.appendChild(item);
+			if (!d.Line) {
+				return;
+			}
+			var start = offsetOf(code, d.Line, d.Column);
+			if (start < 0) {
+				return;
+			}
+			var end = start;
+			while (end < code.length && /\w/.test(code[end])) {
+				end++;
+			}
+			if (end == start && end < code.length && code[end] != "\n") {
+				end++;
+			}
+			marks.push({start: start, end: end});
+			item.onclick = function() {
+				inputCode.focus();
+				inputCode.selectionStart = inputCode.selectionEnd = start;
+				askTypes();
+			};
+		});
+		marks.sort(function(a, b) { return a.start - b.start; });
+		var html = "";
+		var off = 0;
+		marks.forEach(function(m) {
+			if (m.start < off) {
+				return;
+			}
+			html += escapeHTML(code.substring(off, m.start));
+			html += "<mark>" + escapeHTML(code.substring(m.start, m.end) || " ") + "</mark>";
+			off = m.end;
+		});
+		// A trailing newline takes no room unless something follows it.
+		html += escapeHTML(code.substring(off)) + " ";
+		highlights.innerHTML = html;
+		highlights.scrollTop = inputCode.scrollTop;
+	};
+	inputCode.addEventListener("scroll", function() {
+		highlights.scrollTop = inputCode.scrollTop;
+		highlights.scrollLeft = inputCode.scrollLeft;
+	});
+
+	var typesPos = null;
+	var askTypes = (function() {
+		var timer = null;
+		return function() {
+			clearTimeout(timer);
+			timer = setTimeout(function() {
+				var code = inputCode.value;
+				typesPos = positionOf(code, inputCode.selectionStart);
+				ws.send(JSON.stringify({
+					"type": "types",
+					"value": code,
+					"line": typesPos.line,
+					"column": typesPos.column,
+				}));
+			}, 200);
+		};
+	})();
+	var showTypes = function(t) {
+		// Ignore responses for where the cursor isn't anymore.
+		if (!t || !typesPos || t.Line != typesPos.line || t.Column != typesPos.column) {
+			return;
+		}
+		if (t.Object) {
+			typeInfo.textContent = t.Object;
+		} else if (t.Type) {
+			typeInfo.textContent = t.Expr + ": " + t.Type;
+		} else {
+			typeInfo.textContent = "";
+		}
+	};
 
 	var receivedTranslation = function() {};
 
@@ -318,12 +637,20 @@ window.addEventListener("load", function(evt) {
 				runButton.textContent = "Run";
 				runButton.disabled = false;
 			}
+		} else if (data.type == "diagnostics") {
+			diags = data.value || [];
+			showDiagnostics();
+		} else if (data.type == "types") {
+			showTypes(data.value);
+		} else if (data.type == "output") {
+			executed.textContent += data.value.Message;
 		} else if (data.type == "translate") {
 			receivedTranslation();
 			translated.textContent = data.value;
 		} else if (data.type == "format") {
 			if (data.value) {
 				inputCode.value = data.value;
+				translate();
 			}
 			formatButton.textContent = "Format";
 			formatButton.disabled = false;
@@ -376,7 +703,12 @@ window.addEventListener("load", function(evt) {
 	})();
 
 	inputCode.onchange = translate;
-	inputCode.onkeyup = translate;
+	inputCode.onkeyup = function() {
+		translate();
+		askTypes();
+	};
+	inputCode.onclick = askTypes;
+	inputCode.addEventListener("input", showDiagnostics);
 	ws.onopen = function() {
 		var gist = "{{.Gist}}";
 		if (gist) {
@@ -408,23 +740,23 @@ window.addEventListener("load", function(evt) {
 
 	shareButton.onclick = function(ev) {
 		ev.preventDefault();
-		ajax("https://api.github.com/gists", {
+		var failed = function(req) {
+			alert("Couldn't share: " + req.responseText);
+			shareButton.textContent = "Share";
+			shareButton.disabled = false;
+		};
+		ajax("/share", {
 			method: 'POST',
-			data: JSON.stringify({
-				files: {
-					'sgoplayground.go': {
-						content: inputCode.value
-					}
-				}
-			}),
+			data: inputCode.value,
 			success: function(req) {
-				var id = JSON.parse(req.responseText).id;
-				shareInput.value = window.location.origin + window.location.pathname + '?gist=' + id;
+				shareInput.value = window.location.origin + '/p/' + req.responseText;
 				shareInput.style.display = 'inline';
 				shareInput.focus();
 				shareButton.textContent = "Share";
 				shareButton.disabled = false;
 			},
+			fail: failed,
+			other: failed,
 		});
 		shareButton.textContent = "Sharing...";
 		shareButton.disabled = true;
@@ -0,0 +1,220 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package main
+
+/* sgoplayground/run.sgo:3 */ import (
+/* sgoplayground/run.sgo:4 */ 	"bytes"
+/* sgoplayground/run.sgo:5 */ 	"context"
+/* sgoplayground/run.sgo:6 */ 	"fmt"
+/* sgoplayground/run.sgo:7 */ 	"io"
+/* sgoplayground/run.sgo:8 */ 	"io/ioutil"
+/* sgoplayground/run.sgo:9 */ 	"os"
+/* sgoplayground/run.sgo:10 */ 	"os/exec"
+/* sgoplayground/run.sgo:11 */ 	"path/filepath"
+/* sgoplayground/run.sgo:12 */ 	"strings"
+/* sgoplayground/run.sgo:13 */ 	"sync"
+/* sgoplayground/run.sgo:14 */ 	"syscall"
+/* sgoplayground/run.sgo:15 */ 	"time"
+/* sgoplayground/run.sgo:16 */ 	"unicode/utf8"
+/* sgoplayground/run.sgo:17 */ )
+
+/* sgoplayground/run.sgo:19 */ const (
+	// buildTimeout is the maximum time building a program can take.
+/* sgoplayground/run.sgo:21 */ 	buildTimeout = time.Minute
+
+	// fileLimit is the maximum size of the files a program can write, in
+	// blocks of 512 or 1024 bytes, depending on the shell.
+/* sgoplayground/run.sgo:25 */ 	fileLimit = 2048
+
+	// waitDelay is how long to wait, once a command ends or is killed, for
+	// processes it started to stop writing its output.
+/* sgoplayground/run.sgo:29 */ 	waitDelay = time.Second
+/* sgoplayground/run.sgo:30 */ )
+
+// A runResult is the value of the "execute" message sent when a program run
+// locally ends, after its output. It's shaped like the Go playground's
+// responses, which the page understands too.
+/* sgoplayground/run.sgo:35 */ type runResult struct {
+	// For SGo: string
+	Errors string
+/* sgoplayground/run.sgo:37 */ }
+
+// An outputEvent is the value of an "output" message, with something a
+// program run locally has written. Kind is "stdout", "stderr", or "system"
+// for messages about the program, like its exit status.
+/* sgoplayground/run.sgo:42 */ type outputEvent struct {
+	// For SGo: string
+	Kind    string
+	// For SGo: string
+	Message string
+/* sgoplayground/run.sgo:45 */ }
+
+// runLocal builds the Go program src and runs it, sending what it writes to c
+// as "output" messages as soon as it's written. When ctx is done, the program
+// is killed, and nothing else is sent.
+//
+// The program runs with the time, memory, process and output limits set by
+// the command-line flags, in a temporary directory and without the server's
+// environment. It isn't isolated otherwise, so the server should run in a
+// container, or as a dedicated unprivileged user. The process limit is for
+// all the processes of the user running the program, not just the program's,
+// so that user mustn't run anything else, the server included, or it would
+// count against the programs' limit, and programs could keep it from starting
+// processes.
+/* sgoplayground/run.sgo:59 */ func runLocal(ctx context.Context, c sender, src []byte) runResult {
+/* sgoplayground/run.sgo:60 */ 	dir, err := ioutil.TempDir("", "sgoplayground")
+/* sgoplayground/run.sgo:61 */ 	if err != nil {
+/* sgoplayground/run.sgo:62 */ 		return runResult{Errors: err.Error()}
+/* sgoplayground/run.sgo:63 */ 	}
+/* sgoplayground/run.sgo:64 */ 	defer os.RemoveAll(dir)
+/* sgoplayground/run.sgo:65 */ 	err = ioutil.WriteFile(filepath.Join(dir, "main.go"), src, 0666)
+/* sgoplayground/run.sgo:66 */ 	if err != nil {
+/* sgoplayground/run.sgo:67 */ 		return runResult{Errors: err.Error()}
+/* sgoplayground/run.sgo:68 */ 	}
+
+/* sgoplayground/run.sgo:70 */ 	var buildOut bytes.Buffer
+/* sgoplayground/run.sgo:71 */ 	build := exec.Command("go", "build", "-o", "prog", "main.go")
+/* sgoplayground/run.sgo:72 */ 	build.Dir = dir
+/* sgoplayground/run.sgo:73 */ 	build.Stdout = &buildOut
+/* sgoplayground/run.sgo:74 */ 	build.Stderr = &buildOut
+/* sgoplayground/run.sgo:75 */ 	killed, err := runTimeout(ctx, build, buildTimeout)
+/* sgoplayground/run.sgo:76 */ 	if ctx.Err() != nil {
+/* sgoplayground/run.sgo:77 */ 		return runResult{}
+/* sgoplayground/run.sgo:78 */ 	}
+/* sgoplayground/run.sgo:79 */ 	if killed {
+/* sgoplayground/run.sgo:80 */ 		return runResult{Errors: "Build timed out."}
+/* sgoplayground/run.sgo:81 */ 	}
+/* sgoplayground/run.sgo:82 */ 	if err != nil {
+/* sgoplayground/run.sgo:83 */ 		if buildOut.Len() == 0 {
+/* sgoplayground/run.sgo:84 */ 			return runResult{Errors: err.Error()}
+/* sgoplayground/run.sgo:85 */ 		}
+/* sgoplayground/run.sgo:86 */ 		return runResult{Errors: strings.TrimPrefix(buildOut.String(), "# command-line-arguments\n")}
+/* sgoplayground/run.sgo:87 */ 	}
+
+	// Limiting the address space breaks the Go runtime, which reserves much
+	// more than it uses, so memory is limited by data segment size instead.
+	// The number of processes is -u in bash and -p in dash.
+/* sgoplayground/run.sgo:92 */ 	seconds := int((*timeout + time.Second - 1) / time.Second)
+/* sgoplayground/run.sgo:93 */ 	run := exec.Command("/bin/sh", "-c", fmt.Sprintf(
+/* sgoplayground/run.sgo:94 */ 		"ulimit -d %d && ulimit -t %d && ulimit -f %d && { ulimit -u %d 2>/dev/null || ulimit -p %d; } && exec ./prog",
+/* sgoplayground/run.sgo:95 */ 		*memory<<10, seconds, fileLimit, *procs, *procs,
+/* sgoplayground/run.sgo:96 */ 	))
+/* sgoplayground/run.sgo:97 */ 	run.Dir = dir
+/* sgoplayground/run.sgo:98 */ 	run.Env = []string{"HOME=" + dir, "TMPDIR=" + dir}
+/* sgoplayground/run.sgo:99 */ 	out := &output{ctx: ctx, c: c, left: *maxOutput}
+/* sgoplayground/run.sgo:100 */ 	run.Stdout = out.writer("stdout")
+/* sgoplayground/run.sgo:101 */ 	run.Stderr = out.writer("stderr")
+/* sgoplayground/run.sgo:102 */ 	killed, err = runTimeout(ctx, run, *timeout)
+
+/* sgoplayground/run.sgo:104 */ 	if out.truncated {
+/* sgoplayground/run.sgo:105 */ 		out.send("system", "\nOutput truncated.")
+/* sgoplayground/run.sgo:106 */ 	}
+/* sgoplayground/run.sgo:107 */ 	switch {
+/* sgoplayground/run.sgo:108 */ 	case killed:
+/* sgoplayground/run.sgo:109 */ 		out.send("system", "\nProgram timed out.\n")
+/* sgoplayground/run.sgo:110 */ 	case err != nil:
+/* sgoplayground/run.sgo:111 */ 		out.send("system", "\nProgram exited: "+err.Error()+".\n")
+/* sgoplayground/run.sgo:112 */ 	default:
+/* sgoplayground/run.sgo:113 */ 		out.send("system", "\nProgram exited.\n")
+/* sgoplayground/run.sgo:114 */ 	}
+/* sgoplayground/run.sgo:115 */ 	return runResult{}
+/* sgoplayground/run.sgo:116 */ }
+
+// runTimeout runs cmd in a new process group, killing the group if cmd is
+// still running after d, or when ctx is done. It reports whether it did.
+// Processes left in the group when cmd ends are killed too, so that they can't
+// keep running, or keep its output open.
+/* sgoplayground/run.sgo:122 */ func runTimeout(ctx context.Context, cmd *exec.Cmd, d time.Duration) (killed bool, err error) {
+/* sgoplayground/run.sgo:123 */ 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+/* sgoplayground/run.sgo:124 */ 	cmd.WaitDelay = waitDelay
+/* sgoplayground/run.sgo:125 */ 	err = cmd.Start()
+/* sgoplayground/run.sgo:126 */ 	if err != nil {
+/* sgoplayground/run.sgo:127 */ 		return false, err
+/* sgoplayground/run.sgo:128 */ 	}
+/* sgoplayground/run.sgo:129 */ 	kill := func() {
+/* sgoplayground/run.sgo:130 */ 		if p := cmd.Process; p != nil {
+			// The group's ID is the process ID of cmd, its leader.
+/* sgoplayground/run.sgo:132 */ 			syscall.Kill(-p.Pid, syscall.SIGKILL)
+/* sgoplayground/run.sgo:133 */ 		}
+/* sgoplayground/run.sgo:134 */ 	}
+/* sgoplayground/run.sgo:135 */ 	defer kill()
+
+/* sgoplayground/run.sgo:137 */ 	done := make(chan error, 1)
+/* sgoplayground/run.sgo:138 */ 	go func() {
+/* sgoplayground/run.sgo:139 */ 		done <- cmd.Wait()
+/* sgoplayground/run.sgo:140 */ 	}()
+/* sgoplayground/run.sgo:141 */ 	select {
+/* sgoplayground/run.sgo:142 */ 	case err := <-done:
+/* sgoplayground/run.sgo:143 */ 		return false, err
+/* sgoplayground/run.sgo:144 */ 	case <-time.After(d):
+/* sgoplayground/run.sgo:145 */ 	case <-ctx.Done():
+/* sgoplayground/run.sgo:146 */ 	}
+/* sgoplayground/run.sgo:147 */ 	kill()
+/* sgoplayground/run.sgo:148 */ 	return true, <-done
+/* sgoplayground/run.sgo:149 */ }
+
+// An output sends a program's output to the page, up to a maximum number of
+// bytes, after which it's discarded, until ctx is done.
+/* sgoplayground/run.sgo:153 */ type output struct {
+/* sgoplayground/run.sgo:154 */ 	ctx context.Context
+/* sgoplayground/run.sgo:155 */ 	c   sender
+
+/* sgoplayground/run.sgo:157 */ 	mu        sync.Mutex
+/* sgoplayground/run.sgo:158 */ 	left      int
+/* sgoplayground/run.sgo:159 */ 	truncated bool
+/* sgoplayground/run.sgo:160 */ }
+
+// send sends message as an "output" message of the given kind.
+// For SGo: (*output) func(kind, message string)
+func (o *output) send(kind, message string) {
+/* sgoplayground/run.sgo:164 */ 	if o.ctx.Err() != nil {
+/* sgoplayground/run.sgo:165 */ 		return
+/* sgoplayground/run.sgo:166 */ 	}
+/* sgoplayground/run.sgo:167 */ 	o.c.send(&msgType{
+/* sgoplayground/run.sgo:168 */ 		Type:  "output",
+/* sgoplayground/run.sgo:169 */ 		Value: outputEvent{Kind: kind, Message: message},
+/* sgoplayground/run.sgo:170 */ 	})
+/* sgoplayground/run.sgo:171 */ }
+
+// writer returns a writer whose writes are sent with the given kind.
+// For SGo: (*output) func(kind string) io.Writer
+func (o *output) writer(kind string) io.Writer {
+/* sgoplayground/run.sgo:175 */ 	return &outputWriter{o: o, kind: kind}
+/* sgoplayground/run.sgo:176 */ }
+
+/* sgoplayground/run.sgo:178 */ type outputWriter struct {
+/* sgoplayground/run.sgo:179 */ 	o    *output
+/* sgoplayground/run.sgo:180 */ 	kind string
+
+	// partial is the start of a UTF-8 sequence that ended a write, which is
+	// sent along with the rest of it.
+/* sgoplayground/run.sgo:184 */ 	partial []byte
+/* sgoplayground/run.sgo:185 */ }
+
+// For SGo: (*outputWriter) func(p []byte) (int, ?error)
+func (w *outputWriter) Write(p []byte) (int, error) {
+/* sgoplayground/run.sgo:188 */ 	n := len(p)
+/* sgoplayground/run.sgo:189 */ 	w.o.mu.Lock()
+/* sgoplayground/run.sgo:190 */ 	if len(p) > w.o.left {
+/* sgoplayground/run.sgo:191 */ 		p = p[:w.o.left]
+/* sgoplayground/run.sgo:192 */ 		w.o.truncated = true
+/* sgoplayground/run.sgo:193 */ 	}
+/* sgoplayground/run.sgo:194 */ 	w.o.left -= len(p)
+/* sgoplayground/run.sgo:195 */ 	w.o.mu.Unlock()
+
+/* sgoplayground/run.sgo:197 */ 	p = append(w.partial, p...)
+/* sgoplayground/run.sgo:198 */ 	end := len(p)
+/* sgoplayground/run.sgo:199 */ 	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+/* sgoplayground/run.sgo:200 */ 		if utf8.RuneStart(p[i]) {
+/* sgoplayground/run.sgo:201 */ 			if !utf8.FullRune(p[i:]) {
+/* sgoplayground/run.sgo:202 */ 				end = i
+/* sgoplayground/run.sgo:203 */ 			}
+/* sgoplayground/run.sgo:204 */ 			break
+/* sgoplayground/run.sgo:205 */ 		}
+/* sgoplayground/run.sgo:206 */ 	}
+/* sgoplayground/run.sgo:207 */ 	w.partial = append([]byte(nil), p[end:]...)
+/* sgoplayground/run.sgo:208 */ 	if end > 0 {
+/* sgoplayground/run.sgo:209 */ 		w.o.send(w.kind, string(p[:end]))
+/* sgoplayground/run.sgo:210 */ 	}
+/* sgoplayground/run.sgo:211 */ 	return n, nil
+/* sgoplayground/run.sgo:212 */ }
@@ -0,0 +1,255 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package main
+
+/* sgoplayground/run_test.sgo:3 */ import (
+/* sgoplayground/run_test.sgo:4 */ 	"bytes"
+/* sgoplayground/run_test.sgo:5 */ 	"context"
+/* sgoplayground/run_test.sgo:6 */ 	"os/exec"
+/* sgoplayground/run_test.sgo:7 */ 	"strings"
+/* sgoplayground/run_test.sgo:8 */ 	"sync"
+/* sgoplayground/run_test.sgo:9 */ 	"testing"
+/* sgoplayground/run_test.sgo:10 */ 	"time"
+/* sgoplayground/run_test.sgo:11 */ )
+
+// A recorder is a sender that keeps the messages sent to it.
+/* sgoplayground/run_test.sgo:14 */ type recorder struct {
+/* sgoplayground/run_test.sgo:15 */ 	mu   sync.Mutex
+/* sgoplayground/run_test.sgo:16 */ 	msgs []*msgType
+/* sgoplayground/run_test.sgo:17 */ }
+
+// For SGo: (*recorder) func(msg *msgType)
+func (r *recorder) send(msg *msgType) {
+/* sgoplayground/run_test.sgo:20 */ 	r.mu.Lock()
+/* sgoplayground/run_test.sgo:21 */ 	defer r.mu.Unlock()
+/* sgoplayground/run_test.sgo:22 */ 	r.msgs = append(r.msgs, msg)
+/* sgoplayground/run_test.sgo:23 */ }
+
+// output returns what a program wrote, and what was said about it in "system"
+// output messages.
+// For SGo: (*recorder) func() (written, system string)
+func (r *recorder) output() (written, system string) {
+/* sgoplayground/run_test.sgo:28 */ 	r.mu.Lock()
+/* sgoplayground/run_test.sgo:29 */ 	defer r.mu.Unlock()
+/* sgoplayground/run_test.sgo:30 */ 	for _, msg := range r.msgs {
+/* sgoplayground/run_test.sgo:31 */ 		v := msg.Value
+/* sgoplayground/run_test.sgo:32 */ 		if msg.Type != "output" || v == nil {
+/* sgoplayground/run_test.sgo:33 */ 			continue
+/* sgoplayground/run_test.sgo:34 */ 		}
+/* sgoplayground/run_test.sgo:35 */ 		ev, ok := v.(outputEvent)
+/* sgoplayground/run_test.sgo:36 */ 		if !ok {
+/* sgoplayground/run_test.sgo:37 */ 			continue
+/* sgoplayground/run_test.sgo:38 */ 		}
+/* sgoplayground/run_test.sgo:39 */ 		if ev.Kind == "system" {
+/* sgoplayground/run_test.sgo:40 */ 			system += ev.Message
+/* sgoplayground/run_test.sgo:41 */ 		} else {
+/* sgoplayground/run_test.sgo:42 */ 			written += ev.Message
+/* sgoplayground/run_test.sgo:43 */ 		}
+/* sgoplayground/run_test.sgo:44 */ 	}
+/* sgoplayground/run_test.sgo:45 */ 	return written, system
+/* sgoplayground/run_test.sgo:46 */ }
+
+// setLimits sets the limits for running programs, and returns a function that
+// restores the previous ones.
+/* sgoplayground/run_test.sgo:50 */ func setLimits(d time.Duration, maxOut int) func() {
+/* sgoplayground/run_test.sgo:51 */ 	oldTimeout, oldOutput := *timeout, *maxOutput
+/* sgoplayground/run_test.sgo:52 */ 	*timeout, *maxOutput = d, maxOut
+/* sgoplayground/run_test.sgo:53 */ 	return func() {
+/* sgoplayground/run_test.sgo:54 */ 		*timeout, *maxOutput = oldTimeout, oldOutput
+/* sgoplayground/run_test.sgo:55 */ 	}
+/* sgoplayground/run_test.sgo:56 */ }
+
+/* sgoplayground/run_test.sgo:58 */ func TestRunTimeoutKillsGroup(t *testing.T) {
+	// The output isn't a file, so waiting for the shell also waits for the
+	// sleeps it starts to close it.
+/* sgoplayground/run_test.sgo:61 */ 	var out bytes.Buffer
+/* sgoplayground/run_test.sgo:62 */ 	cmd := exec.Command("/bin/sh", "-c", "/bin/sleep 60 & /bin/sleep 60")
+/* sgoplayground/run_test.sgo:63 */ 	cmd.Stdout = &out
+/* sgoplayground/run_test.sgo:64 */ 	start := time.Now()
+/* sgoplayground/run_test.sgo:65 */ 	killed, _ := runTimeout(context.Background(), cmd, 100*time.Millisecond)
+/* sgoplayground/run_test.sgo:66 */ 	if !killed {
+/* sgoplayground/run_test.sgo:67 */ 		t.Errorf("not killed")
+/* sgoplayground/run_test.sgo:68 */ 	}
+/* sgoplayground/run_test.sgo:69 */ 	if elapsed := time.Since(start); elapsed > 10*time.Second {
+/* sgoplayground/run_test.sgo:70 */ 		t.Errorf("took %v", elapsed)
+/* sgoplayground/run_test.sgo:71 */ 	}
+
+	// The shell ends right away, but the sleep it leaves behind keeps the
+	// output open.
+/* sgoplayground/run_test.sgo:75 */ 	cmd = exec.Command("/bin/sh", "-c", "/bin/sleep 60 &")
+/* sgoplayground/run_test.sgo:76 */ 	cmd.Stdout = &out
+/* sgoplayground/run_test.sgo:77 */ 	start = time.Now()
+/* sgoplayground/run_test.sgo:78 */ 	killed, _ = runTimeout(context.Background(), cmd, time.Minute)
+/* sgoplayground/run_test.sgo:79 */ 	if killed {
+/* sgoplayground/run_test.sgo:80 */ 		t.Errorf("killed")
+/* sgoplayground/run_test.sgo:81 */ 	}
+/* sgoplayground/run_test.sgo:82 */ 	if elapsed := time.Since(start); elapsed > 10*time.Second {
+/* sgoplayground/run_test.sgo:83 */ 		t.Errorf("took %v", elapsed)
+/* sgoplayground/run_test.sgo:84 */ 	}
+
+	// Stopped before the timeout.
+/* sgoplayground/run_test.sgo:87 */ 	ctx, cancel := context.WithCancel(context.Background())
+/* sgoplayground/run_test.sgo:88 */ 	time.AfterFunc(100*time.Millisecond, cancel)
+/* sgoplayground/run_test.sgo:89 */ 	cmd = exec.Command("/bin/sh", "-c", "/bin/sleep 60 & /bin/sleep 60")
+/* sgoplayground/run_test.sgo:90 */ 	cmd.Stdout = &out
+/* sgoplayground/run_test.sgo:91 */ 	start = time.Now()
+/* sgoplayground/run_test.sgo:92 */ 	killed, _ = runTimeout(ctx, cmd, time.Minute)
+/* sgoplayground/run_test.sgo:93 */ 	if !killed {
+/* sgoplayground/run_test.sgo:94 */ 		t.Errorf("not killed when stopped")
+/* sgoplayground/run_test.sgo:95 */ 	}
+/* sgoplayground/run_test.sgo:96 */ 	if elapsed := time.Since(start); elapsed > 10*time.Second {
+/* sgoplayground/run_test.sgo:97 */ 		t.Errorf("took %v", elapsed)
+/* sgoplayground/run_test.sgo:98 */ 	}
+/* sgoplayground/run_test.sgo:99 */ }
+
+/* sgoplayground/run_test.sgo:101 */ func TestRunLocal(t *testing.T) {
+/* sgoplayground/run_test.sgo:102 */ 	if testing.Short() {
+/* sgoplayground/run_test.sgo:103 */ 		t.Skip("builds programs")
+/* sgoplayground/run_test.sgo:104 */ 	}
+
+/* sgoplayground/run_test.sgo:106 */ 	for _, tt := range []struct {
+/* sgoplayground/run_test.sgo:107 */ 		name    string
+/* sgoplayground/run_test.sgo:108 */ 		src     string
+/* sgoplayground/run_test.sgo:109 */ 		timeout time.Duration
+/* sgoplayground/run_test.sgo:110 */ 		output  int
+/* sgoplayground/run_test.sgo:111 */ 		errors  string
+/* sgoplayground/run_test.sgo:112 */ 		written string
+/* sgoplayground/run_test.sgo:113 */ 		system  string
+/* sgoplayground/run_test.sgo:114 */ 	}{{
+/* sgoplayground/run_test.sgo:115 */ 		name: "output",
+/* sgoplayground/run_test.sgo:116 */ 		src: `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Println("hello, 世界")
+	fmt.Fprintln(os.Stderr, "oops")
+	os.Exit(3)
+}
+`,
+/* sgoplayground/run_test.sgo:129 */ 		timeout: 10 * time.Second,
+/* sgoplayground/run_test.sgo:130 */ 		output:  1 << 20,
+/* sgoplayground/run_test.sgo:131 */ 		written: "hello, 世界\noops\n",
+/* sgoplayground/run_test.sgo:132 */ 		system:  "\nProgram exited: exit status 3.\n",
+/* sgoplayground/run_test.sgo:133 */ 	}, {
+/* sgoplayground/run_test.sgo:134 */ 		name: "truncated",
+/* sgoplayground/run_test.sgo:135 */ 		src: `package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() {
+	fmt.Print(strings.Repeat("x", 100))
+}
+`,
+/* sgoplayground/run_test.sgo:146 */ 		timeout: 10 * time.Second,
+/* sgoplayground/run_test.sgo:147 */ 		output:  10,
+/* sgoplayground/run_test.sgo:148 */ 		written: "xxxxxxxxxx",
+/* sgoplayground/run_test.sgo:149 */ 		system:  "\nOutput truncated.\nProgram exited.\n",
+/* sgoplayground/run_test.sgo:150 */ 	}, {
+/* sgoplayground/run_test.sgo:151 */ 		name: "timeout",
+/* sgoplayground/run_test.sgo:152 */ 		src: `package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+func main() {
+	fmt.Println("started")
+	cmd := exec.Command("/bin/sleep", "3600")
+	cmd.Stdout = os.Stdout
+	cmd.Start()
+	time.Sleep(time.Hour)
+}
+`,
+/* sgoplayground/run_test.sgo:169 */ 		timeout: time.Second,
+/* sgoplayground/run_test.sgo:170 */ 		output:  1 << 20,
+/* sgoplayground/run_test.sgo:171 */ 		written: "started\n",
+/* sgoplayground/run_test.sgo:172 */ 		system:  "\nProgram timed out.\n",
+/* sgoplayground/run_test.sgo:173 */ 	}, {
+/* sgoplayground/run_test.sgo:174 */ 		name: "build error",
+/* sgoplayground/run_test.sgo:175 */ 		src: `package main
+
+func main() {
+	undefined()
+}
+`,
+/* sgoplayground/run_test.sgo:181 */ 		timeout: 10 * time.Second,
+/* sgoplayground/run_test.sgo:182 */ 		output:  1 << 20,
+/* sgoplayground/run_test.sgo:183 */ 		errors:  "undefined: undefined",
+/* sgoplayground/run_test.sgo:184 */ 	}} {
+/* sgoplayground/run_test.sgo:185 */ 		restore := setLimits(tt.timeout, tt.output)
+/* sgoplayground/run_test.sgo:186 */ 		r := &recorder{}
+/* sgoplayground/run_test.sgo:187 */ 		start := time.Now()
+/* sgoplayground/run_test.sgo:188 */ 		res := runLocal(context.Background(), r, []byte(tt.src))
+/* sgoplayground/run_test.sgo:189 */ 		elapsed := time.Since(start)
+/* sgoplayground/run_test.sgo:190 */ 		restore()
+
+/* sgoplayground/run_test.sgo:192 */ 		if !strings.Contains(res.Errors, tt.errors) || (tt.errors == "") != (res.Errors == "") {
+/* sgoplayground/run_test.sgo:193 */ 			t.Errorf("%s: got errors %q, want %q", tt.name, res.Errors, tt.errors)
+/* sgoplayground/run_test.sgo:194 */ 		}
+/* sgoplayground/run_test.sgo:195 */ 		written, system := r.output()
+/* sgoplayground/run_test.sgo:196 */ 		if written != tt.written {
+/* sgoplayground/run_test.sgo:197 */ 			t.Errorf("%s: got output %q, want %q", tt.name, written, tt.written)
+/* sgoplayground/run_test.sgo:198 */ 		}
+/* sgoplayground/run_test.sgo:199 */ 		if system != tt.system {
+/* sgoplayground/run_test.sgo:200 */ 			t.Errorf("%s: got system messages %q, want %q", tt.name, system, tt.system)
+/* sgoplayground/run_test.sgo:201 */ 		}
+/* sgoplayground/run_test.sgo:202 */ 		if elapsed > tt.timeout+buildTimeout {
+/* sgoplayground/run_test.sgo:203 */ 			t.Errorf("%s: took %v", tt.name, elapsed)
+/* sgoplayground/run_test.sgo:204 */ 		}
+/* sgoplayground/run_test.sgo:205 */ 	}
+/* sgoplayground/run_test.sgo:206 */ }
+
+// A stopper is a recorder that stops a run once it's sent something.
+/* sgoplayground/run_test.sgo:209 */ type stopper struct {
+/* sgoplayground/run_test.sgo:210 */ 	recorder
+/* sgoplayground/run_test.sgo:211 */ 	stop func()
+/* sgoplayground/run_test.sgo:212 */ }
+
+/* sgoplayground/run_test.sgo:214 */ func (s *stopper) send(msg *msgType) {
+/* sgoplayground/run_test.sgo:215 */ 	s.recorder.send(msg)
+/* sgoplayground/run_test.sgo:216 */ 	s.stop()
+/* sgoplayground/run_test.sgo:217 */ }
+
+/* sgoplayground/run_test.sgo:219 */ func TestRunLocalStopped(t *testing.T) {
+/* sgoplayground/run_test.sgo:220 */ 	if testing.Short() {
+/* sgoplayground/run_test.sgo:221 */ 		t.Skip("builds programs")
+/* sgoplayground/run_test.sgo:222 */ 	}
+
+/* sgoplayground/run_test.sgo:224 */ 	restore := setLimits(time.Minute, 1<<20)
+/* sgoplayground/run_test.sgo:225 */ 	defer restore()
+/* sgoplayground/run_test.sgo:226 */ 	ctx, cancel := context.WithCancel(context.Background())
+/* sgoplayground/run_test.sgo:227 */ 	s := &stopper{stop: cancel}
+/* sgoplayground/run_test.sgo:228 */ 	start := time.Now()
+/* sgoplayground/run_test.sgo:229 */ 	res := runLocal(ctx, s, []byte(`package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func main() {
+	fmt.Println("started")
+	time.Sleep(time.Hour)
+}
+`))
+/* sgoplayground/run_test.sgo:241 */ 	if elapsed := time.Since(start); elapsed > buildTimeout {
+/* sgoplayground/run_test.sgo:242 */ 		t.Errorf("took %v", elapsed)
+/* sgoplayground/run_test.sgo:243 */ 	}
+/* sgoplayground/run_test.sgo:244 */ 	if res.Errors != "" {
+/* sgoplayground/run_test.sgo:245 */ 		t.Errorf("got errors %q", res.Errors)
+/* sgoplayground/run_test.sgo:246 */ 	}
+/* sgoplayground/run_test.sgo:247 */ 	written, system := s.output()
+/* sgoplayground/run_test.sgo:248 */ 	if written != "started\n" || system != "" {
+/* sgoplayground/run_test.sgo:249 */ 		t.Errorf("got output %q and system messages %q, want only the output before stopping", written, system)
+/* sgoplayground/run_test.sgo:250 */ 	}
+/* sgoplayground/run_test.sgo:251 */ }
@@ -0,0 +1,85 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package main
+
+/* sgoplayground/share.sgo:3 */ import (
+/* sgoplayground/share.sgo:4 */ 	"crypto/sha256"
+/* sgoplayground/share.sgo:5 */ 	"errors"
+/* sgoplayground/share.sgo:6 */ 	"fmt"
+/* sgoplayground/share.sgo:7 */ 	"io/ioutil"
+/* sgoplayground/share.sgo:8 */ 	"net/http"
+/* sgoplayground/share.sgo:9 */ 	"os"
+/* sgoplayground/share.sgo:10 */ 	"path/filepath"
+/* sgoplayground/share.sgo:11 */ )
+
+// maxShareSize is the maximum size of a shared program, in bytes.
+/* sgoplayground/share.sgo:14 */ const maxShareSize = 64 << 10
+
+// A shareStore keeps shared programs as files in a directory, named after
+// their IDs, which are derived from their contents.
+/* sgoplayground/share.sgo:18 */ type shareStore struct {
+/* sgoplayground/share.sgo:19 */ 	dir string
+/* sgoplayground/share.sgo:20 */ }
+
+// handleShare stores the program in the body of a POST request, and responds
+// with its ID, which the page at /p/<ID> shows.
+// For SGo: (shareStore) func(w http.ResponseWriter, req *http.Request)
+func (s shareStore) handleShare(w http.ResponseWriter, req *http.Request) {
+/* sgoplayground/share.sgo:25 */ 	if req.Method != "POST" {
+/* sgoplayground/share.sgo:26 */ 		http.Error(w, "shared programs must be POSTed", http.StatusMethodNotAllowed)
+/* sgoplayground/share.sgo:27 */ 		return
+/* sgoplayground/share.sgo:28 */ 	}
+/* sgoplayground/share.sgo:29 */ 	code, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxShareSize))
+/* sgoplayground/share.sgo:30 */ 	if err != nil {
+/* sgoplayground/share.sgo:31 */ 		http.Error(w, err.Error(), http.StatusBadRequest)
+/* sgoplayground/share.sgo:32 */ 		return
+/* sgoplayground/share.sgo:33 */ 	}
+/* sgoplayground/share.sgo:34 */ 	id, err := s.put(code)
+/* sgoplayground/share.sgo:35 */ 	if err != nil {
+/* sgoplayground/share.sgo:36 */ 		http.Error(w, err.Error(), http.StatusInternalServerError)
+/* sgoplayground/share.sgo:37 */ 		return
+/* sgoplayground/share.sgo:38 */ 	}
+/* sgoplayground/share.sgo:39 */ 	fmt.Fprint(w, id)
+/* sgoplayground/share.sgo:40 */ }
+
+// put stores code and returns its ID.
+// For SGo: (shareStore) func(code []byte) (string \ error)
+func (s shareStore) put(code []byte) (string, error) {
+/* sgoplayground/share.sgo:44 */ 	id := fmt.Sprintf("%x", sha256.Sum256(code))[:12]
+/* sgoplayground/share.sgo:45 */ 	err := os.MkdirAll(s.dir, 0755)
+/* sgoplayground/share.sgo:46 */ 	if err != nil {
+/* sgoplayground/share.sgo:47 */ 		return "", err
+/* sgoplayground/share.sgo:48 */ 	}
+/* sgoplayground/share.sgo:49 */ 	err = ioutil.WriteFile(filepath.Join(s.dir, id), code, 0644)
+/* sgoplayground/share.sgo:50 */ 	if err != nil {
+/* sgoplayground/share.sgo:51 */ 		return "", err
+/* sgoplayground/share.sgo:52 */ 	}
+/* sgoplayground/share.sgo:53 */ 	return id, nil
+/* sgoplayground/share.sgo:54 */ }
+
+// get returns the program stored with the given ID.
+// For SGo: (shareStore) func(id string) ([]byte \ error)
+func (s shareStore) get(id string) ([]byte, error) {
+/* sgoplayground/share.sgo:58 */ 	if !validShareID(id) {
+/* sgoplayground/share.sgo:59 */ 		return nil, errors.New("invalid ID: " + id)
+/* sgoplayground/share.sgo:60 */ 	}
+/* sgoplayground/share.sgo:61 */ 	code, err := ioutil.ReadFile(filepath.Join(s.dir, id))
+/* sgoplayground/share.sgo:62 */ 	if err != nil {
+/* sgoplayground/share.sgo:63 */ 		return nil, err
+/* sgoplayground/share.sgo:64 */ 	}
+/* sgoplayground/share.sgo:65 */ 	return code, nil
+/* sgoplayground/share.sgo:66 */ }
+
+// validShareID reports whether id is like the ones put returns, so that it
+// can't name any other file.
+/* sgoplayground/share.sgo:70 */ func validShareID(id string) bool {
+/* sgoplayground/share.sgo:71 */ 	if len(id) != 12 {
+/* sgoplayground/share.sgo:72 */ 		return false
+/* sgoplayground/share.sgo:73 */ 	}
+/* sgoplayground/share.sgo:74 */ 	for _, r := range id {
+/* sgoplayground/share.sgo:75 */ 		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+/* sgoplayground/share.sgo:76 */ 			return false
+/* sgoplayground/share.sgo:77 */ 		}
+/* sgoplayground/share.sgo:78 */ 	}
+/* sgoplayground/share.sgo:79 */ 	return true
+/* sgoplayground/share.sgo:80 */ }
@@ -0,0 +1,92 @@
+// Autogenerated by SGo. DO NOT EDIT!
+
+package main
+
+/* sgoplayground/share_test.sgo:3 */ import (
+/* sgoplayground/share_test.sgo:4 */ 	"io/ioutil"
+/* sgoplayground/share_test.sgo:5 */ 	"net/http"
+/* sgoplayground/share_test.sgo:6 */ 	"net/http/httptest"
+/* sgoplayground/share_test.sgo:7 */ 	"os"
+/* sgoplayground/share_test.sgo:8 */ 	"path/filepath"
+/* sgoplayground/share_test.sgo:9 */ 	"strings"
+/* sgoplayground/share_test.sgo:10 */ 	"testing"
+/* sgoplayground/share_test.sgo:11 */ )
+
+/* sgoplayground/share_test.sgo:13 */ func TestShareStore(t *testing.T) {
+/* sgoplayground/share_test.sgo:14 */ 	dir, err := ioutil.TempDir("", "sgoplayground")
+/* sgoplayground/share_test.sgo:15 */ 	if err != nil {
+/* sgoplayground/share_test.sgo:16 */ 		t.Fatal(err)
+/* sgoplayground/share_test.sgo:17 */ 	}
+/* sgoplayground/share_test.sgo:18 */ 	defer os.RemoveAll(dir)
+/* sgoplayground/share_test.sgo:19 */ 	s := shareStore{dir: filepath.Join(dir, "shared")}
+
+/* sgoplayground/share_test.sgo:21 */ 	code := []byte("package main\n")
+/* sgoplayground/share_test.sgo:22 */ 	id, err := s.put(code)
+/* sgoplayground/share_test.sgo:23 */ 	if err != nil {
+/* sgoplayground/share_test.sgo:24 */ 		t.Fatal(err)
+/* sgoplayground/share_test.sgo:25 */ 		return
+/* sgoplayground/share_test.sgo:26 */ 	}
+/* sgoplayground/share_test.sgo:27 */ 	if !validShareID(id) {
+/* sgoplayground/share_test.sgo:28 */ 		t.Errorf("invalid ID %q", id)
+/* sgoplayground/share_test.sgo:29 */ 	}
+/* sgoplayground/share_test.sgo:30 */ 	if again, err := s.put(code); err != nil {
+/* sgoplayground/share_test.sgo:31 */ 		t.Error(err)
+/* sgoplayground/share_test.sgo:32 */ 	} else if again != id {
+/* sgoplayground/share_test.sgo:33 */ 		t.Errorf("got %q putting the same code again, want %q", again, id)
+/* sgoplayground/share_test.sgo:34 */ 	}
+/* sgoplayground/share_test.sgo:35 */ 	if other, err := s.put([]byte("package other\n")); err != nil {
+/* sgoplayground/share_test.sgo:36 */ 		t.Error(err)
+/* sgoplayground/share_test.sgo:37 */ 	} else if other == id {
+/* sgoplayground/share_test.sgo:38 */ 		t.Errorf("got the same ID %q putting other code", id)
+/* sgoplayground/share_test.sgo:39 */ 	}
+
+/* sgoplayground/share_test.sgo:41 */ 	if got, err := s.get(id); err != nil {
+/* sgoplayground/share_test.sgo:42 */ 		t.Error(err)
+/* sgoplayground/share_test.sgo:43 */ 	} else if string(got) != string(code) {
+/* sgoplayground/share_test.sgo:44 */ 		t.Errorf("got %q, want %q", got, code)
+/* sgoplayground/share_test.sgo:45 */ 	}
+/* sgoplayground/share_test.sgo:46 */ 	for _, id := range []string{"", "000000000000", "ABCDEF012345", id + "0", "../../x/abcd", "../shared/.."} {
+/* sgoplayground/share_test.sgo:47 */ 		if _, err := s.get(id); err == nil {
+/* sgoplayground/share_test.sgo:48 */ 			t.Errorf("got no error for %q", id)
+/* sgoplayground/share_test.sgo:49 */ 		}
+/* sgoplayground/share_test.sgo:50 */ 	}
+/* sgoplayground/share_test.sgo:51 */ }
+
+/* sgoplayground/share_test.sgo:53 */ func TestHandleShare(t *testing.T) {
+/* sgoplayground/share_test.sgo:54 */ 	dir, err := ioutil.TempDir("", "sgoplayground")
+/* sgoplayground/share_test.sgo:55 */ 	if err != nil {
+/* sgoplayground/share_test.sgo:56 */ 		t.Fatal(err)
+/* sgoplayground/share_test.sgo:57 */ 	}
+/* sgoplayground/share_test.sgo:58 */ 	defer os.RemoveAll(dir)
+/* sgoplayground/share_test.sgo:59 */ 	s := shareStore{dir: dir}
+
+/* sgoplayground/share_test.sgo:61 */ 	share := func(method, body string) (int, string) {
+/* sgoplayground/share_test.sgo:62 */ 		rec := httptest.NewRecorder()
+/* sgoplayground/share_test.sgo:63 */ 		req := httptest.NewRequest(method, "/share", strings.NewReader(body))
+/* sgoplayground/share_test.sgo:64 */ 		if rec == nil {
+/* sgoplayground/share_test.sgo:65 */ 			return 0, ""
+/* sgoplayground/share_test.sgo:66 */ 		}
+/* sgoplayground/share_test.sgo:67 */ 		if req == nil {
+/* sgoplayground/share_test.sgo:68 */ 			return 0, ""
+/* sgoplayground/share_test.sgo:69 */ 		}
+/* sgoplayground/share_test.sgo:70 */ 		s.handleShare(rec, req)
+/* sgoplayground/share_test.sgo:71 */ 		return rec.Code, rec.Body.String()
+/* sgoplayground/share_test.sgo:72 */ 	}
+
+/* sgoplayground/share_test.sgo:74 */ 	if status, _ := share("GET", ""); status != http.StatusMethodNotAllowed {
+/* sgoplayground/share_test.sgo:75 */ 		t.Errorf("GET: got status %d", status)
+/* sgoplayground/share_test.sgo:76 */ 	}
+/* sgoplayground/share_test.sgo:77 */ 	if status, _ := share("POST", strings.Repeat("x", maxShareSize+1)); status != http.StatusBadRequest {
+/* sgoplayground/share_test.sgo:78 */ 		t.Errorf("too large: got status %d", status)
+/* sgoplayground/share_test.sgo:79 */ 	}
+
+/* sgoplayground/share_test.sgo:81 */ 	status, id := share("POST", "package main\n")
+/* sgoplayground/share_test.sgo:82 */ 	if status != http.StatusOK {
+/* sgoplayground/share_test.sgo:83 */ 		t.Fatalf("got status %d: %s", status, id)
+/* sgoplayground/share_test.sgo:84 */ 	}
+/* sgoplayground/share_test.sgo:85 */ 	if code, err := s.get(id); err != nil {
+/* sgoplayground/share_test.sgo:86 */ 		t.Error(err)
+/* sgoplayground/share_test.sgo:87 */ 	} else if string(code) != "package main\n" {
+/* sgoplayground/share_test.sgo:88 */ 		t.Errorf("got %q for %q", code, id)
+/* sgoplayground/share_test.sgo:89 */ 	}
+/* sgoplayground/share_test.sgo:90 */ }
@@ -48,6 +48,11 @@ In the pattern, single-character lowercase identifiers serve as
 wildcards matching arbitrary sub-expressions; those expressions
 will be substituted for the same identifiers in the replacement.
 
+Patterns may use SGo syntax. A selector pattern x.f only matches
+x?.f if it is itself x?.f, and a replacement that makes an already
+optional type optional, like the one for *T in ?*T with the rule
+'*a -> ?*a', leaves it as it was.
+
 When gofmt reads from standard input, it accepts either a full Go program
 or a program fragment.  A program fragment must be a syntactically
 valid declaration list, statement list, or expression.  When formatting
@@ -93,6 +98,15 @@ When invoked with -s gofmt will make the following source transformations where
 	will be simplified to:
 		for range v {...}
 
+	An assignment of the form:
+		x, err := f()
+		if err != nil {...}
+	where f is declared in the same file and its results are entangled,
+	will be simplified to:
+		x \ err := f()
+		if err != nil {...}
+	The same goes for !ok instead of err != nil.
+
 This may result in changes that are incompatible with earlier versions of Go.
 */
 package main
@@ -60,6 +60,7 @@ func rewriteFile(pattern, replace ast.Expr, p *ast.File) *ast.File {
 	m := make(map[string]reflect.Value)
 	pat := reflect.ValueOf(pattern)
 	repl := reflect.ValueOf(replace)
+	replaced := make(map[ast.Node]bool)
 
 	var rewriteVal func(val reflect.Value) reflect.Value
 	rewriteVal = func(val reflect.Value) reflect.Value {
@@ -71,8 +72,18 @@ func rewriteFile(pattern, replace ast.Expr, p *ast.File) *ast.File {
 			delete(m, k)
 		}
 		val = apply(rewriteVal, val)
+		if opt, ok := val.Interface().(*ast.OptionalType); ok && opt != nil {
+			// ??T isn't valid; an optional type that a replacement
+			// made optional stays as it is.
+			if elt, ok := opt.Elt.(*ast.OptionalType); ok && replaced[elt] {
+				val = reflect.ValueOf(elt)
+			}
+		}
 		if match(m, pat, val) {
 			val = subst(m, repl, reflect.ValueOf(val.Interface().(ast.Node).Pos()))
+			if n, ok := val.Interface().(ast.Node); ok {
+				replaced[n] = true
+			}
 		}
 		return val
 	}
@@ -110,6 +121,7 @@ var (
 	objectPtrType = reflect.TypeOf((*ast.Object)(nil))
 	positionType  = reflect.TypeOf(token.NoPos)
 	callExprType  = reflect.TypeOf((*ast.CallExpr)(nil))
+	selectorType  = reflect.TypeOf((*ast.SelectorExpr)(nil))
 	scopePtrType  = reflect.TypeOf((*ast.Scope)(nil))
 )
 
@@ -206,6 +218,14 @@ func match(m map[string]reflect.Value, pattern, val reflect.Value) bool {
 		if p.Ellipsis.IsValid() != v.Ellipsis.IsValid() {
 			return false
 		}
+	case selectorType:
+		// Likewise, the Quest fields must match since that is
+		// how x.f and x?.f are different.
+		p := pattern.Interface().(*ast.SelectorExpr)
+		v := val.Interface().(*ast.SelectorExpr)
+		if p.Quest.IsValid() != v.Quest.IsValid() {
+			return false
+		}
 	}
 
 	p := reflect.Indirect(pattern)
@@ -97,6 +97,15 @@ func (s *simplifier) Visit(node ast.Node) ast.Visitor {
 		// An example where it does not:
 		//       x, y := b[:n], b[n:]
 
+	case *ast.BlockStmt:
+		s.entangleAssigns(n.List)
+
+	case *ast.CaseClause:
+		s.entangleAssigns(n.Body)
+
+	case *ast.CommClause:
+		s.entangleAssigns(n.Body)
+
 	case *ast.RangeStmt:
 		// - a range of the form: for x, _ = range v {...}
 		// can be simplified to: for x = range v {...}
@@ -113,6 +122,78 @@ func (s *simplifier) Visit(node ast.Node) ast.Visitor {
 	return s
 }
 
+// entangleAssigns rewrites in list the assignments of the form:
+//
+//	x, err := f()
+//	if err != nil {...}
+//
+// to:
+//
+//	x \ err := f()
+//	if err != nil {...}
+//
+// when f is a function declared in the file whose last result is
+// entangled with the others. Without the backslash, such an assignment
+// doesn't type-check.
+func (s *simplifier) entangleAssigns(list []ast.Stmt) {
+	for i, stmt := range list {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || assign.Lhs.EntangledPos != 0 || assign.Rhs.Len() != 1 || i+1 == len(list) {
+			continue
+		}
+		call, ok := assign.Rhs.List[0].(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		res := entangledResults(call.Fun)
+		if res == nil || assign.Lhs.Len() != res.NumFields()+1 {
+			continue
+		}
+		last, ok := assign.Lhs.List[assign.Lhs.Len()-1].(*ast.Ident)
+		if !ok || isBlank(last) {
+			continue
+		}
+		if ifStmt, ok := list[i+1].(*ast.IfStmt); ok && ifStmt.Init == nil && checksEntangled(ifStmt.Cond, last) {
+			assign.Lhs.EntangledPos = assign.Lhs.Len()
+		}
+	}
+}
+
+// entangledResults returns the results of the function fun refers to, if it's
+// declared in the file and they are entangled.
+func entangledResults(fun ast.Expr) *ast.FieldList {
+	id, ok := fun.(*ast.Ident)
+	if !ok || id.Obj == nil || id.Obj.Kind != ast.Fun {
+		return nil
+	}
+	decl, ok := id.Obj.Decl.(*ast.FuncDecl)
+	if !ok || decl.Recv != nil {
+		return nil
+	}
+	if res := decl.Type.Results; res != nil && res.Entangled != nil {
+		return res
+	}
+	return nil
+}
+
+// checksEntangled reports whether cond is x != nil or !x.
+func checksEntangled(cond ast.Expr, x *ast.Ident) bool {
+	switch cond := cond.(type) {
+	case *ast.BinaryExpr:
+		nilIdent, ok := cond.Y.(*ast.Ident)
+		return cond.Op == token.NEQ && isIdent(cond.X, x) && ok && nilIdent.Name == "nil"
+	case *ast.UnaryExpr:
+		return cond.Op == token.NOT && isIdent(cond.X, x)
+	}
+	return false
+}
+
+// isIdent reports whether e is an identifier with the same name as x.
+func isIdent(e ast.Expr, x *ast.Ident) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == x.Name
+}
+
 func isBlank(x ast.Expr) bool {
 	ident, ok := x.(*ast.Ident)
 	return ok && ident.Name == "_"
@@ -9,6 +9,12 @@ It's a drop-in replacement for your editor's sgofmt-on-save hook.
 It has the same command-line interface as sgofmt and formats
 your code in the same way.
 
+Missing imports are looked up in the standard library, in GOROOT and
+GOPATH, including packages written only in SGo, and in the module
+cache. When several packages export the referenced names, those with
+SGo annotations win: packages written in SGo, with "For SGo:" doc
+comments, or annotated in a sgovendor folder or SGOANNPATH.
+
 For emacs, make sure you have the latest go-mode.el:
    https://github.com/dominikh/go-mode.el
 Then in your .emacs file:
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"go/build"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/importer"
+	"github.com/tcard/sgo/sgo/parser"
+	"github.com/tcard/sgo/sgo/scanner"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+// A snapshot is the result of checking the package in a directory, with the
+// contents of the open documents in place of the files on disk.
+type snapshot struct {
+	dir   string
+	fset  *token.FileSet
+	files map[string]*ast.File // by URI
+	srcs  map[string][]byte    // by URI
+	pkg   *types.Package
+	info  *types.Info
+	diags map[string][]Diagnostic // by URI
+}
+
+// check parses and type-checks the SGo files of the package in dir.
+func (s *server) check(dir string) *snapshot {
+	snap := &snapshot{
+		dir:   dir,
+		fset:  token.NewFileSet(),
+		files: map[string]*ast.File{},
+		srcs:  map[string][]byte{},
+		diags: map[string][]Diagnostic{},
+		info: &types.Info{
+			Types:      map[ast.Expr]types.TypeAndValue{},
+			Defs:       map[*ast.Ident]types.Object{},
+			Uses:       map[*ast.Ident]types.Object{},
+			Implicits:  map[ast.Node]types.Object{},
+			Selections: map[*ast.SelectorExpr]*types.Selection{},
+			Scopes:     map[ast.Node]*types.Scope{},
+			Narrowings: map[*ast.Ident]*types.Narrowing{},
+		},
+	}
+
+	names := map[string]bool{}
+	if entries, err := ioutil.ReadDir(dir); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), ".sgo") {
+				names[e.Name()] = true
+			}
+		}
+	}
+	for uri := range s.docs {
+		if path := uriToPath(uri); filepath.Dir(path) == dir && strings.HasSuffix(path, ".sgo") {
+			names[filepath.Base(path)] = true
+		}
+	}
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	// Files of other packages, like package main files with a build tag
+	// to ignore them, are left out.
+	var files []*ast.File
+	var pkgName string
+	for _, name := range sorted {
+		path := filepath.Join(dir, name)
+		uri := pathToURI(path)
+		src, ok := s.docs[uri]
+		if !ok {
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			src = string(b)
+		}
+		f, err := parser.ParseFile(snap.fset, path, src, parser.AllErrors|parser.ParseComments)
+		if f == nil {
+			continue
+		}
+		if pkgName == "" {
+			pkgName = f.Name.Name
+		} else if f.Name.Name != pkgName {
+			continue
+		}
+		snap.files[uri] = f
+		snap.srcs[uri] = []byte(src)
+		snap.diags[uri] = []Diagnostic{}
+		files = append(files, f)
+		if errs, ok := err.(scanner.ErrorList); ok {
+			for _, err := range errs {
+				snap.addDiag(err.Pos, err.Msg, severityError)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return snap
+	}
+
+	imp, err := importer.DefaultFrom(files, dir)
+	if err != nil {
+		snap.addDiag(snap.fset.Position(files[0].Package), err.Error(), severityError)
+		return snap
+	}
+	path := pkgName
+	if bp, err := build.ImportDir(dir, build.FindOnly); err == nil && bp.ImportPath != "." {
+		path = bp.ImportPath
+	}
+	conf := &types.Config{
+		Importer: imp,
+		Error: func(err error) {
+			if err, ok := err.(types.Error); ok {
+				snap.addDiag(snap.fset.Position(err.Pos), err.Msg, severityError)
+			}
+		},
+		Warn: func(err error) {
+			if err, ok := err.(types.Error); ok {
+				snap.addDiag(snap.fset.Position(err.Pos), err.Msg, severityWarning)
+			}
+		},
+	}
+	snap.pkg, _ = conf.Check(path, snap.fset, files, snap.info)
+	return snap
+}
+
+func (snap *snapshot) addDiag(pos token.Position, msg string, severity int) {
+	uri := pathToURI(pos.Filename)
+	if _, ok := snap.diags[uri]; !ok {
+		return
+	}
+	start := toPosition(snap.srcs[uri], pos)
+	end := start
+	if src := snap.srcs[uri]; pos.Offset < len(src) {
+		// Cover the identifier at the position, if any.
+		n := pos.Offset
+		for n < len(src) {
+			r, size := utf8.DecodeRune(src[n:])
+			if !isIdentRune(r) {
+				break
+			}
+			n += size
+		}
+		end.Character += len(utf16.Encode([]rune(string(src[pos.Offset:n]))))
+	}
+	snap.diags[uri] = append(snap.diags[uri], Diagnostic{
+		Range:    Range{start, end},
+		Severity: severity,
+		Source:   "sgo",
+		Message:  msg,
+	})
+}
+
+// fileAt returns the file with the given URI and the position in it that p
+// refers to.
+func (snap *snapshot) fileAt(uri string, p Position) (*ast.File, token.Pos, bool) {
+	f, ok := snap.files[uri]
+	if !ok {
+		return nil, token.NoPos, false
+	}
+	offset, ok := toOffset(snap.srcs[uri], p)
+	if !ok {
+		return nil, token.NoPos, false
+	}
+	return f, snap.fset.File(f.Pos()).Pos(offset), true
+}
+
+// location returns the LSP location of the range from pos to end, which
+// are in the snapshot's files.
+func (snap *snapshot) location(pos, end token.Pos) Location {
+	start, stop := snap.fset.Position(pos), snap.fset.Position(end)
+	uri := pathToURI(start.Filename)
+	src := snap.srcs[uri]
+	return Location{URI: uri, Range: Range{toPosition(src, start), toPosition(src, stop)}}
+}
+
+// toPosition converts a token position in src to an LSP position, whose
+// character offset is in UTF-16 code units.
+func toPosition(src []byte, pos token.Position) Position {
+	if pos.Line < 1 {
+		return Position{}
+	}
+	lineStart := pos.Offset - (pos.Column - 1)
+	if lineStart < 0 || pos.Offset > len(src) {
+		return Position{Line: pos.Line - 1, Character: pos.Column - 1}
+	}
+	return Position{Line: pos.Line - 1, Character: len(utf16.Encode([]rune(string(src[lineStart:pos.Offset]))))}
+}
+
+// toOffset converts an LSP position to a byte offset in src.
+func toOffset(src []byte, p Position) (int, bool) {
+	offset := 0
+	for line := 0; line < p.Line; line++ {
+		i := strings.IndexByte(string(src[offset:]), '\n')
+		if i < 0 {
+			return 0, false
+		}
+		offset += i + 1
+	}
+	for units := 0; units < p.Character && offset < len(src) && src[offset] != '\n'; {
+		r, size := utf8.DecodeRune(src[offset:])
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset, true
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
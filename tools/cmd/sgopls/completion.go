@@ -0,0 +1,222 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+func (s *server) completion(params TextDocumentPositionParams) *CompletionList {
+	list := &CompletionList{Items: []CompletionItem{}}
+	uri := params.TextDocument.URI
+	snap := s.snapshotFor(uri)
+	if snap.pkg == nil {
+		return list
+	}
+	f, pos, ok := snap.fileAt(uri, params.Position)
+	if !ok {
+		return list
+	}
+	src := snap.srcs[uri]
+	offset := snap.fset.Position(pos).Offset
+
+	// The identifier being typed, up to the cursor.
+	start := offset
+	for start > 0 {
+		r, size := utf8.DecodeLastRune(src[:start])
+		if !isIdentRune(r) {
+			break
+		}
+		start -= size
+	}
+	prefix := string(src[start:offset])
+
+	var items []CompletionItem
+	if start > 0 && src[start-1] == '.' {
+		dot := pos - token.Pos(offset-start) - 1
+		items = snap.selectorCompletions(f, dot)
+	} else {
+		items = snap.scopeCompletions(pos - token.Pos(offset-start))
+	}
+	for _, item := range items {
+		if strings.HasPrefix(item.Label, prefix) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Label < list.Items[j].Label })
+	return list
+}
+
+// selectorCompletions returns the names that can be selected from the
+// expression that ends at the dot at position dot.
+func (snap *snapshot) selectorCompletions(f *ast.File, dot token.Pos) []CompletionItem {
+	var x ast.Expr
+	ast.Inspect(f, func(n ast.Node) bool {
+		if x != nil || n == nil || dot < n.Pos() || n.End() <= dot {
+			return false
+		}
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.X.End() == dot {
+			x = sel.X
+		}
+		return true
+	})
+	if x == nil {
+		return nil
+	}
+	qf := types.RelativeTo(snap.pkg)
+
+	if id, ok := x.(*ast.Ident); ok {
+		if pkgName, ok := snap.info.Uses[id].(*types.PkgName); ok {
+			var items []CompletionItem
+			scope := pkgName.Imported().Scope()
+			for _, name := range scope.Names() {
+				if obj := scope.Lookup(name); obj.Exported() {
+					items = append(items, objectItem(obj, qf))
+				}
+			}
+			return items
+		}
+	}
+
+	tv, ok := snap.info.Types[x]
+	if !ok || tv.Type == nil {
+		return nil
+	}
+	T := tv.Type
+	if opt, ok := T.(*types.Optional); ok {
+		// Selecting from an optional is an error, but completing what's
+		// there helps while writing the nil check around it.
+		T = opt.Elem()
+	}
+	seen := map[string]bool{}
+	var items []CompletionItem
+	add := func(obj types.Object) {
+		if seen[obj.Name()] || !obj.Exported() && obj.Pkg() != snap.pkg {
+			return
+		}
+		seen[obj.Name()] = true
+		items = append(items, objectItem(obj, qf))
+	}
+	if tv.IsType() {
+		// Method expressions.
+		for _, sel := range methods(T) {
+			add(sel.Obj())
+		}
+		return items
+	}
+	fields(T, add, map[types.Type]bool{})
+	for _, sel := range methods(T) {
+		add(sel.Obj())
+	}
+	return items
+}
+
+// fields calls add for the fields of the struct T is or points to, including
+// promoted ones.
+func fields(T types.Type, add func(types.Object), seen map[types.Type]bool) {
+	if p, ok := T.Underlying().(*types.Pointer); ok {
+		T = p.Elem()
+	}
+	if seen[T] {
+		return
+	}
+	seen[T] = true
+	s, ok := T.Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	for i := 0; i < s.NumFields(); i++ {
+		add(s.Field(i))
+	}
+	for i := 0; i < s.NumFields(); i++ {
+		if f := s.Field(i); f.Anonymous() {
+			fields(f.Type(), add, seen)
+		}
+	}
+}
+
+// methods returns the method set of T, and of *T if T isn't a pointer or an
+// interface.
+func methods(T types.Type) []*types.Selection {
+	var sels []*types.Selection
+	mset := types.NewMethodSet(T)
+	for i := 0; i < mset.Len(); i++ {
+		sels = append(sels, mset.At(i))
+	}
+	if _, ok := T.Underlying().(*types.Pointer); ok {
+		return sels
+	}
+	if types.IsInterface(T) {
+		return sels
+	}
+	mset = types.NewMethodSet(types.NewPointer(T))
+	for i := 0; i < mset.Len(); i++ {
+		sels = append(sels, mset.At(i))
+	}
+	return sels
+}
+
+// scopeCompletions returns the names in scope at pos.
+func (snap *snapshot) scopeCompletions(pos token.Pos) []CompletionItem {
+	qf := types.RelativeTo(snap.pkg)
+	seen := map[string]bool{}
+	var items []CompletionItem
+	scope := snap.pkg.Scope().Innermost(pos)
+	if scope == nil {
+		scope = snap.pkg.Scope()
+	}
+	for ; scope != nil; scope = scope.Parent() {
+		local := scope != snap.pkg.Scope() && scope != types.Universe && scope.Parent() != snap.pkg.Scope()
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			if seen[name] || name == "_" || local && obj.Pos() > pos {
+				// Local names declared after pos aren't in scope yet.
+				continue
+			}
+			seen[name] = true
+			items = append(items, objectItem(obj, qf))
+		}
+	}
+	return items
+}
+
+func objectItem(obj types.Object, qf types.Qualifier) CompletionItem {
+	item := CompletionItem{Label: obj.Name()}
+	switch obj := obj.(type) {
+	case *types.Func:
+		item.Kind = completionFunction
+		if sig, ok := obj.Type().(*types.Signature); ok && sig.Recv() != nil {
+			item.Kind = completionMethod
+		}
+		item.Detail = types.TypeString(obj.Type(), qf)
+	case *types.Var:
+		item.Kind = completionVariable
+		if obj.IsField() {
+			item.Kind = completionField
+		}
+		item.Detail = types.TypeString(obj.Type(), qf)
+	case *types.Const:
+		item.Kind = completionConstant
+		item.Detail = types.TypeString(obj.Type(), qf)
+	case *types.TypeName:
+		item.Kind = completionClass
+		switch obj.Type().Underlying().(type) {
+		case *types.Struct:
+			item.Kind = completionStruct
+		case *types.Interface:
+			item.Kind = completionInterface
+		}
+	case *types.PkgName:
+		item.Kind = completionModule
+		item.Detail = obj.Imported().Path()
+	case *types.Nil:
+		item.Kind = completionConstant
+	default:
+		item.Kind = completionKeyword
+	}
+	return item
+}
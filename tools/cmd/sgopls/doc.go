@@ -0,0 +1,37 @@
+/*
+
+Command sgopls is a language server for SGo sources. It speaks the
+Language Server Protocol over its standard input and output, so any
+editor with an LSP client can use it.
+
+     $ go get github.com/tcard/sgo/tools/cmd/sgopls
+
+It works offline and supports:
+
+  - Diagnostics: the SGo type errors and warnings of a file's package,
+    published when the file is opened or saved.
+  - Hover: the SGo type of the identifier under the cursor, including
+    the narrowed type of optionals after a nil check.
+  - Go to definition: across .sgo files, and into the sources of
+    imported SGo and Go packages.
+  - Completion: of identifiers in scope and of fields and methods after
+    a dot.
+  - Document symbols: the functions, methods, types, variables and
+    constants declared in a file.
+
+A package is checked as a whole, with the unsaved contents of the open
+documents in place of the files on disk. Imports are resolved the same
+way as with the sgo command, including sgovendor directories.
+
+For vim with vim-lsp, for instance:
+
+	au User lsp_setup call lsp#register_server({
+		\ 'name': 'sgopls',
+		\ 'cmd': {server_info->['sgopls']},
+		\ 'whitelist': ['sgo'],
+		\ })
+
+For other editors, you probably know what to do.
+
+*/
+package main // import "github.com/tcard/sgo/tools/cmd/sgopls"
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"go/build"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/parser"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+	"github.com/tcard/sgo/tools/sgo/ast/astutil"
+)
+
+// identAt returns the identifier at the given position of the document at
+// uri, and the object it denotes.
+func (snap *snapshot) identAt(uri string, p Position) (*ast.Ident, types.Object) {
+	f, pos, ok := snap.fileAt(uri, p)
+	if !ok {
+		return nil, nil
+	}
+	path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+	if len(path) == 0 {
+		return nil, nil
+	}
+	id, ok := path[0].(*ast.Ident)
+	if !ok {
+		// The cursor may be just past the end of the identifier.
+		if pos > f.Pos() {
+			path, _ = astutil.PathEnclosingInterval(f, pos-1, pos-1)
+			if len(path) > 0 {
+				id, ok = path[0].(*ast.Ident)
+			}
+		}
+		if !ok {
+			return nil, nil
+		}
+	}
+	obj := snap.info.Uses[id]
+	if obj == nil {
+		obj = snap.info.Defs[id]
+	}
+	if v, ok := obj.(*types.Var); ok && v.Pkg() == snap.pkg && !v.IsField() && snap.fset.File(v.Pos()) == nil {
+		// A variable narrowed by a nil check is a new variable, without a
+		// position. Its declaration is the one of the variable in scope
+		// that it shadows.
+		if scope := snap.pkg.Scope().Innermost(id.Pos()); scope != nil {
+			if _, outer := scope.LookupParent(id.Name, id.Pos()); outer != nil {
+				obj = outer
+			}
+		}
+	}
+	return id, obj
+}
+
+func (s *server) hover(params TextDocumentPositionParams) *Hover {
+	snap := s.snapshotFor(params.TextDocument.URI)
+	if snap.pkg == nil {
+		return nil
+	}
+	id, obj := snap.identAt(params.TextDocument.URI, params.Position)
+	if obj == nil {
+		return nil
+	}
+	qf := types.RelativeTo(snap.pkg)
+	text := types.ObjectString(obj, qf)
+	if n := snap.info.Narrowings[id]; n != nil {
+		// Tell whether a nil check narrowed the variable, and why not.
+		text += "\n// " + n.Explain(snap.fset, qf)
+	}
+	r := snap.location(id.Pos(), id.End()).Range
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```sgo\n" + text + "\n```"},
+		Range:    &r,
+	}
+}
+
+func (s *server) definition(params TextDocumentPositionParams) []Location {
+	locs := []Location{}
+	snap := s.snapshotFor(params.TextDocument.URI)
+	if snap.pkg == nil {
+		return locs
+	}
+	id, obj := snap.identAt(params.TextDocument.URI, params.Position)
+	if obj == nil {
+		return locs
+	}
+	if pkgName, ok := obj.(*types.PkgName); ok {
+		if loc, ok := snap.packageLocation(pkgName.Imported().Path()); ok {
+			locs = append(locs, loc)
+		}
+		return locs
+	}
+	if obj.Pkg() == nil {
+		// Universe objects have no source.
+		return locs
+	}
+	if obj.Pkg() == snap.pkg {
+		if obj.Pos().IsValid() {
+			locs = append(locs, snap.location(obj.Pos(), obj.Pos()+token.Pos(len(obj.Name()))))
+		}
+		return locs
+	}
+
+	// Objects from other packages come from an importer with its own file
+	// set, so they are looked up by name in the package's sources.
+	var recv string
+	if sel, ok := snap.selectionOf(id); ok {
+		recv = typeName(sel.Recv())
+	} else if fn, ok := obj.(*types.Func); ok {
+		if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+			recv = typeName(sig.Recv().Type())
+		}
+	}
+	if loc, ok := snap.declLocation(obj.Pkg().Path(), obj, recv); ok {
+		locs = append(locs, loc)
+	}
+	return locs
+}
+
+// selectionOf returns the selection whose selected identifier is id.
+func (snap *snapshot) selectionOf(id *ast.Ident) (*types.Selection, bool) {
+	for sel, s := range snap.info.Selections {
+		if sel.Sel == id {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// typeName returns the name of the named type T is, or points to.
+func typeName(T types.Type) string {
+	for {
+		switch t := T.(type) {
+		case *types.Pointer:
+			T = t.Elem()
+			continue
+		case *types.Optional:
+			T = t.Elem()
+			continue
+		case *types.Named:
+			return t.Obj().Name()
+		}
+		return ""
+	}
+}
+
+// packageSources parses the sources of the package with the given import
+// path: its .sgo files, or its Go files if it has none.
+func (snap *snapshot) packageSources(path string) (*token.FileSet, []*ast.File, bool) {
+	bp, err := build.Import(path, snap.dir, 0)
+	if err != nil && bp == nil {
+		return nil, nil, false
+	}
+	names := sgoFiles(bp.Dir)
+	if len(names) == 0 {
+		names = bp.GoFiles
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range names {
+		f, _ := parser.ParseFile(fset, filepath.Join(bp.Dir, name), nil, parser.ParseComments)
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+	return fset, files, len(files) > 0
+}
+
+func sgoFiles(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.sgo"))
+	var names []string
+	for _, m := range matches {
+		if !strings.HasSuffix(m, "_test.sgo") {
+			names = append(names, filepath.Base(m))
+		}
+	}
+	return names
+}
+
+// packageLocation returns the location of the package clause of the
+// package with the given import path.
+func (snap *snapshot) packageLocation(path string) (Location, bool) {
+	fset, files, ok := snap.packageSources(path)
+	if !ok {
+		return Location{}, false
+	}
+	f := files[0]
+	for _, file := range files {
+		if file.Doc != nil {
+			f = file
+			break
+		}
+	}
+	return fileLocation(fset, f.Name.Pos(), f.Name.End()), true
+}
+
+// declLocation returns the location of the declaration of obj in the
+// sources of the package with the given import path. For fields and methods,
+// recv is the name of the type they belong to.
+func (snap *snapshot) declLocation(path string, obj types.Object, recv string) (Location, bool) {
+	fset, files, ok := snap.packageSources(path)
+	if !ok {
+		return Location{}, false
+	}
+	name := obj.Name()
+	var found *ast.Ident
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Name.Name != name {
+					continue
+				}
+				var declRecv string
+				if decl.Recv != nil && len(decl.Recv.List) > 0 {
+					declRecv = recvName(decl.Recv.List[0].Type)
+				}
+				if declRecv == recv {
+					found = decl.Name
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if recv == "" && spec.Name.Name == name {
+							found = spec.Name
+						} else if recv != "" && spec.Name.Name == recv {
+							found = memberIdent(spec.Type, name)
+						}
+					case *ast.ValueSpec:
+						if recv != "" {
+							continue
+						}
+						for _, id := range spec.Names.List {
+							if id.Name == name {
+								found = id
+							}
+						}
+					}
+					if found != nil {
+						break
+					}
+				}
+			}
+			if found != nil {
+				return fileLocation(fset, found.Pos(), found.End()), true
+			}
+		}
+	}
+	return Location{}, false
+}
+
+// recvName returns the name of the base type of a method receiver.
+func recvName(typ ast.Expr) string {
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// memberIdent returns the identifier that declares the field or interface
+// method with the given name in typ.
+func memberIdent(typ ast.Expr, name string) *ast.Ident {
+	var fields *ast.FieldList
+	switch t := typ.(type) {
+	case *ast.StructType:
+		fields = t.Fields
+	case *ast.InterfaceType:
+		fields = t.Methods
+	default:
+		return nil
+	}
+	for _, field := range fields.List {
+		for _, id := range field.Names {
+			if id.Name == name {
+				return id
+			}
+		}
+		if len(field.Names) == 0 && embeddedName(field.Type) == name {
+			return identOf(field.Type)
+		}
+	}
+	return nil
+}
+
+func embeddedName(typ ast.Expr) string {
+	if id := identOf(typ); id != nil {
+		return id.Name
+	}
+	return ""
+}
+
+func identOf(typ ast.Expr) *ast.Ident {
+	switch t := typ.(type) {
+	case *ast.StarExpr:
+		return identOf(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel
+	case *ast.Ident:
+		return t
+	}
+	return nil
+}
+
+func fileLocation(fset *token.FileSet, pos, end token.Pos) Location {
+	start, stop := fset.Position(pos), fset.Position(end)
+	src, _ := ioutil.ReadFile(start.Filename)
+	return Location{URI: pathToURI(start.Filename), Range: Range{toPosition(src, start), toPosition(src, stop)}}
+}
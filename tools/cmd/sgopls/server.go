@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// A server answers the requests of a single client. Requests are handled one
+// at a time, in the order they arrive.
+type server struct {
+	conn     *conn
+	docs     map[string]string // open documents' contents, by URI
+	shutdown bool
+}
+
+// serve runs a server that reads requests from r and writes responses and
+// notifications to w, until the client sends the exit notification or r is
+// closed.
+func serve(r io.Reader, w io.Writer) error {
+	s := &server{
+		conn: newConn(r, w),
+		docs: map[string]string{},
+	}
+	for {
+		msg, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit without shutdown")
+			}
+			return nil
+		}
+		result, rerr := s.handle(msg)
+		if msg.ID == nil {
+			// Notifications don't get a response.
+			continue
+		}
+		resp := &message{ID: msg.ID, Result: result, Error: rerr}
+		if rerr == nil && result == nil {
+			resp.Result = json.RawMessage("null")
+		}
+		if err := s.conn.write(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) handle(msg *message) (interface{}, *responseError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    1, // Full.
+					"save":      map[string]bool{"includeText": true},
+				},
+				"hoverProvider":      true,
+				"definitionProvider": true,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{"."},
+				},
+				"documentSymbolProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "sgopls"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshal(msg, &params); err != nil {
+			return nil, err
+		}
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		s.publishDiagnostics(params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshal(msg, &params); err != nil {
+			return nil, err
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[params.TextDocument.URI] = params.ContentChanges[n-1].Text
+		}
+		return nil, nil
+	case "textDocument/didSave":
+		var params DidSaveTextDocumentParams
+		if err := unmarshal(msg, &params); err != nil {
+			return nil, err
+		}
+		if params.Text != nil {
+			s.docs[params.TextDocument.URI] = *params.Text
+		}
+		s.publishDiagnostics(params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshal(msg, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, nil
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := unmarshal(msg, &params); err != nil {
+			return nil, err
+		}
+		if h := s.hover(params); h != nil {
+			return h, nil
+		}
+		return nil, nil
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := unmarshal(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params), nil
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := unmarshal(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(params), nil
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if err := unmarshal(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.documentSymbols(params), nil
+	}
+	if msg.ID == nil {
+		// Unknown notifications, like $/cancelRequest, are ignored.
+		return nil, nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not supported: " + msg.Method}
+}
+
+func unmarshal(msg *message, v interface{}) *responseError {
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// snapshotFor checks the package of the document at uri.
+func (s *server) snapshotFor(uri string) *snapshot {
+	return s.check(filepath.Dir(uriToPath(uri)))
+}
+
+// publishDiagnostics checks the package of the document at uri and sends the
+// errors and warnings of each of its files. Files without problems get an
+// empty list, so that the client clears what was reported before.
+func (s *server) publishDiagnostics(uri string) {
+	snap := s.snapshotFor(uri)
+	if _, ok := snap.diags[uri]; !ok {
+		snap.diags[uri] = []Diagnostic{}
+	}
+	for _, u := range sortedKeys(snap.diags) {
+		s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         u,
+			Diagnostics: snap.diags[u],
+		})
+	}
+}
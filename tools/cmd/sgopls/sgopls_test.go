@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A client drives a server through a scripted session, as an editor would.
+type client struct {
+	t      *testing.T
+	conn   *conn
+	msgs   chan *message
+	nextID int
+	// The last diagnostics published for each file.
+	diags map[string][]Diagnostic
+}
+
+func newClient(t *testing.T) (*client, func()) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := serve(inR, outW)
+		outW.Close()
+		done <- err
+	}()
+	c := &client{
+		t:     t,
+		conn:  newConn(outR, inW),
+		msgs:  make(chan *message, 100),
+		diags: map[string][]Diagnostic{},
+	}
+	// The pipes are synchronous, so messages are read as soon as the
+	// server writes them.
+	go func() {
+		defer close(c.msgs)
+		for {
+			msg, err := c.conn.read()
+			if err != nil {
+				return
+			}
+			c.msgs <- msg
+		}
+	}()
+	return c, func() {
+		c.call("shutdown", nil, nil)
+		c.notify("exit", nil)
+		if err := <-done; err != nil {
+			t.Errorf("serve: %v", err)
+		}
+	}
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.conn.notify(method, params)
+}
+
+func (c *client) call(method string, params, result interface{}) {
+	c.nextID++
+	id := mustMarshal(c.nextID)
+	c.conn.write(&message{ID: (*json.RawMessage)(&id), Method: method, Params: mustMarshal(params)})
+	for msg := range c.msgs {
+		if msg.Method == "textDocument/publishDiagnostics" {
+			var params PublishDiagnosticsParams
+			json.Unmarshal(msg.Params, &params)
+			c.diags[params.URI] = params.Diagnostics
+		}
+		if msg.ID == nil {
+			continue
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s: %s", method, msg.Error.Message)
+		}
+		if result != nil {
+			if err := json.Unmarshal(mustMarshal(msg.Result), result); err != nil {
+				c.t.Fatalf("%s: %v", method, err)
+			}
+		}
+		return
+	}
+	c.t.Fatalf("%s: connection closed", method)
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func testURI(t *testing.T, name string) (string, string) {
+	path, err := filepath.Abs(filepath.Join("testdata", "p", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pathToURI(path), string(src)
+}
+
+func TestSession(t *testing.T) {
+	c, stop := newClient(t)
+	defer stop()
+
+	var init struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	c.call("initialize", map[string]interface{}{}, &init)
+	for _, cap := range []string{"hoverProvider", "definitionProvider", "completionProvider", "documentSymbolProvider"} {
+		if init.Capabilities[cap] == nil {
+			t.Errorf("missing capability %s", cap)
+		}
+	}
+	c.notify("initialized", struct{}{})
+
+	uri, src := testURI(t, "p.sgo")
+	badURI, _ := testURI(t, "bad.sgo")
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "sgo", Version: 1, Text: src},
+	})
+
+	// Diagnostics are published for every file of the package.
+	c.call("textDocument/hover", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}}, nil)
+	if diags, ok := c.diags[uri]; !ok || len(diags) != 0 {
+		t.Errorf("diagnostics for p.sgo: got %v, want none", diags)
+	}
+	if diags := c.diags[badURI]; len(diags) != 1 || diags[0].Range.Start != (Position{4, 8}) || !strings.Contains(diags[0].Message, "?*Thing") {
+		t.Errorf("diagnostics for bad.sgo: got %+v, want one about the ?*Thing t at 4:8", diags)
+	}
+
+	hover := func(line, char int) string {
+		var h *Hover
+		c.call("textDocument/hover", TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     Position{line, char},
+		}, &h)
+		if h == nil {
+			return ""
+		}
+		return h.Contents.Value
+	}
+	for _, test := range []struct {
+		line, char int
+		want       string
+	}{
+		{21, 1, "var t ?*Thing"},
+		{23, 9, "var t ?*Thing\n// t was narrowed to *Thing by the nil check at " + uriToPath(uri) + ":23:5"},
+		{23, 11, "func (*Thing).Upper() string"},
+		{10, 18, "func strings.ToUpper(s string) string"},
+		{5, 1, "field Name string"},
+	} {
+		if got := hover(test.line, test.char); !strings.Contains(got, test.want) {
+			t.Errorf("hover at %d:%d: got %q, want %q", test.line, test.char, got, test.want)
+		}
+	}
+	aliasURI, _ := testURI(t, "alias.sgo")
+	var h *Hover
+	c.call("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: aliasURI},
+		Position:     Position{6, 6},
+	}, &h)
+	if want := "x is not narrowed by the nil check at " + uriToPath(aliasURI) + ":6:5 because its address is taken at " + uriToPath(aliasURI) + ":4:8"; h == nil || !strings.Contains(h.Contents.Value, want) {
+		t.Errorf("hover of aliased x: got %+v, want %q", h, want)
+	}
+
+	definition := func(line, char int) []Location {
+		var locs []Location
+		c.call("textDocument/definition", TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     Position{line, char},
+		}, &locs)
+		return locs
+	}
+	if locs := definition(23, 11); len(locs) != 1 || locs[0].URI != uri || locs[0].Range.Start != (Position{9, 16}) {
+		t.Errorf("definition of Upper: got %+v, want p.sgo:9:16", locs)
+	}
+	if locs := definition(10, 18); len(locs) != 1 || !strings.HasSuffix(locs[0].URI, "/strings/strings.go") {
+		t.Errorf("definition of strings.ToUpper: got %+v, want it in strings.go", locs)
+	}
+
+	// Completion works on unsaved, incomplete changes.
+	edited := strings.Replace(src, "\treturn \"\"\n}", "\tvar th Thing\n\tth.\n\treturn \"\"\n}", 1)
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: edited}},
+	})
+	var list CompletionList
+	c.call("textDocument/completion", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{26, 4},
+	}, &list)
+	var labels []string
+	for _, item := range list.Items {
+		labels = append(labels, item.Label)
+	}
+	if got, want := strings.Join(labels, " "), "Name Upper count"; got != want {
+		t.Errorf("completion after th.: got %q, want %q", got, want)
+	}
+	c.call("textDocument/completion", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{10, 17},
+	}, &list)
+	labels = nil
+	for _, item := range list.Items {
+		labels = append(labels, item.Label)
+	}
+	if got := strings.Join(labels, " "); !strings.Contains(got, "ToUpper") || strings.Contains(got, "Name") {
+		t.Errorf("completion of strings.T: got %q, want names like ToUpper", got)
+	}
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: src}},
+	})
+
+	var symbols []DocumentSymbol
+	c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &symbols)
+	var names []string
+	for _, sym := range symbols {
+		name := sym.Name
+		for _, child := range sym.Children {
+			name += " " + child.Name
+		}
+		names = append(names, name)
+	}
+	if got, want := strings.Join(names, ", "), "Thing Name count Upper, Find, Use"; got != want {
+		t.Errorf("symbols: got %q, want %q", got, want)
+	}
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/token"
+	"github.com/tcard/sgo/sgo/types"
+)
+
+// documentSymbols returns the top-level declarations of the document at
+// uri. Methods are listed as children of their receiver type when it is
+// declared in the same file.
+func (s *server) documentSymbols(params DocumentSymbolParams) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	uri := params.TextDocument.URI
+	snap := s.snapshotFor(uri)
+	f, ok := snap.files[uri]
+	if !ok {
+		return symbols
+	}
+	qf := types.RelativeTo(snap.pkg)
+	detail := func(id *ast.Ident) string {
+		if obj := snap.info.Defs[id]; obj != nil && snap.pkg != nil {
+			return types.TypeString(obj.Type(), qf)
+		}
+		return ""
+	}
+	symbol := func(id *ast.Ident, node ast.Node, kind int) DocumentSymbol {
+		return DocumentSymbol{
+			Name:           id.Name,
+			Detail:         detail(id),
+			Kind:           kind,
+			Range:          snap.location(node.Pos(), node.End()).Range,
+			SelectionRange: snap.location(id.Pos(), id.End()).Range,
+		}
+	}
+
+	typeIndex := map[string]int{}
+	var methods []*ast.FuncDecl
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil {
+				methods = append(methods, decl)
+				continue
+			}
+			symbols = append(symbols, symbol(decl.Name, decl, symbolFunction))
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					sym := symbol(spec.Name, spec, symbolClass)
+					sym.Detail = ""
+					switch t := spec.Type.(type) {
+					case *ast.StructType:
+						sym.Kind = symbolStruct
+						sym.Children = fieldSymbols(t.Fields, symbolField, symbol)
+					case *ast.InterfaceType:
+						sym.Kind = symbolInterface
+						sym.Children = fieldSymbols(t.Methods, symbolMethod, symbol)
+					}
+					typeIndex[spec.Name.Name] = len(symbols)
+					symbols = append(symbols, sym)
+				case *ast.ValueSpec:
+					kind := symbolVariable
+					if decl.Tok == token.CONST {
+						kind = symbolConstant
+					}
+					for _, id := range spec.Names.List {
+						if id.Name != "_" {
+							symbols = append(symbols, symbol(id, spec, kind))
+						}
+					}
+				}
+			}
+		}
+	}
+	for _, m := range methods {
+		sym := symbol(m.Name, m, symbolMethod)
+		var recv string
+		if len(m.Recv.List) > 0 {
+			recv = recvName(m.Recv.List[0].Type)
+		}
+		if i, ok := typeIndex[recv]; ok {
+			symbols[i].Children = append(symbols[i].Children, sym)
+			continue
+		}
+		sym.Name = "(" + recv + ")." + sym.Name
+		symbols = append(symbols, sym)
+	}
+	return symbols
+}
+
+func fieldSymbols(fields *ast.FieldList, kind int, symbol func(*ast.Ident, ast.Node, int) DocumentSymbol) []DocumentSymbol {
+	var syms []DocumentSymbol
+	for _, field := range fields.List {
+		for _, id := range field.Names {
+			syms = append(syms, symbol(id, field, kind))
+		}
+		if len(field.Names) == 0 {
+			if id := identOf(field.Type); id != nil {
+				syms = append(syms, symbol(id, field, kind))
+			}
+		}
+	}
+	return syms
+}
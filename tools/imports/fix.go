@@ -15,6 +15,7 @@ import (
 	"go/build"
 
 	"github.com/tcard/sgo/sgo/ast"
+	"github.com/tcard/sgo/sgo/importer"
 	"github.com/tcard/sgo/sgo/parser"
 	"github.com/tcard/sgo/sgo/token"
 
@@ -195,8 +196,14 @@ func loadPkgIndex() {
 	pkgIndex.m = make(map[string][]pkg)
 	pkgIndex.Unlock()
 
+	roots := build.Default.SrcDirs()
+	modCache := moduleCacheDir()
+	if modCache != "" {
+		roots = append(roots, modCache)
+	}
+
 	var wg sync.WaitGroup
-	for _, path := range build.Default.SrcDirs() {
+	for _, path := range roots {
 		fsgate.enter()
 		f, err := os.Open(path)
 		if err != nil {
@@ -211,12 +218,17 @@ func loadPkgIndex() {
 			fmt.Fprint(os.Stderr, err)
 			continue
 		}
+		mod := path == modCache
 		for _, child := range children {
+			if mod && child.Name() == "cache" {
+				// Downloaded archives, not sources.
+				continue
+			}
 			if child.IsDir() {
 				wg.Add(1)
 				go func(path, name string) {
 					defer wg.Done()
-					loadPkg(&wg, path, name)
+					loadPkg(&wg, path, name, mod)
 				}(path, child.Name())
 			}
 		}
@@ -224,7 +236,61 @@ func loadPkgIndex() {
 	wg.Wait()
 }
 
-func loadPkg(wg *sync.WaitGroup, root, pkgrelpath string) {
+// moduleCacheDir returns the directory where the go command keeps the
+// sources of module dependencies, or "" if there's none.
+func moduleCacheDir() string {
+	dir := os.Getenv("GOMODCACHE")
+	if dir == "" {
+		gopaths := filepath.SplitList(build.Default.GOPATH)
+		if len(gopaths) == 0 {
+			return ""
+		}
+		dir = filepath.Join(gopaths[0], "pkg", "mod")
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// moduleImportPath returns the import path of the package whose path
+// relative to the module cache is relpath, like "github.com/Foo/bar/baz" for
+// "github.com/!foo/bar@v1.0.0/baz". It returns false if relpath isn't inside
+// a module.
+func moduleImportPath(relpath string) (string, bool) {
+	elems := strings.Split(relpath, "/")
+	inModule := false
+	for i, elem := range elems {
+		if j := strings.Index(elem, "@"); j >= 0 {
+			elems[i] = elem[:j]
+			inModule = true
+			break
+		}
+	}
+	if !inModule {
+		return "", false
+	}
+
+	// Upper case letters are escaped as '!' followed by the lower case one.
+	var buf []byte
+	escaped := false
+	for _, c := range []byte(strings.Join(elems, "/")) {
+		switch {
+		case c == '!':
+			escaped = true
+			continue
+		case escaped && 'a' <= c && c <= 'z':
+			c -= 'a' - 'A'
+		}
+		escaped = false
+		buf = append(buf, c)
+	}
+	return string(buf), true
+}
+
+// loadPkg indexes the package in the directory pkgrelpath inside root, and
+// those in its subdirectories. If mod is set, root is the module cache.
+func loadPkg(wg *sync.WaitGroup, root, pkgrelpath string, mod bool) {
 	importpath := filepath.ToSlash(pkgrelpath)
 	dir := filepath.Join(root, importpath)
 
@@ -240,7 +306,7 @@ func loadPkg(wg *sync.WaitGroup, root, pkgrelpath string) {
 		return
 	}
 	// hasGo tracks whether a directory actually appears to be a
-	// Go source code directory. If $GOPATH == $HOME, and
+	// Go or SGo source code directory. If $GOPATH == $HOME, and
 	// $HOME/src has lots of other large non-Go projects in it,
 	// then the calls to importPathToName below can be expensive.
 	hasGo := false
@@ -250,19 +316,25 @@ func loadPkg(wg *sync.WaitGroup, root, pkgrelpath string) {
 		if name == "" || name[0] == '.' || name[0] == '_' || name == "testdata" {
 			continue
 		}
-		if strings.HasSuffix(name, ".go") {
+		if strings.HasSuffix(name, ".go") || strings.HasSuffix(name, ".sgo") {
 			hasGo = true
 		}
 		if child.IsDir() {
 			wg.Add(1)
 			go func(root, name string) {
 				defer wg.Done()
-				loadPkg(wg, root, name)
+				loadPkg(wg, root, name, mod)
 			}(root, filepath.Join(importpath, name))
 		}
 	}
+	if hasGo && mod {
+		importpath, hasGo = moduleImportPath(importpath)
+	}
 	if hasGo {
-		shortName := importPathToName(importpath)
+		shortName := dirPackageName(dir)
+		if shortName == "" {
+			shortName = importPathToName(importpath)
+		}
 		pkgIndex.Lock()
 		pkgIndex.m[shortName] = append(pkgIndex.m[shortName], pkg{
 			importpath: importpath,
@@ -273,35 +345,106 @@ func loadPkg(wg *sync.WaitGroup, root, pkgrelpath string) {
 
 }
 
-// loadExports returns a list exports for a package.
+// dirPackageName returns the package name declared by the Go or SGo files in
+// dir, or "" if it can't tell.
+func dirPackageName(dir string) string {
+	if buildPkg, err := build.ImportDir(dir, 0); err == nil {
+		return buildPkg.Name
+	}
+	for _, file := range sgoFiles(dir) {
+		f, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, file), nil, parser.PackageClauseOnly)
+		if err == nil {
+			return f.Name.Name
+		}
+	}
+	return ""
+}
+
+// sgoFiles returns the names of the SGo files in dir, except for tests.
+func sgoFiles(dir string) []string {
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	names, err := d.Readdirnames(-1)
+	d.Close()
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, name := range names {
+		if strings.HasSuffix(name, ".sgo") && !strings.HasSuffix(name, "_test.sgo") {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+// loadExports returns a list exports for a package, and whether it has SGo
+// annotations, either because it's written in SGo or because it has "For SGo:"
+// doc comments.
 var loadExports = loadExportsGoPath
 
-func loadExportsGoPath(dir string) map[string]bool {
-	exports := make(map[string]bool)
+func loadExportsGoPath(dir string) (exports map[string]bool, annotated bool) {
+	exports = make(map[string]bool)
+	sgoFiles := sgoFiles(dir)
 	buildPkg, err := build.ImportDir(dir, 0)
 	if err != nil {
-		if strings.Contains(err.Error(), "no buildable Go source files in") {
-			return nil
+		if !strings.Contains(err.Error(), "no buildable Go source files in") {
+			fmt.Fprintf(os.Stderr, "could not import %q: %v\n", dir, err)
+			return nil, false
+		}
+		if len(sgoFiles) == 0 {
+			return nil, false
 		}
-		fmt.Fprintf(os.Stderr, "could not import %q: %v\n", dir, err)
-		return nil
 	}
+
+	// Go files translated from SGo are read from their SGo files.
+	files := sgoFiles
+	translated := make(map[string]bool)
+	for _, file := range sgoFiles {
+		translated[file[:len(file)-len(".sgo")]+".go"] = true
+	}
+	if err == nil {
+		for _, goFiles := range [...][]string{buildPkg.GoFiles, buildPkg.CgoFiles} {
+			for _, file := range goFiles {
+				if !translated[file] {
+					files = append(files, file)
+				}
+			}
+		}
+	}
+
 	fset := token.NewFileSet()
-	for _, files := range [...][]string{buildPkg.GoFiles, buildPkg.CgoFiles} {
-		for _, file := range files {
-			f, err := parser.ParseFile(fset, filepath.Join(dir, file), nil, 0)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "could not parse %q: %v\n", file, err)
-				continue
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, file), nil, parser.ParseComments)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not parse %q: %v\n", file, err)
+			continue
+		}
+		for name := range f.Scope.Objects {
+			if ast.IsExported(name) {
+				exports[name] = true
 			}
-			for name := range f.Scope.Objects {
-				if ast.IsExported(name) {
-					exports[name] = true
-				}
+		}
+		if !annotated && hasSGoDocComments(f) {
+			annotated = true
+		}
+	}
+	return exports, annotated || len(sgoFiles) > 0
+}
+
+// hasSGoDocComments reports whether f has "For SGo:" doc comments.
+func hasSGoDocComments(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			s := strings.TrimPrefix(strings.TrimPrefix(c.Text, "//"), "/*")
+			if strings.HasPrefix(strings.TrimSpace(s), "For SGo: ") {
+				return true
 			}
 		}
 	}
-	return exports
+	return false
 }
 
 // findImport searches for a package with the given symbols.
@@ -325,11 +468,22 @@ func findImportGoPath(pkgName string, symbols map[string]bool, filename string)
 
 	pkgIndexOnce.Do(loadPkgIndex)
 
+	// Packages annotated in sgovendor folders or SGOANNPATH for the file.
+	annotatedPaths := make(map[string]bool)
+	if abs, err := filepath.Abs(filename); err == nil {
+		if srcs, err := importer.AnnotationSources(filepath.Dir(abs)); err == nil {
+			for _, src := range srcs {
+				annotatedPaths[src.Path] = true
+			}
+		}
+	}
+
 	// Collect exports for packages with matching names.
 	var (
-		wg       sync.WaitGroup
-		mu       sync.Mutex
-		shortest string
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		best          string
+		bestAnnotated bool
 	)
 	pkgIndex.Lock()
 	for _, pkg := range pkgIndex.m[pkgName] {
@@ -339,7 +493,7 @@ func findImportGoPath(pkgName string, symbols map[string]bool, filename string)
 		wg.Add(1)
 		go func(importpath, dir string) {
 			defer wg.Done()
-			exports := loadExports(dir)
+			exports, annotated := loadExports(dir)
 			if exports == nil {
 				return
 			}
@@ -357,12 +511,20 @@ func findImportGoPath(pkgName string, symbols map[string]bool, filename string)
 				importpath = importpath[len("vendor/"):]
 			}
 
+			annotated = annotated || annotatedPaths[importpath]
+
 			// Save as the answer.
-			// If there are multiple candidates, the shortest wins,
-			// to prefer "bytes" over "github.com/foo/bytes".
+			// If there are multiple candidates, those with SGo annotations
+			// win, and then the shortest, to prefer "bytes" over
+			// "github.com/foo/bytes".
 			mu.Lock()
-			if shortest == "" || len(importpath) < len(shortest) || len(importpath) == len(shortest) && importpath < shortest {
-				shortest = importpath
+			switch {
+			case best == "", annotated && !bestAnnotated:
+				best, bestAnnotated = importpath, annotated
+			case annotated == bestAnnotated:
+				if len(importpath) < len(best) || len(importpath) == len(best) && importpath < best {
+					best = importpath
+				}
 			}
 			mu.Unlock()
 		}(pkg.importpath, pkg.dir)
@@ -370,7 +532,7 @@ func findImportGoPath(pkgName string, symbols map[string]bool, filename string)
 	pkgIndex.Unlock()
 	wg.Wait()
 
-	return shortest, false, nil
+	return best, false, nil
 }
 
 func canUse(filename, dir string) bool {
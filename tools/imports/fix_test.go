@@ -918,6 +918,83 @@ func TestFindImportInternal(t *testing.T) {
 	}
 }
 
+func TestFindImportSGo(t *testing.T) {
+	root, err := ioutil.TempDir("", "goimports-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	pkgIndexOnce = &sync.Once{}
+
+	origStdlib := stdlib
+	defer func() {
+		stdlib = origStdlib
+	}()
+	stdlib = nil
+
+	files := map[string]string{
+		"goroot/src/.keep":                                                "",
+		"gopath/src/example.com/bytes/bytes.go":                           "package bytes\n\ntype Buffer3 struct{}\n",
+		"gopath/src/example.com/sgo/sgobytes/bytes.sgo":                   "package bytes\n\ntype Buffer3 struct{}\n",
+		"gopath/src/example.com/sgo/sgobytes/bytes_test.sgo":              "package bytes_test\n",
+		"gopath/src/example.com/strings/strings.go":                       "package strings\n\nfunc Index2() int\n",
+		"gopath/src/example.com/annotated/strings/strings.go":             "package strings\n\n// For SGo: func() int\nfunc Index2() int\n",
+		"gopath/src/example.com/io/io.go":                                 "package io\n\ntype Reader2 interface{}\n",
+		"gopath/src/example.com/z/io/io.go":                               "package io\n\ntype Reader2 interface{}\n",
+		"gopath/src/example.com/app/sgovendor/example.com/z/io/io.sgoann": "Reader2 interface{}\n",
+		"modcache/example.com/!foo@v1.0.0/bar/bar.go":                     "package bar\n\nfunc Baz()\n",
+		"modcache/cache/download/example.com/!foo/@v/v1.0.0/bar/bar.go":   "package bar\n\nfunc Baz()\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldGOROOT := build.Default.GOROOT
+	oldGOPATH := build.Default.GOPATH
+	oldGOMODCACHE, hadGOMODCACHE := os.LookupEnv("GOMODCACHE")
+	build.Default.GOROOT = filepath.Join(root, "goroot")
+	build.Default.GOPATH = filepath.Join(root, "gopath")
+	os.Setenv("GOMODCACHE", filepath.Join(root, "modcache"))
+	defer func() {
+		build.Default.GOROOT = oldGOROOT
+		build.Default.GOPATH = oldGOPATH
+		if hadGOMODCACHE {
+			os.Setenv("GOMODCACHE", oldGOMODCACHE)
+		} else {
+			os.Unsetenv("GOMODCACHE")
+		}
+	}()
+
+	app := filepath.Join(root, "gopath", "src", "example.com", "app", "x.sgo")
+	for _, tt := range []struct {
+		pkg, symbol, want string
+	}{
+		// Packages with only SGo files, over Go ones.
+		{"bytes", "Buffer3", "example.com/sgo/sgobytes"},
+		// Packages with "For SGo:" doc comments, over those without.
+		{"strings", "Index2", "example.com/annotated/strings"},
+		// Packages annotated in a sgovendor folder, over those that aren't.
+		{"io", "Reader2", "example.com/z/io"},
+		// Packages from the module cache.
+		{"bar", "Baz", "example.com/Foo/bar"},
+	} {
+		got, rename, err := findImportGoPath(tt.pkg, map[string]bool{tt.symbol: true}, app)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || rename {
+			t.Errorf(`findImportGoPath(%q, %s ...)=%q, %t, want %q, false`, tt.pkg, tt.symbol, got, rename, tt.want)
+		}
+	}
+}
+
 func TestFindImportStdlib(t *testing.T) {
 	tests := []struct {
 		pkg     string